@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/agent"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentSocket  string
+	agentTimeout time.Duration
+)
+
+// Agent is the `wits agent` command.
+var Agent = &cobra.Command{
+	Use:   "agent",
+	Short: "Hold the passphrases of encrypted repositories for a session",
+	Long: "Run an agent that holds the passphrases of encrypted repositories, the\n" +
+		"way ssh-agent holds keys, so that each command does not ask again.\n\n" +
+		"The agent prints the line that points commands at it, and then runs\n" +
+		"until it is stopped. A command that finds WITS_AGENT_SOCK set asks it\n" +
+		"for the passphrase before asking on the terminal, and hands it one that\n" +
+		"was typed in once the repository has unlocked with it. A passphrase is\n" +
+		"held in memory only, for --timeout after it was last handed over, and\n" +
+		"forgotten when the agent stops or by `wits agent forget`.\n\n" +
+		"WITS_PASSPHRASE, when it is set, is still read first.",
+	Example: "  wits agent > ~/.wits-agent & sleep 1; . ~/.wits-agent\n" +
+		"  wits agent --timeout 15m --socket ~/.wits-agent.sock",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		l, path, err := agent.Listen(agentSocket)
+		if err != nil {
+			return err
+		}
+		if agentSocket == "" {
+			// Closing the listener removes the socket; this is its directory.
+			defer os.Remove(filepath.Dir(path))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "export %s=%s\n", agent.SocketEnv, path)
+		hold := "for as long as it runs"
+		if agentTimeout > 0 {
+			hold = "for " + agentTimeout.String() + " after each use"
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Holding passphrases %s, until stopped.\n", hold)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return agent.New(agentTimeout).Serve(ctx, l)
+	},
+}
+
+var agentForget = &cobra.Command{
+	Use:   "forget",
+	Short: "Make the agent forget every passphrase it holds",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := agent.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "The agent holds no passphrases now.")
+		return nil
+	},
+}
+
+func init() {
+	Agent.Flags().StringVar(&agentSocket, "socket", "", "the socket to listen on, defaults to a new one in a private temporary directory")
+	Agent.Flags().DurationVar(&agentTimeout, "timeout", agent.DefaultTimeout, "how long to hold a passphrase after it was last used, 0 for as long as the agent runs")
+	Agent.AddCommand(agentForget)
+}
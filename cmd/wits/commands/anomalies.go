@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	anomaliesSessions bool
+	anomaliesFactor   float64
+	anomaliesWindow   int
+)
+
+// Anomalies is the `wits anomalies` command.
+var Anomalies = &cobra.Command{
+	Use:   "anomalies",
+	Short: "List the days and weeks that stand out",
+	Long: "List the days and weeks of grinding that stand out against the rolling\n" +
+		"median of the ones before them: a day at three times the usual day or\n" +
+		"more, a week at three times the usual week, or at a third of it, or\n" +
+		"with nothing logged at all. Each is usually a logging mistake or\n" +
+		"something worth telling the doctor, and only you can say which.\n\n" +
+		"--sessions reads the sessions out of the stash instead. The factor and\n" +
+		"the days the usual is taken over default to 3 and 28; set them for the\n" +
+		"repository under anomalies: in .wits/config.yml (factor, window), or\n" +
+		"for one listing with --factor and --window. Corrected entries are left\n" +
+		"out, so a mistake already reverted is not flagged again.",
+	Example: "  wits anomalies\n" +
+		"  wits anomalies --sessions --factor 2.5\n" +
+		"  wits anomalies warn on",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		m, what := ledger.Grinding, "grinding"
+		if anomaliesSessions {
+			m, what = ledger.Seshing, "sessions"
+		}
+		d := s.Detector(m)
+		if anomaliesFactor != 0 {
+			if anomaliesFactor <= 1 {
+				return fmt.Errorf("a factor of %g flags nothing or everything; it must be above 1", anomaliesFactor)
+			}
+			d.Factor = anomaliesFactor
+		}
+		if anomaliesWindow != 0 {
+			if anomaliesWindow < 7 {
+				return fmt.Errorf("a window of %s is too short for a usual week; it must be 7 days or more",
+					plural(anomaliesWindow, "day"))
+			}
+			d.Window = anomaliesWindow
+		}
+
+		out := cmd.OutOrStdout()
+		d = d.Tuned()
+		found := d.Find(s.Chronological().Events, s.OpenedAt)
+		if len(found) == 0 {
+			fmt.Fprintf(out, "Nothing stands out: no day or week of %s at %g× the usual of the %s before it, or %s of it.\n",
+				what, d.Factor, plural(d.Window, "day"), lightShare(d.Factor))
+			return nil
+		}
+		fmt.Fprintf(out, "Days and weeks of %s at %g× the usual of the %s before them, or %s of it:\n\n",
+			what, d.Factor, plural(d.Window, "day"), lightShare(d.Factor))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tFROM\tTO\tGRAMS\tUSUAL\tTIMES")
+		for _, a := range found {
+			to := ""
+			if a.Days > 1 {
+				to = a.End().Format(time.DateOnly)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.2fg\t%.2fg\t%.1f×\n",
+				a.Kind, a.Start.Format(time.DateOnly), orDash(to), a.Grams, a.Usual, a.Ratio())
+		}
+		return w.Flush()
+	},
+}
+
+var anomaliesWarn = &cobra.Command{
+	Use:   "warn <on|off>",
+	Short: "Warn right after recording an unusually large grind or session",
+	Long: "With warnings on, `wits grind` and `wits sesh` say so right after\n" +
+		"recording an entry that makes its day a heavy day, while a typo is\n" +
+		"still easy to revert. They are off until turned on.",
+	Example:   "  wits anomalies warn on",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		switch args[0] {
+		case "on", "off":
+			s.Repo.Config.Anomalies.Warn = args[0] == "on"
+		default:
+			return fmt.Errorf("%q is neither on nor off", args[0])
+		}
+		if err := s.Repo.SaveConfig(); err != nil {
+			return err
+		}
+		if s.Repo.Config.Anomalies.Warn {
+			fmt.Fprintln(cmd.OutOrStdout(), "Grinds and sessions that make a heavy day will be warned about")
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), "Grinds and sessions will no longer be checked for a heavy day")
+		}
+		return nil
+	},
+}
+
+// warnUnusual warns, where the repository asks for it, that an entry just
+// recorded made its day a heavy one.
+func warnUnusual(out io.Writer, s *session, m ledger.Measure, e journal.Event) {
+	if s.Repo == nil || !s.Repo.Config.Anomalies.Warn {
+		return
+	}
+	if a, ok := s.Detector(m).Unusual(s.Recorder.State().Events, e); ok {
+		fmt.Fprintf(out, "⚠️  that makes %.2fg on %s, %.1f× the usual day of %.2fg; if it is a typo, `wits revert %s`\n",
+			a.Grams, a.Start.Format(time.DateOnly), a.Ratio(), a.Usual, shortHash(e.Hash))
+	}
+}
+
+// lightShare renders the share of the usual week below which a week is
+// light.
+func lightShare(factor float64) string {
+	if factor == 3 {
+		return "a third"
+	}
+	return fmt.Sprintf("1/%g", factor)
+}
+
+func init() {
+	Anomalies.Flags().BoolVar(&anomaliesSessions, "sessions", false, "read the sessions rather than the grinds")
+	Anomalies.Flags().Float64Var(&anomaliesFactor, "factor", 0, "how many times the usual stands out (default 3)")
+	Anomalies.Flags().IntVar(&anomaliesWindow, "window", 0, "the days the usual is taken over (default 28)")
+	Anomalies.AddCommand(anomaliesWarn)
+}
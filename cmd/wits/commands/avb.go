@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	avbDate    string
+	avbDevice  string
+	avbTemp    int
+	avbPurpose string
+	avbNote    string
+	avbForce   bool
+)
+
+// AVB is the `wits avb` command.
+var AVB = &cobra.Command{
+	Use:   "avb",
+	Short: "Collect and use already vaped bud",
+	Long: "Show what the AVB jars hold and how much each device leaves behind:\n" +
+		"the grams of AVB collected per gram seshed, by device and temperature\n" +
+		"band.\n\n" +
+		"`wits avb collect` weighs AVB out of a device into the jar, and\n" +
+		"`wits avb use` draws it down for edibles, a tincture or anything else.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		events := s.Chronological().Events
+		if ledger.TotalYield(events).Collected <= 0 {
+			fmt.Fprintln(out, "No AVB collected yet. Weigh some out of a device with `wits avb collect`.")
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PRODUCT\tAVB")
+		for _, product := range s.State.Products() {
+			if b := s.State.Balances[product]; b.AVB > 0 {
+				fmt.Fprintf(w, "%s\t%.2fg\n", product, b.AVB)
+			}
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+		writeYield(out, events)
+		return nil
+	},
+}
+
+var avbCollect = &cobra.Command{
+	Use:   "collect <product> <amount>",
+	Short: "Weigh AVB out of a device into the jar",
+	Long: "Record already vaped bud as weighed when emptying a device, moving it\n" +
+		"from what the product's sessions put through the device into its AVB.\n\n" +
+		"The device and temperature are what the yield is read by. Left out,\n" +
+		"they are taken from the product's last session before the collection.",
+	Example: "  wits avb collect wedding-cake 0.2\n" +
+		"  wits avb collect lemon 0.15 --device volcano --temp 185",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProduct(journal.Consumed),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		grams, err := parseGrams(args[1])
+		if err != nil {
+			return err
+		}
+		at, err := parseDate(avbDate)
+		if err != nil {
+			return err
+		}
+		if avbForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		e, err := s.Recorder.Collect(args[0], grams, at, avbDevice, avbTemp, avbNote)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "[%s] collected %.2fg %s, %.2fg in the AVB jar\n",
+			shortHash(e.Hash), e.Grams, e.Product, s.Recorder.Available(e.Product, journal.AVB))
+		for _, y := range ledger.Yields(s.Recorder.State().Events) {
+			if y.Device == e.Device && y.Band == ledger.Band(e.Temperature) && y.Seshed > 0 {
+				fmt.Fprintf(out, "%s has yielded %.2fg per gram seshed\n", setting(y), y.Ratio())
+			}
+		}
+		return nil
+	},
+}
+
+var avbUse = &cobra.Command{
+	Use:   "use <product> <amount>",
+	Short: "Draw AVB down for edibles, a tincture or anything else",
+	Long: "Record AVB taken out of the jar and used. --for says what for, so the\n" +
+		"outflow can be totalled by it: edibles, tincture, or whatever you make.",
+	Example: "  wits avb use wedding-cake 3 --for edibles\n" +
+		"  wits avb use lemon 1.5 --for tincture --date 2026-07-29",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProduct(journal.AVB),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		grams, err := parseGrams(args[1])
+		if err != nil {
+			return err
+		}
+		at, err := parseDate(avbDate)
+		if err != nil {
+			return err
+		}
+		if avbForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		e, err := s.Recorder.UseAVB(args[0], grams, at, avbPurpose, avbNote)
+		if err != nil {
+			return err
+		}
+		purpose := ""
+		if e.Purpose != "" {
+			purpose = " for " + e.Purpose
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%s] used %.2fg %s AVB%s, %.2fg left in the AVB jar\n",
+			shortHash(e.Hash), e.Grams, e.Product, purpose, s.Recorder.Available(e.Product, journal.AVB))
+		return nil
+	},
+}
+
+// writeYield renders the grams of AVB collected per gram seshed, overall and
+// for each device and temperature band, and what the AVB went on. It writes
+// nothing before the first collection: a yield of zero would only say that
+// nobody has weighed anything yet.
+func writeYield(out io.Writer, events []journal.Event) {
+	total := ledger.TotalYield(events)
+	if total.Collected <= 0 {
+		return
+	}
+	fmt.Fprintf(out, "%.2fg of AVB collected per gram seshed, %.2fg from %.2fg\n",
+		total.Ratio(), total.Collected, total.Seshed)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DEVICE\tTEMP\tSESHED\tCOLLECTED\tYIELD")
+	for _, y := range ledger.Yields(events) {
+		device, band := y.Device, "-"
+		if device == "" {
+			device = "no device"
+		}
+		if y.Band > 0 {
+			band = fmt.Sprintf("%d-%d°C", y.Band, y.Band+ledger.BandWidth-1)
+		}
+		ratio := "-"
+		if y.Seshed > 0 {
+			ratio = fmt.Sprintf("%.2f", y.Ratio())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.2fg\t%.2fg\t%s\n", device, band, y.Seshed, y.Collected, ratio)
+	}
+	w.Flush()
+	if outflows := ledger.Outflows(events); len(outflows) > 0 {
+		var used float64
+		parts := make([]string, 0, len(outflows))
+		for _, o := range outflows {
+			used += o.Grams
+			purpose := o.Purpose
+			if purpose == "" {
+				purpose = "no stated purpose"
+			}
+			parts = append(parts, fmt.Sprintf("%.2fg for %s", o.Grams, purpose))
+		}
+		fmt.Fprintf(out, "Used %.2fg: %s\n", ledger.Round(used), strings.Join(parts, ", "))
+	}
+}
+
+// setting names a device and temperature band the way a sentence does.
+func setting(y ledger.Yield) string {
+	device := y.Device
+	if device == "" {
+		device = "No device"
+	}
+	if y.Band == 0 {
+		return device
+	}
+	return fmt.Sprintf("%s at %d-%d°C", device, y.Band, y.Band+ledger.BandWidth-1)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{avbCollect, avbUse} {
+		c.Flags().StringVar(&avbDate, "date", "", "when it happened, defaults to now")
+		c.Flags().StringVar(&avbNote, "note", "", "a note to keep with the entry")
+		c.Flags().BoolVar(&avbForce, "force", false, "record it even if it overdraws the account, with a warning")
+	}
+	avbCollect.Flags().StringVar(&avbDevice, "device", "", "the device it came out of, defaults to the last session's")
+	avbCollect.Flags().IntVar(&avbTemp, "temp", 0, "the temperature in degrees Celsius, defaults to the last session's")
+	avbUse.Flags().StringVar(&avbPurpose, "for", "", "what it was used for, for example edibles or tincture")
+	AVB.AddCommand(avbCollect, avbUse)
+}
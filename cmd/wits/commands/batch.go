@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/record"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchFrom     []string
+	batchYield    string
+	batchSlug     string
+	batchDecarb   int
+	batchResidual int
+	batchAt       string
+	batchDate     string
+	batchNote     string
+	batchForce    bool
+)
+
+// Batch is the `wits batch` command.
+var Batch = &cobra.Command{
+	Use:   "batch",
+	Short: "Make edibles and tinctures from AVB, and take portions of them",
+	Long: "List the batches made from already vaped bud, with the milligrams a\n" +
+		"gram of each is estimated to hold and what is left of it.\n\n" +
+		"`wits batch make` draws AVB down into a batch, which comes into storage\n" +
+		"as a product of its own: it can be moved, weighed with `wits reconcile`\n" +
+		"and reverted like any other. `wits batch take` records a portion of it,\n" +
+		"counted in milligrams alongside the sessions.",
+	Example: "  wits batch\n" +
+		"  wits batch make butter --from-avb 7g --yield 250g\n" +
+		"  wits batch take butter 10g",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		var batches []journal.Event
+		for _, e := range ledger.Standing(s.State.Events) {
+			if e.Type == journal.Batch {
+				batches = append(batches, e)
+			}
+		}
+		if len(batches) == 0 {
+			fmt.Fprintln(out, "No batches made yet. `wits batch make butter --from-avb 7g --yield 250g` makes one.")
+			return nil
+		}
+		potency := s.Potency()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BATCH\tMADE\tON\tAVB\tYIELD\tTHC\tCBD\tLEFT")
+		for _, e := range batches {
+			var avb float64
+			for _, u := range ledger.Ingredients(s.State.Events, e.Product) {
+				avb += u.Grams
+			}
+			made := ""
+			if p, err := s.Products.Find(e.Product); err == nil {
+				made = p.Made
+			}
+			thc, cbd := "-", "-"
+			if potency.THC[e.Product] > 0 || potency.CBD[e.Product] > 0 {
+				thc = fmt.Sprintf("%.2fmg/g", potency.THC[e.Product]*10)
+				cbd = fmt.Sprintf("%.2fmg/g", potency.CBD[e.Product]*10)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.2fg\t%.2fg\t%s\t%s\t%.2fg\n",
+				e.Product, made, e.OccurredAt.Format(time.DateOnly), ledger.Round(avb), e.Grams,
+				thc, cbd, s.Recorder.Available(e.Product, journal.Storage))
+		}
+		return w.Flush()
+	},
+}
+
+var batchMake = &cobra.Command{
+	Use:   "make <kind> --from-avb <amount> --yield <amount>",
+	Short: "Draw AVB down into a batch of edibles or tincture",
+	Long: "Record a batch made from AVB: butter, oil, a tincture. The AVB is drawn\n" +
+		"down for the kind of batch, and the batch comes into storage as a new\n" +
+		"product, named for its kind and the day.\n\n" +
+		"--from-avb 7g shares the grams out over every AVB jar by what each\n" +
+		"holds; --from-avb wcake=4g draws on one product's, and may be given once\n" +
+		"per product.\n\n" +
+		"What a gram of the batch holds is estimated from the products' labels:\n" +
+		"the devices' efficiencies say how much of the THC and CBD is still in\n" +
+		"the AVB, or --residual says it where no device has one, and --decarb is\n" +
+		"the share of that the batch takes up. The estimate is kept as the\n" +
+		"batch's potency. A batch with AVB there was nothing to reckon by gets\n" +
+		"none, rather than one that reads too weak.\n\n" +
+		"Reverting the batch leaves the AVB uses standing; revert them as well\n" +
+		"to put the AVB back in its jars.",
+	Example: "  wits batch make butter --from-avb 7g --yield 250g\n" +
+		"  wits batch make tincture --from-avb wcake=4g --from-avb lemon=2g --yield 60g --residual 30",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(batchFrom) == 0 {
+			return fmt.Errorf("say how much AVB went in with --from-avb, such as --from-avb 7g")
+		}
+		if batchYield == "" {
+			return fmt.Errorf("say how much came out with --yield, such as --yield 250g")
+		}
+		var ingredients []record.Ingredient
+		for _, from := range batchFrom {
+			in, err := parseIngredient(from)
+			if err != nil {
+				return err
+			}
+			ingredients = append(ingredients, in)
+		}
+		yield, err := parseGrams(batchYield)
+		if err != nil {
+			return err
+		}
+		if batchDecarb < 0 || batchDecarb > 100 || batchResidual < 0 || batchResidual > 100 {
+			return fmt.Errorf("--decarb and --residual are percentages, from 0 to 100")
+		}
+		at, err := parseDate(batchDate)
+		if err != nil {
+			return err
+		}
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		if batchForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		made, err := s.Recorder.MakeBatch(record.Recipe{
+			Kind:     args[0],
+			Slug:     batchSlug,
+			AVB:      ingredients,
+			Yield:    yield,
+			Decarb:   batchDecarb,
+			Residual: batchResidual,
+			Note:     batchNote,
+		}, s.Potency(), at)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		parts := make([]string, 0, len(made.Uses))
+		for _, u := range made.Uses {
+			parts = append(parts, fmt.Sprintf("%.2fg %s", u.Grams, u.Product))
+		}
+		fmt.Fprintf(out, "[%s] made %.2fg %s from %.2fg of AVB: %s\n",
+			shortHash(made.Batch.Hash), made.Batch.Grams, made.Product.Slug, made.Estimate.Grams,
+			strings.Join(parts, ", "))
+		writeEstimate(out, made)
+		return nil
+	},
+}
+
+var batchTake = &cobra.Command{
+	Use:   "take <batch> <amount>",
+	Short: "Take a portion of a batch",
+	Long: "Record a portion of a batch taken out of storage, and the milligrams\n" +
+		"it is estimated to hold. Portions count towards the milligrams in\n" +
+		"`wits status`, and --at takes one from a location moved to with\n" +
+		"`wits move`.",
+	Example: "  wits batch take butter 10g\n" +
+		"  wits batch take tincture 0.5 --at travel",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProduct(journal.Storage),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		grams, err := parseGrams(args[1])
+		if err != nil {
+			return err
+		}
+		location, err := parseLocation(batchAt)
+		if err != nil {
+			return err
+		}
+		at, err := parseDate(batchDate)
+		if err != nil {
+			return err
+		}
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		if batchForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		e, err := s.Recorder.Portion(args[0], grams, at, location, batchNote)
+		if err != nil {
+			return err
+		}
+		dose := "no estimate of its strength"
+		if d := s.Potency().Session(e); d.THC > 0 || d.CBD > 0 {
+			dose = "about " + milligrams(d.THC, d.CBD)
+		}
+		there := ""
+		if location != "" {
+			there = " at " + location
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%s] portion %.2fg %s, %s, %.2fg left%s\n",
+			shortHash(e.Hash), e.Grams, e.Product, dose,
+			s.Recorder.AvailableAt(e.Product, journal.Storage, location), there)
+		return nil
+	},
+}
+
+// parseIngredient reads the AVB a batch is made from: "7g" from every jar, or
+// "wcake=4g" from one product's.
+func parseIngredient(s string) (record.Ingredient, error) {
+	product, amount, named := strings.Cut(s, "=")
+	if !named {
+		product, amount = "", s
+	}
+	grams, err := parseGrams(amount)
+	if err != nil {
+		return record.Ingredient{}, err
+	}
+	if named && strings.TrimSpace(product) == "" {
+		return record.Ingredient{}, fmt.Errorf("%q names no product; give product=amount, or the amount alone", s)
+	}
+	return record.Ingredient{Product: strings.TrimSpace(product), Grams: grams}, nil
+}
+
+// writeEstimate says what a gram of a new batch is reckoned to hold, or why
+// there is no reckoning.
+func writeEstimate(out io.Writer, made record.Made) {
+	est := made.Estimate
+	if !est.Known() {
+		fmt.Fprintf(out, "No estimate of its strength: nothing to reckon the AVB of %s by. "+
+			"Set the products' THC and CBD, a device's --efficiency, or give --residual.\n",
+			strings.Join(est.Unknown, ", "))
+		return
+	}
+	thc, cbd := est.PerGram()
+	fmt.Fprintf(out, "About %.2fmg THC and %.2fmg CBD a gram, %s in the batch\n",
+		thc, cbd, milligrams(est.THC, est.CBD))
+}
+
+func init() {
+	for _, c := range []*cobra.Command{batchMake, batchTake} {
+		c.Flags().StringVar(&batchDate, "date", "", "when it happened, defaults to now")
+		c.Flags().StringVar(&batchNote, "note", "", "a note to keep with the entry")
+		c.Flags().BoolVar(&batchForce, "force", false, "record it even if it overdraws the account, with a warning")
+	}
+	batchMake.Flags().StringArrayVar(&batchFrom, "from-avb", nil, "the AVB that went in: an amount from every jar, or product=amount")
+	batchMake.Flags().StringVar(&batchYield, "yield", "", "the grams of batch that came out")
+	batchMake.Flags().StringVar(&batchSlug, "slug", "", "the slug to refer to the batch by, defaults to its kind")
+	batchMake.Flags().IntVar(&batchDecarb, "decarb", ledger.DefaultDecarb, "the percentage of what the AVB holds that the batch takes up")
+	batchMake.Flags().IntVar(&batchResidual, "residual", 0, "the percentage of the label's THC and CBD still in the AVB, defaults to what the devices leave")
+	batchTake.Flags().StringVar(&batchAt, "at", "", "the location it was taken from, defaults to home")
+	Batch.AddCommand(batchMake, batchTake)
+}
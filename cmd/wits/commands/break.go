@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	breakDays int
+	breakDate string
+	breakNote string
+)
+
+// Break is the `wits break` command.
+var Break = &cobra.Command{
+	Use:   "break",
+	Short: "Declare tolerance breaks, and count the days without",
+	Long: "List the tolerance breaks declared, whether each was kept, and what was\n" +
+		"ground a day before and after it, over as many days as it lasted. Above\n" +
+		"them stands the streak: the days since the last session or grind.\n\n" +
+		"A break is an entry in the journal like any other, and moves no grams.\n" +
+		"A session or a grind during one is recorded all the same, with a warning,\n" +
+		"and counts against it as a lapse. A declared break is not a silent week\n" +
+		"to `wits anomalies`, and the supply projection waits for it to end.",
+	Example: "  wits break\n" +
+		"  wits break start --days 14",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		events, now := s.State.Events, s.OpenedAt
+		writeStreak(out, events, now)
+
+		breaks := ledger.Breaks(events)
+		if len(breaks) == 0 {
+			fmt.Fprintln(out, "No breaks declared. `wits break start --days 14` declares one.")
+			return nil
+		}
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENTRY\tFROM\tDAYS\tUNTIL\tKEPT\tBEFORE\tAFTER\tCHANGE")
+		for _, b := range breaks {
+			c := b.Compare(ledger.Grinding, events, now)
+			kept := "yes"
+			switch {
+			case !b.Kept():
+				kept = plural(len(b.Lapses), "lapse")
+			case b.On(now):
+				kept = "so far"
+			}
+			after, change := "-", "-"
+			if c.AfterDays > 0 {
+				after = fmt.Sprintf("%.2fg/day", c.After)
+				if c.AfterDays < b.Days() {
+					after += fmt.Sprintf(" (%s)", plural(c.AfterDays, "day"))
+				}
+			}
+			if changed, ok := c.Changed(); ok {
+				change = fmt.Sprintf("%+.0f%%", changed*100)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%.2fg/day\t%s\t%s\n",
+				shortHash(b.Event.Hash), b.Start.Format(time.DateOnly), b.Days(),
+				b.End.Format(time.DateOnly), kept, c.Before, after, change)
+		}
+		return w.Flush()
+	},
+}
+
+var breakStart = &cobra.Command{
+	Use:   "start",
+	Short: "Declare a tolerance break",
+	Long: "Declare a tolerance break of --days days, starting now or at --date.\n" +
+		"One break runs at a time; revert the one running to declare another.",
+	Example: "  wits break start --days 14\n" +
+		"  wits break start --days 7 --date 2026-08-01 --note \"holiday\"",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if breakDays <= 0 {
+			return fmt.Errorf("--days says how long the break lasts, and is at least 1")
+		}
+		at, err := parseDate(breakDate)
+		if err != nil {
+			return err
+		}
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		e, err := s.Recorder.Break(breakDays, at, breakNote)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		b, _ := ledger.BreakAt(s.Recorder.State().Events, e.OccurredAt)
+		fmt.Fprintf(out, "[%s] break of %s, until %s\n",
+			shortHash(e.Hash), plural(e.Days, "day"), b.End.Format("2006-01-02 15:04"))
+		if c := b.Compare(ledger.Grinding, s.Recorder.State().Events, e.OccurredAt); c.Before > 0 {
+			fmt.Fprintf(out, "Ground %.2fg a day in the %s before it; `wits break` compares the days after.\n",
+				c.Before, plural(b.Days(), "day"))
+		}
+		return nil
+	},
+}
+
+// writeStreak says how long it has been since the last session or grind, and
+// where the break running stands.
+func writeStreak(out io.Writer, events []journal.Event, now time.Time) {
+	if b, ok := ledger.BreakAt(events, now); ok {
+		state := "kept so far"
+		if !b.Kept() {
+			state = plural(len(b.Lapses), "lapse") + " so far"
+		}
+		fmt.Fprintf(out, "Day %d of a break of %s, %s to go, %s.\n",
+			b.Day(now), plural(b.Days(), "day"), plural(b.Left(now), "day"), state)
+	}
+	days, last := ledger.Streak(events, now)
+	if last.IsZero() {
+		return
+	}
+	fmt.Fprintf(out, "%s without a session or grind; the last was on %s.\n",
+		plural(days, "day"), last.Format(time.DateOnly))
+}
+
+// warnBreak says so when a session or a grind was recorded during a break,
+// with the revert that undoes it if it was a slip of the keyboard rather
+// than of the break.
+func warnBreak(out io.Writer, s *session, e journal.Event) {
+	b, ok := ledger.BreakAt(s.Recorder.State().Events, e.OccurredAt)
+	if !ok {
+		return
+	}
+	fmt.Fprintf(out, "⚠️  that is day %d of the break of %s declared %s, and counts as a lapse; if it is a mistake, `wits revert %s`\n",
+		b.Day(e.OccurredAt), plural(b.Days(), "day"), b.Start.Format(time.DateOnly), shortHash(e.Hash))
+}
+
+func init() {
+	breakStart.Flags().IntVar(&breakDays, "days", 0, "how many days the break lasts")
+	breakStart.Flags().StringVar(&breakDate, "date", "", "when the break starts, defaults to now")
+	breakStart.Flags().StringVar(&breakNote, "note", "", "a note to keep with the entry")
+	Break.AddCommand(breakStart)
+}
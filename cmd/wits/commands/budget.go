@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+var budgetUntil string
+
+// Budget is the `wits budget` command.
+var Budget = &cobra.Command{
+	Use:   "budget",
+	Short: "Spread the shelf over the days until the next fill",
+	Long: "Spread what the shelf held when the cycle opened over the days until a\n" +
+		"date, and show day by day whether the grinding since is ahead of that\n" +
+		"allowance or behind it.\n\n" +
+		"The shelf is the fill's own share of the jars and what older cycles still\n" +
+		"have standing in them, so the allowance counts exactly the grams there\n" +
+		"are. Without --until, the date is the next fill as expected from the\n" +
+		"time between the fills so far.",
+	Example: "  wits budget --until 2026-11-15\n" +
+		"  wits budget",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		state := s.Chronological()
+		cycle := state.CurrentCycle()
+		if cycle == nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "No cycle in progress. Storage is empty, so there is nothing to budget.")
+			return nil
+		}
+		until := state.NextFill()
+		if budgetUntil != "" {
+			if until, err = parseDate(budgetUntil); err != nil {
+				return err
+			}
+		}
+		if until.IsZero() {
+			return errors.New("no date to budget until; give one with --until " +
+				"(the next fill is estimated once two are on record)")
+		}
+		b := state.Budget(cycle, until, s.OpenedAt)
+		if b.DaysLeft(s.OpenedAt) <= 0 {
+			return fmt.Errorf("%s has already passed", b.Until.Format(time.DateOnly))
+		}
+		writeBudget(cmd.OutOrStdout(), b, s.OpenedAt)
+		return nil
+	},
+}
+
+// writeBudget renders a budget: the allowance, the days so far against it,
+// and what the rest of the shelf allows a day from here.
+func writeBudget(out io.Writer, b ledger.Budget, now time.Time) {
+	fmt.Fprintf(out, "%.2fg a day from %s through %s: %.2fg over %s\n",
+		b.Allowance, b.Start.Format(time.DateOnly), b.Until.Format(time.DateOnly),
+		b.Total(), plural(b.Span(), "day"))
+	fmt.Fprintf(out, "%.2fg on the shelf, %.2fg of this fill and %.2fg carried; %.2fg ground since\n\n",
+		b.Shelf(), b.Fill, b.Carried, b.Ground)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tGROUND\tBALANCE")
+	for _, d := range b.Days {
+		fmt.Fprintf(w, "%s\t%.2fg\t%s\n", d.Day.Format(time.DateOnly), d.Ground, balance(d.Balance))
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nNow %s; %.2fg a day from here lasts through %s, %s counting today\n",
+		balance(b.Balance()), b.FromHere(now), b.Until.Format(time.DateOnly),
+		plural(b.DaysLeft(now), "day"))
+}
+
+// balance says how far a balance is ahead of the budget or behind it.
+func balance(grams float64) string {
+	switch {
+	case grams > 0:
+		return fmt.Sprintf("%.2fg ahead", grams)
+	case grams < 0:
+		return fmt.Sprintf("%.2fg behind", -grams)
+	}
+	return "on budget"
+}
+
+func init() {
+	Budget.Flags().StringVar(&budgetUntil, "until", "", "the last day the shelf has to last, defaults to the expected next fill")
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/TheDonDope/wits/pkg/bundle"
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/signing"
 	"github.com/spf13/cobra"
 )
 
@@ -20,10 +22,10 @@ var (
 var Bundle = &cobra.Command{
 	Use:   "bundle",
 	Short: "Write the whole repository to a single compact file",
-	Long: "Write the catalogs and every event to one file, the way `git bundle`\n" +
-		"packs a repository for carrying elsewhere. `wits restore` reads it back\n" +
-		"into an empty repository, reproducing the journal exactly, hashes and\n" +
-		"all.\n\n" +
+	Long: "Write the catalogs, the prescriptions and every event to one file, the\n" +
+		"way `git bundle` packs a repository for carrying elsewhere. `wits\n" +
+		"restore` reads it back into an empty repository, reproducing the journal\n" +
+		"exactly, hashes and all.\n\n" +
 		"The format is plain text, so the record stays legible with nothing but a\n" +
 		"text editor, and diffs cleanly in git. It is small anyway: what the\n" +
 		"journal spends most of its bytes on — sequence numbers, account pairs\n" +
@@ -52,14 +54,15 @@ var Bundle = &cobra.Command{
 		}
 
 		if err := bundle.Write(out, bundle.Contents{
-			Products: s.Products,
-			Devices:  s.Devices,
-			Events:   s.State.Events,
+			Products:      s.Products,
+			Devices:       s.Devices,
+			Events:        s.State.Journal(),
+			Prescriptions: s.Prescriptions,
 		}); err != nil {
 			return err
 		}
 		if bundleOut != "" {
-			fmt.Fprintf(cmd.ErrOrStderr(), "Bundled %d events into %s\n", len(s.State.Events), bundleOut)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Bundled %d events into %s\n", len(s.State.Journal()), bundleOut)
 		}
 		return nil
 	},
@@ -74,15 +77,19 @@ var Restore = &cobra.Command{
 		"one the bundle was written from.\n\n" +
 		"The repository must be empty. Restoring into a journal that already holds\n" +
 		"events would interleave two histories, and there is no way to do that\n" +
-		"without deciding which one is right.",
-	Example: "  wits init . && wits restore history.wits",
-	Args:    cobra.ExactArgs(1),
+		"without deciding which one is right.\n\n" +
+		"Signed checkpoints copied into .wits/checkpoints/ beforehand are checked\n" +
+		"against the restored chain before anything is written, and a bundle that\n" +
+		"no longer passes through every one of them is refused.",
+	Example: "  wits init . && wits restore history.wits\n" +
+		"  wits init . && cp -r ../signed/.wits/checkpoints .wits/ && wits restore history.wits",
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := open()
 		if err != nil {
 			return err
 		}
-		if n := len(s.State.Events); n > 0 {
+		if n := len(s.State.Journal()); n > 0 {
 			return fmt.Errorf("journal already holds %d events; restore into an empty repository", n)
 		}
 
@@ -108,29 +115,65 @@ var Restore = &cobra.Command{
 		}
 
 		if contents.Products != nil && len(contents.Products.Products) > 0 {
-			if err := contents.Products.Save(s.Repo.ProductsPath()); err != nil {
+			if err := s.Repo.SaveProducts(contents.Products); err != nil {
 				return err
 			}
 		}
 		if contents.Devices != nil && len(contents.Devices.Devices) > 0 {
-			if err := contents.Devices.Save(s.Repo.DevicesPath()); err != nil {
+			if err := s.Repo.SaveDevices(contents.Devices); err != nil {
 				return err
 			}
 		}
-		for i, e := range contents.Events {
-			if _, err := s.Journal().Append(e); err != nil {
-				return fmt.Errorf("restoring event %d: %w", i+1, err)
+		if len(contents.Prescriptions.Prescriptions) > 0 {
+			if err := s.Repo.SavePrescriptions(contents.Prescriptions); err != nil {
+				return err
 			}
 		}
+		// The checkpoints already in the repository — carried across with the
+		// bundle — are held against the journal it is about to become, before
+		// any of it is written: a bundle that has been edited since one was
+		// signed is refused whole.
+		if err := verifyCheckpoints(s, contents.Events); err != nil {
+			return err
+		}
+		// One append for the lot: a bundle that cannot be restored whole leaves
+		// the journal as empty as it found it, ready to try again.
+		if _, err := s.Journal().AppendAll(contents.Events); err != nil {
+			return fmt.Errorf("restoring: %w", err)
+		}
 		if err := s.Journal().Verify(); err != nil {
 			return fmt.Errorf("the restored journal does not verify: %w", err)
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Restored %d products, %d devices and %d events.\n",
-			len(contents.Products.Products), len(contents.Devices.Devices), len(contents.Events))
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored %d products, %d devices, %d prescriptions and %d events.\n",
+			len(contents.Products.Products), len(contents.Devices.Devices),
+			len(contents.Prescriptions.Prescriptions), len(contents.Events))
 		return nil
 	},
 }
 
+// verifyCheckpoints checks every signed checkpoint in the repository against
+// the journal the events would make.
+func verifyCheckpoints(s *session, events []journal.Event) error {
+	checkpoints, err := signing.Load(s.Repo)
+	if err != nil || len(checkpoints) == 0 {
+		return err
+	}
+	chained, err := journal.Chain(events)
+	if err != nil {
+		return err
+	}
+	trusted, err := signing.PublicKey(s.Repo)
+	if err != nil {
+		return err
+	}
+	for _, c := range checkpoints {
+		if err := signing.Verify(c, chained, trusted); err != nil {
+			return fmt.Errorf("refusing to restore: %w", err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	Bundle.Flags().StringVar(&bundleOut, "out", "", "write to this file instead of stdout")
 	Bundle.Flags().BoolVar(&bundleGzip, "gzip", false, "compress the bundle")
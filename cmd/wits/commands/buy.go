@@ -2,13 +2,18 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/TheDonDope/wits/pkg/ledger"
 )
 
 var (
-	buyDate string
-	buySlug string
+	buyDate     string
+	buySlug     string
+	buyPrice    string
+	buyCurrency string
 )
 
 // Buy is the `wits buy` command.
@@ -19,10 +24,13 @@ var Buy = &cobra.Command{
 		"A product that is not in the catalog yet is added to it. The name is\n" +
 		"parsed for a manufacturer, a THC/CBD ratio and a cultivar where it\n" +
 		"follows the usual convention; anything it gets wrong can be corrected in\n" +
-		".wits/products.yml.",
+		".wits/products.yml.\n\n" +
+		"With --price, the fill is recorded with what it cost in total, which\n" +
+		"`wits spend` adds up per product, per cycle and per year.",
 	Example: "  wits buy \"Enua 22/1 Wedding Cake\" 20g\n" +
 		"  wits buy \"Cannamedical 28/1 Lemon Cookie\" 10g --slug lemon\n" +
-		"  wits buy \"Cantourage 25/1 MAC1+\" 20g --date 2026-07-09",
+		"  wits buy \"Cantourage 25/1 MAC1+\" 20g --date 2026-07-09\n" +
+		"  wits buy wedding-cake 20g --price 189,90",
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := open()
@@ -37,7 +45,15 @@ var Buy = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		e, product, added, err := s.Recorder.Buy(args[0], buySlug, grams, at)
+		var price int64
+		var currency string
+		if buyPrice != "" {
+			if price, err = parsePrice(buyPrice); err != nil {
+				return err
+			}
+			currency = strings.ToUpper(strings.TrimSpace(buyCurrency))
+		}
+		e, product, added, err := s.Recorder.BuyPriced(args[0], buySlug, grams, at, price, currency)
 		if err != nil {
 			return err
 		}
@@ -47,8 +63,13 @@ var Buy = &cobra.Command{
 			fmt.Fprintf(cmd.OutOrStdout(), "New product %s — refer to it as %s\n",
 				product.Name, product.Slug)
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "[%s] purchase %.2fg %s into storage\n",
-			shortHash(e.Hash), e.Grams, product.Slug)
+		cost := ""
+		if e.Price != 0 {
+			cost = fmt.Sprintf(" for %s (%s/g)", ledger.Money(e.Price, e.Currency),
+				perGram(e.Price, e.Grams, e.Currency))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%s] purchase %.2fg %s into storage%s\n",
+			shortHash(e.Hash), e.Grams, product.Slug, cost)
 		return nil
 	},
 }
@@ -56,4 +77,6 @@ var Buy = &cobra.Command{
 func init() {
 	Buy.Flags().StringVar(&buyDate, "date", "", "the date the fill happened, defaults to now")
 	Buy.Flags().StringVar(&buySlug, "slug", "", "what to call it from now on; made up if not given")
+	Buy.Flags().StringVar(&buyPrice, "price", "", "what the fill cost in total, such as 189.90")
+	Buy.Flags().StringVar(&buyCurrency, "currency", "EUR", "the currency the price is in")
 }
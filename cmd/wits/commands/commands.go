@@ -8,6 +8,8 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,6 +18,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/TheDonDope/wits/pkg/workspace"
 )
 
@@ -25,9 +28,25 @@ type session struct {
 	*workspace.Workspace
 }
 
-// open reads the repository containing the working directory.
+// patient is whose entries a command reads and records, set by --patient or
+// else by WITS_PATIENT.
+var patient string
+
+// PatientFlag adds --patient to a command and every command under it: the
+// root's, so that it is everyone's.
+func PatientFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&patient, "patient", "",
+		"whose entries to read and record, in a household of several patients (or WITS_PATIENT)")
+}
+
+// open reads the repository containing the working directory, for the
+// patient asked for.
 func open() (*session, error) {
-	ws, err := workspace.Here()
+	who := patient
+	if who == "" {
+		who = os.Getenv("WITS_PATIENT")
+	}
+	ws, err := workspace.HereFor(who)
 	if err != nil {
 		return nil, err
 	}
@@ -47,6 +66,31 @@ func parseGrams(s string) (float64, error) {
 	return grams, nil
 }
 
+// parsePrice reads a price written as "189.90", "189,90" or "189" into
+// hundredths, which is how it is kept: a price read as a float and added up
+// over a year would not come to what the receipts say.
+func parsePrice(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.Replace(strings.TrimSpace(s), ",", ".", 1), ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("%q has more than two decimals", s)
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("%q is not a price", s)
+	}
+	var hundredths int64
+	if frac != "" {
+		if hundredths, err = strconv.ParseInt((frac + "0")[:2], 10, 64); err != nil || strings.ContainsAny(frac, "+-") {
+			return 0, fmt.Errorf("%q is not a price", s)
+		}
+	}
+	price := units*100 + hundredths
+	if price <= 0 {
+		return 0, fmt.Errorf("a price must be positive, got %q", s)
+	}
+	return price, nil
+}
+
 // parseDate reads a --date flag. An empty value means now, so that the common
 // case of logging as you go needs no flag at all.
 func parseDate(s string) (time.Time, error) {
@@ -61,6 +105,32 @@ func parseDate(s string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("%q is not a date, expected YYYY-MM-DD", s)
 }
 
+// asOf is a moment to read the ledger at, with the way the commands name it.
+type asOf struct {
+	ledger.Moment
+	label string
+}
+
+// parseAsOf reads an --as-of flag the way ledger.ParseMoment does, looking an
+// entry up among the patient's.
+func parseAsOf(s *session, v string) (asOf, error) {
+	m, err := ledger.ParseMoment(v, s.Recorder.Find)
+	if err != nil {
+		return asOf{}, err
+	}
+	if m.Entry != "" {
+		return asOf{Moment: m, label: shortHash(m.Entry)}, nil
+	}
+	return asOf{Moment: m, label: v}, nil
+}
+
+// forced warns about an overdraft recorded with --force.
+func forced(out io.Writer) func(error) {
+	return func(err error) {
+		fmt.Fprintf(out, "⚠️  %v; recording it anyway.\n", err)
+	}
+}
+
 // shortHash abbreviates an event hash the way git abbreviates a commit.
 func shortHash(h string) string {
 	if len(h) > 7 {
@@ -128,8 +198,8 @@ func completeEntry(_ *cobra.Command, args []string, prefix string) ([]string, co
 		if !strings.HasPrefix(short, prefix) {
 			continue
 		}
-		out = append(out, fmt.Sprintf("%s\t%s %.2f g %s on %s",
-			short, e.Type, e.Grams, e.Product, e.OccurredAt.Format(time.DateOnly)))
+		out = append(out, fmt.Sprintf("%s\t%s %s %s on %s",
+			short, e.Type, amount(e), e.Product, e.OccurredAt.Format(time.DateOnly)))
 	}
 	return out, cobra.ShellCompDirectiveNoFileComp
 }
@@ -2,14 +2,22 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/TheDonDope/wits/pkg/agent"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/seal"
+	"github.com/TheDonDope/wits/pkg/tui"
+	"github.com/TheDonDope/wits/pkg/workspace"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,69 +98,924 @@ func TestBuyAndGrind(t *testing.T) {
 		assert.ErrorContains(t, err, "cannot take", "Should not let the balance go negative")
 	})
 
+	t.Run("RefusesABackdatedGrindBeforeThePurchase", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-10")
+		require.NoError(t, err)
+		defer func() { grindDate, grindForce = "", false }()
+
+		_, err = run(t, dir, Grind, "wedding", "1", "--date", "2026-07-09")
+		assert.ErrorContains(t, err, "on 2026-07-09", "Should check the jar as it was that day")
+
+		out, err := run(t, dir, Grind, "wedding", "1", "--date", "2026-07-09", "--force")
+		require.NoError(t, err, "Should record it when forced")
+		assert.Contains(t, out, "⚠️  only 0.00g of wcake-221 in storage on 2026-07-09", "and warn")
+	})
+
 	t.Run("RefusesAnUnknownProduct", func(t *testing.T) {
 		dir := repository(t)
 
-		_, err := run(t, dir, Grind, "blueberry", "1")
+		_, err := run(t, dir, Grind, "blueberry", "1")
+
+		assert.Error(t, err, "Should not grind a product it has never seen")
+	})
+
+	t.Run("RejectsANonsenseAmount", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+		require.NoError(t, err)
+
+		_, err = run(t, dir, Grind, "wedding", "lots")
+
+		assert.ErrorContains(t, err, "not an amount", "Should reject an amount it cannot read")
+	})
+
+	t.Run("OutsideARepository", func(t *testing.T) {
+		_, err := run(t, t.TempDir(), Status)
+
+		assert.ErrorContains(t, err, "not a wits repository", "Should say there is no repository")
+	})
+}
+
+func TestStatusCommand(t *testing.T) {
+	t.Run("ReportsTheCurrentCycle", func(t *testing.T) {
+		dir := repository(t)
+		yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", yesterday)
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "2", "--date", yesterday)
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "On cycle 1", "Should report the cycle")
+		assert.Contains(t, out, "18.00g", "Should report what is left in storage")
+		assert.Contains(t, out, "days left at that rate", "Should estimate the supply")
+	})
+
+	// A cycle opened today is what every new repository shows, so its wording is
+	// the first anyone reads.
+	t.Run("CountsTheFirstDayInTheSingular", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "0.75")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "left over 1 day,", "Should not report a cycle as being 1 days old")
+		assert.NotContains(t, out, "1 days", "Should not report any count of one in the plural")
+	})
+
+	t.Run("PlacesABackdatedEntryInItsCycle", func(t *testing.T) {
+		dir := repository(t)
+		daysAgo := func(n int) string { return time.Now().AddDate(0, 0, -n).Format(time.DateOnly) }
+		defer func() { buyDate, grindDate = "", "" }()
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(10))
+		require.NoError(t, err)
+		_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "10g", "--date", daysAgo(1))
+		require.NoError(t, err)
+		// A grind forgotten at the time, typed in after the second fill.
+		_, err = run(t, dir, Grind, "wedding", "5", "--date", daysAgo(5))
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "On cycle 2", out)
+		assert.Contains(t, out, "Nothing ground yet this cycle",
+			"Should count the grind in the cycle it happened in, not the one it was typed in")
+	})
+
+	t.Run("EmptyRepository", func(t *testing.T) {
+		out, err := run(t, repository(t), Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "No cycle in progress", "Should not pretend there is a cycle")
+	})
+
+	t.Run("ReadsTheCycleBySessions", func(t *testing.T) {
+		dir := repository(t)
+		defer func() { statusSessions, seshTemp = false, 0 }()
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "2")
+		require.NoError(t, err)
+		for _, sesh := range [][]string{{"0.1", "180"}, {"0.2", "190"}, {"0.3", "200"}} {
+			_, err = run(t, dir, Sesh, "wedding", sesh[0], "--temp", sesh[1])
+			require.NoError(t, err)
+		}
+
+		out, err := run(t, dir, Status, "--sessions")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "3 sessions over 1 day, 3.00 a day with a session", "Should count the sessions a day")
+		assert.Contains(t, out, "0.20g per session (median), 0.10g to 0.30g", "Should spread the grams per session")
+		assert.Contains(t, out, "190°C median temperature, over 3 of 3 sessions", "Should give the median temperature")
+		assert.Contains(t, out, "1.40g in the stash, about 2 days at that rate", "Should say how long the stash lasts")
+		assert.NotContains(t, out, "PRODUCT", "Should not print the grind table")
+	})
+}
+
+func TestDoses(t *testing.T) {
+	dir := repository(t)
+	defer func() { grindDate, seshDate, seshDevice, seshTemp, deviceEff = "", "", "", 0, 0 }()
+	_, err := run(t, dir, Device, "add", "Volcano", "--efficiency", "50")
+	require.NoError(t, err)
+	deviceEff = 0
+	_, err = run(t, dir, Device, "add", "Mighty")
+	require.NoError(t, err)
+	_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "2")
+	require.NoError(t, err)
+	_, err = run(t, dir, Sesh, "wedding", "0.3", "--device", "volcano")
+	require.NoError(t, err)
+	_, err = run(t, dir, Sesh, "wedding", "0.2", "--device", "mighty")
+	require.NoError(t, err)
+
+	t.Run("StatusCountsMilligrams", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "110mg THC and 5mg CBD put through a device in 2 sessions",
+			"Should multiply the grams by the product's strength")
+		assert.Contains(t, out, "110mg THC and 5mg CBD today", "Should total today")
+		assert.Contains(t, out, "33mg THC and 2mg CBD extracted at the devices' efficiencies, from 0.30g of 0.50g",
+			"Should only assume an extraction for the device that has an efficiency")
+	})
+
+	t.Run("SetsAnEfficiencyLater", func(t *testing.T) {
+		out, err := run(t, dir, Device, "efficiency", "mighty", "80%")
+		require.NoError(t, err)
+		assert.Contains(t, out, "mighty is assumed to extract 80%", "Should confirm the assumption")
+
+		out, err = run(t, dir, Device, "list")
+		require.NoError(t, err)
+		assert.Regexp(t, `mighty\s+Mighty.*80%`, out, "Should list the efficiency")
+
+		out, err = run(t, dir, Status)
+		require.NoError(t, err)
+		assert.Contains(t, out, "from 0.50g of 0.50g", "Should now count every session as extracted")
+	})
+
+	t.Run("RefusesAnEfficiencyAboveAHundred", func(t *testing.T) {
+		_, err := run(t, dir, Device, "efficiency", "mighty", "120")
+
+		assert.ErrorContains(t, err, "from 0 to 100", "Should refuse an impossible percentage")
+	})
+
+	t.Run("ShowsTheDoseOfASession", func(t *testing.T) {
+		t.Chdir(dir)
+		s, err := open()
+		require.NoError(t, err)
+		out, err := run(t, dir, Show, shortHash(s.State.Events[2].Hash))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "66mg THC and 3mg CBD, 33mg THC and 2mg CBD extracted", "Should dose the one session")
+	})
+}
+
+func TestAsOf(t *testing.T) {
+	dir := repository(t)
+	daysAgo := func(n int) string { return time.Now().AddDate(0, 0, -n).Format(time.DateOnly) }
+	defer func() { buyDate, grindDate, statusAsOf, logAsOf = "", "", "", "" }()
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(10))
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "2", "--date", daysAgo(8))
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "3", "--date", daysAgo(1))
+	require.NoError(t, err)
+
+	t.Run("StatusAsItHappened", func(t *testing.T) {
+		out, err := run(t, dir, Status, "--as-of", daysAgo(5))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "As of "+daysAgo(5)+", as it happened", "Should say which moment it reads")
+		assert.Regexp(t, `wcake-221\s+18.00g`, out, "Should leave out the grind since")
+		assert.Contains(t, out, "(day 6)", "Should count the days of the cycle up to then")
+	})
+
+	t.Run("StatusAsRecorded", func(t *testing.T) {
+		out, err := run(t, dir, Status, "--as-of", daysAgo(5))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "did not hold 2 entries dated by then yet", "Should own up to what was typed in later")
+		assert.Regexp(t, `wcake-221\s+0.00g\s+0.00g\s+0.00g\s+believed, against 18.00g, 2.00g`, out,
+			"Should set what the ledger believed beside what happened")
+	})
+
+	t.Run("StatusAtAnEntry", func(t *testing.T) {
+		t.Chdir(dir)
+		s, err := open()
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status, "--as-of", shortHash(s.State.Events[1].Hash))
+
+		require.NoError(t, err)
+		assert.Regexp(t, `wcake-221\s+18.00g`, out, "Should read the state right after the entry")
+		assert.Contains(t, out, "held the same", "Should find both readings agree at an entry's own moments")
+	})
+
+	t.Run("LogMarksWhatCameLater", func(t *testing.T) {
+		out, err := run(t, dir, Log, "--as-of", daysAgo(5))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "recorded later, "+time.Now().Format(time.DateOnly), "Should mark the entries typed in since")
+		assert.NotContains(t, out, "3.00g", "Should leave out the grind dated after")
+	})
+
+	t.Run("RefusesSomethingThatIsNeither", func(t *testing.T) {
+		_, err := run(t, dir, Status, "--as-of", "yesterday")
+
+		assert.ErrorContains(t, err, "neither a date nor an entry", "Should say what it expected")
+	})
+}
+
+func TestAVBCommand(t *testing.T) {
+	dir := repository(t)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	defer func() { buyDate, grindDate, seshDate, seshDevice, seshTemp = "", "", "", "", 0 }()
+	defer func() { avbPurpose = "" }()
+	_, err := run(t, dir, Device, "add", "Volcano")
+	require.NoError(t, err)
+	_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", yesterday)
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "2", "--date", yesterday)
+	require.NoError(t, err)
+	_, err = run(t, dir, Sesh, "wedding", "1", "--device", "volcano", "--temp", "185", "--date", yesterday)
+	require.NoError(t, err)
+
+	t.Run("CollectsFromTheLastSession", func(t *testing.T) {
+		out, err := run(t, dir, AVB, "collect", "wedding", "0.3g")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "0.30g in the AVB jar", "Should say what the jar holds")
+		assert.Contains(t, out, "volcano at 180-189°C has yielded 0.30g per gram seshed",
+			"Should read the yield for the setting the session used")
+	})
+
+	t.Run("UsesItForAPurpose", func(t *testing.T) {
+		out, err := run(t, dir, AVB, "use", "wedding", "0.2", "--for", "edibles")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "for edibles, 0.10g left", "Should say what it was for and what is left")
+	})
+
+	t.Run("RefusesMoreThanTheJarHolds", func(t *testing.T) {
+		_, err := run(t, dir, AVB, "use", "wedding", "5")
+
+		assert.ErrorContains(t, err, "in the AVB jar", "Should refuse to overdraw the jar")
+	})
+
+	t.Run("ShowsTheYieldInStatus", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "0.30g of AVB collected per gram seshed", "Should report the yield")
+		assert.Contains(t, out, "180-189°C", "Should break it down by temperature band")
+		assert.Contains(t, out, "Used 0.20g: 0.20g for edibles", "Should total the outflow by purpose")
+	})
+}
+
+func TestBatchCommand(t *testing.T) {
+	dir := repository(t)
+	defer func() { buyDate, grindDate, seshDate, seshDevice, seshTemp, deviceEff = "", "", "", "", 0, 0 }()
+	defer func() {
+		batchFrom, batchYield, batchSlug, batchAt, batchDate, batchNote = nil, "", "", "", "", ""
+		batchDecarb, batchResidual, batchForce = ledger.DefaultDecarb, 0, false
+	}()
+	buyDate, grindDate, seshDate = "", "", ""
+	_, err := run(t, dir, Device, "add", "Volcano", "--efficiency", "70")
+	require.NoError(t, err)
+	_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "2")
+	require.NoError(t, err)
+	_, err = run(t, dir, Sesh, "wedding", "2", "--device", "volcano")
+	require.NoError(t, err)
+	_, err = run(t, dir, AVB, "collect", "wedding", "1.5g")
+	require.NoError(t, err)
+
+	t.Run("ListsNoneYet", func(t *testing.T) {
+		out, err := run(t, dir, Batch)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "No batches made yet", "Should say how to make one")
+	})
+
+	t.Run("NeedsTheYield", func(t *testing.T) {
+		_, err := run(t, dir, Batch, "make", "butter", "--from-avb", "1g")
+
+		assert.ErrorContains(t, err, "--yield", "Should ask how much came out")
+	})
+
+	t.Run("MakesABatch", func(t *testing.T) {
+		batchFrom = nil
+		out, err := run(t, dir, Batch, "make", "butter", "--from-avb", "1g", "--yield", "100g")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "made 100.00g butter from 1.00g of AVB", "Should say what came of what")
+		// 1g of AVB was 1.33g of bud, of which the Volcano left 30% and the
+		// butter took up 80%.
+		assert.Contains(t, out, "About 0.70mg THC and 0.03mg CBD a gram", "Should estimate a gram of it")
+	})
+
+	t.Run("ListsTheBatch", func(t *testing.T) {
+		out, err := run(t, dir, Batch)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "0.70mg/g", "Should keep the estimate as its potency")
+		assert.Contains(t, out, "100.00g", "and list what is left")
+	})
+
+	t.Run("TakesAPortion", func(t *testing.T) {
+		out, err := run(t, dir, Batch, "take", "butter", "10g")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "portion 10.00g butter, about 7mg THC", "Should dose the portion")
+		assert.Contains(t, out, "90.00g left", "and say what is left of the batch")
+	})
+
+	t.Run("ShowsTheBatchInStatus", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "90.00g of 100.00g of butter left", "Should say what is left of each batch")
+	})
+
+	t.Run("RefusesMoreAVBThanThereIs", func(t *testing.T) {
+		batchFrom, batchYield = nil, ""
+		_, err := run(t, dir, Batch, "make", "oil", "--from-avb", "wedding=1g", "--yield", "50g")
+
+		assert.ErrorContains(t, err, "in the AVB jar", "Should refuse to overdraw the jar")
+	})
+}
+
+func TestFeelCommand(t *testing.T) {
+	dir := repository(t)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+	defer func() { buyDate, grindDate, seshDate, seshDevice, seshTemp = "", "", "", "", 0 }()
+	defer func() { feelDate, feelSession, feelNote = "", "", "" }()
+	_, err := run(t, dir, Device, "add", "Volcano")
+	require.NoError(t, err)
+	_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", yesterday)
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "2", "--date", yesterday)
+	require.NoError(t, err)
+	_, err = run(t, dir, Sesh, "wedding", "0.3", "--device", "volcano", "--temp", "185", "--date", yesterday)
+	require.NoError(t, err)
+
+	t.Run("ScoresOnItsOwn", func(t *testing.T) {
+		out, err := run(t, dir, Feel, "sleep=3", "pain=6")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "feel pain=6 sleep=3", "Should read the scores back in the order of the symptoms")
+		assert.NotContains(t, out, "after", "Should follow no session unless told to")
+	})
+
+	t.Run("FollowsTheLastSession", func(t *testing.T) {
+		out, err := run(t, dir, Feel, "pain=2", "--session", "last")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "after the 0.30g of", "Should say which session it follows")
+		assert.Contains(t, out, "in volcano at 185°C", "with its device and temperature")
+	})
+
+	t.Run("RefusesWhatIsNotAScore", func(t *testing.T) {
+		for args, want := range map[string]string{
+			"pain":           "not a symptom=score pair",
+			"joy=5":          "not a symptom Wits knows",
+			"pain=11":        "scored from 0 to 10",
+			"pain=2 pain=3":  "scored twice",
+			"nausea=several": "scored from 0 to 10",
+		} {
+			_, err := run(t, dir, Feel, strings.Fields(args)...)
+
+			assert.ErrorContains(t, err, want, "Should refuse %q", args)
+		}
+	})
+
+	t.Run("LeavesTheBalancesAlone", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "1.70g", "Should leave the stash as the session left it")
+	})
+
+	t.Run("ShowsAndRevertsLikeAnyEntry", func(t *testing.T) {
+		t.Chdir(dir)
+		s, err := open()
+		require.NoError(t, err)
+		feel := s.State.Events[len(s.State.Events)-1]
+
+		out, err := run(t, dir, Show, shortHash(feel.Hash))
+		require.NoError(t, err)
+		assert.Regexp(t, `pain\s+2 of 10 \(0 none, 10 unbearable\)`, out, "Should show each score on its scale")
+		assert.Contains(t, out, "session", "Should name the session it follows")
+		assert.NotContains(t, out, "grams", "A feel has no grams to show")
+
+		defer func() { logOneline = false }()
+		out, err = run(t, dir, Log, "--oneline")
+		require.NoError(t, err)
+		assert.Contains(t, out, "pain=2", "Should log the scores in place of grams")
+
+		out, err = run(t, dir, Revert, shortHash(feel.Hash))
+		require.NoError(t, err)
+		assert.Contains(t, out, "undid feel pain=2", "Should undo it like any other entry")
+	})
+}
+
+func TestBreakCommand(t *testing.T) {
+	dir := repository(t)
+	daysAgo := func(n int) string { return time.Now().AddDate(0, 0, -n).Format(time.DateOnly) }
+	defer func() { buyDate, grindDate = "", "" }()
+	defer func() { breakDays, breakDate, breakNote = 0, "", "" }()
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(20))
+	require.NoError(t, err)
+	for n := 20; n > 10; n-- {
+		_, err = run(t, dir, Grind, "wedding", "1", "--date", daysAgo(n))
+		require.NoError(t, err)
+	}
+
+	t.Run("DeclaresOne", func(t *testing.T) {
+		out, err := run(t, dir, Break, "start", "--days", "7", "--date", daysAgo(10))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "break of 7 days, until "+daysAgo(3), "Should say when it ends")
+		assert.Contains(t, out, "Ground 1.00g a day in the 7 days before it", "Should say what it is measured against")
+	})
+
+	t.Run("WarnsOfALapse", func(t *testing.T) {
+		out, err := run(t, dir, Grind, "wedding", "1", "--date", daysAgo(8))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "day 3 of the break of 7 days", "Should say the grind fell in the break")
+		assert.Contains(t, out, "counts as a lapse", "and what that means")
+	})
+
+	t.Run("OneAtATime", func(t *testing.T) {
+		_, err := run(t, dir, Break, "start", "--days", "3", "--date", daysAgo(9))
+
+		assert.ErrorContains(t, err, "revert it to declare another", "Should refuse a break inside a break")
+	})
+
+	t.Run("NeedsItsDays", func(t *testing.T) {
+		breakDays = 0
+		_, err := run(t, dir, Break, "start")
+
+		assert.ErrorContains(t, err, "--days", "Should ask how long it lasts")
+	})
+
+	t.Run("ComparesBeforeAndAfter", func(t *testing.T) {
+		for n := 3; n > 0; n-- {
+			_, err := run(t, dir, Grind, "wedding", "0.5", "--date", daysAgo(n))
+			require.NoError(t, err)
+		}
+
+		out, err := run(t, dir, Break)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "1 day without a session or grind", "Should count the streak")
+		assert.Contains(t, out, "1 lapse", "Should say the break was not kept")
+		assert.Contains(t, out, "1.00g/day", "Should show the days before")
+		assert.Contains(t, out, "0.50g/day (3 days)", "and the days after so far")
+		assert.Contains(t, out, "-50%", "Should read the change")
+	})
+
+	t.Run("ProjectsFromTheEndOfOne", func(t *testing.T) {
+		breakDate = ""
+		out, err := run(t, dir, Break, "start", "--days", "14")
+		require.NoError(t, err)
+		assert.Contains(t, out, "break of 14 days", "Should declare one from now")
+
+		out, err = run(t, dir, Break)
+		require.NoError(t, err)
+		assert.Contains(t, out, "Day 1 of a break of 14 days, 13 days to go, kept so far", "Should say where it stands")
+
+		out, err = run(t, dir, Status)
+		require.NoError(t, err)
+		assert.Contains(t, out, "from the end of the break on", "Should not count the break against the supply")
+	})
+}
+
+func TestRxCommand(t *testing.T) {
+	dir := repository(t)
+	week := time.Now().AddDate(0, 0, -6).Format(time.DateOnly)
+	defer func() { buyDate, grindDate = "", "" }()
+	defer func() { rxDate, rxPrescriber, rxMaxDaily = "", "", "" }()
+
+	t.Run("AddsOneForProductsNotBoughtYet", func(t *testing.T) {
+		out, err := run(t, dir, Rx, "add", "Enua 22/1 Wedding Cake", "20", "Cannamedical 28/1 Lemon Cookie", "10g",
+			"--prescriber", "Dr. Weber", "--date", week, "--max-daily", "1")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "refer to it as wcake-221", "Should add the product to the catalog")
+		assert.Contains(t, out, "Added prescription 1 for 30.00g", "Should number the prescription")
+	})
+
+	t.Run("RefusesAnOddNumberOfArguments", func(t *testing.T) {
+		_, err := run(t, dir, Rx, "add", "wcake-221", "20", "lemon")
+
+		assert.ErrorContains(t, err, "pairs of a product and an amount", "Should want an amount for every product")
+	})
+
+	t.Run("ListsThemWithWhatFilledThem", func(t *testing.T) {
+		_, err := run(t, dir, Buy, "wcake-221", "17.5", "--date", week)
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Rx, "list")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Dr. Weber", "Should name the prescriber")
+		assert.Contains(t, out, "17.50g of 30.00g", "Should say how much has been filled")
+	})
+
+	t.Run("ShowsTheLinkedPurchases", func(t *testing.T) {
+		out, err := run(t, dir, Rx, "show", "1")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Filled by 1 purchase", "Should list the purchase that filled it")
+		assert.Regexp(t, `wcake-221\s+20.00g\s+17.50g\s+2.50g`, out, "Should break it down by product")
+	})
+
+	t.Run("StatusReportsWhatIsUnfilled", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "12.5 g of 30 g prescribed still unfilled", "Should total what is still open")
+		assert.NotContains(t, out, "⚠️", "Should not warn while within the daily maximum")
+	})
+
+	t.Run("StatusWarnsAboveTheDailyMaximum", func(t *testing.T) {
+		_, err := run(t, dir, Grind, "wcake-221", "10", "--date", week)
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "1.43g a day over the last 7 days, above the 1g a day prescription 1 allows",
+			"Should warn when the average is above the prescribed maximum")
+	})
+}
+
+func TestPatientFlag(t *testing.T) {
+	dir := repository(t)
+	defer func() { patient = "" }()
+
+	t.Run("KeepsAPatientsStorageTheirOwn", func(t *testing.T) {
+		patient = "anna"
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+		require.NoError(t, err)
+
+		patient = "ben"
+		_, err = run(t, dir, Grind, "wcake-221", "1")
+
+		assert.ErrorContains(t, err, "cannot take", "Should never grind from another patient's storage")
+	})
+
+	t.Run("StampsThePrescription", func(t *testing.T) {
+		patient = "ben"
+		defer func() { rxDate, rxPrescriber, rxMaxDaily = "", "", "" }()
+		_, err := run(t, dir, Rx, "add", "wcake-221", "10")
+		require.NoError(t, err)
+
+		patient = "anna"
+		out, err := run(t, dir, Rx, "list")
+
+		require.NoError(t, err)
+		assert.NotContains(t, out, "10.00g", "Should list only the patient's own prescriptions")
+	})
+
+	t.Run("StatusNamesThePatient", func(t *testing.T) {
+		patient = "ben"
+		_, err := run(t, dir, Buy, "wcake-221", "5g")
+		require.NoError(t, err)
+
+		patient = "anna"
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Patient anna; also in this journal: ben", "Should say whose status it is")
+		assert.Contains(t, out, "20.00g of 20.00g left", "Should count the patient's fill alone")
+	})
+
+	t.Run("ReadsTheEnvironment", func(t *testing.T) {
+		patient = ""
+		t.Setenv("WITS_PATIENT", "ben")
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Patient ben", "Should take the patient from WITS_PATIENT")
+		assert.Contains(t, out, "5.00g of 5.00g left", "and read their status")
+	})
+
+	t.Run("BundlesEveryPatient", func(t *testing.T) {
+		patient = "anna"
+		defer func() { bundleOut = "" }()
+		bundled := filepath.Join(t.TempDir(), "history.wits")
+		out, err := run(t, dir, Bundle, "--out", bundled)
+		require.NoError(t, err)
+		assert.Contains(t, out, "Bundled 2 events", "Should bundle the whole journal, whoever is selected")
+
+		// A repository holding only ben's entries is no more empty for anna.
+		other := repository(t)
+		patient = "ben"
+		_, err = run(t, other, Buy, "Enua 22/1 Wedding Cake", "5g")
+		require.NoError(t, err)
+		patient = "anna"
+		_, err = run(t, other, Restore, bundled)
+
+		assert.ErrorContains(t, err, "already holds 1 events", "Should refuse to restore over another patient's entries")
+	})
+
+	t.Run("ShowsOnlyThePatientsEntries", func(t *testing.T) {
+		patient = "ben"
+		t.Chdir(dir)
+		s, err := open()
+		require.NoError(t, err)
+		bought := s.State.Journal()[0]
+
+		_, err = run(t, dir, Show, shortHash(bought.Hash))
+
+		assert.ErrorContains(t, err, "is anna's entry; reach it with --patient anna",
+			"Should say whose entry it is rather than show it without its balances")
+	})
+
+	t.Run("SignsTheTip", func(t *testing.T) {
+		patient = "anna"
+		defer func() { signNewKey = false }()
+		out, err := run(t, dir, Sign, "--new-key")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Signed entry 2", "Should sign the journal's last entry, whoever's it is")
+	})
+
+	t.Run("RefusesANameThatIsNotAPatients", func(t *testing.T) {
+		patient = "Anna Smith"
+		_, err := run(t, dir, Status)
+
+		assert.Error(t, err, "Should refuse rather than start a patient nobody meant")
+	})
+}
+
+func TestBudgetCommand(t *testing.T) {
+	daysAgo := func(n int) string { return time.Now().AddDate(0, 0, -n).Format(time.DateOnly) }
+	defer func() { buyDate, grindDate = "", "" }()
+
+	t.Run("LaysTheDaysAgainstTheAllowance", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(2))
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "1", "--date", daysAgo(2))
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "4", "--date", daysAgo(1))
+		require.NoError(t, err)
+		defer func() { budgetUntil = "" }()
+
+		out, err := run(t, dir, Budget, "--until", time.Now().AddDate(0, 0, 7).Format(time.DateOnly))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "2.00g a day from "+daysAgo(2), "Should spread the fill over the ten days")
+		assert.Regexp(t, daysAgo(2)+`\s+1.00g\s+1.00g ahead`, out, "Should be ahead after the first day")
+		assert.Regexp(t, daysAgo(1)+`\s+4.00g\s+1.00g behind`, out, "Should be behind after the second")
+		assert.Contains(t, out, "Now 1.00g ahead", "Should gain a day's allowance today")
+		assert.Contains(t, out, "1.88g a day from here", "Should spread the rest over the eight days left")
+	})
+
+	t.Run("AgreesWithTheDashboard", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(30))
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "2", "--date", daysAgo(5))
+		require.NoError(t, err)
+		// Filled before that grind, and only written down after it.
+		_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(10))
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Budget)
+		require.NoError(t, err)
+		s, err := open()
+		require.NoError(t, err)
+		card, err := tui.Snapshot(tui.From(s.Workspace), "Dashboard", 110, 80, nil)
+		require.NoError(t, err)
+
+		said := regexp.MustCompile(`([0-9.]+)g a day from(?s:.*)Now ([0-9.]+g (ahead|behind))`).FindStringSubmatch(out)
+		require.NotNil(t, said, "Should give an allowance and where today stands, got %s", out)
+		shown := regexp.MustCompile(`([0-9.]+) g a day  until(?s:.*?)([0-9.]+ g (ahead|behind))`).FindStringSubmatch(card)
+		require.NotNil(t, shown, "The dashboard should show a budget")
+		assert.Equal(t, said[1], shown[1], "The dashboard should budget the same allowance as the command")
+		assert.Equal(t, said[2], strings.Replace(shown[2], " g", "g", 1), "and stand the same today")
+	})
+
+	t.Run("NeedsADateWithOnlyOneFill", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+		require.NoError(t, err)
+
+		_, err = run(t, dir, Budget)
+
+		assert.ErrorContains(t, err, "--until", "Should ask for a date it cannot estimate")
+	})
+
+	t.Run("RefusesADateThatHasPassed", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", daysAgo(5))
+		require.NoError(t, err)
+
+		defer func() { budgetUntil = "" }()
+
+		_, err = run(t, dir, Budget, "--until", daysAgo(1))
+
+		assert.ErrorContains(t, err, "already passed", "Should not budget for the past")
+	})
+}
+
+func TestLotsCommand(t *testing.T) {
+	dir := repository(t)
+	defer func() { buyDate, grindDate = "", "" }()
 
-		assert.Error(t, err, "Should not grind a product it has never seen")
+	t.Run("NothingInStorage", func(t *testing.T) {
+		out, err := run(t, dir, Lots)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Nothing in storage.", "Should say the shelf is bare")
 	})
 
-	t.Run("RejectsANonsenseAmount", func(t *testing.T) {
-		dir := repository(t)
-		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+	t.Run("SplitsASharedJarByFill", func(t *testing.T) {
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "10g", "--date", "2026-06-01")
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wcake-221", "7g", "--date", "2026-06-20")
+		require.NoError(t, err)
+		_, err = run(t, dir, Buy, "wcake-221", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		_, err = run(t, dir, Buy, "Cannamedical 28/1 Lemon Cookie", "5g", "--date", "2026-07-01")
 		require.NoError(t, err)
 
-		_, err = run(t, dir, Grind, "wedding", "lots")
+		out, err := run(t, dir, Lots, "wcake-221")
 
-		assert.ErrorContains(t, err, "not an amount", "Should reject an amount it cannot read")
+		require.NoError(t, err)
+		assert.Regexp(t, `wcake-221\s+1\s+2026-06-01\s+\w{7}\s+10.00g\s+3.00g`, out,
+			"Should keep the older fill's remainder on its own cycle")
+		assert.Regexp(t, `wcake-221\s+2\s+2026-07-01\s+\w{7}\s+20.00g\s+20.00g`, out,
+			"Should list the newer fill after it")
+		assert.Contains(t, out, "wcake-221 holds 2 lots: the next grind draws on the 3.00g bought 2026-06-01 first.",
+			"Should say which lot goes first")
+		assert.NotContains(t, out, "lcook", "Should show only the product asked for")
 	})
 
-	t.Run("OutsideARepository", func(t *testing.T) {
-		_, err := run(t, t.TempDir(), Status)
+	t.Run("EveryProductWithoutAnArgument", func(t *testing.T) {
+		out, err := run(t, dir, Lots)
 
-		assert.ErrorContains(t, err, "not a wits repository", "Should say there is no repository")
+		require.NoError(t, err)
+		assert.Contains(t, out, "lcook-281", "Should list every jar")
+		assert.NotContains(t, out, "lcook-281 holds", "A jar of one fill needs no order")
 	})
 }
 
-func TestStatusCommand(t *testing.T) {
-	t.Run("ReportsTheCurrentCycle", func(t *testing.T) {
-		dir := repository(t)
-		yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
-		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", yesterday)
+func TestAnomaliesCommand(t *testing.T) {
+	dir := repository(t)
+	defer func() { buyDate, grindDate = "", "" }()
+	defer func() { anomaliesSessions, anomaliesFactor, anomaliesWindow = false, 0, 0 }()
+
+	// Dated back from today, so no week after them is silent yet.
+	ago := func(days int) string { return time.Now().AddDate(0, 0, -days).Format(time.DateOnly) }
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "50g", "--date", ago(13))
+	require.NoError(t, err)
+	for day := 12; day >= 3; day-- {
+		_, err := run(t, dir, Grind, "wcake-221", "0.5g", "--date", ago(day))
 		require.NoError(t, err)
-		_, err = run(t, dir, Grind, "wedding", "2", "--date", yesterday)
+	}
+
+	t.Run("NothingStandsOut", func(t *testing.T) {
+		out, err := run(t, dir, Anomalies)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Nothing stands out: no day or week of grinding at 3× the usual of the 28 days",
+			"Should say what was looked for")
+	})
+
+	t.Run("WarnsOnlyOnceAskedTo", func(t *testing.T) {
+		out, err := run(t, dir, Grind, "wcake-221", "2g", "--date", ago(2))
 		require.NoError(t, err)
+		assert.NotContains(t, out, "⚠️", "Should stay quiet until warnings are on")
 
-		out, err := run(t, dir, Status)
+		out, err = run(t, dir, Anomalies, "warn", "on")
+		require.NoError(t, err)
+		assert.Contains(t, out, "will be warned about", "Should confirm the setting")
+
+		out, err = run(t, dir, Grind, "wcake-221", "5g", "--date", ago(1))
 
 		require.NoError(t, err)
-		assert.Contains(t, out, "On cycle 1", "Should report the cycle")
-		assert.Contains(t, out, "18.00g", "Should report what is left in storage")
-		assert.Contains(t, out, "days left at that rate", "Should estimate the supply")
+		assert.Regexp(t, `⚠️  that makes 5.00g on `+ago(1)+`, 10.0× the usual day of 0.50g; if it is a typo, `+
+			"`wits revert \\w{7}`", out, "Should warn right after the grind, with the way back")
 	})
 
-	// A cycle opened today is what every new repository shows, so its wording is
-	// the first anyone reads.
-	t.Run("CountsTheFirstDayInTheSingular", func(t *testing.T) {
-		dir := repository(t)
-		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+	t.Run("ListsTheHeavyDays", func(t *testing.T) {
+		out, err := run(t, dir, Anomalies)
+
 		require.NoError(t, err)
-		_, err = run(t, dir, Grind, "wedding", "0.75")
+		assert.Regexp(t, `heavy day\s+`+ago(2)+`\s+-\s+2.00g\s+0.50g\s+4.0×`, out, "Should list the first heavy day")
+		assert.Regexp(t, `heavy day\s+`+ago(1)+`\s+-\s+5.00g\s+0.50g\s+10.0×`, out, "Should list the second")
+	})
+
+	t.Run("TakesAFactor", func(t *testing.T) {
+		out, err := run(t, dir, Anomalies, "--factor", "5")
+
 		require.NoError(t, err)
+		assert.NotContains(t, out, ago(2), "Should leave out a day under the factor")
+		assert.Contains(t, out, ago(1), "Should keep the day over it")
+
+		_, err = run(t, dir, Anomalies, "--factor", "1")
+		assert.Error(t, err, "Should refuse a factor that flags everything")
+		anomaliesFactor = 0
+		_, err = run(t, dir, Anomalies, "--window", "3")
+		assert.Error(t, err, "Should refuse a window shorter than a week")
+		anomaliesWindow = 0
+	})
 
-		out, err := run(t, dir, Status)
+	t.Run("WarnOff", func(t *testing.T) {
+		_, err := run(t, dir, Anomalies, "warn", "off")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Grind, "wcake-221", "5g", "--date", ago(0))
 
 		require.NoError(t, err)
-		assert.Contains(t, out, "left over 1 day,", "Should not report a cycle as being 1 days old")
-		assert.NotContains(t, out, "1 days", "Should not report any count of one in the plural")
+		assert.NotContains(t, out, "⚠️", "Should stop warning once turned off")
 	})
+}
 
-	t.Run("EmptyRepository", func(t *testing.T) {
-		out, err := run(t, repository(t), Status)
+func TestSpendCommand(t *testing.T) {
+	dir := repository(t)
+	defer func() { buyDate, buyPrice, buyCurrency, spendYear = "", "", "EUR", 0 }()
+	defer func() { rxDate, rxPrescriber = "", "" }()
+
+	t.Run("NothingPricedYet", func(t *testing.T) {
+		out, err := run(t, dir, Spend)
 
 		require.NoError(t, err)
-		assert.Contains(t, out, "No cycle in progress", "Should not pretend there is a cycle")
+		assert.Contains(t, out, "No purchase has a price yet", "Should say how to record one")
+	})
+
+	t.Run("BuyRecordsThePrice", func(t *testing.T) {
+		_, err := run(t, dir, Rx, "add", "Enua 22/1 Wedding Cake", "30", "--prescriber", "Dr. Weber",
+			"--date", "2025-03-01")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Buy, "wcake-221", "20g", "--date", "2025-03-02", "--price", "189,90")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "for 189.90 EUR (9.50 EUR/g)", "Should repeat the price and the price of a gram")
+	})
+
+	t.Run("RefusesAPriceThatIsNotOne", func(t *testing.T) {
+		for _, price := range []string{"-5", "abc", "1.999", "0"} {
+			_, err := run(t, dir, Buy, "wcake-221", "20g", "--price", price)
+			assert.Error(t, err, "Should refuse %q as a price", price)
+		}
+	})
+
+	t.Run("TotalsPerYearProductAndCycle", func(t *testing.T) {
+		_, err := run(t, dir, Buy, "Cannamedical 28/1 Lemon Cookie", "10g", "--date", "2025-03-02",
+			"--price", "105", "--currency", "chf")
+		require.NoError(t, err)
+		buyCurrency = "EUR"
+		_, err = run(t, dir, Buy, "wcake-221", "10g", "--date", "2026-01-05", "--price", "95")
+		require.NoError(t, err)
+		buyPrice = ""
+		_, err = run(t, dir, Buy, "wcake-221", "5g", "--date", "2026-01-05")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Spend)
+
+		require.NoError(t, err)
+		assert.Regexp(t, `2025\s+105.00 CHF\s+10.00g\s+10.50 CHF`, out, "Should keep currencies apart")
+		assert.Regexp(t, `2025\s+189.90 EUR\s+20.00g\s+9.50 EUR`, out, "Should total the year")
+		assert.Regexp(t, `wcake-221\s+284.90 EUR\s+30.00g\s+9.50 EUR`, out, "Should price a gram of each product")
+		assert.Regexp(t, `1 \(2025-03-02\)\s+105.00 CHF`, out, "Should total each cycle")
+		assert.Contains(t, out, "5.00g bought without a price is not counted", "Should own up to unpriced grams")
+	})
+
+	t.Run("ItemisesAYearForAClaim", func(t *testing.T) {
+		out, err := run(t, dir, Spend, "--year", "2025")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Purchases in 2025", "Should title the year")
+		assert.Regexp(t, `2025-03-02\s+Enua 22/1 Wedding Cake\s+Enua\s+1\s+20.00g\s+189.90 EUR\s+9.50 EUR`, out,
+			"Should itemise each purchase with its prescription")
+		assert.Regexp(t, `total\s+20.00g\s+189.90 EUR`, out, "Should total each currency")
+		assert.Contains(t, out, "Prescription 1 issued 2025-03-01 by Dr. Weber", "Should cite the prescriptions")
+		assert.NotContains(t, out, "2026-01-05", "Should leave out other years")
+	})
+
+	t.Run("AYearWithoutPurchases", func(t *testing.T) {
+		out, err := run(t, dir, Spend, "--year", "2020")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "No purchases in 2020", "Should say there is nothing to claim")
 	})
 }
 
@@ -276,6 +1139,71 @@ func TestHomeCommand(t *testing.T) {
 	})
 }
 
+func TestMoveCommand(t *testing.T) {
+	dir := repository(t)
+	defer func() {
+		moveTo, moveFrom, moveStash, moveDate, moveNote = "", "", false, "", ""
+		grindAt, reconcileAt = "", ""
+	}()
+	// Everything here happens today, whatever dates earlier tests left set.
+	buyDate, grindDate = "", ""
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g")
+	require.NoError(t, err)
+
+	t.Run("CarriesGramsToALocation", func(t *testing.T) {
+		out, err := run(t, dir, Move, "wcake", "2g", "--to", "travel")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "home -> travel", "Should say where the grams went")
+		assert.Contains(t, out, "2.00g there, 18.00g left at home", "and what each place holds now")
+	})
+
+	t.Run("NeedsSomewhereToGo", func(t *testing.T) {
+		moveTo = ""
+		_, err := run(t, dir, Move, "wcake", "1")
+
+		assert.ErrorContains(t, err, "--to", "Should ask where the grams go")
+	})
+
+	t.Run("GrindsAtALocation", func(t *testing.T) {
+		out, err := run(t, dir, Grind, "wcake", "0.5", "--at", "travel")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "1.50g left in storage there", "Should draw on the location's storage")
+	})
+
+	t.Run("RefusesMoreThanTheLocationHolds", func(t *testing.T) {
+		grindAt = ""
+		_, err := run(t, dir, Grind, "wcake", "1", "--at", "travel")
+		require.NoError(t, err)
+
+		_, err = run(t, dir, Grind, "wcake", "1", "--at", "travel")
+
+		assert.ErrorContains(t, err, "at travel", "Should say which location is short")
+	})
+
+	t.Run("StatusListsTheLocations", func(t *testing.T) {
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "LOCATION", "Should list storage by location")
+		assert.Contains(t, out, "travel", "with the named ones")
+		assert.Contains(t, out, "18.00", "and what is left at home")
+	})
+
+	t.Run("ReconcilesOneLocationAlone", func(t *testing.T) {
+		grindAt = ""
+		out, err := run(t, dir, Reconcile, "wcake", "0.4", "--at", "travel")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "0.10g", "Should record the difference at the location")
+
+		out, err = run(t, dir, Move, "wcake", "0.4", "--from", "travel", "--to", "home")
+		require.NoError(t, err)
+		assert.Contains(t, out, "18.40g there", "and leave home as it was")
+	})
+}
+
 func TestReconcileCommand(t *testing.T) {
 	stocked := func(t *testing.T) string {
 		t.Helper()
@@ -405,17 +1333,305 @@ func TestReconcileCommand(t *testing.T) {
 
 		var out bytes.Buffer
 		require.NoError(t, applyReadings(&out, s, journal.Stash,
-			[]string{"wcake-221"}, []string{"1.75"}))
+			[]jar{{slug: "wcake-221"}}, []string{"1.75"}))
 		assert.Contains(t, out.String(), "out of stash of wcake-221", "Should record a typed reading")
 
 		out.Reset()
 		require.NoError(t, applyReadings(&out, s, journal.Stash,
-			[]string{"wcake-221"}, []string{""}))
+			[]jar{{slug: "wcake-221"}}, []string{""}))
 		assert.Contains(t, out.String(), "nothing to record", "Should treat a blank as a skip")
 
 		out.Reset()
 		require.NoError(t, applyReadings(&out, s, journal.Stash,
-			[]string{"wcake-221"}, []string{"1.75"}))
+			[]jar{{slug: "wcake-221"}}, []string{"1.75"}))
 		assert.Contains(t, out.String(), "already matches", "Should say when the scale agrees")
 	})
 }
+
+func TestFsckCommand(t *testing.T) {
+	t.Run("AHealthyRepository", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Fsck)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Checked 1 event, no problems found", "Should say it is healthy")
+	})
+
+	t.Run("ASickRepositoryFails", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		// The product disappears from the catalog behind the journal's back.
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".wits", "products.yml"), []byte("products: []\n"), 0600))
+		defer func() { fsckJSON = false }()
+
+		out, err := run(t, dir, Fsck, "--json")
+
+		assert.ErrorContains(t, err, "1 problem", "Should exit non-zero")
+		assert.Contains(t, out, `"kind": "unknown-product"`, "Should report the problem as JSON")
+	})
+
+	t.Run("RepairsATornLine", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		// The power goes out halfway through the next entry.
+		path := filepath.Join(dir, ".wits", "journal.ndjson")
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"seq":2,"type":"gri`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = run(t, dir, Grind, "wedding", "1g")
+		assert.ErrorContains(t, err, "torn line", "Should refuse to record on top of it")
+		out, err := run(t, dir, Fsck)
+		assert.Error(t, err)
+		assert.Contains(t, out, "torn-line: after entry 1", "Should report it")
+
+		defer func() { fsckRepair = false }()
+		out, err = run(t, dir, Fsck, "--repair")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Moved a torn line of 20 bytes after entry 1", "Should say what it did")
+		assert.Contains(t, out, "no problems found")
+		assert.FileExists(t, path+".quarantine", "and keep the fragment")
+		_, err = run(t, dir, Grind, "wedding", "1g")
+		assert.NoError(t, err, "The journal should take entries again")
+	})
+}
+
+func TestShowCommand(t *testing.T) {
+	dir := repository(t)
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+	require.NoError(t, err)
+	_, err = run(t, dir, Grind, "wedding", "0.75", "--date", "2026-07-02")
+	require.NoError(t, err)
+	t.Chdir(dir)
+	s, err := open()
+	require.NoError(t, err)
+	grind := s.State.Events[1]
+
+	t.Run("EveryFieldAndTheBalancesAround", func(t *testing.T) {
+		out, err := run(t, dir, Show, shortHash(grind.Hash))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, grind.Hash, "Should print the full hash")
+		assert.Contains(t, out, grind.Prev, "and the one it chains onto")
+		assert.Contains(t, out, "storage -> stash", "Should name the accounts")
+		assert.Regexp(t, `cycle\s+1, opened 2026-07-01`, out, "Should place it in its cycle")
+		assert.Regexp(t, `storage\s+20.00g\s+19.25g`, out, "Should show storage before and after")
+		assert.Regexp(t, `stash\s+0.00g\s+0.75g`, out, "Should show the stash before and after")
+	})
+
+	t.Run("NamesItsCorrection", func(t *testing.T) {
+		_, err := run(t, dir, Revert, shortHash(grind.Hash))
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Show, shortHash(grind.Hash))
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "reverted by", "Should say the entry was undone")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		defer func() { showJSON = false }()
+
+		out, err := run(t, dir, Show, shortHash(grind.Hash), "--json")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, `"cycle": 1`, "Should carry the cycle")
+		assert.Contains(t, out, `"Storage": 19.25`, "and the balance after")
+	})
+
+	t.Run("AnUnknownEntry", func(t *testing.T) {
+		_, err := run(t, dir, Show, "zzzzzzz")
+
+		assert.ErrorContains(t, err, "no entry matches", "Should say nothing matched")
+	})
+}
+
+func TestReindexCommand(t *testing.T) {
+	dir := repository(t)
+	_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+	require.NoError(t, err)
+
+	out, err := run(t, dir, Reindex)
+
+	require.NoError(t, err)
+	assert.Contains(t, out, "Reindexed 1 event", "Should say what it folded")
+	assert.FileExists(t, filepath.Join(dir, ".wits", "index", "fold.json"), "and where it put it")
+}
+
+func TestSignCommand(t *testing.T) {
+	t.Run("NeedsAKey", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+
+		_, err = run(t, dir, Sign)
+
+		assert.ErrorContains(t, err, "no signing key", "Should say how to make one")
+	})
+
+	t.Run("FromInit", func(t *testing.T) {
+		dir := t.TempDir()
+		defer func() { initSigningKey = false }()
+		out, err := run(t, dir, Init, ".", "--signing-key")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Created a signing key", "Should make the key with the repository")
+		_, err = run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+
+		out, err = run(t, dir, Sign)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "Signed entry 1", "Should say what it signed")
+		out, err = run(t, dir, Fsck)
+		require.NoError(t, err, out)
+	})
+
+	t.Run("RestoreHoldsTheBundleToIt", func(t *testing.T) {
+		dir := repository(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "1g", "--date", "2026-07-02")
+		require.NoError(t, err)
+		defer func() { signNewKey = false }()
+		_, err = run(t, dir, Sign, "--new-key")
+		require.NoError(t, err)
+		bundled := filepath.Join(t.TempDir(), "history.wits")
+		defer func() { bundleOut = "" }()
+		_, err = run(t, dir, Bundle, "--out", bundled)
+		require.NoError(t, err)
+		checkpoints := filepath.Join(dir, ".wits", "checkpoints")
+
+		// A faithful bundle restores under the checkpoint.
+		clean := repository(t)
+		require.NoError(t, os.CopyFS(filepath.Join(clean, ".wits", "checkpoints"), os.DirFS(checkpoints)))
+		_, err = run(t, clean, Restore, bundled)
+		require.NoError(t, err)
+
+		// An edited one does not, and nothing is written.
+		raw, err := os.ReadFile(bundled)
+		require.NoError(t, err)
+		// The 20 g purchase, in the bundle's centigrams, becomes 25 g.
+		edited := strings.Replace(string(raw), " 2000 ", " 2500 ", 1)
+		require.NotEqual(t, string(raw), edited)
+		require.NoError(t, os.WriteFile(bundled, []byte(edited), 0600))
+		tampered := repository(t)
+		require.NoError(t, os.CopyFS(filepath.Join(tampered, ".wits", "checkpoints"), os.DirFS(checkpoints)))
+
+		_, err = run(t, tampered, Restore, bundled)
+
+		assert.ErrorContains(t, err, "refusing to restore", "Should refuse a bundle edited since it was signed")
+		assert.NoFileExists(t, filepath.Join(tampered, ".wits", "journal.ndjson"), "and write nothing")
+	})
+}
+
+func TestAgentCommand(t *testing.T) {
+	t.Run("ForgetsWhatTheAgentHolds", func(t *testing.T) {
+		l, path, err := agent.Listen(filepath.Join(t.TempDir(), "agent.sock"))
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = agent.New(0).Serve(ctx, l) }()
+		t.Setenv(agent.SocketEnv, path)
+		require.NoError(t, agent.Hand("/home/anna/.wits", []byte("correct horse")))
+
+		out, err := run(t, t.TempDir(), Agent, "forget")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "holds no passphrases", "Should say the agent let go")
+		_, err = agent.Ask("/home/anna/.wits")
+		assert.ErrorIs(t, err, agent.ErrNotHeld)
+	})
+
+	t.Run("ForgetNeedsAnAgent", func(t *testing.T) {
+		t.Setenv(agent.SocketEnv, "")
+
+		_, err := run(t, t.TempDir(), Agent, "forget")
+
+		assert.ErrorIs(t, err, agent.ErrNoAgent)
+	})
+}
+
+func TestEncryptedRepository(t *testing.T) {
+	t.Setenv(workspace.PassphraseEnv, "correct horse")
+	encrypted := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		defer func() { initEncrypt = false }()
+		out, err := run(t, dir, Init, ".", "--encrypt")
+		require.NoError(t, err)
+		require.Contains(t, out, "encrypted")
+		return dir
+	}
+
+	t.Run("WorksAsAPlainOne", func(t *testing.T) {
+		dir := encrypted(t)
+		_, err := run(t, dir, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		_, err = run(t, dir, Grind, "wedding", "1g", "--date", "2026-07-02")
+		require.NoError(t, err)
+		_, err = run(t, dir, Rx, "add", "wedding", "30")
+		require.NoError(t, err)
+
+		out, err := run(t, dir, Status)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "wcake-221", "Should read back what it sealed")
+		for _, name := range []string{"journal.ndjson", "products.yml", "prescriptions.yml", filepath.Join("index", "fold.json")} {
+			raw, err := os.ReadFile(filepath.Join(dir, ".wits", name))
+			require.NoError(t, err)
+			assert.NotContains(t, string(raw), "wcake", "Should keep %s sealed", name)
+		}
+	})
+
+	t.Run("NeedsThePassphrase", func(t *testing.T) {
+		dir := encrypted(t)
+		t.Setenv(workspace.PassphraseEnv, "battery staple")
+
+		_, err := run(t, dir, Status)
+
+		assert.ErrorIs(t, err, seal.ErrWrongPassphrase)
+	})
+
+	t.Run("BundlesByteForByte", func(t *testing.T) {
+		plain := repository(t)
+		_, err := run(t, plain, Buy, "Enua 22/1 Wedding Cake", "20g", "--date", "2026-07-01")
+		require.NoError(t, err)
+		_, err = run(t, plain, Grind, "wedding", "1g", "--date", "2026-07-02")
+		require.NoError(t, err)
+		defer func() { rxDate, rxPrescriber, rxMaxDaily = "", "", "" }()
+		_, err = run(t, plain, Rx, "add", "wedding", "30", "--prescriber", "Dr. Weber", "--date", "2026-06-30")
+		require.NoError(t, err)
+		defer func() { bundleOut = "" }()
+		original := filepath.Join(t.TempDir(), "plain.wits")
+		_, err = run(t, plain, Bundle, "--out", original)
+		require.NoError(t, err)
+
+		dir := encrypted(t)
+		_, err = run(t, dir, Restore, original)
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(dir, ".wits", "prescriptions.yml"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "Weber", "Should seal the prescriptions it restores")
+		out, err := run(t, dir, Rx, "list")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Dr. Weber", "Should read back the prescription it restored")
+		assert.Contains(t, out, "20.00g of 30.00g", "and match the restored purchase against it")
+		again := filepath.Join(t.TempDir(), "sealed.wits")
+		_, err = run(t, dir, Bundle, "--out", again)
+		require.NoError(t, err)
+
+		want, err := os.ReadFile(original)
+		require.NoError(t, err)
+		got, err := os.ReadFile(again)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), "Should chain the plaintext, so the bundle does not change")
+	})
+}
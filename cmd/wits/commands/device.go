@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/TheDonDope/wits/pkg/catalog"
@@ -13,6 +15,7 @@ var (
 	deviceMinTemp int
 	deviceMaxTemp int
 	deviceDefault int
+	deviceEff     int
 )
 
 // Device is the `wits device` command.
@@ -24,15 +27,23 @@ var Device = &cobra.Command{
 }
 
 var deviceAdd = &cobra.Command{
-	Use:     "add <name>",
-	Short:   "Add a device",
-	Example: "  wits device add \"Volcano Hybrid\" --min 40 --max 230 --default 185",
+	Use:   "add <name>",
+	Short: "Add a device",
+	Long: "Add a vaporizer to the device catalog.\n\n" +
+		"--efficiency is the percentage of the THC and CBD in what goes into it\n" +
+		"that it is assumed to extract. Doses are reported in milligrams put\n" +
+		"through a device either way; with an efficiency, also in milligrams\n" +
+		"extracted.",
+	Example: "  wits device add \"Volcano Hybrid\" --min 40 --max 230 --default 185 --efficiency 60",
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := open()
 		if err != nil {
 			return err
 		}
+		if err := checkEfficiency(deviceEff); err != nil {
+			return err
+		}
 		devices := s.Devices
 		device := &catalog.Device{
 			Name:        args[0],
@@ -40,11 +51,12 @@ var deviceAdd = &cobra.Command{
 			MinTemp:     deviceMinTemp,
 			MaxTemp:     deviceMaxTemp,
 			DefaultTemp: deviceDefault,
+			Efficiency:  deviceEff,
 		}
 		if err := devices.Add(device); err != nil {
 			return err
 		}
-		if err := devices.Save(s.Repo.DevicesPath()); err != nil {
+		if err := s.Repo.SaveDevices(devices); err != nil {
 			return err
 		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Added device %s (%s)\n", device.Slug, device.Name)
@@ -68,7 +80,7 @@ var deviceList = &cobra.Command{
 			return nil
 		}
 		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SLUG\tNAME\tKIND\tRANGE\tDEFAULT")
+		fmt.Fprintln(w, "SLUG\tNAME\tKIND\tRANGE\tDEFAULT\tEFFICIENCY")
 		for _, d := range devices.Devices {
 			temps := "-"
 			if d.MaxTemp > 0 {
@@ -78,12 +90,61 @@ var deviceList = &cobra.Command{
 			if d.DefaultTemp > 0 {
 				def = fmt.Sprintf("%d°C", d.DefaultTemp)
 			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Slug, d.Name, d.Kind, temps, def)
+			eff := "-"
+			if d.Efficiency > 0 {
+				eff = fmt.Sprintf("%d%%", d.Efficiency)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.Slug, d.Name, d.Kind, temps, def, eff)
 		}
 		return w.Flush()
 	},
 }
 
+var deviceEfficiency = &cobra.Command{
+	Use:   "efficiency <device> <percent>",
+	Short: "Set the share of cannabinoids a device is assumed to extract",
+	Long: "Set the percentage of the THC and CBD in what goes into a device that it\n" +
+		"is assumed to extract, for the doses in milligrams extracted. 0 takes\n" +
+		"the assumption away again.",
+	Example: "  wits device efficiency volcano 60",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		device, err := s.Devices.Find(args[0])
+		if err != nil {
+			return err
+		}
+		pct, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(args[1]), "%"))
+		if err != nil {
+			return fmt.Errorf("%q is not a percentage", args[1])
+		}
+		if err := checkEfficiency(pct); err != nil {
+			return err
+		}
+		device.Efficiency = pct
+		if err := s.Repo.SaveDevices(s.Devices); err != nil {
+			return err
+		}
+		if pct == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s no longer assumes an efficiency\n", device.Slug)
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is assumed to extract %d%%\n", device.Slug, pct)
+		return nil
+	},
+}
+
+// checkEfficiency refuses a percentage no device could extract.
+func checkEfficiency(pct int) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("an efficiency is a percentage from 0 to 100, got %d", pct)
+	}
+	return nil
+}
+
 // Temps is the `wits temps` command.
 var Temps = &cobra.Command{
 	Use:   "temps <celsius>",
@@ -137,5 +198,6 @@ func init() {
 	deviceAdd.Flags().IntVar(&deviceMinTemp, "min", 0, "the lowest temperature it can be set to")
 	deviceAdd.Flags().IntVar(&deviceMaxTemp, "max", 0, "the highest temperature it can be set to")
 	deviceAdd.Flags().IntVar(&deviceDefault, "default", 0, "the temperature to assume when none is given")
-	Device.AddCommand(deviceAdd, deviceList)
+	deviceAdd.Flags().IntVar(&deviceEff, "efficiency", 0, "the percentage of THC and CBD it is assumed to extract")
+	Device.AddCommand(deviceAdd, deviceList, deviceEfficiency)
 }
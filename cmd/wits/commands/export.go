@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/TheDonDope/wits/pkg/catalog"
+	"github.com/TheDonDope/wits/pkg/journal"
 	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/spf13/cobra"
 )
@@ -111,8 +112,17 @@ func writeMarkdown(out io.Writer, cycles []ledger.Cycle, products *catalog.Catal
 		fmt.Fprintln(out, "| Date | Event | Amount | Product | Device | Note |")
 		fmt.Fprintln(out, "| --- | --- | ---: | --- | --- | --- |")
 		for _, e := range c.Events {
-			fmt.Fprintf(out, "| %s | %s | %.2f g | %s | %s | %s |\n",
-				e.OccurredAt.Format(time.DateOnly), e.Type, e.Grams, e.Product, e.Device, e.Note)
+			// A feel's amount is its scores, which are what a prescriber
+			// reading the export wants from it, and a break's is its days.
+			amount := fmt.Sprintf("%.2f g", e.Grams)
+			switch e.Type {
+			case journal.Feel:
+				amount = e.Scores.String()
+			case journal.Break:
+				amount = plural(e.Days, "day")
+			}
+			fmt.Fprintf(out, "| %s | %s | %s | %s | %s | %s |\n",
+				e.OccurredAt.Format(time.DateOnly), e.Type, amount, e.Product, e.Device, e.Note)
 		}
 	}
 }
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	feelDate    string
+	feelSession string
+	feelNote    string
+)
+
+// Feel is the `wits feel` command.
+var Feel = &cobra.Command{
+	Use:   "feel <symptom=score>...",
+	Short: "Score how the symptoms are, after a session or on their own",
+	Long: "Record how the symptoms are, each on a scale from 0 to 10: pain,\n" +
+		"sleep, nausea, appetite and anxiety. A score of pain, nausea or anxiety\n" +
+		"is how bad it is; one of sleep or appetite how good.\n\n" +
+		"A feel moves no grams and leaves every balance alone, but it is\n" +
+		"chained, bundled and reverted like any other entry. --session names the\n" +
+		"session it follows, by its hash or as `last`, and the analysis screen\n" +
+		"then reads the scores against that session's product, device and\n" +
+		"temperature: the evidence to bring to whoever prescribes.",
+	Example: "  wits feel pain=6 sleep=3\n" +
+		"  wits feel pain=2 --session last --note \"two hours after\"",
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		out := make([]string, 0, len(journal.Symptoms))
+		for _, s := range journal.Symptoms {
+			out = append(out, fmt.Sprintf("%s=\t0 %s, %d %s", s.Name, s.Low, journal.MaxScore, s.High))
+		}
+		return out, cobra.ShellCompDirectiveNoSpace
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scores, err := parseScores(args)
+		if err != nil {
+			return err
+		}
+		at, err := parseDate(feelDate)
+		if err != nil {
+			return err
+		}
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		e, err := s.Recorder.Feel(scores, at, feelSession, feelNote)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "[%s] feel %s", shortHash(e.Hash), e.Scores)
+		if session, err := s.Recorder.Find(e.Session); err == nil {
+			fmt.Fprintf(out, ", after the %.2fg of %s seshed %s", session.Grams, session.Product,
+				session.OccurredAt.Format("2006-01-02 15:04"))
+			if session.Device != "" {
+				fmt.Fprintf(out, " in %s", session.Device)
+			}
+			if session.Temperature > 0 {
+				fmt.Fprintf(out, " at %d°C", session.Temperature)
+			}
+		}
+		fmt.Fprintln(out)
+		return nil
+	},
+}
+
+// parseScores reads symptom=score pairs. A symptom given twice is refused
+// rather than one of the two silently kept.
+func parseScores(args []string) (journal.Scores, error) {
+	scores := journal.Scores{}
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a symptom=score pair, such as pain=6", arg)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := journal.LookupSymptom(name); !ok {
+			return nil, fmt.Errorf("%q is not a symptom Wits knows; it knows %s", name, symptoms())
+		}
+		if _, ok := scores[name]; ok {
+			return nil, fmt.Errorf("%s is scored twice", name)
+		}
+		score, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || score < 0 || score > journal.MaxScore {
+			return nil, fmt.Errorf("%s is scored from 0 to %d, not %q", name, journal.MaxScore, value)
+		}
+		scores[name] = score
+	}
+	return scores, nil
+}
+
+// symptoms lists the symptoms a feel can score.
+func symptoms() string {
+	names := make([]string, len(journal.Symptoms))
+	for i, s := range journal.Symptoms {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// amount renders what an entry recorded: its grams, a feel's scores, or the
+// days of a break.
+func amount(e journal.Event) string {
+	switch e.Type {
+	case journal.Feel:
+		return e.Scores.String()
+	case journal.Break:
+		return plural(e.Days, "day")
+	}
+	return fmt.Sprintf("%.2fg", e.Grams)
+}
+
+func init() {
+	Feel.Flags().StringVar(&feelDate, "date", "", "when the symptoms were, defaults to now")
+	Feel.Flags().StringVar(&feelSession, "session", "", "the session it follows, by its hash or `last`")
+	Feel.Flags().StringVar(&feelNote, "note", "", "a note to keep with the entry")
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/TheDonDope/wits/pkg/fsck"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fsckJSON   bool
+	fsckRepair bool
+)
+
+// Fsck is the `wits fsck` command.
+var Fsck = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the repository for damage",
+	Long: "Check the repository the way `git fsck` checks a git one.\n\n" +
+		"The hash chain is verified first, but a chain that verifies only proves\n" +
+		"nothing was edited. The fold is replayed as well, looking for accounts\n" +
+		"drawn below zero, entries naming products or devices the catalogs do not\n" +
+		"hold, corrections of entries that do not exist or were already corrected,\n" +
+		"sequence numbers used twice, and entries recorded before they happened.\n" +
+		"Every signed checkpoint is verified, and must still be a prefix of the\n" +
+		"chain.\n\n" +
+		"Any problem makes the command exit non-zero, so a backup script can\n" +
+		"refuse to bundle a repository that is sick.",
+	Example: "  wits fsck\n" +
+		"  wits fsck --json && wits bundle --out history.wits",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if fsckRepair {
+			torn, err := s.Journal().Quarantine()
+			if err != nil {
+				return err
+			}
+			if torn != nil && !fsckJSON {
+				fmt.Fprintf(out, "Moved a torn line of %d bytes after entry %d into %s.\n",
+					len(torn.Fragment), torn.Seq, s.Journal().QuarantinePath())
+			}
+		}
+		report, err := fsck.CheckRepo(s.Repo, s.Products, s.Devices)
+		if err != nil {
+			return err
+		}
+
+		if fsckJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			for _, p := range report.Problems {
+				fmt.Fprintln(out, p)
+			}
+			if report.OK() {
+				fmt.Fprintf(out, "Checked %s, no problems found.\n", plural(report.Events, "event"))
+			} else {
+				fmt.Fprintf(out, "\nChecked %s, %s found.\n",
+					plural(report.Events, "event"), plural(len(report.Problems), "problem"))
+			}
+		}
+		if !report.OK() {
+			return fmt.Errorf("the repository has %s", plural(len(report.Problems), "problem"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	Fsck.Flags().BoolVar(&fsckJSON, "json", false, "write the report as JSON")
+	Fsck.Flags().BoolVar(&fsckRepair, "repair", false, "set a torn last line aside so the journal takes entries again")
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/spf13/cobra"
 )
 
-var grindDate string
+var (
+	grindDate  string
+	grindForce bool
+	grindAt    string
+)
 
 // Grind is the `wits grind` command.
 var Grind = &cobra.Command{
@@ -16,9 +21,18 @@ var Grind = &cobra.Command{
 	Long: "Record grinding product for the day, moving grams from storage into\n" +
 		"that product's stash.\n\n" +
 		"The product can be given as a slug or as any unambiguous part of its\n" +
-		"name, so a daily entry stays short.",
+		"name, so a daily entry stays short.\n\n" +
+		"A backdated grind is checked on the day it is dated: it is refused if\n" +
+		"storage did not hold the grams then, or if taking them then would leave\n" +
+		"a later entry short. --force records it anyway, with a warning.\n\n" +
+		"With `wits anomalies warn on`, a grind that makes its day three times\n" +
+		"the usual day is warned about right away, while a typo is easy to revert.\n" +
+		"So is a grind during a break declared with `wits break start`.\n\n" +
+		"--at grinds at a location moved to with `wits move`: from the storage\n" +
+		"there into the stash there.",
 	Example: "  wits grind wedding-cake 0.75\n" +
-		"  wits grind lemon 1.2 --date 2026-07-29",
+		"  wits grind lemon 1.2 --date 2026-07-29\n" +
+		"  wits grind wcake 0.5 --at travel",
 	Args:              cobra.ExactArgs(2),
 	ValidArgsFunction: completeProduct(journal.Storage),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -30,20 +44,36 @@ var Grind = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		location, err := parseLocation(grindAt)
+		if err != nil {
+			return err
+		}
 		at, err := parseDate(grindDate)
 		if err != nil {
 			return err
 		}
-		e, err := s.Recorder.Grind(args[0], grams, at)
+		if grindForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		e, err := s.Recorder.GrindAt(args[0], grams, at, location)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "[%s] grind %.2fg %s, %.2fg left in storage\n",
-			shortHash(e.Hash), e.Grams, e.Product, s.Recorder.Available(e.Product, journal.Storage))
+		if location != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "[%s] grind %.2fg %s at %s, %.2fg left in storage there\n",
+				shortHash(e.Hash), e.Grams, e.Product, location, s.Recorder.AvailableAt(e.Product, journal.Storage, location))
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "[%s] grind %.2fg %s, %.2fg left in storage\n",
+				shortHash(e.Hash), e.Grams, e.Product, s.Recorder.Available(e.Product, journal.Storage))
+		}
+		warnUnusual(cmd.OutOrStdout(), s, ledger.Grinding, e)
+		warnBreak(cmd.OutOrStdout(), s, e)
 		return nil
 	},
 }
 
 func init() {
 	Grind.Flags().StringVar(&grindDate, "date", "", "the date it was ground, defaults to now")
+	Grind.Flags().StringVar(&grindAt, "at", "", "the location it was ground at, defaults to home")
+	Grind.Flags().BoolVar(&grindForce, "force", false, "record it even if it overdraws storage, with a warning")
 }
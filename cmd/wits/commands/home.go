@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 
 	tea "charm.land/bubbletea/v2"
@@ -14,14 +15,22 @@ var Home = &cobra.Command{
 	Use:   "home",
 	Short: "Launch the interface",
 	Args:  cobra.NoArgs,
-	RunE: func(_ *cobra.Command, _ []string) error {
+	RunE: func(cmd *cobra.Command, _ []string) error {
 		// The same way every other command finds a repository, rather than a
 		// second copy of the discovery this one used to carry.
 		s, err := open()
 		if err != nil {
 			return err
 		}
-		if _, err := tea.NewProgram(tui.New(tui.From(s.Workspace))).Run(); err != nil {
+		// Entries recorded from another terminal while the interface is open
+		// show up in it, rather than waiting for a restart.
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		app := tui.New(tui.From(s.Workspace))
+		if err := app.Watch(ctx); err != nil {
+			return err
+		}
+		if _, err := tea.NewProgram(app).Run(); err != nil {
 			return fmt.Errorf("running the interface: %w", err)
 		}
 		return nil
@@ -2,18 +2,31 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/TheDonDope/wits/pkg/repo"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initSigningKey bool
+	initEncrypt    bool
+)
+
 // Init is the `wits init` command.
 var Init = &cobra.Command{
 	Use:   "init [directory]",
 	Short: "Create an empty Wits repository",
 	Long: "Create an empty Wits repository, the way `git init` creates one.\n\n" +
 		"This makes a .wits directory holding your configuration, your product\n" +
-		"and device catalogs, and the append-only journal every command writes to.",
+		"and device catalogs, and the append-only journal every command writes to.\n\n" +
+		"With --signing-key it also makes the key `wits sign` signs checkpoints\n" +
+		"of the journal with.\n\n" +
+		"With --encrypt the journal and both catalogs are sealed at rest under a\n" +
+		"key derived from a passphrase. Every command then asks for it, or reads\n" +
+		"it from WITS_PASSPHRASE. There is no way back in without it.",
+	Example: "  wits init\n" +
+		"  wits init --encrypt ~/records",
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir := "."
@@ -24,7 +37,29 @@ var Init = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Initialised empty Wits repository in %s\n", r.Root())
+		if initEncrypt {
+			passphrase, err := newPassphrase(r)
+			if err == nil {
+				err = r.Encrypt(passphrase)
+			}
+			if err != nil {
+				// A repository left half encrypted is worse than none; the
+				// directory is new, so nothing of anyone's goes with it.
+				os.RemoveAll(r.Root())
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Initialised empty encrypted Wits repository in %s\n", r.Root())
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Initialised empty Wits repository in %s\n", r.Root())
+		}
+		if initSigningKey {
+			return newSigningKey(cmd.OutOrStdout(), r)
+		}
 		return nil
 	},
 }
+
+func init() {
+	Init.Flags().BoolVar(&initEncrypt, "encrypt", false, "seal the journal and catalogs under a passphrase")
+	Init.Flags().BoolVar(&initSigningKey, "signing-key", false, "also create a key for signing checkpoints")
+}
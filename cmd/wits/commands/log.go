@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,7 @@ var (
 	logProduct string
 	logCurrent bool
 	logLimit   int
+	logAsOf    string
 )
 
 // Log is the `wits log` command.
@@ -22,7 +24,13 @@ var Log = &cobra.Command{
 	Short: "Show the journal, newest first",
 	Long: "Show the events in the journal, newest first, the way `git log` shows\n" +
 		"commits. Nothing here can be edited: a mistake is corrected by\n" +
-		"appending a compensating event.",
+		"appending a compensating event.\n\n" +
+		"With --as-of, only the entries of a past moment are shown: those dated\n" +
+		"by then, each one recorded later marked as such, and any recorded by\n" +
+		"then but dated after it. The unmarked ones are what the ledger held\n" +
+		"at the time.",
+	Example: "  wits log -n 10\n" +
+		"  wits log --as-of 2026-08-01",
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		s, err := open()
@@ -30,13 +38,31 @@ var Log = &cobra.Command{
 			return err
 		}
 
-		events := s.State.Events
+		events, state := s.State.Events, s.State
+		marks := map[string]string{}
+		if logAsOf != "" {
+			a, err := parseAsOf(s, logAsOf)
+			if err != nil {
+				return err
+			}
+			events, marks = asOfEntries(events, a)
+			state = s.State.At(a.Occurred, ledger.AsOccurred)
+		}
 		if logCurrent {
-			if cycle := s.State.CurrentCycle(); cycle != nil {
-				events = cycle.Events
-			} else {
-				events = nil
+			// The cycle that was current at the moment asked about, not today's.
+			in := map[string]bool{}
+			if cycle := state.CurrentCycle(); cycle != nil {
+				for _, e := range cycle.Events {
+					in[e.Hash] = true
+				}
 			}
+			var current []journal.Event
+			for _, e := range events {
+				if in[e.Hash] {
+					current = append(current, e)
+				}
+			}
+			events = current
 		}
 		if logProduct != "" {
 			product, err := s.Products.Find(logProduct)
@@ -66,11 +92,12 @@ var Log = &cobra.Command{
 			}
 			e := events[i]
 			if logOneline {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%.2fg\t%s\n",
-					shortHash(e.Hash), e.OccurredAt.Format(time.DateOnly), e.Type, e.Grams, e.Product)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s%s\n",
+					shortHash(e.Hash), e.OccurredAt.Format(time.DateOnly), e.Type, amount(e), e.Product, marks[e.Hash])
 			} else {
-				fmt.Fprintf(w, "%s\t%s\t%-11s\t%.2fg\t%s\t%s -> %s\n",
-					shortHash(e.Hash), e.OccurredAt.Format(time.DateOnly), e.Type, e.Grams, e.Product, e.From, e.To)
+				fmt.Fprintf(w, "%s\t%s\t%-11s\t%s\t%s\t%s -> %s%s\n",
+					shortHash(e.Hash), e.OccurredAt.Format(time.DateOnly), e.Type, amount(e), e.Product, e.From, e.To,
+					marks[e.Hash])
 			}
 			shown++
 		}
@@ -78,9 +105,37 @@ var Log = &cobra.Command{
 	},
 }
 
+// asOfEntries returns the entries dated or recorded by a moment, in journal
+// order, with a mark for each one that the two readings disagree about.
+func asOfEntries(events []journal.Event, a asOf) ([]journal.Event, map[string]string) {
+	dated, held := map[string]bool{}, map[string]bool{}
+	for _, e := range a.Happened(events) {
+		dated[e.Hash] = true
+	}
+	for _, e := range a.RecordedBy(events) {
+		held[e.Hash] = true
+	}
+	marks := map[string]string{}
+	var out []journal.Event
+	for _, e := range events {
+		happened, recorded := dated[e.Hash], held[e.Hash]
+		switch {
+		case happened && !recorded:
+			marks[e.Hash] = "  (recorded later, " + e.RecordedAt.Format(time.DateOnly) + ")"
+		case recorded && !happened:
+			marks[e.Hash] = "  (dated later)"
+		case !happened:
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, marks
+}
+
 func init() {
 	Log.Flags().BoolVar(&logOneline, "oneline", false, "one compact line per event")
 	Log.Flags().StringVar(&logProduct, "product", "", "only events for this product")
 	Log.Flags().BoolVar(&logCurrent, "current", false, "only events in the current cycle")
 	Log.Flags().IntVarP(&logLimit, "max-count", "n", 0, "show at most this many events")
+	Log.Flags().StringVar(&logAsOf, "as-of", "", "only the entries of a past date, time or entry")
 }
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+// Lots is the `wits lots` command.
+var Lots = &cobra.Command{
+	Use:   "lots [product]",
+	Short: "Show whose grams are left in each jar",
+	Long: "Show the lots in storage: each fill's grams still standing in a jar,\n" +
+		"with the cycle they are on the account of, when they were bought, how\n" +
+		"many grams the purchase brought and how many are left.\n\n" +
+		"A jar refilled before it was empty holds a lot per fill. No scale can\n" +
+		"tell them apart, so the ledger says the oldest leave first: the next\n" +
+		"grind draws on the top lot of a jar. Grams a reconciliation found in an\n" +
+		"empty jar are a lot of their own, marked as found.",
+	Example: "  wits lots\n" +
+		"  wits lots wedding-cake",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProduct(journal.Storage),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		state := s.Chronological()
+		out := cmd.OutOrStdout()
+
+		var lots []ledger.Lot
+		if len(args) == 1 {
+			product, err := s.Products.Find(args[0])
+			if err != nil {
+				return err
+			}
+			if lots = state.Lots(product.Slug); len(lots) == 0 {
+				fmt.Fprintf(out, "Nothing of %s in storage.\n", product.Slug)
+				return nil
+			}
+		} else if lots = state.AllLots(); len(lots) == 0 {
+			fmt.Fprintln(out, "Nothing in storage.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PRODUCT\tCYCLE\tBOUGHT\tPURCHASE\tPURCHASED\tLEFT")
+		lotsOf := map[string]int{}
+		for _, l := range lots {
+			entry, purchased := shortHash(l.Entry), fmt.Sprintf("%.2fg", l.Purchased)
+			if l.Found() {
+				entry, purchased = "found", "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%.2fg\n",
+				l.Product, l.Cycle+1, l.Bought.Format(time.DateOnly), entry, purchased, l.Remaining)
+			lotsOf[l.Product]++
+		}
+		w.Flush()
+
+		// Only a shared jar has an order worth spelling out.
+		shared := false
+		for i, l := range lots {
+			if lotsOf[l.Product] < 2 || (i > 0 && lots[i-1].Product == l.Product) {
+				continue
+			}
+			if !shared {
+				fmt.Fprintln(out)
+				shared = true
+			}
+			fmt.Fprintf(out, "%s holds %s: the next grind draws on the %.2fg bought %s first.\n",
+				l.Product, plural(lotsOf[l.Product], "lot"), l.Remaining, l.Bought.Format(time.DateOnly))
+		}
+		return nil
+	},
+}
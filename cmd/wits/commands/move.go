@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveFrom  string
+	moveTo    string
+	moveStash bool
+	moveDate  string
+	moveNote  string
+)
+
+// Move is the `wits move` command.
+var Move = &cobra.Command{
+	Use:   "move <product> <amount> --to <location>",
+	Short: "Carry grams from one location of storage or the stash to another",
+	Long: "Record grams carried from one place to another: from the jar at home\n" +
+		"into the travel tin, into the fridge, to a partner's. Storage and the\n" +
+		"stash are each split into locations, named as you go; whatever has not\n" +
+		"been moved anywhere is at home.\n\n" +
+		"A move changes where grams sit and nothing else: the balances, the\n" +
+		"cycle and the fill are as they were. `wits grind --at` and `wits sesh\n" +
+		"--at` draw on a location, `wits status` lists what each one holds, and\n" +
+		"`wits reconcile` weighs each on its own.",
+	Example: "  wits move wcake 2g --to travel\n" +
+		"  wits move wcake 1 --from travel --to home\n" +
+		"  wits move lemon 0.5 --stash --to travel",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProduct(journal.Storage),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if moveTo == "" {
+			return fmt.Errorf("say where it goes with --to, such as --to travel, or --to %s", journal.Home)
+		}
+		from, err := parseLocation(moveFrom)
+		if err != nil {
+			return err
+		}
+		to, err := parseLocation(moveTo)
+		if err != nil {
+			return err
+		}
+		grams, err := parseGrams(args[1])
+		if err != nil {
+			return err
+		}
+		at, err := parseDate(moveDate)
+		if err != nil {
+			return err
+		}
+		account := journal.Storage
+		if moveStash {
+			account = journal.Stash
+		}
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		e, err := s.Recorder.Move(args[0], account, grams, from, to, at, moveNote)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%s] move %.2fg %s in %s, %s -> %s: %.2fg there, %.2fg left at %s\n",
+			shortHash(e.Hash), e.Grams, e.Product, account, journal.LocationName(from), journal.LocationName(to),
+			s.Recorder.AvailableAt(e.Product, account, to), s.Recorder.AvailableAt(e.Product, account, from),
+			journal.LocationName(from))
+		return nil
+	},
+}
+
+// parseLocation reads a location as it is typed. Home, or nothing at all, is
+// the location left unnamed.
+func parseLocation(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == journal.Home {
+		return "", nil
+	}
+	if err := journal.CheckLocation(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func init() {
+	Move.Flags().StringVar(&moveTo, "to", "", "the location it goes to")
+	Move.Flags().StringVar(&moveFrom, "from", "", "the location it comes from, defaults to home")
+	Move.Flags().BoolVar(&moveStash, "stash", false, "move ground product in the stash, rather than storage")
+	Move.Flags().StringVar(&moveDate, "date", "", "when it was moved, defaults to now")
+	Move.Flags().StringVar(&moveNote, "note", "", "a note to keep with the entry")
+}
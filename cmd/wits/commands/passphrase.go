@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/workspace"
+)
+
+func init() {
+	workspace.Passphrase = askPassphrase
+}
+
+// askPassphrase supplies the passphrase of an encrypted repository: from
+// WITS_PASSPHRASE when it is set, or the agent when one holds it, otherwise
+// by asking on the terminal, once per command.
+func askPassphrase(r *repo.Repo) ([]byte, error) {
+	if p, err := workspace.FromEnvironment(r); err == nil {
+		return p, nil
+	}
+	return readPassphrase(fmt.Sprintf("Passphrase for %s: ", r.Root()))
+}
+
+// newPassphrase asks for the passphrase of a repository about to be
+// encrypted, twice, since a mistyped one locks the record away for good.
+func newPassphrase(r *repo.Repo) ([]byte, error) {
+	if p, err := workspace.FromEnvironment(r); err == nil {
+		return p, nil
+	}
+	p, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	again, err := readPassphrase("The same again: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(p, again) {
+		return nil, errors.New("the passphrases do not match")
+	}
+	return p, nil
+}
+
+// readPassphrase asks for a passphrase on the terminal without echoing it.
+func readPassphrase(prompt string) ([]byte, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("%w: set %s, or run in a terminal to be asked", repo.ErrLocked, workspace.PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return p, err
+}
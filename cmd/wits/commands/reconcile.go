@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -21,6 +22,7 @@ var (
 	reconcileAVB    bool
 	reconcileReason string
 	reconcileDryRun bool
+	reconcileAt     string
 )
 
 // accountNames maps the words the command line accepts to the accounts that
@@ -44,12 +46,17 @@ var Reconcile = &cobra.Command{
 		"the account agrees with the jar again.\n\n" +
 		"Run without arguments it is interactive: pick storage or the stash, tick\n" +
 		"the jars to weigh, and each is asked for in turn. Naming just the account\n" +
-		"skips the first question. The full form records one jar directly.",
+		"skips the first question. The full form records one jar directly.\n\n" +
+		"Once grams have been moved with `wits move`, each location is a jar of\n" +
+		"its own: the interactive form asks for every one, and --at weighs only\n" +
+		"the one named. An adjustment moves grams in or out of the location it\n" +
+		"weighed, and leaves the others as they were.",
 	Example: "  wits reconcile\n" +
 		"  wits reconcile stash\n" +
 		"  wits reconcile stash wcake-221 1.75\n" +
 		"  wits reconcile storage wcake-221 17.6 --dry-run\n" +
-		"  wits reconcile wedding-cake 17.6",
+		"  wits reconcile wedding-cake 17.6\n" +
+		"  wits reconcile storage wcake-221 3.9 --at travel",
 	Args:              cobra.MaximumNArgs(3),
 	ValidArgsFunction: completeReconcile,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -62,10 +69,17 @@ var Reconcile = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		location, err := parseLocation(reconcileAt)
+		if err != nil {
+			return err
+		}
+		weigh := func(account journal.Account) error {
+			return reconcileInteractively(cmd, s, account, location, reconcileAt != "")
+		}
 
 		switch len(args) {
 		case 0:
-			return reconcileInteractively(cmd, s, flagged)
+			return weigh(flagged)
 		case 1:
 			account, ok := accountNames[args[0]]
 			if !ok {
@@ -74,7 +88,7 @@ var Reconcile = &cobra.Command{
 			if flagged != "" {
 				return fmt.Errorf("the account is already named in the arguments; drop the flag")
 			}
-			return reconcileInteractively(cmd, s, account)
+			return weigh(account)
 		case 2:
 			if _, ok := accountNames[args[0]]; ok {
 				return fmt.Errorf("which product? try `wits reconcile %s <product> <weight>`", args[0])
@@ -83,7 +97,7 @@ var Reconcile = &cobra.Command{
 			if flagged == "" {
 				flagged = journal.Storage
 			}
-			return reconcileOne(cmd, s, flagged, args[0], args[1])
+			return reconcileOne(cmd, s, flagged, location, args[0], args[1])
 		default:
 			account, ok := accountNames[args[0]]
 			if !ok {
@@ -92,7 +106,7 @@ var Reconcile = &cobra.Command{
 			if flagged != "" {
 				return fmt.Errorf("the account is already named in the arguments; drop the flag")
 			}
-			return reconcileOne(cmd, s, account, args[1], args[2])
+			return reconcileOne(cmd, s, account, location, args[1], args[2])
 		}
 	},
 }
@@ -113,19 +127,23 @@ func flaggedAccount() (journal.Account, error) {
 
 // reconcileOne records a single jar against the scale, which is the shorthand
 // for the daily case of one suspicious balance.
-func reconcileOne(cmd *cobra.Command, s *session, account journal.Account, ref, weight string) error {
+func reconcileOne(cmd *cobra.Command, s *session, account journal.Account, location, ref, weight string) error {
 	weighed, err := parseWeight(weight)
 	if err != nil {
 		return err
 	}
 	out := cmd.OutOrStdout()
 	if reconcileDryRun {
-		expected, difference, err := s.Recorder.Difference(ref, account, weighed)
+		expected, difference, err := s.Recorder.DifferenceAt(ref, account, location, weighed)
 		if err != nil {
 			return err
 		}
+		where := string(account)
+		if location != "" {
+			where += " at " + location
+		}
 		fmt.Fprintf(out, "%s holds %.2fg by the ledger and %.2fg on the scale: %+.2fg\n",
-			account, expected, weighed, difference)
+			where, expected, weighed, difference)
 		if difference == 0 {
 			fmt.Fprintln(out, "Nothing to reconcile.")
 			return nil
@@ -134,7 +152,7 @@ func reconcileOne(cmd *cobra.Command, s *session, account journal.Account, ref,
 		return nil
 	}
 
-	e, err := s.Recorder.Reconcile(ref, account, weighed, reconcileReason)
+	e, err := s.Recorder.ReconcileAt(ref, account, location, weighed, reconcileReason)
 	if err != nil {
 		return err
 	}
@@ -145,28 +163,49 @@ func reconcileOne(cmd *cobra.Command, s *session, account journal.Account, ref,
 // writeAdjustment reports one recorded adjustment the way the journal will
 // remember it.
 func writeAdjustment(out io.Writer, s *session, e journal.Event, account journal.Account) {
-	direction := "into"
+	direction, location := "into", e.ToLocation
 	if e.To == journal.External {
-		direction = "out of"
+		direction, location = "out of", e.FromLocation
+	}
+	where := string(account)
+	if location != "" {
+		where += " at " + location
 	}
 	fmt.Fprintf(out, "[%s] adjusted %.2fg %s %s of %s, now %.2fg\n",
-		shortHash(e.Hash), e.Grams, direction, account, e.Product,
-		s.Recorder.Available(e.Product, account))
+		shortHash(e.Hash), e.Grams, direction, where, e.Product,
+		s.Recorder.AvailableAt(e.Product, account, location))
 }
 
-// jar is one product's balance in the account being weighed.
+// jar is one product's balance in one location of the account being weighed.
+// Placed says whether the product is kept anywhere but home, which is when a
+// jar has to say where it is.
 type jar struct {
 	slug     string
+	location string
+	placed   bool
 	expected float64
 }
 
-// jarsOf lists the products with something in the account, alphabetically, so
-// a session at the scale visits them in a stable order.
+// label names a jar in a question: the product, and where it is once that
+// could be more than one place.
+func (j jar) label(s *session) string {
+	if !j.placed {
+		return s.ProductName(j.slug)
+	}
+	return s.ProductName(j.slug) + " at " + journal.LocationName(j.location)
+}
+
+// jarsOf lists the jars with something in the account, alphabetically and home
+// first, so a session at the scale visits them in a stable order.
 func jarsOf(s *session, account journal.Account) []jar {
 	var jars []jar
 	for _, slug := range s.State.Products() {
-		if held := s.Recorder.Available(slug, account); held > 0 {
-			jars = append(jars, jar{slug: slug, expected: held})
+		b := s.State.Balances[slug]
+		locations := b.Locations(account)
+		for _, location := range append([]string{""}, locations...) {
+			if held := b.In(account, location); held > 0 {
+				jars = append(jars, jar{slug: slug, location: location, placed: len(locations) > 0, expected: held})
+			}
 		}
 	}
 	return jars
@@ -179,7 +218,10 @@ func jarsOf(s *session, account journal.Account) []jar {
 //
 // Nothing is written until every question is answered: abandoning the forms
 // halfway records nothing, which is the only honest meaning of escape.
-func reconcileInteractively(cmd *cobra.Command, s *session, account journal.Account) error {
+//
+// A location given with --at keeps to the jars there; otherwise every
+// location of every product is a jar of its own.
+func reconcileInteractively(cmd *cobra.Command, s *session, account journal.Account, location string, only bool) error {
 	if !term.IsTerminal(os.Stdin.Fd()) {
 		return fmt.Errorf("reconcile without a product is interactive and needs a terminal; " +
 			"use `wits reconcile <account> <product> <weight>`")
@@ -190,12 +232,15 @@ func reconcileInteractively(cmd *cobra.Command, s *session, account journal.Acco
 		}
 	}
 	jars := jarsOf(s, account)
+	if only {
+		jars = slices.DeleteFunc(jars, func(j jar) bool { return j.location != location })
+	}
 	if len(jars) == 0 {
 		fmt.Fprintf(cmd.OutOrStdout(), "Nothing in %s to weigh.\n", account)
 		return nil
 	}
 
-	selected, expected, err := pickJars(s, account, jars)
+	selected, err := pickJars(s, account, jars)
 	if err != nil {
 		return err
 	}
@@ -204,7 +249,7 @@ func reconcileInteractively(cmd *cobra.Command, s *session, account journal.Acco
 		return nil
 	}
 
-	readings, err := askReadings(s, selected, expected)
+	readings, err := askReadings(s, selected)
 	if err != nil {
 		return err
 	}
@@ -212,41 +257,38 @@ func reconcileInteractively(cmd *cobra.Command, s *session, account journal.Acco
 }
 
 // pickJars offers the jars as a checklist, every one of them ticked, and
-// returns what stayed ticked along with each jar's ledger figure.
-func pickJars(s *session, account journal.Account, jars []jar) ([]string, map[string]float64, error) {
-	selected := make([]string, 0, len(jars))
-	expected := map[string]float64{}
-	options := make([]huh.Option[string], 0, len(jars))
+// returns what stayed ticked.
+func pickJars(s *session, account journal.Account, jars []jar) ([]jar, error) {
+	selected := slices.Clone(jars)
+	options := make([]huh.Option[jar], 0, len(jars))
 	for _, j := range jars {
-		selected = append(selected, j.slug)
-		expected[j.slug] = j.expected
 		options = append(options, huh.NewOption(
-			fmt.Sprintf("%s — ledger says %.2f g", s.ProductName(j.slug), j.expected), j.slug))
+			fmt.Sprintf("%s — ledger says %.2f g", j.label(s), j.expected), j))
 	}
 	// The height is the option count plus the chrome; left to its default the
 	// inline form shows a one-row viewport, which reads as a single jar.
 	err := formErr(huh.NewForm(huh.NewGroup(
-		huh.NewMultiSelect[string]().
+		huh.NewMultiSelect[jar]().
 			Title(fmt.Sprintf("Weigh which of %s", account)).
 			Description("Every jar is ticked; untick what stays on the shelf.").
 			Options(options...).
 			Height(min(len(options), 12) + 3).
 			Value(&selected),
 	)).Run())
-	return selected, expected, err
+	return selected, err
 }
 
 // askReadings asks for each jar's scale reading, one question per jar in its
 // own group, so the scale session reads as jar after jar rather than a wall
 // of fields.
-func askReadings(s *session, selected []string, expected map[string]float64) ([]string, error) {
+func askReadings(s *session, selected []jar) ([]string, error) {
 	readings := make([]string, len(selected))
 	groups := make([]*huh.Group, 0, len(selected))
-	for i, slug := range selected {
+	for i, j := range selected {
 		groups = append(groups, huh.NewGroup(
 			huh.NewInput().
-				Title(s.ProductName(slug)).
-				Description(fmt.Sprintf("On the scale, in grams — ledger says %.2f g. Blank to skip.", expected[slug])).
+				Title(j.label(s)).
+				Description(fmt.Sprintf("On the scale, in grams — ledger says %.2f g. Blank to skip.", j.expected)).
 				Value(&readings[i]).
 				Validate(optionalGrams),
 		))
@@ -277,10 +319,11 @@ func askAccount(s *session, account *journal.Account) error {
 
 // applyReadings records the collected weights, or reports what they would
 // change under --dry-run. Jars left blank are skipped, and a jar that already
-// matches is said to match rather than silently passed over.
-func applyReadings(out io.Writer, s *session, account journal.Account, slugs, readings []string) error {
-	recorded := 0
-	for i, slug := range slugs {
+// matches is said to match rather than silently passed over. The adjustments
+// are recorded together: a reading that cannot be recorded refuses the lot.
+func applyReadings(out io.Writer, s *session, account journal.Account, jars []jar, readings []string) error {
+	var taken []record.Reading
+	for i, j := range jars {
 		reading := strings.TrimSpace(readings[i])
 		if reading == "" {
 			continue
@@ -289,35 +332,55 @@ func applyReadings(out io.Writer, s *session, account journal.Account, slugs, re
 		if err != nil {
 			return err
 		}
-		if reconcileDryRun {
-			expected, difference, err := s.Recorder.Difference(slug, account, weighed)
+		taken = append(taken, record.Reading{Product: j.slug, Location: j.location, Weighed: weighed})
+	}
+
+	if reconcileDryRun {
+		for _, reading := range taken {
+			expected, difference, err := s.Recorder.DifferenceAt(reading.Product, account, reading.Location, reading.Weighed)
 			if err != nil {
 				return err
 			}
 			fmt.Fprintf(out, "%s: %.2fg by the ledger, %.2fg on the scale: %+.2fg\n",
-				slug, expected, weighed, difference)
-			recorded++
-			continue
+				jarName(reading), expected, reading.Weighed, difference)
 		}
-		e, err := s.Recorder.Reconcile(slug, account, weighed, reconcileReason)
-		if errors.Is(err, record.ErrNothingToReconcile) {
-			fmt.Fprintf(out, "%s: the ledger already matches the scale (%.2f g)\n", slug, weighed)
-			continue
+		if len(taken) == 0 {
+			fmt.Fprintln(out, "Every jar was skipped or already matched; nothing to record.")
+		} else {
+			fmt.Fprintln(out, "\nDry run. Nothing was written. Re-run without --dry-run to record it.")
 		}
-		if err != nil {
-			return err
+		return nil
+	}
+
+	recorded, err := s.Recorder.ReconcileAll(account, taken, reconcileReason)
+	if err != nil {
+		return err
+	}
+	adjusted := map[record.Reading]journal.Event{}
+	for _, e := range recorded {
+		adjusted[record.Reading{Product: e.Product, Location: e.FromLocation + e.ToLocation}] = e
+	}
+	for _, reading := range taken {
+		if e, ok := adjusted[record.Reading{Product: reading.Product, Location: reading.Location}]; ok {
+			writeAdjustment(out, s, e, account)
+		} else {
+			fmt.Fprintf(out, "%s: the ledger already matches the scale (%.2f g)\n", jarName(reading), reading.Weighed)
 		}
-		writeAdjustment(out, s, e, account)
-		recorded++
 	}
-	if recorded == 0 {
+	if len(recorded) == 0 {
 		fmt.Fprintln(out, "Every jar was skipped or already matched; nothing to record.")
-	} else if reconcileDryRun {
-		fmt.Fprintln(out, "\nDry run. Nothing was written. Re-run without --dry-run to record it.")
 	}
 	return nil
 }
 
+// jarName names the jar a reading was taken of, by its slug.
+func jarName(r record.Reading) string {
+	if r.Location == "" {
+		return r.Product
+	}
+	return r.Product + " at " + r.Location
+}
+
 // optionalGrams accepts a weight or nothing at all, since a blank reading
 // means a jar was skipped.
 func optionalGrams(v string) error {
@@ -386,7 +449,13 @@ func completeReconcile(_ *cobra.Command, args []string, prefix string) ([]string
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
 		var out []string
+		seen := map[string]bool{}
 		for _, j := range jarsOf(s, account) {
+			// A product kept in several locations is still one argument.
+			if seen[j.slug] {
+				continue
+			}
+			seen[j.slug] = true
 			if strings.HasPrefix(j.slug, prefix) {
 				out = append(out, fmt.Sprintf("%s\t%.2f g · %s", j.slug, j.expected, s.ProductName(j.slug)))
 			}
@@ -401,5 +470,6 @@ func init() {
 	Reconcile.Flags().BoolVar(&reconcileStash, "stash", false, "weigh the stash rather than storage")
 	Reconcile.Flags().BoolVar(&reconcileAVB, "avb", false, "weigh the already vaped bud")
 	Reconcile.Flags().StringVar(&reconcileReason, "reason", "", "why the amounts differ")
+	Reconcile.Flags().StringVar(&reconcileAt, "at", "", "weigh the jar at this location, home for the unnamed one")
 	Reconcile.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "show the difference without recording it")
 }
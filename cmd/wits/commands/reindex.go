@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/TheDonDope/wits/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// Reindex is the `wits reindex` command.
+var Reindex = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the cached fold from the journal",
+	Long: "Throw away the cached fold in .wits/index/ and build it again by\n" +
+		"replaying the whole journal.\n\n" +
+		"The cache is only ever a shortcut. A cache that does not match the\n" +
+		"journal is discarded on its own, so this should never be needed; it is\n" +
+		"here so that nobody has to take that on trust.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		state, err := workspace.Reindex(s.Repo, s.Patient)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Reindexed %s into %s\n",
+			plural(len(state.Events), "event"), s.Repo.IndexPath())
+		return nil
+	},
+}
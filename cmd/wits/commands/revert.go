@@ -35,9 +35,12 @@ var Revert = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "[%s] undid %s %.2fg %s from %s\n",
-			shortHash(e.Hash), original.Type, original.Grams, original.Product,
-			original.OccurredAt.Format("2006-01-02"))
+		what := amount(original)
+		if original.Product != "" {
+			what += " " + original.Product
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%s] undid %s %s from %s\n",
+			shortHash(e.Hash), original.Type, what, original.OccurredAt.Format("2006-01-02"))
 		return nil
 	},
 }
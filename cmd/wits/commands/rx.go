@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/rx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rxPrescriber string
+	rxDate       string
+	rxValidUntil string
+	rxMaxDaily   string
+	rxNote       string
+)
+
+// Rx is the `wits rx` command.
+var Rx = &cobra.Command{
+	Use:   "rx",
+	Short: "Keep your prescriptions",
+	Long: "Keep prescriptions as written: who prescribed them, when, which products\n" +
+		"and how many grams of each, the most a day, and until when they can be\n" +
+		"filled.\n\n" +
+		"Purchases are matched to the prescriptions they fill as they are read:\n" +
+		"each fills the oldest valid prescription that names its product and\n" +
+		"still has grams of it open. Nothing has to be linked by hand.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error { return rxList.RunE(cmd, args) },
+}
+
+var rxAdd = &cobra.Command{
+	Use:   "add <product> <amount> [<product> <amount>...]",
+	Short: "Add a prescription",
+	Long: "Add a prescription for one or more products, each followed by the\n" +
+		"grams prescribed of it. A product that is not in the catalog yet is\n" +
+		"added to it, the way `wits buy` adds one.",
+	Example: "  wits rx add wedding-cake 20 lemon 10 --prescriber \"Dr. Weber\" --max-daily 1\n" +
+		"  wits rx add \"Enua 22/1 Wedding Cake\" 30g --date 2026-07-01 --valid-until 2026-09-30",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || len(args)%2 != 0 {
+			return fmt.Errorf("expected pairs of a product and an amount, got %d arguments", len(args))
+		}
+		return nil
+	},
+	ValidArgsFunction: completeProduct(""),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		issued, err := parseDate(rxDate)
+		if err != nil {
+			return err
+		}
+		p := &rx.Prescription{Prescriber: rxPrescriber, Issued: issued, Note: rxNote, Patient: s.Patient}
+		if rxValidUntil != "" {
+			if p.ValidUntil, err = parseDate(rxValidUntil); err != nil {
+				return err
+			}
+		}
+		if rxMaxDaily != "" {
+			if p.MaxDaily, err = parseGrams(rxMaxDaily); err != nil {
+				return err
+			}
+		}
+		// Every amount is read before any product is registered, so a typo in
+		// the last one does not leave the first ones in the catalog.
+		amounts := make([]float64, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			g, err := parseGrams(args[i])
+			if err != nil {
+				return err
+			}
+			amounts = append(amounts, g)
+		}
+		out := cmd.OutOrStdout()
+		for i := 0; i < len(args); i += 2 {
+			product, added, err := s.Recorder.Register(args[i], "")
+			if err != nil {
+				return err
+			}
+			if added {
+				fmt.Fprintf(out, "New product %s — refer to it as %s\n", product.Name, product.Slug)
+			}
+			p.Items = append(p.Items, rx.Item{Product: product.Slug, Grams: amounts[i/2]})
+		}
+		if err := s.Prescriptions.Add(p); err != nil {
+			return err
+		}
+		if err := s.Repo.SavePrescriptions(s.Prescriptions); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added prescription %d for %.2fg\n", p.ID, p.Prescribed())
+		for _, f := range rx.Fill(s.Prescribed(), s.State.Events) {
+			if f.ID == p.ID && f.Total() > 0 {
+				fmt.Fprintf(out, "%.2fg of it already filled by %s\n",
+					f.Total(), plural(len(f.Purchases), "purchase"))
+			}
+		}
+		return nil
+	},
+}
+
+var rxList = &cobra.Command{
+	Use:   "list",
+	Short: "List your prescriptions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if len(s.Prescribed().Prescriptions) == 0 {
+			fmt.Fprintln(out, "No prescriptions yet. Add one with `wits rx add`.")
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tISSUED\tPRESCRIBER\tPRODUCTS\tFILLED\tMAX DAILY\tVALID UNTIL")
+		for _, f := range rx.Fill(s.Prescribed(), s.State.Events) {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.2fg of %.2fg\t%s\t%s\n",
+				f.ID, f.Issued.Format(time.DateOnly), orDash(f.Prescriber), products(f.Prescription),
+				f.Total(), f.Prescribed(), maxDaily(f.Prescription), validUntil(f.Prescription, s.OpenedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var rxShow = &cobra.Command{
+	Use:     "show <id>",
+	Short:   "Show one prescription and the purchases that filled it",
+	Example: "  wits rx show 1",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		p, err := s.Prescribed().Find(args[0])
+		if err != nil {
+			return err
+		}
+		var f rx.Filled
+		for _, filled := range rx.Fill(s.Prescribed(), s.State.Events) {
+			if filled.ID == p.ID {
+				f = filled
+			}
+		}
+		out := cmd.OutOrStdout()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "prescription\t%d\n", p.ID)
+		fmt.Fprintf(w, "issued\t%s\n", p.Issued.Format(time.DateOnly))
+		if p.Prescriber != "" {
+			fmt.Fprintf(w, "prescriber\t%s\n", p.Prescriber)
+		}
+		fmt.Fprintf(w, "valid until\t%s\n", validUntil(p, s.OpenedAt))
+		fmt.Fprintf(w, "max daily\t%s\n", maxDaily(p))
+		if p.Note != "" {
+			fmt.Fprintf(w, "note\t%s\n", p.Note)
+		}
+		w.Flush()
+
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PRODUCT\tPRESCRIBED\tFILLED\tUNFILLED")
+		for _, it := range p.Items {
+			filled := f.Filled[it.Product]
+			fmt.Fprintf(w, "%s\t%.2fg\t%.2fg\t%.2fg\n",
+				it.Product, it.Grams, filled, ledger.Round(max(it.Grams-filled, 0)))
+		}
+		fmt.Fprintf(w, "\t\t\t\n")
+		fmt.Fprintf(w, "total\t%.2fg\t%.2fg\t%.2fg\n", p.Prescribed(), f.Total(), f.Unfilled())
+		w.Flush()
+
+		fmt.Fprintln(out)
+		if len(f.Purchases) == 0 {
+			fmt.Fprintln(out, "No purchases have filled it yet.")
+			return nil
+		}
+		fmt.Fprintf(out, "Filled by %s:\n", plural(len(f.Purchases), "purchase"))
+		for _, e := range f.Purchases {
+			fmt.Fprintf(out, "  [%s] %s  %.2fg %s\n",
+				shortHash(e.Hash), e.OccurredAt.Format(time.DateOnly), e.Grams, e.Product)
+		}
+		return nil
+	},
+}
+
+// writePrescriptions renders what is left to fill of the prescriptions still
+// valid, and warns when the daily average has gone above the most the
+// prescription in force allows. It writes nothing for a repository without
+// prescriptions.
+func writePrescriptions(out io.Writer, ps *rx.Prescriptions, events []journal.Event, now time.Time) {
+	if ps == nil || len(ps.Prescriptions) == 0 {
+		return
+	}
+	var prescribed, unfilled float64
+	for _, f := range rx.Fill(ps, events) {
+		if f.Valid(now) {
+			prescribed += f.Prescribed()
+			unfilled += f.Unfilled()
+		}
+	}
+	if prescribed > 0 {
+		fmt.Fprintf(out, "%s g of %s g prescribed still unfilled\n",
+			grams(ledger.Round(unfilled)), grams(ledger.Round(prescribed)))
+	}
+	if p := ps.InForce(now); p != nil {
+		if avg := ledger.RollingAverage(events, rx.AverageDays, now); avg > p.MaxDaily {
+			fmt.Fprintf(out, "⚠️  %.2fg a day over the last %d days, above the %sg a day prescription %d allows\n",
+				avg, rx.AverageDays, grams(p.MaxDaily), p.ID)
+		}
+	}
+}
+
+// products lists a prescription's products and their grams for a table cell.
+func products(p *rx.Prescription) string {
+	parts := make([]string, 0, len(p.Items))
+	for _, it := range p.Items {
+		parts = append(parts, fmt.Sprintf("%s %sg", it.Product, grams(it.Grams)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxDaily renders a prescription's daily maximum, or a dash when it sets none.
+func maxDaily(p *rx.Prescription) string {
+	if p.MaxDaily <= 0 {
+		return "-"
+	}
+	return grams(p.MaxDaily) + "g"
+}
+
+// validUntil renders when a prescription lapses, and says so once it has.
+func validUntil(p *rx.Prescription, now time.Time) string {
+	if p.ValidUntil.IsZero() {
+		return "-"
+	}
+	date := p.ValidUntil.Format(time.DateOnly)
+	if !p.Valid(now) && now.After(p.Issued) {
+		return date + " (lapsed)"
+	}
+	return date
+}
+
+// grams renders an amount without the trailing zeros a prescription is not
+// written with: 30, 12.5.
+func grams(g float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", g), "0"), ".")
+}
+
+// orDash renders an empty table cell as a dash.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rxAdd.Flags().StringVar(&rxPrescriber, "prescriber", "", "who prescribed it")
+	rxAdd.Flags().StringVar(&rxDate, "date", "", "the date it was issued, defaults to today")
+	rxAdd.Flags().StringVar(&rxValidUntil, "valid-until", "", "the last day it can be filled on, if it lapses")
+	rxAdd.Flags().StringVar(&rxMaxDaily, "max-daily", "", "the most grams a day it allows")
+	rxAdd.Flags().StringVar(&rxNote, "note", "", "a note to keep with the prescription")
+	Rx.AddCommand(rxAdd, rxList, rxShow)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/TheDonDope/wits/pkg/catalog"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,8 @@ var (
 	seshDevice string
 	seshTemp   int
 	seshNote   string
+	seshForce  bool
+	seshAt     string
 )
 
 // Sesh is the `wits sesh` command.
@@ -27,7 +30,13 @@ var Sesh = &cobra.Command{
 		"and credited separately.\n\n" +
 		"With a temperature, this also reports which compounds that setting is\n" +
 		"hot enough to release, and warns when it is hot enough to produce\n" +
-		"benzene.",
+		"benzene.\n\n" +
+		"A backdated session is checked on the day it is dated, as a grind is;\n" +
+		"--force records one that overdraws the stash, with a warning, and with\n" +
+		"`wits anomalies warn on` one that makes an unusually heavy day is too.\n" +
+		"A session during a break declared with `wits break start` is recorded\n" +
+		"and warned about as a lapse.\n\n" +
+		"--at draws on the stash at a location moved to with `wits move`.",
 	Example: "  wits sesh wedding-cake 0.3 --device volcano --temp 185\n" +
 		"  wits sesh lemon 0.2 --date 2026-07-29",
 	Args:              cobra.ExactArgs(2),
@@ -41,20 +50,34 @@ var Sesh = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		location, err := parseLocation(seshAt)
+		if err != nil {
+			return err
+		}
 		at, err := parseDate(seshDate)
 		if err != nil {
 			return err
 		}
-		e, err := s.Recorder.Session(args[0], grams, at, seshDevice, seshTemp, seshNote)
+		if seshForce {
+			s.Recorder.Force(forced(cmd.OutOrStdout()))
+		}
+		e, err := s.Recorder.SessionAt(args[0], grams, at, location, seshDevice, seshTemp, seshNote)
 		if err != nil {
 			return err
 		}
 		out := cmd.OutOrStdout()
-		fmt.Fprintf(out, "[%s] sesh %.2fg %s, %.2fg left in the stash\n",
-			shortHash(e.Hash), e.Grams, e.Product, s.Recorder.Available(e.Product, journal.Stash))
+		if location != "" {
+			fmt.Fprintf(out, "[%s] sesh %.2fg %s at %s, %.2fg left in the stash there\n",
+				shortHash(e.Hash), e.Grams, e.Product, location, s.Recorder.AvailableAt(e.Product, journal.Stash, location))
+		} else {
+			fmt.Fprintf(out, "[%s] sesh %.2fg %s, %.2fg left in the stash\n",
+				shortHash(e.Hash), e.Grams, e.Product, s.Recorder.Available(e.Product, journal.Stash))
+		}
 		if e.Temperature > 0 {
 			writeReleased(out, e.Temperature)
 		}
+		warnUnusual(out, s, ledger.Seshing, e)
+		warnBreak(out, s, e)
 		return nil
 	},
 }
@@ -83,4 +106,6 @@ func init() {
 	Sesh.Flags().StringVar(&seshDevice, "device", "", "the device used")
 	Sesh.Flags().IntVar(&seshTemp, "temp", 0, "the temperature in degrees Celsius")
 	Sesh.Flags().StringVar(&seshNote, "note", "", "a note to keep with the entry")
+	Sesh.Flags().StringVar(&seshAt, "at", "", "the location of the stash it drew on, defaults to home")
+	Sesh.Flags().BoolVar(&seshForce, "force", false, "record it even if it overdraws the stash, with a warning")
 }
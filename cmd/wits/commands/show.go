@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+var showJSON bool
+
+// shown is one entry with everything the ledger knows around it.
+type shown struct {
+	Event      journal.Event   `json:"event"`
+	Cycle      int             `json:"cycle,omitempty"` // counted from 1, as status counts them
+	CycleStart string          `json:"cycle_start,omitempty"`
+	RevertedBy string          `json:"reverted_by,omitempty"`
+	Before     *ledger.Balance `json:"before,omitempty"`
+	After      *ledger.Balance `json:"after,omitempty"`
+}
+
+// Show is the `wits show` command.
+var Show = &cobra.Command{
+	Use:   "show <entry>",
+	Short: "Show one entry in full",
+	Long: "Show one entry in full, the way `git show` shows a commit: both\n" +
+		"timestamps, the accounts the grams moved between, the device and\n" +
+		"temperature, the note, and the hashes chaining it to the entry before.\n\n" +
+		"Around it, what the ledger knows: the cycle it was recorded in, the\n" +
+		"correction that undid it or the entry it undoes, and what the product\n" +
+		"held in each account immediately before and after it.\n\n" +
+		"The entry is named by its hash, abbreviated as `wits log` shows it.\n" +
+		"Another patient's entry is refused: its balances are theirs, so show\n" +
+		"it with --patient.",
+	Example: "  wits show 8297238\n" +
+		"  wits show 8297238 --json",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntry,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		e, err := s.Recorder.Find(args[0])
+		if err != nil {
+			return err
+		}
+		entry := showEntry(s, e)
+		if showJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(entry)
+		}
+		writeShown(cmd.OutOrStdout(), s, entry)
+		return nil
+	},
+}
+
+// showEntry gathers what the ledger knows around an entry. The balances come
+// from folding the journal up to the entry and through it, so they are what
+// the ledger said at that point in the record, not what it says now.
+func showEntry(s *session, e journal.Event) shown {
+	out := shown{Event: e}
+	if c := s.State.CycleOf(e.Hash); c != nil {
+		out.Cycle = c.Seq + 1
+		out.CycleStart = c.Start.Format("2006-01-02")
+	}
+	if revert := s.Recorder.RevertOf(e.Hash); revert != nil {
+		out.RevertedBy = revert.Hash
+	}
+	events := s.State.Events
+	for i := range events {
+		if events[i].Hash != e.Hash {
+			continue
+		}
+		if e.Product != "" && !e.Massless() {
+			out.Before = balanceOf(ledger.Fold(s.State.Patient, events[:i]), e.Product)
+			out.After = balanceOf(ledger.Fold(s.State.Patient, events[:i+1]), e.Product)
+		}
+		break
+	}
+	return out
+}
+
+// located names where in an account an entry's grams were: the location of a
+// placed account, home included, and nothing for the others.
+func located(account journal.Account, location string) string {
+	if !journal.Placed(account) {
+		return string(account)
+	}
+	return journal.LocationName(location)
+}
+
+// balanceOf returns a product's balance in a state, empty rather than nil for
+// a product the state has not met yet.
+func balanceOf(state *ledger.State, product string) *ledger.Balance {
+	if b, ok := state.Balances[product]; ok {
+		return b
+	}
+	return &ledger.Balance{Product: product}
+}
+
+// writeShown renders an entry as a list of fields, then the balances.
+func writeShown(out io.Writer, s *session, entry shown) {
+	e := entry.Event
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "entry\t%s\n", e.Hash)
+	fmt.Fprintf(w, "seq\t%d\n", e.Seq)
+	fmt.Fprintf(w, "type\t%s\n", e.Type)
+	fmt.Fprintf(w, "occurred\t%s\n", e.OccurredAt.Format("2006-01-02 15:04:05 -0700"))
+	fmt.Fprintf(w, "recorded\t%s\n", e.RecordedAt.Format("2006-01-02 15:04:05 -0700"))
+	if e.Product != "" {
+		fmt.Fprintf(w, "product\t%s (%s)\n", e.Product, s.ProductName(e.Product))
+	}
+	switch e.Type {
+	case journal.Feel:
+		for _, name := range e.Scores.Names() {
+			sym, _ := journal.LookupSymptom(name)
+			fmt.Fprintf(w, "%s\t%d of %d (0 %s, %d %s)\n", name, e.Scores[name], journal.MaxScore,
+				sym.Low, journal.MaxScore, sym.High)
+		}
+		if e.Session != "" {
+			fmt.Fprintf(w, "session\t%s\n", e.Session)
+		}
+	case journal.Break:
+		fmt.Fprintf(w, "days\t%d, until %s\n", e.Days, e.OccurredAt.AddDate(0, 0, e.Days).Format("2006-01-02 15:04"))
+	default:
+		fmt.Fprintf(w, "grams\t%.2fg\n", e.Grams)
+		fmt.Fprintf(w, "accounts\t%s -> %s\n", e.From, e.To)
+		if e.FromLocation != "" || e.ToLocation != "" {
+			fmt.Fprintf(w, "locations\t%s -> %s\n", located(e.From, e.FromLocation), located(e.To, e.ToLocation))
+		}
+	}
+	if e.Device != "" {
+		fmt.Fprintf(w, "device\t%s\n", e.Device)
+	}
+	if e.Temperature != 0 {
+		fmt.Fprintf(w, "temperature\t%d°C\n", e.Temperature)
+	}
+	if e.Purpose != "" {
+		fmt.Fprintf(w, "purpose\t%s\n", e.Purpose)
+	}
+	if e.Into != "" {
+		fmt.Fprintf(w, "into\t%s (%s)\n", e.Into, s.ProductName(e.Into))
+	}
+	if e.Patient != "" {
+		fmt.Fprintf(w, "patient\t%s\n", e.Patient)
+	}
+	if d := s.Potency().Session(e); d.THC > 0 || d.CBD > 0 {
+		dose := milligrams(d.THC, d.CBD)
+		if d.Extracted() {
+			dose += fmt.Sprintf(", %s extracted", milligrams(d.ExtractedTHC, d.ExtractedCBD))
+		}
+		fmt.Fprintf(w, "dose\t%s\n", dose)
+	}
+	if e.Price != 0 {
+		fmt.Fprintf(w, "price\t%s\n", ledger.Money(e.Price, e.Currency))
+	}
+	if e.Note != "" {
+		fmt.Fprintf(w, "note\t%s\n", e.Note)
+	}
+	prev := e.Prev
+	if prev == "" {
+		prev = "(the first entry)"
+	}
+	fmt.Fprintf(w, "prev\t%s\n", prev)
+	if entry.Cycle > 0 {
+		fmt.Fprintf(w, "cycle\t%d, opened %s\n", entry.Cycle, entry.CycleStart)
+	}
+	if e.Reverts != "" {
+		fmt.Fprintf(w, "reverts\t%s\n", e.Reverts)
+	}
+	if entry.RevertedBy != "" {
+		fmt.Fprintf(w, "reverted by\t%s\n", entry.RevertedBy)
+	}
+	w.Flush()
+
+	if entry.Before == nil || entry.After == nil {
+		return
+	}
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tBEFORE\tAFTER")
+	for _, row := range []struct {
+		account       journal.Account
+		before, after float64
+	}{
+		{journal.Storage, entry.Before.Storage, entry.After.Storage},
+		{journal.Stash, entry.Before.Stash, entry.After.Stash},
+		{journal.Consumed, entry.Before.Consumed, entry.After.Consumed},
+		{journal.AVB, entry.Before.AVB, entry.After.AVB},
+	} {
+		fmt.Fprintf(w, "%s\t%.2fg\t%.2fg\n", row.account, row.before, row.after)
+	}
+	w.Flush()
+}
+
+func init() {
+	Show.Flags().BoolVar(&showJSON, "json", false, "write the entry as JSON")
+}
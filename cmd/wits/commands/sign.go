@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/signing"
+	"github.com/spf13/cobra"
+)
+
+var signNewKey bool
+
+// Sign is the `wits sign` command.
+var Sign = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a checkpoint of the journal",
+	Long: "Sign the tip of the journal — its sequence number, its hash and the time\n" +
+		"of signing — with this repository's Ed25519 key, and keep the signature\n" +
+		"in .wits/checkpoints/.\n\n" +
+		"The hash chain alone proves the journal is consistent, not that it is the\n" +
+		"one that was written: anyone who can write the file can rewrite it and\n" +
+		"compute every hash again. They cannot sign again. A checkpoint shows\n" +
+		"that everything up to it stood as it does now when it was signed, and\n" +
+		"`wits fsck` and `wits restore` check that every checkpoint still does.\n\n" +
+		"The key is made by `wits init --signing-key`, or here with --new-key.\n" +
+		"Its public half, .wits/signing.pub, is what to hand whoever is to check.",
+	Example: "  wits sign --new-key\n" +
+		"  wits sign",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		if signNewKey {
+			if err := newSigningKey(cmd.OutOrStdout(), s.Repo); err != nil {
+				return err
+			}
+			if len(s.State.Journal()) == 0 {
+				return nil
+			}
+		}
+		c, err := signing.Sign(s.Repo, s.State.Journal(), time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Signed entry %d (%s) at %s.\n",
+			c.Seq, shortHash(c.Tip), c.SignedAt.Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// newSigningKey makes a repository's signing key and says where it went.
+func newSigningKey(out io.Writer, r *repo.Repo) error {
+	public, err := signing.NewKey(r)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Created a signing key. Its public half, to hand on, is in %s:\n  %s\n",
+		r.PublicKeyPath(), base64.StdEncoding.EncodeToString(public))
+	return nil
+}
+
+func init() {
+	Sign.Flags().BoolVar(&signNewKey, "new-key", false, "create the repository's signing key first")
+}
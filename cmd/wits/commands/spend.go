@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/rx"
+)
+
+var spendYear int
+
+// Spend is the `wits spend` command.
+var Spend = &cobra.Command{
+	Use:   "spend",
+	Short: "Show what the purchases cost",
+	Long: "Add up the prices recorded with `wits buy --price`: per calendar year,\n" +
+		"per product with the price of a gram, and per cycle.\n\n" +
+		"With --year, list every purchase of that year instead, with its date,\n" +
+		"product, manufacturer, the prescription it filled and what it cost: the\n" +
+		"itemisation an insurance or tax reimbursement claim asks for. Purchases\n" +
+		"recorded without a price are listed too, and said to be missing from the\n" +
+		"total rather than counted as free.",
+	Example: "  wits spend\n" +
+		"  wits spend --year 2025",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := open()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if spendYear != 0 {
+			writeClaim(out, s, spendYear)
+			return nil
+		}
+		state := s.Chronological()
+		if !ledger.Spent(state.Events).Priced() {
+			fmt.Fprintln(out, "No purchase has a price yet. Record one with `wits buy <product> <amount> --price <total>`.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "YEAR\tSPENT\tGRAMS\tPER GRAM")
+		for _, y := range ledger.SpentPerYear(state.Events) {
+			writeCosts(w, strconv.Itoa(y.Year), y.Spending)
+		}
+		w.Flush()
+
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PRODUCT\tSPENT\tGRAMS\tPER GRAM")
+		for _, p := range ledger.SpentPerProduct(state.Events) {
+			writeCosts(w, p.Product, p.Spending)
+		}
+		w.Flush()
+
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CYCLE\tSPENT\tGRAMS\tPER GRAM")
+		for _, c := range state.Cycles {
+			writeCosts(w, fmt.Sprintf("%d (%s)", c.Seq+1, c.Start.Format(time.DateOnly)), ledger.Spent(c.Events))
+		}
+		w.Flush()
+
+		writeUnpriced(out, ledger.Spent(state.Events))
+		return nil
+	},
+}
+
+// writeCosts writes a table row per currency a spending was in, or a row of
+// dashes when none of it was priced.
+func writeCosts(w io.Writer, label string, s ledger.Spending) {
+	if !s.Priced() {
+		fmt.Fprintf(w, "%s\t-\t%.2fg\t-\n", label, s.Unpriced)
+		return
+	}
+	for _, c := range s.Costs {
+		fmt.Fprintf(w, "%s\t%s\t%.2fg\t%s\n",
+			label, ledger.Money(c.Price, c.Currency), c.Grams, perGram(c.Price, c.Grams, c.Currency))
+	}
+}
+
+// writeUnpriced says how many grams were left out of the totals for want of
+// a price.
+func writeUnpriced(out io.Writer, s ledger.Spending) {
+	if s.Unpriced > 0 {
+		fmt.Fprintf(out, "\n%.2fg bought without a price is not counted in the totals.\n", s.Unpriced)
+	}
+}
+
+// writeClaim itemises the purchases of one year for a reimbursement claim.
+func writeClaim(out io.Writer, s *session, year int) {
+	state := s.Chronological()
+	spent := ledger.SpentIn(state.Events, year)
+	if len(spent.Purchases) == 0 {
+		fmt.Fprintf(out, "No purchases in %d.\n", year)
+		return
+	}
+
+	// A purchase that filled more than one prescription names all of them.
+	filled := map[string][]*rx.Prescription{}
+	if s.Prescriptions != nil {
+		for _, f := range rx.Fill(s.Prescribed(), state.Events) {
+			for _, e := range f.Purchases {
+				filled[e.Hash] = append(filled[e.Hash], f.Prescription)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "Purchases in %d\n\n", year)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tPRODUCT\tMANUFACTURER\tPRESCRIPTION\tGRAMS\tPRICE\tPER GRAM")
+	var cited []*rx.Prescription
+	seen := map[int]bool{}
+	for _, e := range ledger.Chronological(spent.Purchases) {
+		name, manufacturer := e.Product, ""
+		if p, err := s.Products.Find(e.Product); err == nil {
+			name, manufacturer = p.Name, p.Manufacturer
+		}
+		ids := make([]string, 0, len(filled[e.Hash]))
+		for _, p := range filled[e.Hash] {
+			ids = append(ids, strconv.Itoa(p.ID))
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				cited = append(cited, p)
+			}
+		}
+		price, each := "-", "-"
+		if e.Price != 0 {
+			price, each = ledger.Money(e.Price, e.Currency), perGram(e.Price, e.Grams, e.Currency)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2fg\t%s\t%s\n",
+			e.OccurredAt.Format(time.DateOnly), name, orDash(manufacturer),
+			orDash(strings.Join(ids, ", ")), e.Grams, price, each)
+	}
+	fmt.Fprintln(w, "\t\t\t\t\t\t")
+	for _, c := range spent.Costs {
+		fmt.Fprintf(w, "total\t\t\t\t%.2fg\t%s\t%s\n",
+			c.Grams, ledger.Money(c.Price, c.Currency), perGram(c.Price, c.Grams, c.Currency))
+	}
+	w.Flush()
+
+	if len(cited) > 0 {
+		fmt.Fprintln(out)
+		for _, p := range cited {
+			fmt.Fprintf(out, "Prescription %d issued %s", p.ID, p.Issued.Format(time.DateOnly))
+			if p.Prescriber != "" {
+				fmt.Fprintf(out, " by %s", p.Prescriber)
+			}
+			fmt.Fprintln(out)
+		}
+	}
+	writeUnpriced(out, spent)
+}
+
+// perGram renders the price of a gram, rounded to the hundredth.
+func perGram(price int64, grams float64, currency string) string {
+	if grams <= 0 {
+		return "-"
+	}
+	return ledger.Money(int64(float64(price)/grams+0.5), currency)
+}
+
+func init() {
+	Spend.Flags().IntVar(&spendYear, "year", 0, "itemise the purchases of one calendar year, for a claim")
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/TheDonDope/wits/pkg/journal"
 	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/rx"
 	"github.com/spf13/cobra"
 )
 
@@ -17,20 +21,208 @@ var Status = &cobra.Command{
 	Short: "Show what is left and how long it will last",
 	Long: "Show the working state derived from the journal: how much of each\n" +
 		"product is in storage and in its stash, how far through the current cycle\n" +
-		"you are, and how long the remainder will last at the observed rate.",
+		"you are, and how long the remainder will last at the observed rate.\n" +
+		"Once AVB has been collected, the yield of each device follows.\n\n" +
+		"With prescriptions kept by `wits rx`, it says how much of them is still\n" +
+		"unfilled, and warns when the average over the last week is above the\n" +
+		"daily maximum prescribed.\n\n" +
+		"Once grams have been carried elsewhere with `wits move`, what storage\n" +
+		"and the stash hold in each location follows the table.\n\n" +
+		"The cycle's sessions are also counted in milligrams of THC and CBD,\n" +
+		"from the strength of each product, and in milligrams extracted where a\n" +
+		"device has an efficiency (see `wits device efficiency`).\n\n" +
+		"Entries are counted in the order they happened, so one recorded late\n" +
+		"with --date falls in the cycle it belongs to.\n\n" +
+		"With --sessions, the cycle is read by its sessions instead: how many a\n" +
+		"day, how much a session draws and how it spreads, the temperature they\n" +
+		"ran at, and how long the stash lasts at that rate.\n\n" +
+		"With --as-of, the status is the one of a past moment: a date, which\n" +
+		"means the end of that day, a date and time, or an entry. It is read\n" +
+		"from what had happened by then, as the journal knows it now, followed\n" +
+		"by what the ledger believed then: the entries dated by then that were\n" +
+		"recorded later, and the balances it showed without them.",
+	Example: "  wits status\n" +
+		"  wits status --sessions\n" +
+		"  wits status --as-of 2026-08-01\n" +
+		"  wits status --as-of 3f9c2ab",
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		s, err := open()
 		if err != nil {
 			return err
 		}
-		writeStatus(cmd.OutOrStdout(), s.State)
+		out := cmd.OutOrStdout()
+		writePatient(out, s)
+		write := func(state *ledger.State, now time.Time) {
+			writeStatus(out, state, s.Prescribed(), s.Potency(), now)
+		}
+		if statusSessions {
+			write = func(state *ledger.State, now time.Time) { writeSessions(out, state, now) }
+		}
+		if statusAsOf == "" {
+			write(s.Chronological(), s.OpenedAt)
+			return nil
+		}
+		a, err := parseAsOf(s, statusAsOf)
+		if err != nil {
+			return err
+		}
+		state := s.Chronological()
+		fmt.Fprintf(out, "As of %s, %s\n\n", a.label, ledger.AsOccurred)
+		write(state.At(a.Occurred, ledger.AsOccurred), a.Occurred)
+		fmt.Fprintln(out)
+		writeBelieved(out, state, a)
 		return nil
 	},
 }
 
-// writeStatus renders the state as a table.
-func writeStatus(out io.Writer, state *ledger.State) {
+var (
+	statusAsOf     string
+	statusSessions bool
+)
+
+// writePatient says whose status it is, in a household that keeps several
+// patients, and who else the journal keeps: entries recorded without
+// --patient are nobody's but their own, and a status of them alone would
+// otherwise pass for the household's.
+func writePatient(out io.Writer, s *session) {
+	var others []string
+	for _, p := range s.Patients() {
+		if p != s.Patient {
+			others = append(others, p)
+		}
+	}
+	switch {
+	case s.Patient != "" && len(others) > 0:
+		fmt.Fprintf(out, "Patient %s; also in this journal: %s\n\n", s.Patient, strings.Join(others, ", "))
+	case s.Patient != "":
+		fmt.Fprintf(out, "Patient %s\n\n", s.Patient)
+	case len(others) > 0:
+		fmt.Fprintf(out, "The entries recorded without a patient; this journal keeps %s apart, and `--patient` reads theirs\n\n",
+			strings.Join(others, ", "))
+	}
+}
+
+// writeBelieved sets the recorded reading of a moment beside the one of what
+// happened: the entries one holds and the other does not, and the balances
+// that differ between them.
+func writeBelieved(out io.Writer, state *ledger.State, a asOf) {
+	recorded := map[string]bool{}
+	for _, e := range a.RecordedBy(state.Events) {
+		recorded[e.Hash] = true
+	}
+	happened := map[string]bool{}
+	var late, early []journal.Event
+	for _, e := range a.Happened(state.Events) {
+		happened[e.Hash] = true
+		if !recorded[e.Hash] {
+			late = append(late, e)
+		}
+	}
+	for _, e := range state.Events {
+		if recorded[e.Hash] && !happened[e.Hash] {
+			early = append(early, e)
+		}
+	}
+	if len(late) == 0 && len(early) == 0 {
+		fmt.Fprintf(out, "The ledger %s at %s held the same: nothing dated by then was entered later.\n",
+			ledger.AsRecorded, a.label)
+		return
+	}
+
+	fmt.Fprintf(out, "The ledger %s at %s", ledger.AsRecorded, a.label)
+	if len(late) > 0 {
+		fmt.Fprintf(out, " did not hold %s dated by then yet", entries(len(late)))
+	}
+	if len(early) > 0 {
+		if len(late) > 0 {
+			fmt.Fprint(out, ", and")
+		}
+		fmt.Fprintf(out, " already held %s dated after it", entries(len(early)))
+	}
+	fmt.Fprintln(out, ":")
+	for _, e := range append(late, early...) {
+		fmt.Fprintf(out, "  [%s] %s %.2fg %s, dated %s, recorded %s\n", shortHash(e.Hash), e.Type, e.Grams,
+			e.Product, e.OccurredAt.Format(time.DateOnly), e.RecordedAt.Format(time.DateOnly))
+	}
+
+	then := state.At(a.Occurred, ledger.AsOccurred)
+	believed := state.Believed(a.Moment)
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT\tSTORAGE\tSTASH\tAVB\t")
+	for _, product := range sortedProducts(then, believed) {
+		b, h := balanceOf(believed, product), balanceOf(then, product)
+		if b.Storage == h.Storage && b.Stash == h.Stash && b.Consumed == h.Consumed && b.AVB == h.AVB {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.2fg\t%.2fg\t%.2fg\tbelieved, against %.2fg, %.2fg and %.2fg\n",
+			product, b.Storage, b.Stash, b.AVB, h.Storage, h.Stash, h.AVB)
+	}
+	w.Flush()
+}
+
+// writeLocations lists where the grams are, for the products some of which
+// have been moved out of home: storage and the stash of each location, home
+// first. It says nothing of a journal that keeps no locations.
+func writeLocations(out io.Writer, state *ledger.State) {
+	var placed []string
+	for _, product := range state.Products() {
+		b := state.Balances[product]
+		if len(b.Locations(journal.Storage)) > 0 || len(b.Locations(journal.Stash)) > 0 {
+			placed = append(placed, product)
+		}
+	}
+	if len(placed) == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT\tLOCATION\tSTORAGE\tSTASH")
+	for _, product := range placed {
+		b := state.Balances[product]
+		locations := []string{""}
+		for _, l := range append(b.Locations(journal.Storage), b.Locations(journal.Stash)...) {
+			if !slices.Contains(locations, l) {
+				locations = append(locations, l)
+			}
+		}
+		slices.Sort(locations[1:])
+		for _, l := range locations {
+			fmt.Fprintf(w, "%s\t%s\t%.2fg\t%.2fg\n", product, journal.LocationName(l),
+				b.In(journal.Storage, l), b.In(journal.Stash, l))
+		}
+	}
+	w.Flush()
+}
+
+// entries counts journal entries.
+func entries(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
+
+// sortedProducts lists the products with a balance in either state, by slug.
+func sortedProducts(states ...*ledger.State) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range states {
+		for product := range s.Balances {
+			if !seen[product] {
+				seen[product] = true
+				out = append(out, product)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// writeStatus renders the state as a table, with the prescriptions as they
+// stand at now and the cycle's doses at the given potency.
+func writeStatus(out io.Writer, state *ledger.State, ps *rx.Prescriptions, potency ledger.Potency, now time.Time) {
 	cycle := state.CurrentCycle()
 	if cycle == nil {
 		fmt.Fprintln(out, "No cycle in progress. Storage is empty.")
@@ -39,13 +231,14 @@ func writeStatus(out io.Writer, state *ledger.State) {
 			fmt.Fprintf(out, "The last cycle ran %s to %s.\n",
 				last.Start.Format(time.DateOnly), last.End.Format(time.DateOnly))
 		}
+		writePrescriptions(out, ps, state.Events, now)
 		fmt.Fprintln(out, "\nRecord your next fill with `wits buy`.")
 		return
 	}
 
 	stats := ledger.Summarise(cycle.Events)
 	fmt.Fprintf(out, "On cycle %d, opened %s (day %d)\n\n",
-		len(state.Cycles), cycle.Start.Format(time.DateOnly), daysSince(cycle.Start))
+		len(state.Cycles), cycle.Start.Format(time.DateOnly), daysSince(cycle.Start, now))
 
 	// Only the products of this fill. Four years of history holds every product
 	// ever dispensed, and listing all of them buries the three that are on the
@@ -78,11 +271,138 @@ func writeStatus(out io.Writer, state *ledger.State) {
 	if stats.PerActiveDay > 0 {
 		fmt.Fprintf(out, "%.2fg per active day, %.2fg median, %.2fg per elapsed day\n",
 			stats.PerActiveDay, stats.MedianPerDay, stats.PerElapsedDay)
-		fmt.Fprintf(out, "About %s left at that rate\n",
-			plural(int(math.Round(stats.DaysLeft(remaining))), "day"))
+		// A break pauses the draw rather than ending it, so the days left
+		// count from when it is over.
+		left := plural(int(math.Round(stats.DaysLeft(remaining))), "day")
+		if b, ok := ledger.BreakAt(state.Events, now); ok {
+			fmt.Fprintf(out, "About %s left at that rate, from the end of the break on %s\n",
+				left, b.End.Format(time.DateOnly))
+		} else {
+			fmt.Fprintf(out, "About %s left at that rate\n", left)
+		}
 	} else {
 		fmt.Fprintln(out, "Nothing ground yet this cycle, so there is no rate to extrapolate from")
 	}
+	writeLocations(out, state)
+	writeDoses(out, potency, cycle.Events, now)
+	writeBatches(out, potency, state, cycle.Events)
+	writePrescriptions(out, ps, state.Events, now)
+
+	// The yield reads across every cycle: a device is emptied every few
+	// days at most, and a month's worth of weighings is too few to say much.
+	if ledger.TotalYield(state.Events).Collected > 0 {
+		fmt.Fprintln(out)
+		writeYield(out, state.Events)
+	}
+}
+
+// writeSessions renders the current cycle by its sessions, the way
+// writeStatus renders it by its grinds.
+func writeSessions(out io.Writer, state *ledger.State, now time.Time) {
+	cycle := state.CurrentCycle()
+	if cycle == nil {
+		fmt.Fprintln(out, "No cycle in progress, so no sessions to read.")
+		return
+	}
+	fmt.Fprintf(out, "On cycle %d, opened %s (day %d)\n\n",
+		len(state.Cycles), cycle.Start.Format(time.DateOnly), daysSince(cycle.Start, now))
+	st := ledger.Sessions(cycle.Events)
+	if st.Sessions == 0 {
+		fmt.Fprintln(out, "No sessions logged this cycle. Record one with `wits sesh`.")
+		return
+	}
+
+	fmt.Fprintf(out, "%s over %s, %.2f a day with a session, %.2f a calendar day\n",
+		plural(st.Sessions, "session"), plural(st.ActiveDays, "day"), st.DailySessions, st.ElapsedSessions)
+	p := st.PerSession
+	fmt.Fprintf(out, "%.2fg per session (median), %.2fg to %.2fg, the middle half %.2fg to %.2fg\n",
+		p.Median, p.Min, p.Max, p.Lower, p.Upper)
+	fmt.Fprintf(out, "%.2fg seshed, %.2fg per active day, %.2fg median, %.2fg per elapsed day\n",
+		st.Grams, st.PerActiveDay, st.MedianPerDay, st.PerElapsedDay)
+	if st.Temperatures > 0 {
+		fmt.Fprintf(out, "%d°C median temperature, over %d of %s\n",
+			st.MedianTemp, st.Temperatures, plural(st.Sessions, "session"))
+	}
+	var stash float64
+	for _, b := range state.Balances {
+		stash += b.Stash
+	}
+	if stash = ledger.Round(stash); stash > 0 {
+		fmt.Fprintf(out, "%.2fg in the stash, about %s at that rate\n",
+			stash, plural(int(math.Round(st.DaysLeft(stash))), "day"))
+	}
+
+	standing := ledger.Standing(cycle.Events)
+	if avb := ledger.SummariseBy(ledger.Collecting, standing); avb.Grams > 0 {
+		fmt.Fprintf(out, "\n%.2fg of AVB collected on %s\n", avb.Grams, plural(avb.ActiveDays, "day"))
+	}
+	if adj := ledger.SummariseBy(ledger.Adjustments, standing); adj.Grams > 0 {
+		fmt.Fprintf(out, "%.2fg moved by adjustments on %s\n", adj.Grams, plural(adj.ActiveDays, "day"))
+	}
+}
+
+// writeDoses renders the milligrams the sessions among events put through a
+// device, per session day and today, and what the devices are assumed to have
+// extracted of them. It writes nothing before the first session.
+func writeDoses(out io.Writer, potency ledger.Potency, events []journal.Event, now time.Time) {
+	d := potency.Dose(events)
+	if d.Sessions == 0 {
+		return
+	}
+	days := potency.PerDay(events)
+	fmt.Fprintf(out, "%s put through a device in %s, %s per session day\n",
+		milligrams(d.THC, d.CBD), plural(d.Sessions, "session"),
+		milligrams(d.THC/float64(len(days)), d.CBD/float64(len(days))))
+	if last := days[len(days)-1]; last.Day.Format(time.DateOnly) == now.Format(time.DateOnly) {
+		fmt.Fprintf(out, "%s today\n", milligrams(last.THC, last.CBD))
+	}
+	if d.Extracted() {
+		fmt.Fprintf(out, "%s extracted at the devices' efficiencies, from %.2fg of %.2fg seshed\n",
+			milligrams(d.ExtractedTHC, d.ExtractedCBD), d.Assumed, d.Grams)
+	}
+	if d.Unknown > 0 {
+		fmt.Fprintf(out, "%.2fg seshed of products with no THC or CBD on record is not counted\n", d.Unknown)
+	}
+}
+
+// writeBatches renders the batches made from AVB that are still in storage,
+// and the portions of them taken among events, in the milligrams their
+// estimates give them. A batch is on no fill's account, so it stands apart
+// from the cycle's products.
+func writeBatches(out io.Writer, potency ledger.Potency, state *ledger.State, events []journal.Event) {
+	made := map[string]float64{}
+	for _, e := range ledger.Standing(state.Events) {
+		if e.Type == journal.Batch {
+			made[e.Product] = ledger.Round(made[e.Product] + e.Grams)
+		}
+	}
+	for _, product := range state.Products() {
+		left := state.Balances[product].Storage
+		if made[product] <= 0 || left <= 0 {
+			continue
+		}
+		strength := "no estimate of its strength"
+		if thc, cbd := potency.THC[product]*10, potency.CBD[product]*10; thc > 0 || cbd > 0 {
+			strength = fmt.Sprintf("about %.2fmg THC and %.2fmg CBD a gram", thc, cbd)
+		}
+		fmt.Fprintf(out, "%.2fg of %.2fg of %s left, %s\n", left, made[product], product, strength)
+	}
+	d := potency.Eaten(events)
+	if d.Sessions == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s in %s of batches, %.2fg\n", milligrams(d.THC, d.CBD), plural(d.Sessions, "portion"), d.Grams)
+	if d.Unknown > 0 {
+		fmt.Fprintf(out, "%.2fg of batches with no estimate of their strength is not counted\n", d.Unknown)
+	}
+}
+
+// milligrams renders a dose of THC and CBD, leaving out a CBD of none.
+func milligrams(thc, cbd float64) string {
+	if math.Round(cbd) == 0 {
+		return fmt.Sprintf("%.0fmg THC", thc)
+	}
+	return fmt.Sprintf("%.0fmg THC and %.0fmg CBD", thc, cbd)
 }
 
 // percent formats a share as a percentage, or a dash when there is nothing to
@@ -94,14 +414,18 @@ func percent(have, of float64) string {
 	return fmt.Sprintf("%.0f%%", have/of*100)
 }
 
-// daysSince returns the number of days from t until today, counting today. It
-// counts calendar days, the way the interface does, so the two never disagree
-// about what day of the cycle it is around midnight.
-func daysSince(t time.Time) int {
-	now := time.Now()
+// daysSince returns the number of days from t until now, counting now's day.
+// It counts calendar days, the way the interface does, so the two never
+// disagree about what day of the cycle it is around midnight.
+func daysSince(t, now time.Time) int {
 	ty, tm, td := t.Date()
 	ny, nm, nd := now.Date()
 	from := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
 	to := time.Date(ny, nm, nd, 0, 0, 0, 0, time.UTC)
 	return int(to.Sub(from).Hours()/24) + 1
 }
+
+func init() {
+	Status.Flags().StringVar(&statusAsOf, "as-of", "", "the status at a past date, time or entry")
+	Status.Flags().BoolVar(&statusSessions, "sessions", false, "read the cycle by its sessions rather than its grinds")
+}
@@ -40,15 +40,33 @@ func init() {
 		commands.Grind,
 		commands.Import,
 		commands.Sesh,
+		commands.Move,
+		commands.Feel,
+		commands.Break,
+		commands.AVB,
+		commands.Batch,
+		commands.Rx,
 		commands.Device,
 		commands.Temps,
 		commands.Status,
+		commands.Budget,
+		commands.Spend,
+		commands.Anomalies,
+		commands.Lots,
 		commands.Log,
+		commands.Show,
 		commands.Reconcile,
 		commands.Restore,
 		commands.Revert,
 		commands.Export,
+		commands.Fsck,
+		commands.Reindex,
+		commands.Sign,
+		commands.Agent,
 	)
+	// Whose entries: every command reads and records as one patient, so the
+	// flag is the root's and every command's under it.
+	commands.PatientFlag(rootCmd)
 }
 
 func main() {
@@ -9,6 +9,7 @@
 //	witsnap screens --repo /tmp/wits-demo
 //	witsnap screens --screen storage --press p,tick,tick
 //	witsnap json --repo /tmp/wits-demo | jq .balances
+//	witsnap json --as-of 2026-08-01 | jq .as_of.recorded_later
 package main
 
 import (
@@ -48,12 +49,13 @@ func fail(msg string) {
 	os.Exit(1)
 }
 
-// open reads the repository the flags point at, or the working directory.
-func open(dir string) (*workspace.Workspace, error) {
+// open reads the repository the flags point at, or the working directory, for
+// the patient they name.
+func open(dir, patient string) (*workspace.Workspace, error) {
 	if dir == "" {
-		return workspace.Here()
+		return workspace.HereFor(patient)
 	}
-	return workspace.Open(dir)
+	return workspace.OpenFor(dir, patient)
 }
 
 // screens renders one screen or all of them, in the order of the tab bar.
@@ -64,11 +66,12 @@ func screens(args []string) error {
 	width := fs.Int("width", 100, "the terminal width to render at")
 	height := fs.Int("height", 40, "the terminal height to render at")
 	press := fs.String("press", "", "keys to press first, comma separated — p,tick,tick replays")
+	patient := fs.String("patient", "", "whose entries, in a household of several patients")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
-	ws, err := open(*repo)
+	ws, err := open(*repo, *patient)
 	if err != nil {
 		return err
 	}
@@ -97,12 +100,39 @@ func screens(args []string) error {
 // client can start from without reading a single screen.
 type state struct {
 	GeneratedAt time.Time                  `json:"generated_at"`
+	Patient     string                     `json:"patient,omitempty"`
 	Events      int                        `json:"events"`
 	Balances    map[string]*ledger.Balance `json:"balances"`
+	Lots        []lot                      `json:"lots"`
 	Cycles      []ledger.Cycle             `json:"cycles"`
 	Current     *currentCycle              `json:"current_cycle,omitempty"`
 	PerDay      map[string]dayTotals       `json:"per_day"`
 	Devices     []deviceUse                `json:"device_usage"`
+	CycleDoses  []cycleDose                `json:"cycle_doses,omitempty"`
+	AsOf        *asOf                      `json:"as_of,omitempty"`
+}
+
+// asOf says which past moment the document was read at. Everything else in it
+// reads what had happened by OccurredAt; Believed is what the ledger showed at
+// RecordedAt, without the entries in RecordedLater. Read at an entry, the
+// ledger is the journal through the entry's Seq.
+type asOf struct {
+	OccurredAt    time.Time                  `json:"occurred_at"`
+	RecordedAt    time.Time                  `json:"recorded_at"`
+	Seq           int                        `json:"seq,omitempty"`
+	Believed      map[string]*ledger.Balance `json:"believed_balances"`
+	RecordedLater []string                   `json:"recorded_later"`
+}
+
+// lot is a ledger.Lot: one fill's grams still standing in a jar, oldest
+// first within each product. Entry is empty for grams a reconciliation found.
+type lot struct {
+	Product   string    `json:"product"`
+	Cycle     int       `json:"cycle"` // Seq + 1, as status numbers them
+	Bought    time.Time `json:"bought"`
+	Purchased float64   `json:"purchased"`
+	Remaining float64   `json:"remaining"`
+	Entry     string    `json:"entry,omitempty"`
 }
 
 // currentCycle is scoped to the fill: held and remaining count the cycle's
@@ -118,11 +148,30 @@ type currentCycle struct {
 	CarriedCycles int       `json:"carried_cycles,omitempty"`
 	PerActiveDay  float64   `json:"per_active_day"`
 	DaysLeft      float64   `json:"days_left"`
+	Dose          *dose     `json:"dose,omitempty"`
 }
 
 type dayTotals struct {
 	Ground float64 `json:"ground,omitempty"`
 	Seshed float64 `json:"seshed,omitempty"`
+	Dose   *dose   `json:"dose,omitempty"`
+}
+
+// dose is a ledger.Dose in milligrams. The extracted figures are there only
+// where a device has an efficiency, and cover extracted_from grams.
+type dose struct {
+	Sessions      int     `json:"sessions"`
+	THC           float64 `json:"thc_mg"`
+	CBD           float64 `json:"cbd_mg"`
+	ExtractedTHC  float64 `json:"extracted_thc_mg,omitempty"`
+	ExtractedCBD  float64 `json:"extracted_cbd_mg,omitempty"`
+	ExtractedFrom float64 `json:"extracted_from,omitempty"`
+	Unknown       float64 `json:"unknown_potency,omitempty"`
+}
+
+type cycleDose struct {
+	Cycle int `json:"cycle"` // Seq + 1, as status numbers them
+	dose
 }
 
 type deviceUse struct {
@@ -136,33 +185,76 @@ type deviceUse struct {
 func dump(args []string) error {
 	fs := flag.NewFlagSet("json", flag.ExitOnError)
 	repo := fs.String("repo", "", "the repository to read; defaults to the working directory")
+	at := fs.String("as-of", "", "read the state at a past date (the end of it), date and time, or entry")
+	patient := fs.String("patient", "", "whose entries, in a household of several patients")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	ws, err := open(*repo)
+	ws, err := open(*repo, *patient)
 	if err != nil {
 		return err
 	}
 
+	// Cycles and lots are attributed in the order entries happened, as
+	// status attributes them, so a backdated entry is where it belongs.
+	st := ws.Chronological()
+	var past *asOf
+	if *at != "" {
+		// The way wits reads --as-of: a date is the end of that day, and an
+		// entry is the moment it was dated and the one it was recorded.
+		m, err := ledger.ParseMoment(*at, ws.Recorder.Find)
+		if err != nil {
+			return err
+		}
+		past = &asOf{OccurredAt: m.Occurred, RecordedAt: m.Recorded, Seq: m.Seq,
+			Believed: st.Believed(m).Balances, RecordedLater: []string{}}
+		recorded := map[string]bool{}
+		for _, e := range m.RecordedBy(st.Events) {
+			recorded[e.Hash] = true
+		}
+		for _, e := range m.Happened(st.Events) {
+			if !recorded[e.Hash] {
+				past.RecordedLater = append(past.RecordedLater, e.Hash)
+			}
+		}
+		st = st.At(m.Occurred, ledger.AsOccurred)
+	}
 	out := state{
 		GeneratedAt: time.Now().Truncate(time.Second),
-		Events:      len(ws.State.Events),
-		Balances:    ws.State.Balances,
-		Cycles:      cyclesOf(ws),
-		Current:     current(ws),
-		PerDay:      perDay(ws),
-		Devices:     deviceUsage(ws),
+		Patient:     ws.Patient,
+		Events:      len(st.Events),
+		Balances:    st.Balances,
+		Lots:        lotsOf(st),
+		Cycles:      cyclesOf(st),
+		Current:     current(st, ws.Potency()),
+		PerDay:      perDay(st, ws.Potency()),
+		Devices:     deviceUsage(st),
+		CycleDoses:  cycleDoses(st, ws.Potency()),
+		AsOf:        past,
 	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	return enc.Encode(out)
 }
 
+// lotsOf lists the lots in storage, an empty list rather than null when the
+// shelf is bare.
+func lotsOf(st *ledger.State) []lot {
+	out := []lot{}
+	for _, l := range st.AllLots() {
+		out = append(out, lot{
+			Product: l.Product, Cycle: l.Cycle + 1, Bought: l.Bought,
+			Purchased: l.Purchased, Remaining: l.Remaining, Entry: l.Entry,
+		})
+	}
+	return out
+}
+
 // cyclesOf strips the event lists out of the cycles: a client that wants the
 // events can read the journal, and the summary should stay a summary.
-func cyclesOf(ws *workspace.Workspace) []ledger.Cycle {
-	out := make([]ledger.Cycle, len(ws.State.Cycles))
-	for i, c := range ws.State.Cycles {
+func cyclesOf(st *ledger.State) []ledger.Cycle {
+	out := make([]ledger.Cycle, len(st.Cycles))
+	for i, c := range st.Cycles {
 		c.Events = nil
 		out[i] = c
 	}
@@ -170,19 +262,19 @@ func cyclesOf(ws *workspace.Workspace) []ledger.Cycle {
 }
 
 // current summarises the cycle in progress, if there is one.
-func current(ws *workspace.Workspace) *currentCycle {
-	c := ws.Cycle()
+func current(st *ledger.State, potency ledger.Potency) *currentCycle {
+	c := st.CurrentCycle()
 	if c == nil {
 		return nil
 	}
 	stats := ledger.Summarise(c.Events)
-	remaining := ws.State.FillOnShelf(c)
+	remaining := st.FillOnShelf(c)
 	held := c.Purchased
 	pct := 0.0
 	if held > 0 {
 		pct = remaining / held
 	}
-	carried, jars, open := ws.State.CarriedOnShelf(c)
+	carried, jars, open := st.CarriedOnShelf(c)
 	return &currentCycle{
 		Start:         c.Start,
 		Held:          held,
@@ -193,13 +285,42 @@ func current(ws *workspace.Workspace) *currentCycle {
 		CarriedCycles: open,
 		PerActiveDay:  stats.PerActiveDay,
 		DaysLeft:      stats.DaysLeft(remaining),
+		Dose:          doseOf(potency.Dose(c.Events)),
+	}
+}
+
+// doseOf converts a dose, or returns nil for one without a session.
+func doseOf(d ledger.Dose) *dose {
+	if d.Sessions == 0 {
+		return nil
+	}
+	return &dose{
+		Sessions:      d.Sessions,
+		THC:           d.THC,
+		CBD:           d.CBD,
+		ExtractedTHC:  d.ExtractedTHC,
+		ExtractedCBD:  d.ExtractedCBD,
+		ExtractedFrom: d.Assumed,
+		Unknown:       d.Unknown,
 	}
 }
 
-// perDay totals the grams ground and seshed per calendar day.
-func perDay(ws *workspace.Workspace) map[string]dayTotals {
+// cycleDoses lists the dose of every cycle with a session in it.
+func cycleDoses(st *ledger.State, potency ledger.Potency) []cycleDose {
+	var out []cycleDose
+	for _, c := range st.Cycles {
+		if d := doseOf(potency.Dose(c.Events)); d != nil {
+			out = append(out, cycleDose{Cycle: c.Seq + 1, dose: *d})
+		}
+	}
+	return out
+}
+
+// perDay totals the grams ground and seshed per calendar day, and the dose
+// of the day's sessions.
+func perDay(st *ledger.State, potency ledger.Potency) map[string]dayTotals {
 	out := map[string]dayTotals{}
-	for _, e := range ws.State.Events {
+	for _, e := range st.Events {
 		day := e.OccurredAt.Format(time.DateOnly)
 		t := out[day]
 		switch e.Type {
@@ -212,16 +333,22 @@ func perDay(ws *workspace.Workspace) map[string]dayTotals {
 		}
 		out[day] = t
 	}
+	for _, d := range potency.PerDay(st.Events) {
+		day := d.Day.Format(time.DateOnly)
+		t := out[day]
+		t.Dose = doseOf(d.Dose)
+		out[day] = t
+	}
 	return out
 }
 
 // deviceUsage totals the sessions per device, the way the sessions screen
 // counts them: sessions without a device are owned rather than dropped.
-func deviceUsage(ws *workspace.Workspace) []deviceUse {
+func deviceUsage(st *ledger.State) []deviceUse {
 	byDev := map[string]*deviceUse{}
 	var order []string
 	temps := map[string][2]int{}
-	for _, e := range ws.State.Events {
+	for _, e := range st.Events {
 		if e.Type != journal.Sesh {
 			continue
 		}
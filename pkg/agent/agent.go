@@ -0,0 +1,243 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SocketEnv names the environment variable that holds the agent's socket.
+const SocketEnv = "WITS_AGENT_SOCK"
+
+// DefaultTimeout is how long a passphrase is held after it was last handed
+// over, unless the agent is started with another.
+const DefaultTimeout = time.Hour
+
+var (
+	// ErrNotHeld is returned when the agent holds no passphrase for a
+	// repository.
+	ErrNotHeld = errors.New("the agent holds no passphrase for this repository")
+	// ErrNoAgent is returned when no agent socket is named in SocketEnv.
+	ErrNoAgent = fmt.Errorf("no agent: %s is not set", SocketEnv)
+)
+
+// held is one repository's passphrase and when the agent lets go of it.
+type held struct {
+	passphrase []byte
+	until      time.Time
+}
+
+// Agent holds passphrases by repository.
+type Agent struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	held map[string]held
+}
+
+// New returns an agent that holds each passphrase for timeout after it was
+// last handed over, or for as long as it runs when timeout is zero.
+func New(timeout time.Duration) *Agent {
+	return &Agent{timeout: timeout, now: time.Now, held: map[string]held{}}
+}
+
+// Listen opens the agent's socket at path, which only its owner can connect
+// to. With no path, the socket is made in a new directory of its own under
+// the system's temporary one, which only its owner can enter, the way
+// ssh-agent makes its own; the path is returned either way. A socket left
+// behind at path by an agent that is no longer running is replaced; one that
+// still answers is not.
+func Listen(path string) (net.Listener, string, error) {
+	if path == "" {
+		dir, err := os.MkdirTemp("", "wits-agent-")
+		if err != nil {
+			return nil, "", err
+		}
+		path = filepath.Join(dir, "agent.sock")
+	} else if _, err := os.Stat(path); err == nil {
+		if c, err := net.Dial("unix", path); err == nil {
+			c.Close()
+			return nil, "", fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, "", err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, "", err
+	}
+	return l, path, nil
+}
+
+// Serve answers requests on the listener until the context is done, then
+// forgets everything it holds and closes the listener.
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	defer a.Forget()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go a.answer(c)
+	}
+}
+
+// answer reads one request from a connection and writes its reply.
+func (a *Agent) answer(c net.Conn) {
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(c).ReadString('\n')
+	if err != nil {
+		return
+	}
+	verb, args := parse(line)
+	reply := "no"
+	switch {
+	case verb == "get" && len(args) == 1:
+		if p, ok := a.Get(string(args[0])); ok {
+			reply = "ok " + base64.StdEncoding.EncodeToString(p)
+		}
+	case verb == "put" && len(args) == 2:
+		a.Put(string(args[0]), args[1])
+		reply = "ok"
+	case verb == "forget" && len(args) == 0:
+		a.Forget()
+		reply = "ok"
+	}
+	fmt.Fprintln(c, reply)
+}
+
+// Get returns the passphrase held for a repository, if it is still held.
+func (a *Agent) Get(root string) ([]byte, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	h, ok := a.held[root]
+	if !ok {
+		return nil, false
+	}
+	if !h.until.IsZero() && !a.now().Before(h.until) {
+		a.drop(root)
+		return nil, false
+	}
+	return slices.Clone(h.passphrase), true
+}
+
+// Put holds a repository's passphrase, for the agent's timeout from now.
+func (a *Agent) Put(root string, passphrase []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.drop(root)
+	h := held{passphrase: slices.Clone(passphrase)}
+	if a.timeout > 0 {
+		h.until = a.now().Add(a.timeout)
+	}
+	a.held[root] = h
+}
+
+// Forget lets go of every passphrase the agent holds.
+func (a *Agent) Forget() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for root := range a.held {
+		a.drop(root)
+	}
+}
+
+// drop lets go of one passphrase, overwriting it first so it does not linger
+// in memory until the collector gets to it.
+func (a *Agent) drop(root string) {
+	if h, ok := a.held[root]; ok {
+		clear(h.passphrase)
+		delete(a.held, root)
+	}
+}
+
+// Ask asks the agent on the socket in SocketEnv for a repository's
+// passphrase.
+func Ask(root string) ([]byte, error) {
+	reply, err := request("get", []byte(root))
+	if err != nil {
+		return nil, err
+	}
+	verb, args := parse(reply)
+	if verb != "ok" || len(args) != 1 {
+		return nil, ErrNotHeld
+	}
+	return args[0], nil
+}
+
+// Hand gives the agent on the socket in SocketEnv a repository's passphrase
+// to hold.
+func Hand(root string, passphrase []byte) error {
+	_, err := request("put", []byte(root), passphrase)
+	return err
+}
+
+// Clear asks the agent on the socket in SocketEnv to forget every passphrase
+// it holds.
+func Clear() error {
+	_, err := request("forget")
+	return err
+}
+
+// request sends one request to the agent and returns its reply.
+func request(verb string, args ...[]byte) (string, error) {
+	path := os.Getenv(SocketEnv)
+	if path == "" {
+		return "", ErrNoAgent
+	}
+	c, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return "", fmt.Errorf("reaching the agent: %w", err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	fields := []string{verb}
+	for _, a := range args {
+		fields = append(fields, base64.StdEncoding.EncodeToString(a))
+	}
+	if _, err := fmt.Fprintln(c, strings.Join(fields, " ")); err != nil {
+		return "", err
+	}
+	return bufio.NewReader(c).ReadString('\n')
+}
+
+// parse splits a line into its verb and its decoded arguments. An argument
+// that is not base64 makes the whole line read as nothing.
+func parse(line string) (string, [][]byte) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	var args [][]byte
+	for _, f := range fields[1:] {
+		b, err := base64.StdEncoding.DecodeString(f)
+		if err != nil {
+			return "", nil
+		}
+		args = append(args, b)
+	}
+	return fields[0], args
+}
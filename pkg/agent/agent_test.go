@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain handles global test setup
+func TestMain(m *testing.M) {
+	// Disable log output during tests
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// running starts an agent on a socket of its own and points SocketEnv at it.
+func running(t *testing.T, timeout time.Duration) *Agent {
+	t.Helper()
+	l, path, err := Listen(filepath.Join(t.TempDir(), "agent.sock"))
+	require.NoError(t, err)
+	a := New(timeout)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- a.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	t.Setenv(SocketEnv, path)
+	return a
+}
+
+func TestAgent(t *testing.T) {
+	t.Run("HoldsAPassphraseByRepository", func(t *testing.T) {
+		a := New(0)
+		a.Put("/home/anna/.wits", []byte("correct horse"))
+
+		p, ok := a.Get("/home/anna/.wits")
+		assert.True(t, ok)
+		assert.Equal(t, "correct horse", string(p))
+		_, ok = a.Get("/home/ben/.wits")
+		assert.False(t, ok, "Should hold nothing for a repository it was never handed")
+	})
+
+	t.Run("LetsGoAfterTheTimeout", func(t *testing.T) {
+		now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+		a := New(time.Hour)
+		a.now = func() time.Time { return now }
+		a.Put("/home/anna/.wits", []byte("correct horse"))
+
+		now = now.Add(59 * time.Minute)
+		_, ok := a.Get("/home/anna/.wits")
+		assert.True(t, ok, "Should hold it within the hour")
+
+		now = now.Add(time.Minute)
+		_, ok = a.Get("/home/anna/.wits")
+		assert.False(t, ok, "Should let go of it once the hour is up")
+	})
+
+	t.Run("Forgets", func(t *testing.T) {
+		a := New(0)
+		a.Put("/home/anna/.wits", []byte("correct horse"))
+
+		a.Forget()
+
+		_, ok := a.Get("/home/anna/.wits")
+		assert.False(t, ok)
+	})
+}
+
+func TestSocket(t *testing.T) {
+	t.Run("AnswersOnItsSocket", func(t *testing.T) {
+		running(t, 0)
+
+		require.NoError(t, Hand("/home/anna/my wits", []byte("correct horse battery")))
+		p, err := Ask("/home/anna/my wits")
+
+		require.NoError(t, err)
+		assert.Equal(t, "correct horse battery", string(p), "Should keep spaces in the path and the passphrase")
+		_, err = Ask("/home/ben/.wits")
+		assert.ErrorIs(t, err, ErrNotHeld)
+	})
+
+	t.Run("ForgetsOnRequest", func(t *testing.T) {
+		running(t, 0)
+		require.NoError(t, Hand("/home/anna/.wits", []byte("correct horse")))
+
+		require.NoError(t, Clear())
+
+		_, err := Ask("/home/anna/.wits")
+		assert.ErrorIs(t, err, ErrNotHeld)
+	})
+
+	t.Run("KeepsItsSocketToItsOwner", func(t *testing.T) {
+		running(t, 0)
+
+		info, err := os.Stat(os.Getenv(SocketEnv))
+
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("RefusesASecondAgent", func(t *testing.T) {
+		running(t, 0)
+
+		_, _, err := Listen(os.Getenv(SocketEnv))
+
+		assert.ErrorContains(t, err, "already listening", "Should not take over a running agent's socket")
+	})
+
+	t.Run("MakesAPrivateDirectory", func(t *testing.T) {
+		l, path, err := Listen("")
+		require.NoError(t, err)
+		defer os.Remove(filepath.Dir(path))
+		defer l.Close()
+
+		info, err := os.Stat(filepath.Dir(path))
+
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm(), "Should let nobody else into the socket's directory")
+	})
+
+	t.Run("NeedsAnAgent", func(t *testing.T) {
+		t.Setenv(SocketEnv, "")
+
+		_, err := Ask("/home/anna/.wits")
+
+		assert.ErrorIs(t, err, ErrNoAgent)
+	})
+}
@@ -0,0 +1,19 @@
+// Package agent holds the passphrases of encrypted repositories for a session,
+// the way ssh-agent holds keys, so that each command does not have to ask.
+//
+// An agent listens on a Unix socket only its owner can connect to, and the
+// socket's path is handed to commands in WITS_AGENT_SOCK. A command
+// asks it for the passphrase of the repository it is opening; one asked on
+// the terminal instead is handed to the agent once it has unlocked the
+// repository, so a mistyped one is never kept. Passphrases are held in memory
+// only, for a set time after they were last handed over, and forgotten on
+// request or when the agent stops.
+//
+// The protocol is a line a connection each way. A request is a verb and its
+// arguments, each in base64 so that a path or a passphrase with spaces in it
+// stays one field:
+//
+//	get <root>               ok <passphrase>, or no
+//	put <root> <passphrase>  ok
+//	forget                   ok
+package agent
@@ -11,6 +11,7 @@ import (
 
 	"github.com/TheDonDope/wits/pkg/catalog"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/rx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -44,13 +45,18 @@ func fill(t *testing.T, events []journal.Event) (*journal.Journal, []journal.Eve
 func sample() []journal.Event {
 	at := time.Date(2026, time.July, 9, 10, 30, 0, 0, berlin)
 	return []journal.Event{
-		{Type: journal.Purchase, Product: "enua-wedding-cake-221", Grams: 20, OccurredAt: at},
+		{Type: journal.Purchase, Product: "enua-wedding-cake-221", Grams: 20, OccurredAt: at,
+			Price: 18990, Currency: "EUR"},
 		{Type: journal.Purchase, Product: "cannamedical-lemon-cookie-281", Grams: 10, OccurredAt: at},
 		{Type: journal.Grind, Product: "enua-wedding-cake-221", Grams: 0.75, OccurredAt: at.AddDate(0, 0, 1)},
 		{Type: journal.Sesh, Product: "enua-wedding-cake-221", Grams: 0.3, OccurredAt: at.AddDate(0, 0, 1),
 			Device: "volcano-hybrid", Temperature: 185, Note: "evening, with a space"},
 		{Type: journal.Grind, Product: "cannamedical-lemon-cookie-281", Grams: 1.25,
 			OccurredAt: at.AddDate(0, 0, 2), RecordedAt: at.AddDate(0, 0, 3)},
+		{Type: journal.AVBCollect, Product: "enua-wedding-cake-221", Grams: 0.2, OccurredAt: at.AddDate(0, 0, 3),
+			Device: "volcano-hybrid", Temperature: 185},
+		{Type: journal.AVBUse, Product: "enua-wedding-cake-221", Grams: 0.15, OccurredAt: at.AddDate(0, 0, 4),
+			Purpose: "edibles"},
 	}
 }
 
@@ -61,7 +67,8 @@ func catalogs(t *testing.T) (*catalog.Catalog, *catalog.Devices) {
 	require.NoError(t, c.Add(catalog.Parse("Enua 22/1 Wedding Cake")))
 	require.NoError(t, c.Add(catalog.Parse("Cannamedical 28/1 Lemon Cookie")))
 	d := &catalog.Devices{}
-	require.NoError(t, d.Add(&catalog.Device{Name: "Volcano Hybrid", Kind: "desktop", MinTemp: 40, MaxTemp: 230, DefaultTemp: 185}))
+	require.NoError(t, d.Add(&catalog.Device{Name: "Volcano Hybrid", Kind: "desktop", MinTemp: 40, MaxTemp: 230, DefaultTemp: 185,
+		Efficiency: 60}))
 	return c, d
 }
 
@@ -120,6 +127,7 @@ func TestRoundTrip(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 230, d.MaxTemp, "Should keep the temperature range")
 		assert.Equal(t, "desktop", d.Kind, "Should keep the kind")
+		assert.Equal(t, 60, d.Efficiency, "Should keep the extraction efficiency")
 	})
 
 	t.Run("KeepsZoneOffsets", func(t *testing.T) {
@@ -170,6 +178,180 @@ func TestRoundTrip(t *testing.T) {
 		assert.Equal(t, stored[0].Hash, restored[0].Hash, "Should hash identically")
 	})
 
+	t.Run("CarriesFeelsAndBreaks", func(t *testing.T) {
+		at := time.Date(2026, time.July, 9, 20, 0, 0, 0, berlin)
+		j, stored := fill(t, []journal.Event{
+			{Type: journal.Purchase, Product: "wedding-cake", Grams: 10, OccurredAt: at},
+			{Type: journal.Sesh, Product: "wedding-cake", Grams: 0.3, OccurredAt: at},
+		})
+		felt, err := j.Append(journal.Event{Type: journal.Feel, Product: "wedding-cake", OccurredAt: at.Add(time.Hour),
+			Scores: journal.Scores{"pain": 3, "sleep": 7}, Session: stored[1].Hash})
+		require.NoError(t, err)
+		alone, err := j.Append(journal.Event{Type: journal.Feel, OccurredAt: at.Add(2 * time.Hour),
+			Scores: journal.Scores{"anxiety": 0}})
+		require.NoError(t, err)
+		undone, err := j.Append(journal.Event{Type: journal.Adjust, OccurredAt: at.Add(3 * time.Hour),
+			Reverts: alone.Hash, Note: "reverts it"})
+		require.NoError(t, err)
+		pause, err := j.Append(journal.Event{Type: journal.Break, OccurredAt: at.Add(4 * time.Hour), Days: 14})
+		require.NoError(t, err)
+		stored = append(stored, felt, alone, undone, pause)
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Events: stored}))
+		assert.Contains(t, buf.String(), "sc=pain:3,sleep:7", "Should write the scores the way they read")
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		_, restored := fill(t, got.Events)
+		require.Len(t, restored, len(stored))
+		for i := range stored {
+			assert.Equal(t, stored[i].Hash, restored[i].Hash, "event %d should hash identically", i+1)
+		}
+		assert.Empty(t, restored[3].Product, "A feel of no product should not come back as the first one")
+		assert.Equal(t, 14, restored[5].Days, "Should carry how long a break lasts")
+	})
+
+	t.Run("CarriesThePatients", func(t *testing.T) {
+		at := time.Date(2026, time.July, 9, 20, 0, 0, 0, berlin)
+		_, stored := fill(t, []journal.Event{
+			{Type: journal.Purchase, Product: "wedding-cake", Grams: 10, OccurredAt: at, Patient: "anna"},
+			{Type: journal.Purchase, Product: "wedding-cake", Grams: 5, OccurredAt: at, Patient: "ben"},
+			{Type: journal.Grind, Product: "wedding-cake", Grams: 1, OccurredAt: at.Add(time.Hour)},
+		})
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Events: stored}))
+		assert.Contains(t, buf.String(), "pt=anna", "Should write whose entry it is")
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		_, restored := fill(t, got.Events)
+		require.Len(t, restored, len(stored))
+		for i := range stored {
+			assert.Equal(t, stored[i].Hash, restored[i].Hash, "event %d should hash identically", i+1)
+			assert.Equal(t, stored[i].Patient, restored[i].Patient, "event %d should keep its patient", i+1)
+		}
+	})
+
+	t.Run("CarriesThePrescriptions", func(t *testing.T) {
+		products, devices := catalogs(t)
+		_, stored := fill(t, sample())
+		issued := time.Date(2026, time.July, 8, 0, 0, 0, 0, berlin)
+		prescriptions := &rx.Prescriptions{Prescriptions: []*rx.Prescription{
+			{ID: 1, Prescriber: "Dr. Weber", Issued: issued, ValidUntil: issued.AddDate(0, 0, 28), MaxDaily: 1.5,
+				Items: []rx.Item{{Product: "enua-wedding-cake-221", Grams: 20}, {Product: "not-bought-yet", Grams: 10}},
+				Note:  "the usual, twice", Patient: "anna"},
+			{ID: 2, Issued: issued.UTC(), Items: []rx.Item{{Product: "cannamedical-lemon-cookie-281", Grams: 5}}},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Products: products, Devices: devices, Events: stored,
+			Prescriptions: prescriptions}))
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		require.Len(t, got.Prescriptions.Prescriptions, 2, "Should carry every prescription")
+		for i, want := range prescriptions.Prescriptions {
+			have := got.Prescriptions.Prescriptions[i]
+			assert.Equal(t, want.ID, have.ID, "Should keep the number")
+			assert.True(t, want.Issued.Equal(have.Issued), "Should keep the day it was issued")
+			assert.Equal(t, want.Issued.Format(time.RFC3339), have.Issued.Format(time.RFC3339), "in its own zone")
+			assert.True(t, want.ValidUntil.Equal(have.ValidUntil), "Should keep the day it lapses, or none")
+			assert.Equal(t, want.MaxDaily, have.MaxDaily, "Should keep the daily maximum")
+			assert.Equal(t, want.Items, have.Items, "Should keep the products and their grams")
+			assert.Equal(t, want.Prescriber, have.Prescriber, "Should keep the prescriber")
+			assert.Equal(t, want.Note, have.Note, "Should keep the note")
+			assert.Equal(t, want.Patient, have.Patient, "Should keep whose it is")
+		}
+	})
+
+	t.Run("CarriesTheAccountsOfAnAdjustment", func(t *testing.T) {
+		at := time.Date(2026, time.July, 9, 20, 0, 0, 0, berlin)
+		_, stored := fill(t, []journal.Event{
+			{Type: journal.Purchase, Product: "wedding-cake", Grams: 10, OccurredAt: at},
+			{Type: journal.Grind, Product: "wedding-cake", Grams: 1, OccurredAt: at},
+		})
+		// What a reconcile and a revert record: grams weighed out of storage,
+		// grams found in it, and a grind put back.
+		_, stored = fill(t, append(stored,
+			journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.4, OccurredAt: at,
+				From: journal.Storage, To: journal.External},
+			journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.1, OccurredAt: at,
+				From: journal.External, To: journal.Storage},
+			journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 1, OccurredAt: at,
+				From: journal.Stash, To: journal.Storage, Reverts: stored[1].Hash},
+		))
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Events: stored}))
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		_, restored := fill(t, got.Events)
+		require.Len(t, restored, len(stored))
+		for i := range stored {
+			assert.Equal(t, stored[i].Hash, restored[i].Hash, "event %d should hash identically", i+1)
+			assert.Equal(t, stored[i].From, restored[i].From, "event %d should keep the account it drew on", i+1)
+			assert.Equal(t, stored[i].To, restored[i].To, "event %d should keep the account it filled", i+1)
+		}
+	})
+
+	t.Run("CarriesTheLocations", func(t *testing.T) {
+		at := time.Date(2026, time.July, 9, 20, 0, 0, 0, berlin)
+		_, stored := fill(t, []journal.Event{
+			{Type: journal.Purchase, Product: "wedding-cake", Grams: 10, OccurredAt: at},
+			{Type: journal.Move, Product: "wedding-cake", Grams: 2, OccurredAt: at, ToLocation: "travel"},
+			{Type: journal.Grind, Product: "wedding-cake", Grams: 1, OccurredAt: at,
+				FromLocation: "travel", ToLocation: "travel"},
+			{Type: journal.Move, Product: "wedding-cake", Grams: 0.5, OccurredAt: at,
+				From: journal.Stash, To: journal.Stash, FromLocation: "travel"},
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.25, OccurredAt: at,
+				From: journal.Storage, To: journal.External, FromLocation: "travel"},
+		})
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Events: stored}))
+		assert.Contains(t, buf.String(), "tl=travel", "Should write where the grams went")
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		_, restored := fill(t, got.Events)
+		require.Len(t, restored, len(stored))
+		for i := range stored {
+			assert.Equal(t, stored[i].Hash, restored[i].Hash, "event %d should hash identically", i+1)
+		}
+		assert.Equal(t, journal.Stash, restored[3].From, "Should keep the account a move stayed in")
+		assert.Equal(t, journal.Storage, restored[4].From, "and the accounts an adjustment moved between")
+	})
+
+	t.Run("CarriesTheBatches", func(t *testing.T) {
+		at := time.Date(2026, time.July, 9, 20, 0, 0, 0, berlin)
+		products := &catalog.Catalog{}
+		require.NoError(t, products.Add(&catalog.Product{Slug: "wedding-cake", Name: "Enua 22/1 Wedding Cake"}))
+		require.NoError(t, products.Add(&catalog.Product{Slug: "butter", Name: "Butter 2026-07-09", Made: "butter", THC: 0.2}))
+		_, stored := fill(t, []journal.Event{
+			{Type: journal.AVBUse, Product: "wedding-cake", Grams: 7, OccurredAt: at, Purpose: "butter", Into: "butter"},
+			{Type: journal.Batch, Product: "butter", Grams: 250, OccurredAt: at},
+			{Type: journal.Portion, Product: "butter", Grams: 10, OccurredAt: at},
+		})
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, Contents{Products: products, Events: stored}))
+		got, err := Read(&buf)
+		require.NoError(t, err)
+
+		_, restored := fill(t, got.Events)
+		require.Len(t, restored, len(stored))
+		for i := range stored {
+			assert.Equal(t, stored[i].Hash, restored[i].Hash, "event %d should hash identically", i+1)
+		}
+		assert.Equal(t, "butter", restored[0].Into, "Should keep the batch the AVB went into")
+		butter, err := got.Products.Find("butter")
+		require.NoError(t, err)
+		assert.Equal(t, "butter", butter.Made, "and what the batch was made as")
+	})
+
 	t.Run("EmptyRepository", func(t *testing.T) {
 		var buf bytes.Buffer
 		require.NoError(t, Write(&buf, Contents{Products: &catalog.Catalog{}, Devices: &catalog.Devices{}}))
@@ -2,9 +2,9 @@
 //
 // A bundle is to a Wits repository what `git bundle` is to a git repository: a
 // portable, self-contained copy of the history that can be carried to another
-// machine and unpacked there. It holds the catalogs and every event, and it
-// round-trips exactly — restoring a bundle produces a journal whose hashes
-// match the one it was written from.
+// machine and unpacked there. It holds the catalogs, the prescriptions and
+// every event, and it round-trips exactly — restoring a bundle produces a
+// journal whose hashes match the one it was written from.
 //
 // The format is deliberately plain text. The journal is a medical record that
 // may outlive this program, so an archive of it should be legible with nothing
@@ -20,7 +20,8 @@ const separator = "--"
 
 // typeCodes abbreviate the event types to a single character. The codes are
 // part of the format, so they may be added to but never reused for something
-// else.
+// else. The same goes for the keys of event attributes, such as pr= for a
+// purchase's price, which a newer bundle may carry and an older reader reject.
 var typeCodes = map[journal.Type]byte{
 	journal.Purchase:   'b',
 	journal.Grind:      'g',
@@ -28,6 +29,11 @@ var typeCodes = map[journal.Type]byte{
 	journal.AVBCollect: 'c',
 	journal.AVBUse:     'u',
 	journal.Adjust:     'a',
+	journal.Feel:       'f',
+	journal.Break:      'k',
+	journal.Move:       'm',
+	journal.Batch:      'e',
+	journal.Portion:    'o',
 }
 
 // typeOf reverses typeCodes.
@@ -75,6 +81,33 @@ func offsetOf(t time.Time) int {
 	return seconds / 60
 }
 
+// scores encodes a feel's scores as name:score pairs, "pain:6,sleep:3".
+func scores(s journal.Scores) string {
+	parts := make([]string, 0, len(s))
+	for _, name := range s.Names() {
+		parts = append(parts, name+":"+num(int64(s[name])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseScores reverses scores. The names are checked when the restored entry
+// is validated, not here.
+func parseScores(v string) (journal.Scores, error) {
+	s := journal.Scores{}
+	for _, pair := range strings.Split(v, ",") {
+		name, score, ok := strings.Cut(pair, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not a name:score pair", pair)
+		}
+		n, err := parseNum(score)
+		if err != nil {
+			return nil, err
+		}
+		s[name] = int(n)
+	}
+	return s, nil
+}
+
 // escape makes a value safe to put in a space-separated field.
 func escape(s string) string {
 	r := strings.NewReplacer("\\", `\\`, " ", `\s`, "\n", `\n`)
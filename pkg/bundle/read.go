@@ -11,6 +11,7 @@ import (
 	cannabis "github.com/TheDonDope/wits/pkg/cannabis"
 	"github.com/TheDonDope/wits/pkg/catalog"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/rx"
 )
 
 // can converts a stored integer back to a genetic type.
@@ -42,7 +43,7 @@ func Read(r io.Reader) (*Contents, error) {
 		return nil, err
 	}
 
-	c := &Contents{Products: &catalog.Catalog{}, Devices: &catalog.Devices{}}
+	c := &Contents{Products: &catalog.Catalog{}, Devices: &catalog.Devices{}, Prescriptions: &rx.Prescriptions{}}
 	var products, devices, notes []string
 
 	for {
@@ -77,6 +78,12 @@ func Read(r io.Reader) (*Contents, error) {
 				return nil, errorf(line, "note entry needs a value")
 			}
 			notes = append(notes, unescape(parts[1]))
+		case 'R':
+			p, err := readPrescription(text, line, products)
+			if err != nil {
+				return nil, err
+			}
+			c.Prescriptions.Prescriptions = append(c.Prescriptions.Prescriptions, p)
 		default:
 			return nil, errorf(line, "unknown header entry %q", text)
 		}
@@ -145,11 +152,13 @@ func readEvent(text string, line int, products, devices, notes []string, in stat
 	}
 	e.Type = typ
 
-	pi, err := parseNum(parts[0][1:])
-	if err != nil || pi < 0 || int(pi) >= len(products) {
-		return e, out, errorf(line, "event refers to product %q, which the header does not define", parts[0][1:])
+	if ref := parts[0][1:]; ref != "" {
+		pi, err := parseNum(ref)
+		if err != nil || pi < 0 || int(pi) >= len(products) {
+			return e, out, errorf(line, "event refers to product %q, which the header does not define", ref)
+		}
+		e.Product = products[pi]
 	}
-	e.Product = products[pi]
 
 	delta, err := parseNum(parts[1])
 	if err != nil {
@@ -205,6 +214,46 @@ func readEvent(text string, line int, products, devices, notes []string, in stat
 				return e, out, errorf(line, "event refers to note %q, which the header does not define", value)
 			}
 			e.Note = notes[ni]
+		case "p":
+			e.Purpose = unescape(value)
+		case "pr":
+			price, err := parseNum(value)
+			if err != nil {
+				return e, out, errorf(line, "unreadable price %q", value)
+			}
+			e.Price = price
+		case "cu":
+			e.Currency = unescape(value)
+		case "sc":
+			sc, err := parseScores(value)
+			if err != nil {
+				return e, out, errorf(line, "unreadable scores %q", value)
+			}
+			e.Scores = sc
+		case "se":
+			e.Session = value
+		case "dy":
+			days, err := parseNum(value)
+			if err != nil {
+				return e, out, errorf(line, "unreadable days %q", value)
+			}
+			e.Days = int(days)
+		case "pt":
+			e.Patient = value
+		case "fa":
+			e.From = journal.Account(value)
+		case "ta":
+			e.To = journal.Account(value)
+		case "fl":
+			e.FromLocation = value
+		case "tl":
+			e.ToLocation = value
+		case "in":
+			pi, err := parseNum(value)
+			if err != nil || pi < 0 || int(pi) >= len(products) {
+				return e, out, errorf(line, "event goes into product %q, which the header does not define", value)
+			}
+			e.Into = products[pi]
 		case "v":
 			e.Reverts = value
 		default:
@@ -214,7 +263,9 @@ func readEvent(text string, line int, products, devices, notes []string, in stat
 
 	e.OccurredAt = time.Unix(out.occurred, 0).In(zone(out.offset))
 	e.RecordedAt = time.Unix(out.recorded, 0).In(zone(out.recordedOffset))
-	e.From, e.To, _ = journal.Flow(e.Type)
+	if e.From == "" && e.To == "" {
+		e.From, e.To, _ = journal.Flow(e.Type)
+	}
 	return e, out, nil
 }
 
@@ -248,6 +299,8 @@ func readProduct(text string, line int) (string, *catalog.Product, error) {
 			p.Genetic = can(g)
 		case "r":
 			p.Radiated = value == "1"
+		case "mk":
+			p.Made = unescape(value)
 		case "a":
 			var at int64
 			if at, err = parseNum(value); err == nil {
@@ -285,6 +338,8 @@ func readDevice(text string, line int) (string, *catalog.Device, error) {
 			d.MaxTemp, err = strconv.Atoi(value)
 		case "df":
 			d.DefaultTemp, err = strconv.Atoi(value)
+		case "ef":
+			d.Efficiency, err = strconv.Atoi(value)
 		default:
 			return "", nil, errorf(line, "unknown device attribute %q", key)
 		}
@@ -294,3 +349,63 @@ func readDevice(text string, line int) (string, *catalog.Device, error) {
 	}
 	return slug, d, nil
 }
+
+// readPrescription decodes a prescription header entry. Its products must
+// already have been defined, which they are in a bundle this package wrote.
+func readPrescription(text string, line int, products []string) (*rx.Prescription, error) {
+	parts := strings.Fields(text)
+	id, err := parseNum(parts[0][1:])
+	if err != nil {
+		return nil, errorf(line, "unreadable prescription number %q", parts[0][1:])
+	}
+	p := &rx.Prescription{ID: int(id)}
+	var issued, until int64
+	var offset, untilOffset int
+	for _, attr := range parts[1:] {
+		key, value := field(attr)
+		var n int64
+		switch key {
+		case "i":
+			issued, err = parseNum(value)
+		case "z":
+			n, err = parseNum(value)
+			offset = int(n)
+		case "u":
+			until, err = parseNum(value)
+		case "zu":
+			n, err = parseNum(value)
+			untilOffset = int(n)
+		case "md":
+			n, err = parseNum(value)
+			p.MaxDaily = grams(n)
+		case "it":
+			for _, item := range strings.Split(value, ",") {
+				ref, amount, ok := strings.Cut(item, ":")
+				pi, perr := parseNum(ref)
+				if !ok || perr != nil || pi < 0 || int(pi) >= len(products) {
+					return nil, errorf(line, "prescription refers to product %q, which the header does not define", ref)
+				}
+				if n, err = parseNum(amount); err != nil {
+					break
+				}
+				p.Items = append(p.Items, rx.Item{Product: products[pi], Grams: grams(n)})
+			}
+		case "pb":
+			p.Prescriber = unescape(value)
+		case "n":
+			p.Note = unescape(value)
+		case "pt":
+			p.Patient = value
+		default:
+			return nil, errorf(line, "unknown prescription attribute %q", key)
+		}
+		if err != nil {
+			return nil, errorf(line, "unreadable prescription attribute %q: %v", key, err)
+		}
+	}
+	p.Issued = time.Unix(issued, 0).In(zone(offset))
+	if until != 0 {
+		p.ValidUntil = time.Unix(until, 0).In(zone(untilOffset))
+	}
+	return p, nil
+}
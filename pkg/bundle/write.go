@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/TheDonDope/wits/pkg/catalog"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/rx"
 )
 
 // Contents is everything a bundle carries.
@@ -15,13 +17,17 @@ type Contents struct {
 	Products *catalog.Catalog
 	Devices  *catalog.Devices
 	Events   []journal.Event
+	// Prescriptions are carried too: a bundle kept as a backup would
+	// otherwise lose every one, and with them what the fills were held to.
+	Prescriptions *rx.Prescriptions
 }
 
 // Write encodes the contents as a bundle.
 //
 // Only what cannot be derived is written down. Sequence numbers follow from
-// position, the account pair follows from the event type, and the hash chain is
-// recomputed on restore, so none of them appear in the file. Timestamps and
+// position, the account pair follows from the event type, save for the
+// adjustments and moves that name their own, and the hash chain is recomputed
+// on restore, so none of them appear in the file. Timestamps and
 // amounts are stored as deltas against the previous event, which is what makes
 // a run of daily entries cost a handful of bytes each.
 func Write(w io.Writer, c Contents) error {
@@ -29,7 +35,7 @@ func Write(w io.Writer, c Contents) error {
 
 	fmt.Fprintf(out, "%s %d\n", Magic, Version)
 
-	products := productIndex(c.Events, c.Products)
+	products := productIndex(c.Events, c.Products, c.Prescriptions)
 	for i, p := range products.slugs {
 		fmt.Fprintf(out, "P%s %s", num(int64(i)), escape(p))
 		if meta := products.byslug[p]; meta != nil {
@@ -49,6 +55,11 @@ func Write(w io.Writer, c Contents) error {
 	for i, n := range notes.notes {
 		fmt.Fprintf(out, "N%s %s\n", num(int64(i)), escape(n))
 	}
+	if c.Prescriptions != nil {
+		for _, p := range c.Prescriptions.Prescriptions {
+			writePrescription(out, p, products)
+		}
+	}
 	fmt.Fprintln(out, separator)
 
 	var prevOccurred, prevRecorded int64
@@ -62,9 +73,15 @@ func Write(w io.Writer, c Contents) error {
 		occurred := e.OccurredAt.Unix()
 		recorded := e.RecordedAt.Unix()
 
+		// An entry without a product, a feel scored on its own, has no index
+		// after its code. Index 0 would restore it as the first product.
+		product := ""
+		if e.Product != "" {
+			product = num(int64(products.index[e.Product]))
+		}
 		fmt.Fprintf(out, "%c%s %s %s",
 			code,
-			num(int64(products.index[e.Product])),
+			product,
 			num(occurred-prevOccurred),
 			num(centigrams(e.Grams)),
 		)
@@ -95,6 +112,50 @@ func Write(w io.Writer, c Contents) error {
 		if e.Note != "" {
 			fmt.Fprintf(out, " n=%s", num(int64(notes.index[e.Note])))
 		}
+		// A purpose is one of a handful of words, so it is written out where
+		// it stands rather than indexed in the header the way notes are.
+		if e.Purpose != "" {
+			fmt.Fprintf(out, " p=%s", escape(e.Purpose))
+		}
+		// A price is in the currency's hundredths, an integer for the same
+		// reason amounts are, and the currency is written beside it.
+		if e.Price != 0 {
+			fmt.Fprintf(out, " pr=%s", num(e.Price))
+		}
+		if e.Currency != "" {
+			fmt.Fprintf(out, " cu=%s", escape(e.Currency))
+		}
+		// Scores are written name:score, in the order of the symptoms, so the
+		// line reads like the command that recorded them.
+		if len(e.Scores) > 0 {
+			fmt.Fprintf(out, " sc=%s", scores(e.Scores))
+		}
+		if e.Session != "" {
+			fmt.Fprintf(out, " se=%s", e.Session)
+		}
+		if e.Days != 0 {
+			fmt.Fprintf(out, " dy=%s", num(int64(e.Days)))
+		}
+		// A patient's name is a slug, and written as it stands.
+		if e.Patient != "" {
+			fmt.Fprintf(out, " pt=%s", e.Patient)
+		}
+		// The accounts follow from the type, except for an adjustment and a
+		// move, which say which they moved between; the locations are slugs.
+		if from, to, _ := journal.Flow(e.Type); e.From != from || e.To != to {
+			fmt.Fprintf(out, " fa=%s ta=%s", e.From, e.To)
+		}
+		if e.FromLocation != "" {
+			fmt.Fprintf(out, " fl=%s", e.FromLocation)
+		}
+		if e.ToLocation != "" {
+			fmt.Fprintf(out, " tl=%s", e.ToLocation)
+		}
+		// The batch an AVB use went into is a product like any other, and
+		// referred to by its index the same way.
+		if e.Into != "" {
+			fmt.Fprintf(out, " in=%s", num(int64(products.index[e.Into])))
+		}
 		if e.Reverts != "" {
 			fmt.Fprintf(out, " v=%s", e.Reverts)
 		}
@@ -133,6 +194,9 @@ func writeProduct(out io.Writer, p *catalog.Product) {
 	if p.Radiated {
 		fmt.Fprint(out, " r=1")
 	}
+	if p.Made != "" {
+		fmt.Fprintf(out, " mk=%s", escape(p.Made))
+	}
 	if !p.AddedAt.IsZero() {
 		fmt.Fprintf(out, " a=%s", num(p.AddedAt.Unix()))
 	}
@@ -155,6 +219,37 @@ func writeDevice(out io.Writer, d *catalog.Device) {
 	if d.DefaultTemp != 0 {
 		fmt.Fprintf(out, " df=%d", d.DefaultTemp)
 	}
+	if d.Efficiency != 0 {
+		fmt.Fprintf(out, " ef=%d", d.Efficiency)
+	}
+}
+
+// writePrescription writes a prescription as a header entry under its own
+// number. Its products are referred to by index, the way events refer to
+// them, and its grams are centigrams.
+func writePrescription(out io.Writer, p *rx.Prescription, products index) {
+	fmt.Fprintf(out, "R%s i=%s z=%s", num(int64(p.ID)), num(p.Issued.Unix()), num(int64(offsetOf(p.Issued))))
+	if !p.ValidUntil.IsZero() {
+		fmt.Fprintf(out, " u=%s zu=%s", num(p.ValidUntil.Unix()), num(int64(offsetOf(p.ValidUntil))))
+	}
+	if p.MaxDaily != 0 {
+		fmt.Fprintf(out, " md=%s", num(centigrams(p.MaxDaily)))
+	}
+	items := make([]string, 0, len(p.Items))
+	for _, it := range p.Items {
+		items = append(items, num(int64(products.index[it.Product]))+":"+num(centigrams(it.Grams)))
+	}
+	fmt.Fprintf(out, " it=%s", strings.Join(items, ","))
+	if p.Prescriber != "" {
+		fmt.Fprintf(out, " pb=%s", escape(p.Prescriber))
+	}
+	if p.Note != "" {
+		fmt.Fprintf(out, " n=%s", escape(p.Note))
+	}
+	if p.Patient != "" {
+		fmt.Fprintf(out, " pt=%s", p.Patient)
+	}
+	fmt.Fprint(out, "\n")
 }
 
 // trimFloat renders a percentage without trailing zeroes.
@@ -178,12 +273,24 @@ type deviceIdx struct {
 
 // productIndex orders products by how often they appear, so the ones referred
 // to most get the shortest identifiers.
-func productIndex(events []journal.Event, c *catalog.Catalog) index {
+func productIndex(events []journal.Event, c *catalog.Catalog, ps *rx.Prescriptions) index {
 	counts := map[string]int{}
 	for _, e := range events {
 		if e.Product != "" {
 			counts[e.Product]++
 		}
+		if e.Into != "" {
+			counts[e.Into]++
+		}
+	}
+	if ps != nil {
+		for _, p := range ps.Prescriptions {
+			for _, it := range p.Items {
+				if _, ok := counts[it.Product]; !ok {
+					counts[it.Product] = 0
+				}
+			}
+		}
 	}
 	if c != nil {
 		for _, p := range c.Products {
@@ -35,6 +35,7 @@ type Product struct {
 	THC          float64         `yaml:"thc,omitempty"`
 	CBD          float64         `yaml:"cbd,omitempty"`
 	Terpenes     []string        `yaml:"terpenes,omitempty"`
+	Made         string          `yaml:"made,omitempty"` // what a batch was made as from AVB, empty if dispensed
 	AddedAt      time.Time       `yaml:"added_at"`
 }
 
@@ -49,14 +50,20 @@ type Catalog struct {
 // Load reads the catalog from path. A missing file is an empty catalog, but an
 // unreadable or malformed one is an error rather than a silent reset.
 func Load(path string) (*Catalog, error) {
-	c := &Catalog{}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return c, nil
+			return &Catalog{}, nil
 		}
 		return nil, err
 	}
+	return Unmarshal(data)
+}
+
+// Unmarshal reads a catalog from the contents of products.yml, for a caller
+// that reads the file itself — one whose repository is sealed, say.
+func Unmarshal(data []byte) (*Catalog, error) {
+	c := &Catalog{}
 	if err := yaml.Unmarshal(data, c); err != nil {
 		return nil, fmt.Errorf("reading the product catalog: %w", err)
 	}
@@ -65,14 +72,19 @@ func Load(path string) (*Catalog, error) {
 
 // Save writes the catalog to path.
 func (c *Catalog) Save(path string) error {
-	sort.Slice(c.Products, func(i, j int) bool { return c.Products[i].Slug < c.Products[j].Slug })
-	data, err := yaml.Marshal(c)
+	data, err := c.Marshal()
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(path, data, 0600)
 }
 
+// Marshal returns the catalog as products.yml holds it, sorted by slug.
+func (c *Catalog) Marshal() ([]byte, error) {
+	sort.Slice(c.Products, func(i, j int) bool { return c.Products[i].Slug < c.Products[j].Slug })
+	return yaml.Marshal(c)
+}
+
 // Add puts a product in the catalog, refusing to shadow an existing slug.
 func (c *Catalog) Add(p *Product) error {
 	if p.Slug == "" {
@@ -25,6 +25,11 @@ var (
 )
 
 // Device is a vaporizer, with the temperature range it can be set to.
+//
+// Efficiency is the percentage of the cannabinoids in a session's material the
+// device is assumed to extract. It is an assumption rather than a measurement,
+// which is why it is optional: without one, doses are counted in what was put
+// through the device and nothing more is claimed.
 type Device struct {
 	Slug        string `yaml:"slug"`
 	Name        string `yaml:"name"`
@@ -32,6 +37,7 @@ type Device struct {
 	MinTemp     int    `yaml:"min_temp,omitempty"`
 	MaxTemp     int    `yaml:"max_temp,omitempty"`
 	DefaultTemp int    `yaml:"default_temp,omitempty"`
+	Efficiency  int    `yaml:"efficiency,omitempty"`
 }
 
 // String returns the display name of the device.
@@ -45,14 +51,19 @@ type Devices struct {
 // LoadDevices reads the device catalog from path. A missing file is an empty
 // catalog, but an unreadable or malformed one is an error.
 func LoadDevices(path string) (*Devices, error) {
-	d := &Devices{}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return d, nil
+			return &Devices{}, nil
 		}
 		return nil, err
 	}
+	return UnmarshalDevices(data)
+}
+
+// UnmarshalDevices reads a device catalog from the contents of devices.yml.
+func UnmarshalDevices(data []byte) (*Devices, error) {
+	d := &Devices{}
 	if err := yaml.Unmarshal(data, d); err != nil {
 		return nil, fmt.Errorf("reading the device catalog: %w", err)
 	}
@@ -61,14 +72,19 @@ func LoadDevices(path string) (*Devices, error) {
 
 // Save writes the device catalog to path.
 func (d *Devices) Save(path string) error {
-	sort.Slice(d.Devices, func(i, j int) bool { return d.Devices[i].Slug < d.Devices[j].Slug })
-	data, err := yaml.Marshal(d)
+	data, err := d.Marshal()
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(path, data, 0600)
 }
 
+// Marshal returns the device catalog as devices.yml holds it, sorted by slug.
+func (d *Devices) Marshal() ([]byte, error) {
+	sort.Slice(d.Devices, func(i, j int) bool { return d.Devices[i].Slug < d.Devices[j].Slug })
+	return yaml.Marshal(d)
+}
+
 // Add puts a device in the catalog, refusing to shadow an existing slug.
 func (d *Devices) Add(device *Device) error {
 	if device.Slug == "" {
@@ -0,0 +1,13 @@
+// Package fsck checks a repository for damage the hash chain cannot see.
+//
+// The chain proves that nothing was edited after it was written, not that
+// what was written makes sense. A journal can verify and still overdraw a jar,
+// name a product the catalog has never heard of, or correct an entry twice.
+// Those are the faults worth finding before a backup copies them somewhere
+// else, and they are found here, the way `git fsck` looks past the object
+// hashes at whether the objects hang together.
+//
+// The one fault that is not an event is a last line cut short by a crash.
+// The journal reads past it and refuses to append onto it; CheckJournal
+// reports it, and the journal's Quarantine is the repair.
+package fsck
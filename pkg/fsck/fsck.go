@@ -0,0 +1,265 @@
+package fsck
+
+import (
+	"fmt"
+
+	"github.com/TheDonDope/wits/pkg/catalog"
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/signing"
+)
+
+// Kind names what is wrong, in a form a script can switch on.
+type Kind string
+
+const (
+	// BrokenChain is a hash chain that does not verify.
+	BrokenChain Kind = "broken-chain"
+	// NegativeBalance is an entry that drew an account below zero.
+	NegativeBalance Kind = "negative-balance"
+	// UnknownProduct is an entry naming a product products.yml does not hold.
+	UnknownProduct Kind = "unknown-product"
+	// UnknownDevice is an entry naming a device devices.yml does not hold.
+	UnknownDevice Kind = "unknown-device"
+	// MissingRevert is a correction of an entry that is not in the journal.
+	MissingRevert Kind = "missing-revert"
+	// RevertOfCorrection is a correction of something that was itself a
+	// correction, which the recorder refuses.
+	RevertOfCorrection Kind = "revert-of-correction"
+	// DoubleRevert is the second correction of one entry.
+	DoubleRevert Kind = "double-revert"
+	// MissingSession is a feel following a session that is not in the
+	// journal, or following an entry that is not a session.
+	MissingSession Kind = "missing-session"
+	// DuplicateSeq is a sequence number used by more than one entry.
+	DuplicateSeq Kind = "duplicate-seq"
+	// RecordedBeforeOccurred is an entry typed in before it happened.
+	RecordedBeforeOccurred Kind = "recorded-before-occurred"
+	// TornLine is a last line cut short by a crash, which the journal will
+	// not append onto until it is set aside.
+	TornLine Kind = "torn-line"
+	// BadCheckpoint is a signed checkpoint that does not verify, or whose tip
+	// the journal no longer passes through.
+	BadCheckpoint Kind = "bad-checkpoint"
+)
+
+// Problem is one thing found wrong. Seq and Hash name the entry it was found
+// on, and are empty for a problem that belongs to no single entry.
+type Problem struct {
+	Kind    Kind   `json:"kind"`
+	Seq     int    `json:"seq,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	Message string `json:"message"`
+}
+
+// String renders the problem as one line, the entry first.
+func (p Problem) String() string {
+	if p.Hash == "" {
+		return fmt.Sprintf("%s: %s", p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%s #%d %s: %s", p.Kind, p.Seq, short(p.Hash), p.Message)
+}
+
+// Report is the outcome of a check.
+type Report struct {
+	Events   int       `json:"events"`
+	Problems []Problem `json:"problems"`
+}
+
+// OK reports whether nothing was found wrong.
+func (r *Report) OK() bool { return len(r.Problems) == 0 }
+
+// add notes a problem on an entry.
+func (r *Report) add(kind Kind, e journal.Event, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{
+		Kind: kind, Seq: e.Seq, Hash: e.Hash, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Check examines a journal's events against the catalogs they refer to. It
+// reads nothing and writes nothing, so it can run over a workspace already
+// open or over events from anywhere else.
+//
+// Every check runs regardless of what the others find: a broken chain is
+// worth knowing about, but so is the overdraft three entries later, and a
+// repair wants the whole list at once.
+func Check(events []journal.Event, products *catalog.Catalog, devices *catalog.Devices) *Report {
+	r := &Report{Events: len(events), Problems: []Problem{}}
+
+	if err := journal.VerifyChain(events); err != nil {
+		r.Problems = append(r.Problems, Problem{Kind: BrokenChain, Message: err.Error()})
+	}
+	for _, o := range ledger.Overdrafts(events) {
+		where := string(o.Account)
+		if o.Location != "" {
+			where += " at " + o.Location
+		}
+		if o.Event.Patient != "" {
+			where = o.Event.Patient + "'s " + where
+		}
+		r.add(NegativeBalance, o.Event, "%s of %s drawn to %.2fg", where, name(o.Event.Product), o.Balance)
+	}
+	checkReferences(r, events, products, devices)
+	checkReverts(r, events)
+	checkSessions(r, events)
+	checkSequence(r, events)
+	return r
+}
+
+// CheckJournal reads a journal and checks it as Check does, and checks the end
+// of the file as well: a last line cut short is not an event, so only the
+// journal itself can report it.
+func CheckJournal(j *journal.Journal, products *catalog.Catalog, devices *catalog.Devices) (*Report, error) {
+	r, _, err := checkJournal(j, products, devices)
+	return r, err
+}
+
+// CheckRepo is CheckJournal over a repository's journal, with its signed
+// checkpoints verified too: every one must still be a prefix of the chain.
+func CheckRepo(rp *repo.Repo, products *catalog.Catalog, devices *catalog.Devices) (*Report, error) {
+	r, events, err := checkJournal(rp.Journal(), products, devices)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints, err := signing.Load(rp)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := signing.PublicKey(rp)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range checkpoints {
+		if err := signing.Verify(c, events, trusted); err != nil {
+			r.Problems = append(r.Problems, Problem{Kind: BadCheckpoint, Message: err.Error()})
+		}
+	}
+	return r, nil
+}
+
+// checkJournal is CheckJournal, handing back the events it read.
+func checkJournal(j *journal.Journal, products *catalog.Catalog, devices *catalog.Devices) (*Report, []journal.Event, error) {
+	events, err := j.Events()
+	if err != nil {
+		return nil, nil, err
+	}
+	torn, err := j.Torn()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := Check(events, products, devices)
+	if torn != nil {
+		// The fragment belongs to no entry, so it is reported by where it
+		// sits rather than pinned to the entry before it.
+		r.Problems = append(r.Problems, Problem{
+			Kind: TornLine,
+			Message: fmt.Sprintf("after entry %d the journal ends in %d bytes that are not a whole entry; "+
+				"`wits fsck --repair` sets them aside", torn.Seq, len(torn.Fragment)),
+		})
+	}
+	return r, events, nil
+}
+
+// checkReferences looks for products and devices the catalogs do not hold.
+// The match is exact: an entry refers to a slug, never to a name.
+func checkReferences(r *Report, events []journal.Event, products *catalog.Catalog, devices *catalog.Devices) {
+	known := map[string]bool{}
+	if devices != nil {
+		for _, d := range devices.Devices {
+			known[d.Slug] = true
+		}
+	}
+	for _, e := range events {
+		if e.Product != "" && (products == nil || !products.Taken(e.Product)) {
+			r.add(UnknownProduct, e, "%s is not in products.yml", e.Product)
+		}
+		if e.Device != "" && !known[e.Device] {
+			r.add(UnknownDevice, e, "%s is not in devices.yml", e.Device)
+		}
+	}
+}
+
+// checkReverts holds every correction to the rules the recorder applies when
+// it writes one: the entry must exist, must not be a correction itself, and
+// must not have been corrected already.
+func checkReverts(r *Report, events []journal.Event) {
+	byHash := make(map[string]journal.Event, len(events))
+	for _, e := range events {
+		byHash[e.Hash] = e
+	}
+	first := map[string]journal.Event{}
+	for _, e := range events {
+		if e.Reverts == "" {
+			continue
+		}
+		target, ok := byHash[e.Reverts]
+		switch {
+		case !ok:
+			r.add(MissingRevert, e, "reverts %s, which is not in the journal", short(e.Reverts))
+			continue
+		case target.Reverts != "":
+			r.add(RevertOfCorrection, e, "reverts %s, which is itself a correction", short(e.Reverts))
+		}
+		if earlier, ok := first[e.Reverts]; ok {
+			r.add(DoubleRevert, e, "reverts %s, already corrected by %s", short(e.Reverts), short(earlier.Hash))
+			continue
+		}
+		first[e.Reverts] = e
+	}
+}
+
+// checkSessions holds every feel that follows a session to what the recorder
+// checks: the session is in the journal, and is a session.
+func checkSessions(r *Report, events []journal.Event) {
+	byHash := make(map[string]journal.Event, len(events))
+	for _, e := range events {
+		byHash[e.Hash] = e
+	}
+	for _, e := range events {
+		if e.Session == "" {
+			continue
+		}
+		switch s, ok := byHash[e.Session]; {
+		case !ok:
+			r.add(MissingSession, e, "follows %s, which is not in the journal", short(e.Session))
+		case s.Type != journal.Sesh:
+			r.add(MissingSession, e, "follows %s, which is a %s, not a session", short(e.Session), s.Type)
+		}
+	}
+}
+
+// checkSequence looks for sequence numbers used twice and for entries that
+// claim to have been typed in before they happened. A backdated entry is
+// normal; a postdated one is a clock or a hand gone wrong.
+func checkSequence(r *Report, events []journal.Event) {
+	seen := map[int]journal.Event{}
+	for _, e := range events {
+		if earlier, ok := seen[e.Seq]; ok {
+			r.add(DuplicateSeq, e, "sequence number %d is also used by %s", e.Seq, short(earlier.Hash))
+		} else {
+			seen[e.Seq] = e
+		}
+		if e.OccurredAt.After(e.RecordedAt) {
+			r.add(RecordedBeforeOccurred, e, "occurred %s but was recorded %s",
+				e.OccurredAt.Format("2006-01-02 15:04:05"), e.RecordedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+// name stands in for an entry without a product, so a message never reads
+// "storage of  drawn".
+func name(product string) string {
+	if product == "" {
+		return "no product"
+	}
+	return product
+}
+
+// short abbreviates a hash the way a commit is abbreviated.
+func short(h string) string {
+	if len(h) > 7 {
+		return h[:7]
+	}
+	return h
+}
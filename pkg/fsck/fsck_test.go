@@ -0,0 +1,239 @@
+package fsck
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TheDonDope/wits/pkg/catalog"
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/signing"
+)
+
+// day returns a timestamp n days into July, so tests read as days.
+func day(n int) time.Time {
+	return time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+// chained appends the events to a fresh journal, so they carry real sequence
+// numbers and hashes, and returns what was stored.
+func chained(t *testing.T, events ...journal.Event) []journal.Event {
+	t.Helper()
+	j := journal.Open(t.TempDir() + "/journal.ndjson")
+	for _, e := range events {
+		_, err := j.Append(e)
+		require.NoError(t, err)
+	}
+	stored, err := j.Events()
+	require.NoError(t, err)
+	return stored
+}
+
+// catalogs holds the one product and the one device the tests refer to.
+func catalogs() (*catalog.Catalog, *catalog.Devices) {
+	return &catalog.Catalog{Products: []*catalog.Product{{Slug: "wcake-221", Name: "Wedding Cake"}}},
+		&catalog.Devices{Devices: []*catalog.Device{{Slug: "volcano", Name: "Volcano"}}}
+}
+
+// kinds lists what a report found, in order.
+func kinds(r *Report) []Kind {
+	out := []Kind{}
+	for _, p := range r.Problems {
+		out = append(out, p.Kind)
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("AHealthyRepository", func(t *testing.T) {
+		products, devices := catalogs()
+		events := chained(t,
+			journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20, OccurredAt: day(0)},
+			journal.Event{Type: journal.Grind, Product: "wcake-221", Grams: 1, OccurredAt: day(1)},
+			journal.Event{Type: journal.Sesh, Product: "wcake-221", Grams: 0.5, OccurredAt: day(1), Device: "volcano"},
+		)
+
+		r := Check(events, products, devices)
+
+		assert.True(t, r.OK(), "Should find nothing wrong, found %v", r.Problems)
+		assert.Equal(t, 3, r.Events, "Should count what it checked")
+	})
+
+	t.Run("AnEmptyRepository", func(t *testing.T) {
+		r := Check(nil, nil, nil)
+
+		assert.True(t, r.OK(), "Should find nothing wrong with nothing")
+		assert.NotNil(t, r.Problems, "Should report an empty list, not null")
+	})
+
+	t.Run("AnOverdraft", func(t *testing.T) {
+		products, devices := catalogs()
+		events := chained(t,
+			journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 1, OccurredAt: day(0)},
+			journal.Event{Type: journal.Grind, Product: "wcake-221", Grams: 2, OccurredAt: day(1)},
+			journal.Event{Type: journal.Grind, Product: "wcake-221", Grams: 1, OccurredAt: day(2)},
+		)
+
+		r := Check(events, products, devices)
+
+		require.Equal(t, []Kind{NegativeBalance}, kinds(r), "Should report where it went under, once")
+		assert.Equal(t, 2, r.Problems[0].Seq, "Should name the entry that overdrew it")
+		assert.Contains(t, r.Problems[0].Message, "-1.00g", "Should say how far under")
+	})
+
+	t.Run("AnotherPatientsOverdraft", func(t *testing.T) {
+		products, devices := catalogs()
+		events := chained(t,
+			journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 10, OccurredAt: day(0)},
+			journal.Event{Type: journal.Grind, Product: "wcake-221", Grams: 5, OccurredAt: day(1), Patient: "bob"},
+		)
+
+		r := Check(events, products, devices)
+
+		require.Equal(t, []Kind{NegativeBalance}, kinds(r), "Should not net bob's grind against another patient's fill")
+		assert.Contains(t, r.Problems[0].Message, "bob's storage", "Should say whose account went under")
+	})
+
+	t.Run("UnknownReferences", func(t *testing.T) {
+		products, devices := catalogs()
+		events := chained(t,
+			journal.Event{Type: journal.Purchase, Product: "lemon-251", Grams: 10, OccurredAt: day(0)},
+			journal.Event{Type: journal.Grind, Product: "lemon-251", Grams: 1, OccurredAt: day(0)},
+			journal.Event{Type: journal.Sesh, Product: "lemon-251", Grams: 0.5, OccurredAt: day(0), Device: "mighty"},
+		)
+
+		r := Check(events, products, devices)
+
+		assert.Equal(t, []Kind{UnknownProduct, UnknownProduct, UnknownProduct, UnknownDevice}, kinds(r),
+			"Should report every entry naming something the catalogs lack")
+	})
+
+	t.Run("BadCorrections", func(t *testing.T) {
+		products, devices := catalogs()
+		j := journal.Open(t.TempDir() + "/journal.ndjson")
+		appended := func(e journal.Event) journal.Event {
+			stored, err := j.Append(e)
+			require.NoError(t, err)
+			return stored
+		}
+		appended(journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20, OccurredAt: day(0)})
+		grind := appended(journal.Event{Type: journal.Grind, Product: "wcake-221", Grams: 1, OccurredAt: day(1)})
+		correction := appended(journal.Event{Type: journal.Adjust, Product: "wcake-221", Grams: 1,
+			From: journal.Stash, To: journal.Storage, OccurredAt: day(2), Reverts: grind.Hash})
+		for _, reverts := range []string{"0000000deadbeef", grind.Hash, correction.Hash} {
+			appended(journal.Event{Type: journal.Adjust, Product: "wcake-221", Grams: 0.1,
+				From: journal.Storage, To: journal.External, OccurredAt: day(3), Reverts: reverts})
+		}
+		events, err := j.Events()
+		require.NoError(t, err)
+
+		r := Check(events, products, devices)
+
+		assert.Equal(t, []Kind{MissingRevert, DoubleRevert, RevertOfCorrection}, kinds(r),
+			"Should hold every correction to the rules the recorder applies")
+	})
+
+	t.Run("FeelsOfNoSession", func(t *testing.T) {
+		products, devices := catalogs()
+		j := journal.Open(t.TempDir() + "/journal.ndjson")
+		purchase, err := j.Append(journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 1,
+			OccurredAt: day(1)})
+		require.NoError(t, err)
+		for _, session := range []string{"0000000deadbeef", purchase.Hash} {
+			_, err := j.Append(journal.Event{Type: journal.Feel, Scores: journal.Scores{"pain": 3},
+				OccurredAt: day(2), Session: session})
+			require.NoError(t, err)
+		}
+		events, err := j.Events()
+		require.NoError(t, err)
+
+		r := Check(events, products, devices)
+
+		assert.Equal(t, []Kind{MissingSession, MissingSession}, kinds(r),
+			"Should hold a feel to following a session that is there")
+	})
+
+	t.Run("ASplicedJournal", func(t *testing.T) {
+		products, devices := catalogs()
+		purchase := journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20, OccurredAt: day(0)}
+		events := append(chained(t, purchase), chained(t, purchase)...)
+
+		r := Check(events, products, devices)
+
+		assert.Equal(t, []Kind{BrokenChain, DuplicateSeq}, kinds(r),
+			"Should find both the broken chain and the number used twice")
+	})
+
+	t.Run("RecordedBeforeItOccurred", func(t *testing.T) {
+		products, devices := catalogs()
+		events := chained(t,
+			journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20,
+				OccurredAt: day(5), RecordedAt: day(1)},
+		)
+
+		r := Check(events, products, devices)
+
+		assert.Equal(t, []Kind{RecordedBeforeOccurred}, kinds(r), "Should refuse to believe a postdated entry")
+	})
+}
+
+func TestCheckJournal(t *testing.T) {
+	products, devices := catalogs()
+	j := journal.Open(t.TempDir() + "/journal.ndjson")
+	_, err := j.Append(journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20, OccurredAt: day(0)})
+	require.NoError(t, err)
+
+	r, err := CheckJournal(j, products, devices)
+	require.NoError(t, err)
+	assert.True(t, r.OK(), "Should find nothing wrong with a journal that ends cleanly")
+
+	f, err := os.OpenFile(j.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":2,"ty`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	r, err = CheckJournal(j, products, devices)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Kind{TornLine}, kinds(r), "Should report the torn line")
+	assert.Equal(t, 1, r.Events, "and check the entries before it as usual")
+}
+
+func TestCheckRepo(t *testing.T) {
+	products, devices := catalogs()
+	rp, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	_, err = signing.NewKey(rp)
+	require.NoError(t, err)
+	purchase := journal.Event{Type: journal.Purchase, Product: "wcake-221", Grams: 20, OccurredAt: day(0)}
+	_, err = rp.Journal().Append(purchase)
+	require.NoError(t, err)
+	events, err := rp.Journal().Events()
+	require.NoError(t, err)
+	_, err = signing.Sign(rp, events, day(1))
+	require.NoError(t, err)
+
+	r, err := CheckRepo(rp, products, devices)
+	require.NoError(t, err)
+	assert.True(t, r.OK(), "Should accept a checkpoint the journal still passes through")
+
+	// The journal is written again from scratch with a different amount: its
+	// chain verifies, and only the checkpoint knows.
+	purchase.Grams = 25
+	rewritten := journal.Open(t.TempDir() + "/journal.ndjson")
+	_, err = rewritten.Append(purchase)
+	require.NoError(t, err)
+	raw, err := os.ReadFile(rewritten.Path())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(rp.JournalPath(), raw, 0600))
+
+	r, err = CheckRepo(rp, products, devices)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Kind{BadCheckpoint}, kinds(r), "Should catch the rewrite")
+}
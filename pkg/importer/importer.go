@@ -187,7 +187,7 @@ func Commit(r *repo.Repo, result *Result) error {
 		return fmt.Errorf("%w: %d of them; import into an empty repository", ErrNotEmpty, len(events))
 	}
 
-	products, err := catalog.Load(r.ProductsPath())
+	products, err := r.LoadProducts()
 	if err != nil {
 		return err
 	}
@@ -196,15 +196,16 @@ func Commit(r *repo.Repo, result *Result) error {
 			return err
 		}
 	}
-	if err := products.Save(r.ProductsPath()); err != nil {
+	if err := r.SaveProducts(products); err != nil {
 		return err
 	}
 
-	for i, e := range result.Events {
-		if _, err := r.Journal().Append(e); err != nil {
-			return fmt.Errorf("entry %d of %d, dated %s: %w",
-				i+1, len(result.Events), e.OccurredAt.Format(time.DateOnly), err)
-		}
+	// One append for the whole workbook, so a refused entry leaves the journal
+	// empty rather than holding the first half of it. The refusal names the
+	// entry by its place in the result and its date, which is what finds the
+	// row in a workbook that runs for years.
+	if _, err := r.Journal().AppendAll(result.Events); err != nil {
+		return fmt.Errorf("importing %d entries: %w", len(result.Events), err)
 	}
 	return nil
 }
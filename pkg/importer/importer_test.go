@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -298,7 +299,7 @@ func TestCommit(t *testing.T) {
 		assert.NoError(t, r.Journal().Verify(), "and the chain should verify")
 
 		// The sheet grinds 0.75 and 0.50 of the Wedding Cake, out of 20 g.
-		state := ledger.Fold(events)
+		state := ledger.Fold("", events)
 		assert.Equal(t, 18.75, state.Balances["enua-wedding-cake-221"].Storage, "Storage should match the sheet")
 		assert.Equal(t, 1.25, state.Balances["enua-wedding-cake-221"].Stash, "and so should the stash")
 	})
@@ -319,6 +320,22 @@ func TestCommit(t *testing.T) {
 		assert.Len(t, events, 5, "and should not have added anything")
 	})
 
+	t.Run("WritesNoEntryIfOneIsRefused", func(t *testing.T) {
+		result, err := Read(build(t, twoProducts()))
+		require.NoError(t, err)
+		result.Events[len(result.Events)-1].Grams = 0
+		r, err := repo.Init(t.TempDir())
+		require.NoError(t, err)
+
+		err = Commit(r, result)
+
+		assert.ErrorContains(t, err, "entry 5 of 5, dated "+result.Events[4].OccurredAt.Format(time.DateOnly),
+			"Should name the entry it refused, and the day to find its row by")
+		events, err := r.Journal().Events()
+		require.NoError(t, err)
+		assert.Empty(t, events, "and leave the journal empty, so the import can be run again")
+	})
+
 	t.Run("ReadingWritesNothing", func(t *testing.T) {
 		path := build(t, twoProducts())
 		before, err := os.Stat(path)
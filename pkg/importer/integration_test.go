@@ -137,7 +137,7 @@ func TestCommittingTheRealWorkbook(t *testing.T) {
 	})
 
 	t.Run("TheFoldMatchesTheSheets", func(t *testing.T) {
-		state := ledger.Fold(events)
+		state := ledger.Fold("", events)
 
 		assert.Len(t, state.Cycles, 29, "29 worksheets become 29 cycles")
 
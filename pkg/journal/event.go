@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -32,7 +33,8 @@ const (
 type Type string
 
 const (
-	// Purchase brings a prescription fill into storage.
+	// Purchase brings a prescription fill into storage. It may carry what the
+	// fill cost, in its Price and Currency.
 	Purchase Type = "purchase"
 	// Grind moves product from storage into the stash.
 	Grind Type = "grind"
@@ -41,10 +43,32 @@ const (
 	Sesh Type = "sesh"
 	// AVBCollect records already vaped bud as weighed when emptying a device.
 	AVBCollect Type = "avb-collect"
-	// AVBUse draws already vaped bud down for edibles, tincture or similar.
+	// AVBUse draws already vaped bud down for edibles, tincture or similar. Its
+	// Purpose says which, and Into names the batch it went into, if one was
+	// recorded.
 	AVBUse Type = "avb-use"
 	// Adjust corrects a balance for a spill or a scale correction.
 	Adjust Type = "adjust"
+	// Feel records how the symptoms were, scored in its Scores, and moves no
+	// grams at all. It may follow a session, named by its Session hash, which
+	// is what lets a score be read against a product, a device and a
+	// temperature.
+	Feel Type = "feel"
+	// Break declares a tolerance break of Days days from when it occurred. It
+	// moves no grams either. A session during one is recorded all the same,
+	// and counts against the break as a lapse.
+	Break Type = "break"
+	// Move carries grams from one location of storage or of the stash to
+	// another, named in its FromLocation and ToLocation: the fridge, the
+	// travel tin. It changes where grams sit and nothing else.
+	Move Type = "move"
+	// Batch brings a batch made from AVB into storage: butter, a tincture.
+	// It is a jar of its own, made rather than dispensed, so it opens no
+	// cycle; the AVB that went into it is drawn down by the uses naming it.
+	Batch Type = "batch"
+	// Portion takes grams of a batch out of storage, eaten or dropped under
+	// the tongue rather than put through a device.
+	Portion Type = "portion"
 )
 
 // flows maps each event type to the accounts it moves grams between.
@@ -55,6 +79,11 @@ var flows = map[Type][2]Account{
 	AVBCollect: {Consumed, AVB},
 	AVBUse:     {AVB, External},
 	Adjust:     {External, External},
+	Feel:       {External, External},
+	Break:      {External, External},
+	Move:       {Storage, Storage},
+	Batch:      {External, Storage},
+	Portion:    {Storage, External},
 }
 
 // Flow returns the accounts an event type moves grams from and to.
@@ -78,20 +107,30 @@ func Flow(t Type) (from, to Account, ok bool) {
 // Field order is significant: the hash is taken over the JSON encoding, and
 // encoding/json emits fields in declaration order.
 type Event struct {
-	Seq         int       `json:"seq"`
-	Type        Type      `json:"type"`
-	OccurredAt  time.Time `json:"occurred_at"`
-	RecordedAt  time.Time `json:"recorded_at"`
-	Product     string    `json:"product,omitempty"`
-	Grams       float64   `json:"grams"`
-	From        Account   `json:"from"`
-	To          Account   `json:"to"`
-	Device      string    `json:"device,omitempty"`
-	Temperature int       `json:"temperature,omitempty"`
-	Note        string    `json:"note,omitempty"`
-	Reverts     string    `json:"reverts,omitempty"`
-	Prev        string    `json:"prev"`
-	Hash        string    `json:"hash"`
+	Seq          int       `json:"seq"`
+	Type         Type      `json:"type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	Product      string    `json:"product,omitempty"`
+	Grams        float64   `json:"grams"`
+	From         Account   `json:"from"`
+	To           Account   `json:"to"`
+	Device       string    `json:"device,omitempty"`
+	Temperature  int       `json:"temperature,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	Purpose      string    `json:"purpose,omitempty"`
+	Price        int64     `json:"price,omitempty"`    // in cents, or whatever the currency's hundredths are
+	Currency     string    `json:"currency,omitempty"` // an ISO 4217 code such as EUR
+	Scores       Scores    `json:"scores,omitempty"`
+	Session      string    `json:"session,omitempty"`       // the hash of the session a feel follows
+	Days         int       `json:"days,omitempty"`          // how long a break is planned to last
+	Patient      string    `json:"patient,omitempty"`       // whose entry it is, empty in a one-patient journal
+	FromLocation string    `json:"from_location,omitempty"` // where in From the grams were, empty for home
+	ToLocation   string    `json:"to_location,omitempty"`   // where in To they went, empty for home
+	Into         string    `json:"into,omitempty"`          // the batch an AVB use went into
+	Reverts      string    `json:"reverts,omitempty"`
+	Prev         string    `json:"prev"`
+	Hash         string    `json:"hash"`
 }
 
 // Validate reports whether the event is well formed enough to be appended.
@@ -100,14 +139,43 @@ func (e Event) Validate() error {
 	if !ok {
 		return fmt.Errorf("unknown event type %q", e.Type)
 	}
-	if e.Type != Adjust && (e.From != from || e.To != to) {
+	if e.Type != Adjust && e.Type != Move && (e.From != from || e.To != to) {
 		return fmt.Errorf("event type %q moves %s -> %s, not %s -> %s", e.Type, from, to, e.From, e.To)
 	}
-	if e.Grams <= 0 {
-		return fmt.Errorf("grams must be positive, got %v", e.Grams)
+	if err := e.validateLocations(); err != nil {
+		return err
 	}
-	if e.Type != AVBUse && e.Product == "" {
-		return fmt.Errorf("event type %q requires a product", e.Type)
+	if err := e.validateFeel(); err != nil {
+		return err
+	}
+	if err := e.validateBreak(); err != nil {
+		return err
+	}
+	if e.Patient != "" {
+		if err := CheckPatient(e.Patient); err != nil {
+			return err
+		}
+	}
+	// A feel moves nothing, and neither does the correction of one; both may
+	// stand without a product, as a score of how the day went.
+	if !e.Massless() {
+		if e.Grams <= 0 {
+			return fmt.Errorf("grams must be positive, got %v", e.Grams)
+		}
+		// AVB is kept per product like every other account, so a use has to
+		// say whose it draws down.
+		if e.Product == "" {
+			return fmt.Errorf("event type %q requires a product", e.Type)
+		}
+	}
+	if e.Purpose != "" && e.Type != AVBUse {
+		return fmt.Errorf("only an %s has a purpose", AVBUse)
+	}
+	if e.Into != "" && e.Type != AVBUse {
+		return fmt.Errorf("only an %s goes into a batch", AVBUse)
+	}
+	if err := e.validatePrice(); err != nil {
+		return err
 	}
 	if e.OccurredAt.IsZero() {
 		return fmt.Errorf("event has no occurred_at timestamp")
@@ -115,6 +183,133 @@ func (e Event) Validate() error {
 	return nil
 }
 
+// Massless reports whether the event moves no grams: a feel, a break, or the
+// correction of either. The ledger leaves such entries out of every balance.
+func (e Event) Massless() bool {
+	return e.Type == Feel || e.Type == Break || e.Type == Adjust && e.Reverts != "" && e.Grams == 0
+}
+
+// validateBreak checks that only a break lasts days, that it lasts at least
+// one, and that it is a break from everything: it moves no grams and names no
+// product.
+func (e Event) validateBreak() error {
+	if e.Type != Break {
+		if e.Days != 0 {
+			return fmt.Errorf("only a %s lasts days", Break)
+		}
+		return nil
+	}
+	if e.Days <= 0 {
+		return fmt.Errorf("a %s lasts at least a day, got %d", Break, e.Days)
+	}
+	if e.Grams != 0 {
+		return fmt.Errorf("a %s moves no grams, got %v", Break, e.Grams)
+	}
+	if e.Product != "" {
+		return fmt.Errorf("a %s is from every product, not %s alone", Break, e.Product)
+	}
+	return nil
+}
+
+// Home is the location of whatever has not been moved anywhere named. It is
+// never written down: an entry at home leaves its location empty, so a journal
+// that keeps no locations reads exactly as it always did.
+const Home = "home"
+
+// Placed reports whether an account is split into locations. Storage and the
+// stash are jars that can be carried about; what has been consumed and the AVB
+// are not kept anywhere in particular.
+func Placed(a Account) bool { return a == Storage || a == Stash }
+
+// validateLocations checks that only a placed account has a location, and that
+// a move goes somewhere: within one placed account, to another location.
+func (e Event) validateLocations() error {
+	for _, side := range []struct {
+		account  Account
+		location string
+	}{{e.From, e.FromLocation}, {e.To, e.ToLocation}} {
+		if side.location == "" {
+			continue
+		}
+		if !Placed(side.account) {
+			return fmt.Errorf("%s is not kept in locations, so %s is not one of its", side.account, side.location)
+		}
+		if err := CheckLocation(side.location); err != nil {
+			return err
+		}
+	}
+	if e.Type != Move {
+		return nil
+	}
+	if e.From != e.To || !Placed(e.From) {
+		return fmt.Errorf("a %s stays within storage or the stash, not %s -> %s", Move, e.From, e.To)
+	}
+	if e.FromLocation == e.ToLocation {
+		return fmt.Errorf("a %s goes from one location to another, not from %s to itself", Move, LocationName(e.FromLocation))
+	}
+	return nil
+}
+
+// CheckLocation reports whether a name will do for a location. It follows the
+// rules of a patient's name, and home is taken: it is where an entry without a
+// location already is.
+func CheckLocation(name string) error {
+	if name == Home {
+		return fmt.Errorf("%s is where grams are when they are nowhere named, and is written as no location at all", Home)
+	}
+	return checkName("location", name)
+}
+
+// LocationName names a location as it is typed, home for the empty one.
+func LocationName(location string) string {
+	if location == "" {
+		return Home
+	}
+	return location
+}
+
+// CheckPatient reports whether a name will do for a patient. It is written on
+// every entry of theirs and typed after every --patient, so it is kept to what
+// a slug is: lower-case letters, digits and dashes, starting with a letter.
+func CheckPatient(name string) error { return checkName("patient", name) }
+
+// checkName holds a name to the rules of a slug, saying what it was to name.
+func checkName(what, name string) error {
+	if name == "" || len(name) > 32 {
+		return fmt.Errorf("a %s is named in 1 to 32 characters, not %q", what, name)
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-'):
+		default:
+			return fmt.Errorf("%q is not a %s name: lower-case letters, digits and dashes, starting with a letter", name, what)
+		}
+	}
+	return nil
+}
+
+// validatePrice checks that only a purchase is priced, and that a price comes
+// with the currency it is in: 250 is a different claim in euros than in francs.
+func (e Event) validatePrice() error {
+	if e.Price == 0 && e.Currency == "" {
+		return nil
+	}
+	if e.Type != Purchase {
+		return fmt.Errorf("only a %s has a price", Purchase)
+	}
+	if e.Price < 0 {
+		return fmt.Errorf("a price cannot be negative, got %d", e.Price)
+	}
+	if e.Price == 0 {
+		return fmt.Errorf("a currency needs a price to go with it")
+	}
+	if len(e.Currency) != 3 || strings.ToUpper(e.Currency) != e.Currency || strings.ContainsAny(e.Currency, "0123456789 ") {
+		return fmt.Errorf("%q is not a currency code such as EUR", e.Currency)
+	}
+	return nil
+}
+
 // sum returns the hash of the event chained onto prev. The event's own Hash is
 // excluded from the calculation, so that hashing is reproducible from the
 // stored line.
@@ -137,6 +332,16 @@ func (e Event) String() string {
 	if len(short) > 7 {
 		short = short[:7]
 	}
+	if e.Type == Feel {
+		return fmt.Sprintf("%s %s %-11s %s", short, at, e.Type, e.Scores)
+	}
+	if e.Type == Break {
+		return fmt.Sprintf("%s %s %-11s %d days", short, at, e.Type, e.Days)
+	}
+	if e.Type == Move {
+		return fmt.Sprintf("%s %s %-11s %.2fg %s %s -> %s", short, at, e.Type, e.Grams, e.Product,
+			LocationName(e.FromLocation), LocationName(e.ToLocation))
+	}
 	if e.Product == "" {
 		return fmt.Sprintf("%s %s %-11s %.2fg", short, at, e.Type, e.Grams)
 	}
@@ -0,0 +1,100 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxScore is the top of the scale a symptom is scored on. The bottom is
+// zero, so a score reads like the pain scale a doctor asks about.
+const MaxScore = 10
+
+// Symptom is something a feel entry scores, and what its ends mean.
+type Symptom struct {
+	Name string
+	Low  string // what a score of zero means
+	High string // what a score of MaxScore means
+	Good bool   // whether a higher score is the better one
+}
+
+// Symptoms are the symptoms a feel entry may score. The names are part of the
+// journal, so they may be added to but never renamed: an entry hashed with
+// "sleep" in it says "sleep" for good.
+var Symptoms = []Symptom{
+	{Name: "pain", Low: "none", High: "unbearable"},
+	{Name: "sleep", Low: "none", High: "slept well", Good: true},
+	{Name: "nausea", Low: "none", High: "vomiting"},
+	{Name: "appetite", Low: "none", High: "hungry", Good: true},
+	{Name: "anxiety", Low: "calm", High: "panic"},
+}
+
+// LookupSymptom returns the symptom of a name.
+func LookupSymptom(name string) (Symptom, bool) {
+	for _, s := range Symptoms {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Symptom{}, false
+}
+
+// Scores are the symptoms a feel entry scores, by name. They encode as a JSON
+// object, whose keys encoding/json sorts, so the hash does not depend on the
+// order they were typed in.
+type Scores map[string]int
+
+// Names returns the names scored, in the order of Symptoms.
+func (s Scores) Names() []string {
+	var names []string
+	for _, sym := range Symptoms {
+		if _, ok := s[sym.Name]; ok {
+			names = append(names, sym.Name)
+		}
+	}
+	return names
+}
+
+// String renders the scores the way `wits feel` takes them, "pain=6 sleep=3".
+func (s Scores) String() string {
+	parts := make([]string, 0, len(s))
+	for _, name := range s.Names() {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, s[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// validateFeel checks what a feel entry carries: at least one score, each of
+// a known symptom and on the scale, and no grams. Only a feel carries scores,
+// or follows a session.
+func (e Event) validateFeel() error {
+	if e.Type != Feel {
+		if len(e.Scores) > 0 || e.Session != "" {
+			return fmt.Errorf("only a %s scores symptoms", Feel)
+		}
+		return nil
+	}
+	if e.Grams != 0 {
+		return fmt.Errorf("a %s moves no grams, got %v", Feel, e.Grams)
+	}
+	if len(e.Scores) == 0 {
+		return fmt.Errorf("a %s needs a score for at least one symptom", Feel)
+	}
+	for name, score := range e.Scores {
+		if _, ok := LookupSymptom(name); !ok {
+			return fmt.Errorf("%q is not a symptom Wits knows, try %s", name, symptomNames())
+		}
+		if score < 0 || score > MaxScore {
+			return fmt.Errorf("%s is scored from 0 to %d, got %d", name, MaxScore, score)
+		}
+	}
+	return nil
+}
+
+// symptomNames lists the known symptoms for an error message.
+func symptomNames() string {
+	names := make([]string, len(Symptoms))
+	for i, s := range Symptoms {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,11 +1,10 @@
 package journal
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"sync"
 	"time"
@@ -15,11 +14,45 @@ import (
 // means the file was edited or truncated outside of Wits.
 var ErrBrokenChain = errors.New("journal hash chain is broken")
 
+// ConflictError is returned by a conditional append when the journal's tip is
+// not the one the caller expected: something else appended in between, and
+// whatever the caller decided from the journal as it was may no longer hold.
+type ConflictError struct {
+	Expected string // the tip the caller expected, empty for an empty journal
+	Tip      string // the tip the journal has
+	Seq      int    // the sequence number of that tip
+}
+
+func (e *ConflictError) Error() string {
+	expected := e.Expected
+	if expected == "" {
+		expected = "an empty journal"
+	}
+	return fmt.Sprintf("the journal has moved on: expected %s, but entry %d (%s) is the tip", expected, e.Seq, e.Tip)
+}
+
+// EntryError is returned when one entry of several appended together is
+// refused. It names the entry by its place among them and the day it
+// happened, so that the row it came from can be found again in whatever it
+// was read from.
+type EntryError struct {
+	Index int   // the entry's place among those appended, from 1
+	Of    int   // how many were appended together
+	Event Event // the entry refused
+	Err   error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("entry %d of %d, dated %s: %v", e.Index, e.Of, e.Event.OccurredAt.Format(time.DateOnly), e.Err)
+}
+
+func (e *EntryError) Unwrap() error { return e.Err }
+
 // Journal is an append-only log of events stored as newline-delimited JSON.
 //
 // The file is only ever opened for appending. Wits never rewrites it, so a
-// failed or partial write can add a bad line but can never destroy an existing
-// one.
+// failed write can never destroy an existing line, and one that fails partway
+// is cut back to where it began.
 //
 // Appending is guarded twice over. The mutex serialises writers inside this
 // process; an advisory lock on a file beside the journal serialises them across
@@ -28,8 +61,9 @@ var ErrBrokenChain = errors.New("journal hash chain is broken")
 // entries claiming the same predecessor and fork the chain. That matters as soon
 // as anything other than a single command talks to a repository.
 type Journal struct {
-	mu   sync.Mutex
-	path string
+	mu     sync.Mutex
+	path   string
+	sealer Sealer
 
 	// The tip of the chain, cached so that a run of appends — a restore is a
 	// thousand of them — does not re-read the whole file for every line. The
@@ -39,6 +73,18 @@ type Journal struct {
 	tip    string
 	size   int64
 	primed bool
+
+	// What the end of the file looked like when the tip was read: a torn line
+	// nothing may be appended onto, or a whole last entry whose newline never
+	// made it to disk.
+	torn *Torn
+	open bool
+
+	// While anything is watching, the hashes appended through this journal,
+	// so that a watch reports only what other processes wrote, each with the
+	// number of watches still to skip it.
+	watchers int
+	mine     map[string]int
 }
 
 // lockPath is the file the cross-process lock is taken on. It is separate from
@@ -67,6 +113,20 @@ func Open(path string) *Journal {
 	return &Journal{path: path}
 }
 
+// Sealer encrypts a journal's lines at rest. Each line is sealed on its own, so
+// a sealed journal is appended to, read and repaired a line at a time exactly
+// as a plain one is; only what is on disk differs. The events — and the hash
+// chain over them — are the same either way.
+type Sealer interface {
+	Seal(line []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// OpenSealed returns the journal stored at path with every line sealed by s.
+func OpenSealed(path string, s Sealer) *Journal {
+	return &Journal{path: path, sealer: s}
+}
+
 // Path returns the file the journal is stored in.
 func (j *Journal) Path() string { return j.path }
 
@@ -74,41 +134,170 @@ func (j *Journal) Path() string { return j.path }
 // single line. The stored event is returned with its Seq, Prev and Hash filled
 // in.
 func (j *Journal) Append(e Event) (Event, error) {
+	stored, err := j.AppendAll([]Event{e})
+	if err != nil {
+		return Event{}, err
+	}
+	return stored[0], nil
+}
+
+// AppendAll appends several events as one: every one is validated and chained
+// before anything is written, and the lines go to disk in a single write and a
+// single sync. Either all of them land or none do — an entry that needs its
+// correction beside it, or a scale session of a dozen jars, is never left half
+// recorded. The stored events are returned in the order given.
+func (j *Journal) AppendAll(events []Event) ([]Event, error) {
+	return j.appendAll(events, nil)
+}
+
+// AppendIf appends the event only if the journal's tip is still expectedTip,
+// the hash of the last entry the caller read; empty means the caller read an
+// empty journal. Otherwise nothing is written and the error is a
+// *ConflictError.
+//
+// It is how a decision taken on a fold is kept honest. A check that an account
+// holds enough grams is only as good as the fold it ran on, and another
+// process may have drawn on the account since: the compare and the append
+// happen under the same lock, so the tip cannot move between them.
+func (j *Journal) AppendIf(e Event, expectedTip string) (Event, error) {
+	stored, err := j.AppendAllIf([]Event{e}, expectedTip)
+	if err != nil {
+		return Event{}, err
+	}
+	return stored[0], nil
+}
+
+// AppendAllIf is AppendAll on the condition AppendIf sets.
+func (j *Journal) AppendAllIf(events []Event, expectedTip string) ([]Event, error) {
+	return j.appendAll(events, &expectedTip)
+}
+
+// appendAll appends the events, first holding the tip to expected when there
+// is one to hold it to.
+func (j *Journal) appendAll(events []Event, expected *string) ([]Event, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
 	release, err := j.lock()
 	if err != nil {
-		return Event{}, err
+		return nil, err
 	}
 	defer release()
 
-	e = withDefaults(e)
-	if err := e.Validate(); err != nil {
-		return Event{}, err
-	}
-
 	if err := j.prime(); err != nil {
-		return Event{}, err
+		return nil, err
 	}
-	e.Seq = j.seq + 1
-	e.Prev = j.tip
-	if e.Hash, err = e.sum(j.tip); err != nil {
-		return Event{}, err
+	if j.torn != nil {
+		return nil, fmt.Errorf("%w after entry %d; run `wits fsck --repair` to set it aside", ErrTornLine, j.torn.Seq)
 	}
-
-	line, err := json.Marshal(e)
+	if expected != nil && *expected != j.tip {
+		return nil, &ConflictError{Expected: *expected, Tip: j.tip, Seq: j.seq}
+	}
+	stored, lines, err := chain(j.seq, j.tip, events)
 	if err != nil {
-		return Event{}, err
+		return nil, err
+	}
+	if lines, err = j.seal(lines); err != nil {
+		return nil, err
+	}
+	var buf []byte
+	if j.open {
+		// The last entry is whole but was cut off before its newline. Ending
+		// it here keeps the next line from being glued onto it.
+		buf = append(buf, '\n')
 	}
-	if err := j.write(line); err != nil {
+	buf = append(buf, lines...)
+
+	if err := j.write(buf); err != nil {
 		// The cache no longer describes the file for certain; the next append
 		// re-reads rather than chaining onto a tip that may not exist.
 		j.primed = false
-		return Event{}, err
+		return nil, err
+	}
+	last := stored[len(stored)-1]
+	j.seq, j.tip, j.open = last.Seq, last.Hash, false
+	j.size += int64(len(buf))
+	if j.watchers > 0 {
+		for _, e := range stored {
+			j.mine[e.Hash] = j.watchers
+		}
+	}
+	return stored, nil
+}
+
+// Chain fills in the events as a fresh journal would store them — defaults,
+// sequence numbers and the hash chain — without writing anything. A restore
+// uses it to see the journal it is about to write before it writes it.
+func Chain(events []Event) ([]Event, error) {
+	stored, _, err := chain(0, "", events)
+	return stored, err
+}
+
+// chain validates the events and chains them onto a tip, returning them as
+// stored along with the lines to write.
+func chain(seq int, tip string, events []Event) ([]Event, []byte, error) {
+	stored := make([]Event, len(events))
+	var buf []byte
+	refused := func(i int, e Event, err error) error {
+		if len(events) == 1 {
+			return err
+		}
+		return &EntryError{Index: i + 1, Of: len(events), Event: e, Err: err}
+	}
+	for i, e := range events {
+		e = withDefaults(e)
+		if err := e.Validate(); err != nil {
+			return nil, nil, refused(i, e, err)
+		}
+		e.Seq = seq + 1
+		e.Prev = tip
+		var err error
+		if e.Hash, err = e.sum(tip); err != nil {
+			return nil, nil, refused(i, e, err)
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, nil, refused(i, e, err)
+		}
+		buf = append(append(buf, line...), '\n')
+		seq, tip = e.Seq, e.Hash
+		stored[i] = e
+	}
+	return stored, buf, nil
+}
+
+// seal seals each of the lines when the journal is sealed.
+func (j *Journal) seal(lines []byte) ([]byte, error) {
+	if j.sealer == nil {
+		return lines, nil
+	}
+	var buf []byte
+	for line := range bytes.Lines(lines) {
+		sealed, err := j.sealer.Seal(bytes.TrimSuffix(line, []byte("\n")))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(buf, sealed...), '\n')
+	}
+	return buf, nil
+}
+
+// decode reads one line as an event, opening it first when the journal is
+// sealed.
+func (j *Journal) decode(line []byte) (Event, error) {
+	var e Event
+	if j.sealer != nil {
+		var err error
+		if line, err = j.sealer.Open(line); err != nil {
+			return e, err
+		}
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return e, fmt.Errorf("not valid JSON: %w", err)
 	}
-	j.seq, j.tip = e.Seq, e.Hash
-	j.size += int64(len(line)) + 1
 	return e, nil
 }
 
@@ -126,10 +315,11 @@ func (j *Journal) prime() error {
 	if j.primed && size == j.size {
 		return nil
 	}
-	events, err := j.read()
+	events, end, err := j.read()
 	if err != nil {
 		return err
 	}
+	j.torn, j.open = end.torn, end.open
 	j.seq = len(events)
 	j.tip = ""
 	if n := len(events); n > 0 {
@@ -161,16 +351,27 @@ func withDefaults(e Event) Event {
 	return e
 }
 
-// write appends one line to the journal and flushes it to disk. The file is
-// only ever opened for appending, so a failed write can add a bad line but can
-// never destroy an existing one.
-func (j *Journal) write(line []byte) error {
+// write appends lines to the journal and flushes them to disk. The file is
+// only ever opened for appending, so a failed write can never destroy an
+// existing line.
+//
+// Nor may it leave part of a batch behind. A write that fails having written
+// some of it is cut back to where the journal ended: those bytes are this
+// call's own, taken under the lock, and nothing can have chained onto them.
+func (j *Journal) write(lines []byte) error {
 	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	if _, err := f.Write(append(line, '\n')); err != nil {
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(lines); err != nil {
+		if terr := f.Truncate(st.Size()); terr != nil {
+			return errors.Join(err, terr)
+		}
 		return err
 	}
 	return f.Sync()
@@ -180,7 +381,8 @@ func (j *Journal) write(line []byte) error {
 func (j *Journal) Events() ([]Event, error) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
-	return j.read()
+	events, _, err := j.read()
+	return events, err
 }
 
 // Verify walks the hash chain and reports the first entry that does not match.
@@ -190,6 +392,12 @@ func (j *Journal) Verify() error {
 	if err != nil {
 		return err
 	}
+	return VerifyChain(events)
+}
+
+// VerifyChain walks the hash chain of events already read, so a caller that
+// has them in hand — a repository check, say — need not read the file twice.
+func VerifyChain(events []Event) error {
 	var prev string
 	for _, e := range events {
 		want, err := e.sum(prev)
@@ -207,37 +415,54 @@ func (j *Journal) Verify() error {
 	return nil
 }
 
+// ending is how the file ends, beyond the events in it.
+type ending struct {
+	torn *Torn // a last line cut short, nil when there is none
+	open bool  // the last entry is whole but has no newline
+}
+
 // read loads and decodes the whole journal. Callers must hold the mutex.
 //
 // A missing file is an empty journal, but any other read error is returned:
 // silently treating an unreadable journal as empty is how an append would go on
 // to record a first event on top of years of history.
-func (j *Journal) read() ([]Event, error) {
-	f, err := os.Open(j.path)
+//
+// A line that does not parse is an error, with one exception. A last line with
+// no newline that does not parse is what a write cut short by a crash leaves,
+// and years of records are not held hostage to it: the events before it are
+// returned, and the fragment is reported in the ending for an append to refuse
+// and a repair to set aside.
+func (j *Journal) read() ([]Event, ending, error) {
+	raw, err := os.ReadFile(j.path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return nil, ending{}, nil
 		}
-		return nil, err
+		return nil, ending{}, err
 	}
-	defer f.Close()
 
 	var events []Event
-	sc := bufio.NewScanner(f)
-	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-	for sc.Scan() {
-		line := sc.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		var e Event
-		if err := json.Unmarshal(line, &e); err != nil {
-			return nil, fmt.Errorf("journal line %d is not valid JSON: %w", len(events)+1, err)
+	var end ending
+	var offset int64
+	for len(raw) > 0 {
+		line, rest, whole := bytes.Cut(raw, []byte("\n"))
+		if len(line) > 0 {
+			e, err := j.decode(line)
+			if err != nil {
+				if whole {
+					return nil, ending{}, fmt.Errorf("journal line %d: %w", len(events)+1, err)
+				}
+				end.torn = &Torn{Offset: offset, Seq: len(events), Fragment: bytes.Clone(line)}
+				if n := len(events); n > 0 {
+					end.torn.After = events[n-1].Hash
+				}
+				break
+			}
+			events = append(events, e)
+			end.open = !whole
 		}
-		events = append(events, e)
-	}
-	if err := sc.Err(); err != nil && !errors.Is(err, io.EOF) {
-		return nil, err
+		offset += int64(len(line)) + 1
+		raw = rest
 	}
-	return events, nil
+	return events, end, nil
 }
@@ -2,6 +2,7 @@ package journal
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"log"
@@ -118,12 +119,101 @@ func TestAppend(t *testing.T) {
 		assert.True(t, e.OccurredAt.Before(e.RecordedAt), "Should record that the entry was late")
 	})
 
+	t.Run("RecordsAFeelWithoutGrams", func(t *testing.T) {
+		j := testJournal(t)
+
+		e, err := j.Append(Event{Type: Feel, Scores: Scores{"sleep": 7, "pain": 2}})
+
+		require.NoError(t, err)
+		assert.Equal(t, External, e.From, "Should move nothing between the tracked accounts")
+		assert.True(t, e.Massless(), "Should count as moving no grams")
+		assert.Contains(t, e.String(), "pain=2 sleep=7", "Should read its scores in the order of the symptoms")
+	})
+
+	t.Run("RecordsABreakOfDays", func(t *testing.T) {
+		j := testJournal(t)
+
+		e, err := j.Append(Event{Type: Break, Days: 14})
+
+		require.NoError(t, err)
+		assert.True(t, e.Massless(), "Should count as moving no grams")
+		assert.Contains(t, e.String(), "break       14 days", "Should read how long it is planned to last")
+	})
+
+	t.Run("RecordsWhosePatient", func(t *testing.T) {
+		j := testJournal(t)
+
+		e, err := j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1, Patient: "anna"})
+		require.NoError(t, err)
+
+		events, err := j.Events()
+		require.NoError(t, err)
+		assert.Equal(t, "anna", events[0].Patient, "Should keep whose entry it is")
+		assert.NoError(t, j.Verify(), "Should chain the patient like any other field")
+		assert.Equal(t, e.Hash, events[0].Hash)
+	})
+
+	t.Run("MovesBetweenLocations", func(t *testing.T) {
+		j := testJournal(t)
+
+		e, err := j.Append(Event{Type: Move, Product: "wedding-cake", Grams: 2, ToLocation: "travel"})
+		require.NoError(t, err)
+		ground, err := j.Append(Event{Type: Move, Product: "wedding-cake", Grams: 0.5,
+			From: Stash, To: Stash, FromLocation: "travel"})
+		require.NoError(t, err)
+
+		assert.Equal(t, Storage, e.From, "Should move within storage unless told otherwise")
+		assert.Contains(t, e.String(), "wedding-cake home -> travel", "Should read where it went")
+		assert.Contains(t, ground.String(), "travel -> home", "and where it came from")
+		assert.NoError(t, j.Verify())
+	})
+
+	t.Run("MakesABatch", func(t *testing.T) {
+		j := testJournal(t)
+
+		use, err := j.Append(Event{Type: AVBUse, Product: "wedding-cake", Grams: 7, Purpose: "butter", Into: "butter"})
+		require.NoError(t, err)
+		batch, err := j.Append(Event{Type: Batch, Product: "butter", Grams: 250})
+		require.NoError(t, err)
+		portion, err := j.Append(Event{Type: Portion, Product: "butter", Grams: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, "butter", use.Into, "Should keep the batch the AVB went into")
+		assert.Equal(t, Storage, batch.To, "Should bring the batch into storage")
+		assert.Equal(t, External, portion.To, "and take a portion out of it")
+		assert.NoError(t, j.Verify())
+	})
+
 	t.Run("RejectsInvalidEvents", func(t *testing.T) {
 		for name, e := range map[string]Event{
-			"UnknownType":    {Type: "smoke", Product: "wedding-cake", Grams: 1},
-			"ZeroGrams":      {Type: Grind, Product: "wedding-cake", Grams: 0},
-			"NegativeGrams":  {Type: Grind, Product: "wedding-cake", Grams: -1},
-			"MissingProduct": {Type: Grind, Grams: 1},
+			"UnknownType":     {Type: "smoke", Product: "wedding-cake", Grams: 1},
+			"ZeroGrams":       {Type: Grind, Product: "wedding-cake", Grams: 0},
+			"NegativeGrams":   {Type: Grind, Product: "wedding-cake", Grams: -1},
+			"MissingProduct":  {Type: Grind, Grams: 1},
+			"AVBUseOfNoOne":   {Type: AVBUse, Grams: 1},
+			"StrayPurpose":    {Type: Grind, Product: "wedding-cake", Grams: 1, Purpose: "edibles"},
+			"PricedGrind":     {Type: Grind, Product: "wedding-cake", Grams: 1, Price: 500, Currency: "EUR"},
+			"NegativePrice":   {Type: Purchase, Product: "wedding-cake", Grams: 1, Price: -500, Currency: "EUR"},
+			"PriceNoCurrency": {Type: Purchase, Product: "wedding-cake", Grams: 1, Price: 500},
+			"CurrencyNoPrice": {Type: Purchase, Product: "wedding-cake", Grams: 1, Currency: "EUR"},
+			"NotACurrency":    {Type: Purchase, Product: "wedding-cake", Grams: 1, Price: 500, Currency: "euro"},
+			"FeelOfNothing":   {Type: Feel},
+			"FeelWithGrams":   {Type: Feel, Grams: 1, Scores: Scores{"pain": 3}},
+			"UnknownSymptom":  {Type: Feel, Scores: Scores{"mood": 3}},
+			"OffTheScale":     {Type: Feel, Scores: Scores{"pain": 11}},
+			"ScoredGrind":     {Type: Grind, Product: "wedding-cake", Grams: 1, Scores: Scores{"pain": 3}},
+			"MasslessAdjust":  {Type: Adjust, Product: "wedding-cake"},
+			"BreakOfNoDays":   {Type: Break},
+			"BreakWithGrams":  {Type: Break, Days: 7, Grams: 1},
+			"BreakFromOne":    {Type: Break, Days: 7, Product: "wedding-cake"},
+			"GrindOfDays":     {Type: Grind, Product: "wedding-cake", Grams: 1, Days: 3},
+			"ShoutedPatient":  {Type: Grind, Product: "wedding-cake", Grams: 1, Patient: "Anna"},
+			"NumberedPatient": {Type: Grind, Product: "wedding-cake", Grams: 1, Patient: "2nd"},
+			"MoveNowhere":     {Type: Move, Product: "wedding-cake", Grams: 1, FromLocation: "fridge", ToLocation: "fridge"},
+			"MoveOutOfStash":  {Type: Move, Product: "wedding-cake", Grams: 1, From: Stash, To: Storage, ToLocation: "fridge"},
+			"HomeByName":      {Type: Move, Product: "wedding-cake", Grams: 1, ToLocation: "home"},
+			"PlacedAVB":       {Type: AVBCollect, Product: "wedding-cake", Grams: 1, ToLocation: "fridge"},
+			"GrindIntoBatch":  {Type: Grind, Product: "wedding-cake", Grams: 1, Into: "butter"},
 		} {
 			t.Run(name, func(t *testing.T) {
 				j := testJournal(t)
@@ -138,6 +228,162 @@ func TestAppend(t *testing.T) {
 	})
 }
 
+func TestAppendAll(t *testing.T) {
+	t.Run("ChainsTheBatch", func(t *testing.T) {
+		j := testJournal(t)
+		first, err := j.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+
+		stored, err := j.AppendAll([]Event{
+			{Type: Grind, Product: "wedding-cake", Grams: 1},
+			{Type: Sesh, Product: "wedding-cake", Grams: 0.25},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, stored, 2)
+		assert.Equal(t, first.Hash, stored[0].Prev, "Should chain the batch onto the tip")
+		assert.Equal(t, stored[0].Hash, stored[1].Prev, "and each entry onto the one before")
+		assert.Equal(t, 3, stored[1].Seq)
+		assert.NoError(t, j.Verify())
+
+		next, err := j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.NoError(t, err)
+		assert.Equal(t, stored[1].Hash, next.Prev, "Should leave the tip after the batch")
+	})
+
+	t.Run("WritesNothingIfAnyEntryIsInvalid", func(t *testing.T) {
+		j := testJournal(t)
+
+		at := time.Date(2024, time.March, 5, 9, 0, 0, 0, time.UTC)
+		_, err := j.AppendAll([]Event{
+			{Type: Purchase, Product: "wedding-cake", Grams: 20, OccurredAt: at},
+			{Type: Grind, Product: "wedding-cake", Grams: 0, OccurredAt: at.AddDate(0, 0, 1)},
+		})
+
+		assert.ErrorContains(t, err, "entry 2 of 2, dated 2024-03-06", "Should name the entry that was refused, and its day")
+		var refused *EntryError
+		require.ErrorAs(t, err, &refused, "Should say which entry it refused")
+		assert.Equal(t, 2, refused.Index)
+		assert.Equal(t, Grind, refused.Event.Type, "and hand it back")
+		events, err := j.Events()
+		require.NoError(t, err)
+		assert.Empty(t, events, "Should not have written the valid entry either")
+	})
+
+	t.Run("NothingIsNothing", func(t *testing.T) {
+		j := testJournal(t)
+
+		stored, err := j.AppendAll(nil)
+
+		assert.NoError(t, err)
+		assert.Empty(t, stored)
+		assert.NoFileExists(t, j.Path(), "Should not even create the journal")
+	})
+}
+
+// hexSealer seals a line by writing it in hex: enough to tell a sealed line
+// from a plain one, and to tell a damaged one from a whole one.
+type hexSealer struct{}
+
+func (hexSealer) Seal(line []byte) ([]byte, error)   { return []byte(hex.EncodeToString(line)), nil }
+func (hexSealer) Open(sealed []byte) ([]byte, error) { return hex.DecodeString(string(sealed)) }
+
+func TestSealed(t *testing.T) {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	batch := []Event{
+		{Type: Purchase, Product: "wedding-cake", Grams: 20, OccurredAt: at, RecordedAt: at},
+		{Type: Grind, Product: "wedding-cake", Grams: 1, OccurredAt: at, RecordedAt: at},
+	}
+
+	t.Run("StoresNoPlaintext", func(t *testing.T) {
+		j := OpenSealed(filepath.Join(t.TempDir(), "journal.ndjson"), hexSealer{})
+
+		_, err := j.AppendAll(batch)
+		require.NoError(t, err)
+
+		raw, err := os.ReadFile(j.Path())
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "wedding-cake", "Should seal what it writes")
+		assert.Equal(t, 2, bytes.Count(raw, []byte("\n")), "one line to an entry")
+		events, err := j.Events()
+		require.NoError(t, err)
+		assert.Len(t, events, 2, "Should read back what it sealed")
+		assert.NoError(t, j.Verify())
+	})
+
+	t.Run("ChainsThePlaintext", func(t *testing.T) {
+		sealed := OpenSealed(filepath.Join(t.TempDir(), "journal.ndjson"), hexSealer{})
+		plain := testJournal(t)
+
+		a, err := sealed.AppendAll(batch)
+		require.NoError(t, err)
+		b, err := plain.AppendAll(batch)
+		require.NoError(t, err)
+
+		assert.Equal(t, b, a, "Should hash the same events to the same chain, sealed or not")
+	})
+
+	t.Run("ReportsATornLine", func(t *testing.T) {
+		j := OpenSealed(filepath.Join(t.TempDir(), "journal.ndjson"), hexSealer{})
+		_, err := j.AppendAll(batch)
+		require.NoError(t, err)
+		f, err := os.OpenFile(j.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString("7b22736571")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		torn, err := j.Torn()
+
+		require.NoError(t, err)
+		require.NotNil(t, torn, "A sealed line cut short is torn like a plain one")
+		assert.Equal(t, 2, torn.Seq)
+	})
+
+	t.Run("RefusesALineThatDoesNotOpen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.ndjson")
+		_, err := OpenSealed(path, hexSealer{}).AppendAll(batch)
+		require.NoError(t, err)
+
+		_, err = Open(path).Events()
+
+		assert.ErrorContains(t, err, "journal line 1", "Should not read a sealed journal as a plain one")
+	})
+}
+
+func TestAppendIf(t *testing.T) {
+	t.Run("AppendsOnTheExpectedTip", func(t *testing.T) {
+		j := testJournal(t)
+		first, err := j.AppendIf(Event{Type: Purchase, Product: "wedding-cake", Grams: 20}, "")
+		require.NoError(t, err, "An empty tip expects an empty journal")
+
+		second, err := j.AppendIf(Event{Type: Grind, Product: "wedding-cake", Grams: 1}, first.Hash)
+
+		require.NoError(t, err)
+		assert.Equal(t, first.Hash, second.Prev)
+	})
+
+	t.Run("RefusesAMovedTip", func(t *testing.T) {
+		j := testJournal(t)
+		first, err := j.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+		// Another process appends behind this one's back.
+		second, err := Open(j.Path()).Append(Event{Type: Grind, Product: "wedding-cake", Grams: 15})
+		require.NoError(t, err)
+
+		_, err = j.AppendAllIf([]Event{{Type: Grind, Product: "wedding-cake", Grams: 10}}, first.Hash)
+
+		var conflict *ConflictError
+		require.ErrorAs(t, err, &conflict, "Should say the journal moved")
+		assert.Equal(t, first.Hash, conflict.Expected)
+		assert.Equal(t, second.Hash, conflict.Tip, "and where it moved to")
+		assert.Equal(t, 2, conflict.Seq)
+		events, err := j.Events()
+		require.NoError(t, err)
+		assert.Len(t, events, 2, "Should write nothing")
+	})
+}
+
 func TestAppendIsAppendOnly(t *testing.T) {
 	j := testJournal(t)
 
@@ -0,0 +1,103 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrTornLine is returned when appending to a journal whose last line was cut
+// short. Chaining onto it would bury the fragment between whole entries, where
+// it stops being a crash and starts being damage.
+var ErrTornLine = errors.New("the journal ends in a torn line")
+
+// Torn is a last line cut short: the bytes a write left behind when the
+// machine went down partway through it. They never became an entry, and
+// nothing before them is affected.
+type Torn struct {
+	// Offset is where the fragment starts in the journal.
+	Offset int64 `json:"offset"`
+	// Seq is how many whole entries precede it, and After the hash of the
+	// last of them; empty when the fragment is all the journal holds.
+	Seq   int    `json:"seq"`
+	After string `json:"after,omitempty"`
+	// Fragment is the bytes themselves. Written down, they are base64, since
+	// a line cut short can be cut through a character.
+	Fragment []byte `json:"fragment"`
+	// QuarantinedAt is when a repair set the fragment aside.
+	QuarantinedAt time.Time `json:"quarantined_at,omitzero"`
+}
+
+// QuarantinePath returns the file torn lines are set aside in, beside the
+// journal.
+func (j *Journal) QuarantinePath() string { return j.path + ".quarantine" }
+
+// Torn reports the journal's torn last line, or nil when it ends cleanly.
+func (j *Journal) Torn() (*Torn, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, end, err := j.read()
+	return end.torn, err
+}
+
+// Quarantine sets a torn last line aside, so the journal can be appended to
+// again. The fragment is written to the quarantine file first, with when and
+// where it was found, and only once that is on disk is it cut from the
+// journal; every whole line is left exactly as it was. A journal that ends
+// cleanly is left alone, and nil is returned.
+//
+// It is the one time Wits shortens the journal, and it only ever removes bytes
+// that never verified as an entry.
+func (j *Journal) Quarantine() (*Torn, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	release, err := j.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	_, end, err := j.read()
+	if err != nil || end.torn == nil {
+		return nil, err
+	}
+	torn := end.torn
+	torn.QuarantinedAt = time.Now().Truncate(time.Second)
+
+	line, err := json.Marshal(torn)
+	if err != nil {
+		return nil, err
+	}
+	q, err := os.OpenFile(j.QuarantinePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := q.Write(append(line, '\n')); err != nil {
+		q.Close()
+		return nil, err
+	}
+	if err := q.Sync(); err != nil {
+		q.Close()
+		return nil, err
+	}
+	if err := q.Close(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := f.Truncate(torn.Offset); err != nil {
+		return nil, fmt.Errorf("the fragment is saved in %s but could not be cut from the journal: %w", j.QuarantinePath(), err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	j.primed = false
+	return torn, nil
+}
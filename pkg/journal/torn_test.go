@@ -0,0 +1,147 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tornJournal returns a journal of two whole entries followed by the first
+// half of a third, the way a write cut short by a power cut leaves it.
+func tornJournal(t *testing.T) (*Journal, []byte) {
+	t.Helper()
+	j := testJournal(t)
+	_, err := j.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+	require.NoError(t, err)
+	_, err = j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+	require.NoError(t, err)
+	whole, err := os.ReadFile(j.Path())
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(j.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":3,"type":"sesh","occ`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return j, whole
+}
+
+func TestTornLine(t *testing.T) {
+	t.Run("TheEntriesBeforeItStillRead", func(t *testing.T) {
+		j, _ := tornJournal(t)
+
+		events, err := j.Events()
+
+		require.NoError(t, err, "A crash mid-write must not lock anyone out of the years before it")
+		assert.Len(t, events, 2)
+		assert.NoError(t, j.Verify())
+	})
+
+	t.Run("IsReported", func(t *testing.T) {
+		j, whole := tornJournal(t)
+
+		torn, err := j.Torn()
+		require.NoError(t, err)
+
+		require.NotNil(t, torn)
+		assert.Equal(t, int64(len(whole)), torn.Offset, "Should say where the fragment starts")
+		assert.Equal(t, 2, torn.Seq, "and how many entries precede it")
+		assert.Equal(t, `{"seq":3,"type":"sesh","occ`, string(torn.Fragment))
+	})
+
+	t.Run("RefusesAnAppendOnTop", func(t *testing.T) {
+		j, whole := tornJournal(t)
+
+		_, err := j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+
+		assert.ErrorIs(t, err, ErrTornLine)
+		raw, err := os.ReadFile(j.Path())
+		require.NoError(t, err)
+		assert.Len(t, raw, len(whole)+27, "Should have written nothing")
+	})
+
+	t.Run("AnyOtherBadLineIsStillAnError", func(t *testing.T) {
+		j, _ := tornJournal(t)
+		f, err := os.OpenFile(j.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString("\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = j.Events()
+
+		assert.ErrorContains(t, err, "not valid JSON", "A finished line that does not parse is damage, not a crash")
+	})
+
+	t.Run("AWholeLastEntryWithoutItsNewline", func(t *testing.T) {
+		j := testJournal(t)
+		first, err := j.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(j.Path())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(j.Path(), raw[:len(raw)-1], 0600))
+
+		next, err := Open(j.Path()).Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.NoError(t, err, "Should accept an entry that only lost its newline")
+
+		events, err := j.Events()
+		require.NoError(t, err)
+		require.Len(t, events, 2, "and end it, rather than glue the next line onto it")
+		assert.Equal(t, first.Hash, next.Prev)
+	})
+}
+
+func TestQuarantine(t *testing.T) {
+	t.Run("SetsTheFragmentAside", func(t *testing.T) {
+		j, whole := tornJournal(t)
+
+		torn, err := j.Quarantine()
+		require.NoError(t, err)
+
+		require.NotNil(t, torn)
+		after, err := os.ReadFile(j.Path())
+		require.NoError(t, err)
+		assert.Equal(t, whole, after, "Should leave every whole line exactly as it was")
+
+		q, err := os.Open(j.QuarantinePath())
+		require.NoError(t, err)
+		defer q.Close()
+		sc := bufio.NewScanner(q)
+		require.True(t, sc.Scan())
+		var recorded Torn
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &recorded))
+		assert.Equal(t, `{"seq":3,"type":"sesh","occ`, string(recorded.Fragment), "Should keep the fragment")
+		assert.Equal(t, 2, recorded.Seq, "and record where it was found")
+		assert.False(t, recorded.QuarantinedAt.IsZero(), "and when it was set aside")
+	})
+
+	t.Run("TheJournalTakesEntriesAgain", func(t *testing.T) {
+		j, _ := tornJournal(t)
+		_, err := j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.ErrorIs(t, err, ErrTornLine)
+
+		_, err = j.Quarantine()
+		require.NoError(t, err)
+		e, err := j.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, e.Seq)
+		assert.NoError(t, j.Verify())
+	})
+
+	t.Run("LeavesAWholeJournalAlone", func(t *testing.T) {
+		j := testJournal(t)
+		_, err := j.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+
+		torn, err := j.Quarantine()
+
+		require.NoError(t, err)
+		assert.Nil(t, torn)
+		assert.NoFileExists(t, j.QuarantinePath(), "Should not create a quarantine with nothing in it")
+	})
+}
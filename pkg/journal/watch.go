@@ -0,0 +1,168 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// pollInterval is how often a watch looks at the journal where the platform
+// cannot say when it has changed.
+const pollInterval = time.Second
+
+// Watch reports events appended to the journal by other processes, in the
+// order they were written, until ctx is done; the channel is then closed.
+//
+// Appends made through this journal are left out: whoever made them already
+// knows. Everything else — another terminal, a script, a sync — is delivered
+// in batches, one batch for whatever landed between two looks at the file.
+// Only whole lines are read, so a line caught half-written is picked up once
+// its writer finishes it.
+//
+// On Linux the kernel says when the directory changes; elsewhere the file is
+// looked at once a second. Neither needs anything running beyond this process.
+func (j *Journal) Watch(ctx context.Context) (<-chan []Event, error) {
+	j.mu.Lock()
+	offset, err := j.fileSize()
+	if err == nil {
+		j.watchers++
+		if j.mine == nil {
+			j.mine = map[string]int{}
+		}
+	}
+	j.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	wake, stop := notify(j.path)
+	out := make(chan []Event)
+	go func() {
+		defer close(out)
+		defer j.unwatch()
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			}
+			var events []Event
+			events, offset = j.since(offset)
+			if len(events) == 0 {
+				continue
+			}
+			select {
+			case out <- events:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// unwatch ends one watch. The last to end forgets this journal's appends.
+func (j *Journal) unwatch() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.watchers--; j.watchers == 0 {
+		j.mine = nil
+	}
+}
+
+// since reads the whole lines written after offset, returning the events
+// other processes appended and the offset to look from next time.
+//
+// A journal shorter than the offset was replaced, not appended to. Nothing in
+// it can be reported as new, so the watch starts again from its end.
+func (j *Journal) since(offset int64) ([]Event, int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, offset
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, offset
+	}
+	if st.Size() < offset {
+		return nil, st.Size()
+	}
+	if st.Size() == offset {
+		return nil, offset
+	}
+	raw, err := io.ReadAll(io.NewSectionReader(f, offset, st.Size()-offset))
+	if err != nil {
+		return nil, offset
+	}
+	end := bytes.LastIndexByte(raw, '\n')
+	if end < 0 {
+		return nil, offset
+	}
+
+	var events []Event
+	for _, line := range bytes.Split(raw[:end], []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		e, err := j.decode(line)
+		if err != nil {
+			// A line that does not parse is the chain's problem, and
+			// Verify's to report; a watch only says what arrived.
+			continue
+		}
+		// Each watch skips its own journal's append once, and the last to
+		// do so forgets it, so the set holds only what is still to be read.
+		if n, ok := j.mine[e.Hash]; ok {
+			if n <= 1 {
+				delete(j.mine, e.Hash)
+			} else {
+				j.mine[e.Hash] = n - 1
+			}
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, offset + int64(end) + 1
+}
+
+// fileSize is the journal's size, zero while it does not exist. Callers must
+// hold the mutex.
+func (j *Journal) fileSize() (int64, error) {
+	st, err := os.Stat(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return st.Size(), nil
+}
+
+// poll wakes a watch once every pollInterval, until stopped.
+func poll() (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(pollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return wake, func() { close(done) }
+}
@@ -0,0 +1,45 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// notify wakes a watch whenever something in the journal's directory is
+// written, created or moved into place, using inotify. The directory rather
+// than the file is watched because the file need not exist yet, and a wake
+// that turns out to be about the lock file costs one stat.
+//
+// Should inotify be unavailable — a container without it, the watch limit
+// reached — the watch polls instead of failing.
+func notify(path string) (<-chan struct{}, func()) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return poll()
+	}
+	mask := uint32(unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_MOVED_TO)
+	if _, err := unix.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		unix.Close(fd)
+		return poll()
+	}
+	// A non-blocking descriptor handed to os.NewFile joins the runtime's
+	// poller, so the read below parks rather than spins, and closing the file
+	// wakes it to return.
+	f := os.NewFile(uintptr(fd), "inotify")
+	wake := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return wake, func() { f.Close() }
+}
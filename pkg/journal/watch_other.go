@@ -0,0 +1,8 @@
+//go:build !linux
+
+package journal
+
+// notify wakes a watch by polling, where there is no inotify to ask.
+func notify(string) (<-chan struct{}, func()) {
+	return poll()
+}
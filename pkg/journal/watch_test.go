@@ -0,0 +1,128 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// next waits for the watch's next batch, failing rather than hanging.
+func next(t *testing.T, ch <-chan []Event) []Event {
+	t.Helper()
+	select {
+	case events, ok := <-ch:
+		require.True(t, ok, "The watch should still be open")
+		return events
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Nothing arrived")
+		return nil
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Run("ReportsAppendsFromElsewhere", func(t *testing.T) {
+		// As in TestAppendIsSafeAcrossProcesses, a second Journal over the
+		// same file is the other process.
+		path := filepath.Join(t.TempDir(), "journal.ndjson")
+		here, there := Open(path), Open(path)
+		_, err := here.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+
+		ch, err := here.Watch(t.Context())
+		require.NoError(t, err)
+		e, err := there.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.NoError(t, err)
+
+		got := next(t, ch)
+
+		require.Len(t, got, 1, "Should report only what was appended since the watch began")
+		assert.Equal(t, e.Hash, got[0].Hash, "and report it as it was stored")
+	})
+
+	t.Run("LeavesOutItsOwnAppends", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.ndjson")
+		here, there := Open(path), Open(path)
+		ch, err := here.Watch(t.Context())
+		require.NoError(t, err)
+
+		_, err = here.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+		e, err := there.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.NoError(t, err)
+
+		var got []Event
+		for len(got) == 0 {
+			got = next(t, ch)
+		}
+		require.Len(t, got, 1, "Should not report what this journal wrote itself")
+		assert.Equal(t, e.Hash, got[0].Hash)
+	})
+
+	t.Run("ForgetsItsOwnAppendsOnceEveryWatchHasSkippedThem", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.ndjson")
+		here, there := Open(path), Open(path)
+		first, err := here.Watch(t.Context())
+		require.NoError(t, err)
+		second, err := here.Watch(t.Context())
+		require.NoError(t, err)
+
+		_, err = here.Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+		e, err := there.Append(Event{Type: Grind, Product: "wedding-cake", Grams: 1})
+		require.NoError(t, err)
+
+		for _, ch := range []<-chan []Event{first, second} {
+			var got []Event
+			for len(got) == 0 {
+				got = next(t, ch)
+			}
+			require.Len(t, got, 1, "Should leave out this journal's own append from every watch")
+			assert.Equal(t, e.Hash, got[0].Hash)
+		}
+		here.mu.Lock()
+		defer here.mu.Unlock()
+		assert.Empty(t, here.mine, "Should not keep a hash every watch has already skipped")
+	})
+
+	t.Run("WaitsForAWholeLine", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.ndjson")
+		j := Open(path)
+		ch, err := j.Watch(t.Context())
+		require.NoError(t, err)
+
+		stored, err := Open(filepath.Join(t.TempDir(), "elsewhere.ndjson")).
+			Append(Event{Type: Purchase, Product: "wedding-cake", Grams: 20})
+		require.NoError(t, err)
+		require.NoError(t, write(t, path, []Event{stored}))
+		raw, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, raw[:len(raw)/2], 0600))
+		time.Sleep(2 * pollInterval)
+		require.NoError(t, os.WriteFile(path, raw, 0600))
+
+		got := next(t, ch)
+
+		require.Len(t, got, 1, "Should deliver the line once it was finished, not half of it")
+		assert.Equal(t, stored.Hash, got[0].Hash)
+	})
+
+	t.Run("ClosesWhenDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		ch, err := testJournal(t).Watch(ctx)
+		require.NoError(t, err)
+
+		cancel()
+
+		select {
+		case _, ok := <-ch:
+			assert.False(t, ok, "Should close the channel once the context is done")
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "The watch never stopped")
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// The defaults of a Detector left at its zero values: a day or a week three
+// times the usual, against the usual of the four weeks before it.
+const (
+	DefaultFactor = 3.0
+	DefaultWindow = 28
+)
+
+// minBaseline is how many days with an amount, or how many weeks, the window
+// must hold before anything is judged against it. The median of two days is
+// not a usual amount, and a new journal would flag its second day.
+const minBaseline = 5
+
+// AnomalyKind is how a day or a week stands out.
+type AnomalyKind int
+
+const (
+	// HeavyDay is a day at Factor times the usual day or more.
+	HeavyDay AnomalyKind = iota
+	// HeavyWeek is a week at Factor times the usual week or more.
+	HeavyWeek
+	// LightWeek is a week at a Factor-th of the usual week or less, but not
+	// nothing.
+	LightWeek
+	// SilentWeek is a week with nothing logged at all, where the weeks
+	// before it had something.
+	SilentWeek
+)
+
+// String names the kind the way `wits anomalies` lists it.
+func (k AnomalyKind) String() string {
+	switch k {
+	case HeavyWeek:
+		return "heavy week"
+	case LightWeek:
+		return "light week"
+	case SilentWeek:
+		return "silent week"
+	default:
+		return "heavy day"
+	}
+}
+
+// Heavy reports whether the kind is above the usual rather than below it.
+func (k AnomalyKind) Heavy() bool { return k == HeavyDay || k == HeavyWeek }
+
+// Anomaly is a day or a week that stands out against the rolling median of
+// the ones before it. It is a question, not a verdict: a heavy day is as
+// often an amount typed with its decimal point in the wrong place as it is
+// a bad day, and a silent week as often a week nobody logged as one without
+// any.
+type Anomaly struct {
+	Kind  AnomalyKind
+	Start time.Time // midnight of the day, or of the week's first day
+	Days  int       // 1 for a day, 7 for a week
+	Grams float64
+	Usual float64 // the rolling median it was measured against
+}
+
+// End returns the last day the anomaly covers, at midnight.
+func (a Anomaly) End() time.Time { return a.Start.AddDate(0, 0, a.Days-1) }
+
+// Ratio returns the grams as a multiple of the usual.
+func (a Anomaly) Ratio() float64 {
+	if a.Usual <= 0 {
+		return 0
+	}
+	return a.Grams / a.Usual
+}
+
+// Detector flags the days and weeks of a measure that deviate from the
+// rolling median before them by a factor.
+type Detector struct {
+	Measure Measure
+	Factor  float64 // how many times the usual, or a how-manyth of it; DefaultFactor when zero
+	Window  int     // days the rolling median looks back over; DefaultWindow when zero
+}
+
+// Tuned returns the detector with the defaults in place of its zero values.
+// A factor of 1 or less would flag every day or none, and counts as unset.
+func (d Detector) Tuned() Detector {
+	if d.Factor <= 1 {
+		d.Factor = DefaultFactor
+	}
+	if d.Window <= 0 {
+		d.Window = DefaultWindow
+	}
+	return d
+}
+
+// Find returns the anomalies among the standing events, oldest first, a day
+// flagged before the week it falls in. Weeks run in sevens from the day of
+// the first entry counted, and only weeks over by now are judged: a week in
+// progress is light by construction.
+func (d Detector) Find(events []journal.Event, now time.Time) []Anomaly {
+	d = d.Tuned()
+	daily := Daily(d.Measure, Standing(events))
+	if len(daily) == 0 {
+		return nil
+	}
+	days := make([]time.Time, 0, len(daily))
+	for key := range daily {
+		day, _ := time.ParseInLocation(time.DateOnly, key, now.Location())
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var out []Anomaly
+	for _, day := range days {
+		if a, ok := d.day(daily, day); ok {
+			out = append(out, a)
+		}
+	}
+
+	// Weekly totals, including the empty weeks: an empty week is what a
+	// silent week is made of, and it counts towards the usual too. A week a
+	// declared break touches is neither: it is quiet on purpose, so it is
+	// never light or silent, and it does not drag the usual down after it.
+	type week struct {
+		grams  float64
+		paused bool
+	}
+	paused := OnBreak(events, now)
+	var weeks []week
+	today := Midnight(now)
+	for start := days[0]; !start.AddDate(0, 0, 7).After(today); start = start.AddDate(0, 0, 7) {
+		var w week
+		for i := 0; i < 7; i++ {
+			key := start.AddDate(0, 0, i).Format(time.DateOnly)
+			w.grams += daily[key]
+			w.paused = w.paused || paused[key]
+		}
+		w.grams = Round(w.grams)
+		weeks = append(weeks, w)
+	}
+	back := max(d.Window/7, 1)
+	for i, w := range weeks {
+		// The usual reaches back past a break for as many weeks as it
+		// would otherwise have had.
+		var prior []float64
+		for j := i - 1; j >= 0 && len(prior) < back; j-- {
+			if !weeks[j].paused {
+				prior = append(prior, weeks[j].grams)
+			}
+		}
+		if len(prior) < min(back, minBaseline) {
+			continue
+		}
+		sort.Float64s(prior)
+		usual := median(prior)
+		if usual <= 0 {
+			continue
+		}
+		a := Anomaly{Start: days[0].AddDate(0, 0, 7*i), Days: 7, Grams: w.grams, Usual: usual}
+		switch {
+		case w.grams >= usual*d.Factor:
+			a.Kind = HeavyWeek
+		case w.paused:
+			continue
+		case w.grams <= 0:
+			a.Kind = SilentWeek
+		case w.grams <= usual/d.Factor:
+			a.Kind = LightWeek
+		default:
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// Unusual reports whether the day of an entry stands out once the entry is
+// counted in it: the check `wits grind` and `wits sesh` make right after
+// recording one. The events are the journal with the entry in it.
+func (d Detector) Unusual(events []journal.Event, e journal.Event) (Anomaly, bool) {
+	if !d.Measure.Counts(e) {
+		return Anomaly{}, false
+	}
+	return d.Tuned().day(Daily(d.Measure, Standing(events)), Midnight(e.OccurredAt))
+}
+
+// day judges one day against the median of the days with an amount in the
+// window before it. The detector is tuned already.
+func (d Detector) day(daily map[string]float64, day time.Time) (Anomaly, bool) {
+	grams := daily[day.Format(time.DateOnly)]
+	if grams <= 0 {
+		return Anomaly{}, false
+	}
+	var prior []float64
+	for i := 1; i <= d.Window; i++ {
+		if g := daily[day.AddDate(0, 0, -i).Format(time.DateOnly)]; g > 0 {
+			prior = append(prior, g)
+		}
+	}
+	if len(prior) < minBaseline {
+		return Anomaly{}, false
+	}
+	sort.Float64s(prior)
+	usual := median(prior)
+	if grams < usual*d.Factor {
+		return Anomaly{}, false
+	}
+	return Anomaly{Kind: HeavyDay, Start: day, Days: 1, Grams: grams, Usual: usual}, true
+}
@@ -0,0 +1,126 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Reading is which of an entry's two timestamps a state as of a moment is
+// read by.
+type Reading int
+
+const (
+	// AsOccurred counts the entries that had happened by the moment, however
+	// late they were typed in: what was in the jar that day, as the journal
+	// knows it now.
+	AsOccurred Reading = iota
+	// AsRecorded counts the entries that had been recorded by the moment:
+	// what the ledger believed that day. An evening logged the next morning
+	// is missing from it, and so is a correction made since.
+	AsRecorded
+)
+
+// String names the reading the way the commands say it.
+func (r Reading) String() string {
+	if r == AsRecorded {
+		return "as recorded"
+	}
+	return "as it happened"
+}
+
+// At returns the state as it stood at t by the given reading, folded in the
+// state's own order. An entry stamped exactly at t counts.
+//
+// Nothing is kept of the states in between: the journal is the history, and
+// any moment of it is a fold of a prefix away.
+func (s *State) At(t time.Time, r Reading) *State {
+	return FoldFor(s.order, s.Patient, Until(s.Events, t, r))
+}
+
+// Until returns the events that had occurred, or had been recorded, by t.
+func Until(events []journal.Event, t time.Time, r Reading) []journal.Event {
+	out := make([]journal.Event, 0, len(events))
+	for _, e := range events {
+		at := e.OccurredAt
+		if r == AsRecorded {
+			at = e.RecordedAt
+		}
+		if !at.After(t) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// AtSeq returns the state as the ledger stood right after the entry with the
+// given sequence number was recorded. Timestamps are to the second, and a
+// reconciliation of several jars records them all in one; the sequence tells
+// them apart.
+func (s *State) AtSeq(seq int) *State {
+	return FoldFor(s.order, s.Patient, Through(s.Events, seq))
+}
+
+// Through returns the events recorded up to and including the given
+// sequence number.
+func Through(events []journal.Event, seq int) []journal.Event {
+	out := make([]journal.Event, 0, len(events))
+	for _, e := range events {
+		if e.Seq <= seq {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Moment is a past moment to read the ledger at, as an --as-of flag names it.
+// It is one instant on both clocks for a date. For an entry it is the moment
+// the entry was dated, and the journal as it stood right after the entry was
+// appended.
+type Moment struct {
+	Occurred time.Time
+	Recorded time.Time
+	Seq      int    // the entry's, or 0 for a date
+	Entry    string // the entry's hash, or empty for a date
+}
+
+// ParseMoment reads an --as-of flag: a date, which means the end of that day,
+// a date and time, or an entry, which find looks up by its hash.
+func ParseMoment(v string, find func(string) (journal.Event, error)) (Moment, error) {
+	if at, err := time.ParseInLocation(time.DateOnly, v, time.Local); err == nil {
+		end := at.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		return Moment{Occurred: end, Recorded: end}, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", time.RFC3339} {
+		if at, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return Moment{Occurred: at, Recorded: at}, nil
+		}
+	}
+	e, err := find(v)
+	if err != nil {
+		return Moment{}, fmt.Errorf("%q is neither a date nor an entry: %w", v, err)
+	}
+	return Moment{Occurred: e.OccurredAt, Recorded: e.RecordedAt, Seq: e.Seq, Entry: e.Hash}, nil
+}
+
+// Happened returns the events dated by the moment.
+func (m Moment) Happened(events []journal.Event) []journal.Event {
+	return Until(events, m.Occurred, AsOccurred)
+}
+
+// RecordedBy returns the events the ledger held at the moment.
+func (m Moment) RecordedBy(events []journal.Event) []journal.Event {
+	if m.Seq > 0 {
+		return Through(events, m.Seq)
+	}
+	return Until(events, m.Recorded, AsRecorded)
+}
+
+// Believed returns the state as the ledger showed it at the moment.
+func (s *State) Believed(m Moment) *State {
+	if m.Seq > 0 {
+		return s.AtSeq(m.Seq)
+	}
+	return s.At(m.Recorded, AsRecorded)
+}
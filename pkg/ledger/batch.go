@@ -0,0 +1,123 @@
+package ledger
+
+import (
+	"slices"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// DefaultDecarb is the percentage of the THC and CBD left in already vaped bud
+// that a batch is assumed to end up holding. A device has decarboxylated
+// nearly all of it on the way through, so what is lost is mostly lost to the
+// infusion: to the pan, the strainer and the plant matter thrown away.
+const DefaultDecarb = 80
+
+// Estimate is what a batch made from AVB is reckoned to hold. Nobody weighs
+// milligrams at home, so this is arithmetic on the labels and the devices, and
+// it is only as good as both.
+type Estimate struct {
+	Grams   float64  // grams of AVB that went in
+	Yield   float64  // grams of batch that came out
+	THC     float64  // milligrams in the whole batch
+	CBD     float64  // milligrams in the whole batch
+	Unknown []string // products whose AVB there was nothing to reckon by
+}
+
+// Known reports whether every gram that went in could be reckoned. A batch
+// estimated from some of its AVB would read weaker than it is, which is the
+// wrong way to be wrong about something eaten.
+func (e Estimate) Known() bool { return len(e.Unknown) == 0 && e.Yield > 0 }
+
+// PerGram returns the milligrams of THC and CBD in a gram of the batch.
+func (e Estimate) PerGram() (thc, cbd float64) {
+	if e.Yield <= 0 {
+		return 0, 0
+	}
+	return Round(e.THC / e.Yield), Round(e.CBD / e.Yield)
+}
+
+// Residual returns the share of a product's THC and CBD still in its AVB: what
+// the devices its sessions went through are assumed to have left behind, by
+// their efficiencies, weighted by the grams put through each. It reports false
+// when none of its sessions went through a device with an efficiency.
+func (p Potency) Residual(events []journal.Event, product string) (float64, bool) {
+	var grams, extracted float64
+	for _, e := range Standing(events) {
+		if e.Type != journal.Sesh || e.Product != product {
+			continue
+		}
+		if eff := p.Efficiency[e.Device]; eff > 0 && e.Device != "" {
+			grams += e.Grams
+			extracted += e.Grams * float64(eff) / 100
+		}
+	}
+	if grams <= 0 {
+		return 0, false
+	}
+	return 1 - extracted/grams, true
+}
+
+// Batch estimates the batch made from the AVB the uses drew down, yielding
+// grams of it. decarb is the percentage of what the AVB held that the batch
+// takes up, DefaultDecarb when zero. residual is the percentage of the label's
+// THC and CBD the AVB still holds; zero reads it from the devices, by
+// Residual, and a product whose devices have no efficiency is Unknown.
+//
+// Vaping takes weight off along with the cannabinoids, so a gram of AVB is
+// more than a gram of the bud it was: the grams are scaled back up by the
+// product's own yield, the AVB collected per gram seshed, where it has one.
+func (p Potency) Batch(events, uses []journal.Event, yield float64, decarb, residual int) Estimate {
+	if decarb <= 0 {
+		decarb = DefaultDecarb
+	}
+	est := Estimate{Yield: yield}
+	for _, u := range uses {
+		est.Grams = Round(est.Grams + u.Grams)
+		thc, cbd := p.THC[u.Product], p.CBD[u.Product]
+		left, ok := float64(residual)/100, residual > 0
+		if !ok {
+			left, ok = p.Residual(events, u.Product)
+		}
+		if thc == 0 && cbd == 0 || !ok {
+			if !slices.Contains(est.Unknown, u.Product) {
+				est.Unknown = append(est.Unknown, u.Product)
+			}
+			continue
+		}
+		bud := u.Grams
+		if ratio := productYield(events, u.Product); ratio > 0 && ratio < 1 {
+			bud = u.Grams / ratio
+		}
+		share := left * float64(decarb) / 100
+		// A gram at 22% held 220mg before the device had any of it.
+		est.THC = Round(est.THC + bud*thc*10*share)
+		est.CBD = Round(est.CBD + bud*cbd*10*share)
+	}
+	return est
+}
+
+// productYield is the AVB collected per gram seshed of one product, over its
+// standing sessions and collections, or 0 when it has neither.
+func productYield(events []journal.Event, product string) float64 {
+	var y Yield
+	for _, e := range yielding(events) {
+		if e.Product == product {
+			count(&y, e)
+		}
+	}
+	if y.Seshed <= 0 {
+		return 0
+	}
+	return y.Collected / y.Seshed
+}
+
+// Ingredients returns the standing AVB uses that went into a batch.
+func Ingredients(events []journal.Event, batch string) []journal.Event {
+	var out []journal.Event
+	for _, e := range Standing(events) {
+		if e.Type == journal.AVBUse && e.Into == batch {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Break is a tolerance break as the journal declared it, and how it went.
+type Break struct {
+	Event journal.Event // the break entry
+	Start time.Time     // when it was declared to begin
+	End   time.Time     // Days after Start, when it is over
+	// Lapses are the standing sessions and grinds recorded during it. A break
+	// with lapses is still a break, only not a kept one.
+	Lapses []journal.Event
+}
+
+// Days returns the days the break was planned to last.
+func (b Break) Days() int { return b.Event.Days }
+
+// Kept reports whether nothing was seshed or ground during the break.
+func (b Break) Kept() bool { return len(b.Lapses) == 0 }
+
+// On reports whether a moment falls within the break.
+func (b Break) On(t time.Time) bool { return !t.Before(b.Start) && t.Before(b.End) }
+
+// Day returns which day of the break a moment falls on, counting from one.
+func (b Break) Day(t time.Time) int {
+	return daysApart(Midnight(b.Start), Midnight(t.In(b.Start.Location()))) + 1
+}
+
+// Left returns the whole days of the break still to come after the day of
+// now, or 0 once it is over.
+func (b Break) Left(now time.Time) int {
+	if !b.On(now) {
+		return 0
+	}
+	return max(b.Days()-b.Day(now), 0)
+}
+
+// Breaks returns the standing breaks, earliest first, each with the sessions
+// and grinds that lapsed it. A reverted break is no break at all, and a
+// reverted session no lapse.
+func Breaks(events []journal.Event) []Break {
+	standing := Standing(events)
+	var out []Break
+	for _, e := range standing {
+		if e.Type == journal.Break {
+			out = append(out, Break{Event: e, Start: e.OccurredAt, End: e.OccurredAt.AddDate(0, 0, e.Days)})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	for _, e := range standing {
+		if !lapses(e) {
+			continue
+		}
+		for i := range out {
+			if out[i].On(e.OccurredAt) {
+				out[i].Lapses = append(out[i].Lapses, e)
+			}
+		}
+	}
+	return out
+}
+
+// BreakAt returns the break a moment falls within, the latest declared where
+// two overlap.
+func BreakAt(events []journal.Event, at time.Time) (Break, bool) {
+	breaks := Breaks(events)
+	for i := len(breaks) - 1; i >= 0; i-- {
+		if breaks[i].On(at) {
+			return breaks[i], true
+		}
+	}
+	return Break{}, false
+}
+
+// OnBreak returns the days any standing break covers, keyed as Daily keys
+// them, through the day of now: a break declared for the weeks ahead has not
+// paused anything yet.
+func OnBreak(events []journal.Event, now time.Time) map[string]bool {
+	days := map[string]bool{}
+	for _, b := range Breaks(events) {
+		for d := Midnight(b.Start); d.Before(b.End) && !d.After(now); d = d.AddDate(0, 0, 1) {
+			days[d.Format(time.DateOnly)] = true
+		}
+	}
+	return days
+}
+
+// lapses reports whether an entry breaks a break: a session or a grind, the
+// two ways grams head towards being consumed.
+func lapses(e journal.Event) bool { return e.Type == journal.Sesh || e.Type == journal.Grind }
+
+// Streak returns the whole days since the last standing session or grind, up
+// to the day of now, and when that was. A session today is a streak of
+// nothing; one yesterday, of a day. With neither in the journal there is no
+// streak to count, and the time is zero.
+func Streak(events []journal.Event, now time.Time) (int, time.Time) {
+	var last time.Time
+	for _, e := range Standing(events) {
+		if lapses(e) && !e.OccurredAt.After(now) && e.OccurredAt.After(last) {
+			last = e.OccurredAt
+		}
+	}
+	if last.IsZero() {
+		return 0, last
+	}
+	return max(daysApart(Midnight(last.In(now.Location())), Midnight(now)), 0), last
+}
+
+// Comparison is what a measure counted a day before a break and after it,
+// over as many days as the break was planned to last.
+type Comparison struct {
+	Break
+	Before    float64 // grams a day in the days before the break
+	After     float64 // grams a day in the days after it, so far
+	AfterDays int     // how many of the days after it have passed; 0 while it runs
+}
+
+// Changed returns the change from before to after as a share of before, and
+// false where either side has nothing to compare.
+func (c Comparison) Changed() (float64, bool) {
+	if c.Before <= 0 || c.AfterDays == 0 {
+		return 0, false
+	}
+	return Round((c.After - c.Before) / c.Before), true
+}
+
+// Compare reads the standing events the measure counts in a window as long
+// as the break on either side of it. The window after the break is only as
+// long as now allows, so a break that ended last week is compared on a week.
+func (b Break) Compare(m Measure, events []journal.Event, now time.Time) Comparison {
+	c := Comparison{Break: b}
+	window := b.Days()
+	from := b.Start.AddDate(0, 0, -window)
+	until := b.End.AddDate(0, 0, window)
+	if now.Before(until) {
+		until = now
+	}
+	if until.After(b.End) {
+		c.AfterDays = min(max(int(until.Sub(b.End).Hours()/24), 1), window)
+	}
+	var before, after float64
+	for _, e := range Standing(events) {
+		if !m.Counts(e) {
+			continue
+		}
+		switch at := e.OccurredAt; {
+		case !at.Before(from) && at.Before(b.Start):
+			before += e.Grams
+		case !at.Before(b.End) && at.Before(until):
+			after += e.Grams
+		}
+	}
+	c.Before = Round(before / float64(window))
+	if c.AfterDays > 0 {
+		c.After = Round(after / float64(c.AfterDays))
+	}
+	return c
+}
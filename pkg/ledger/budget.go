@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"slices"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Budget is the grams the shelf held when a cycle opened, spread evenly over
+// the days until a date, and how the grinding since measures up against it.
+//
+// What the shelf held is counted back from what it holds now: the cycle's own
+// share of the jars, what older cycles still have standing in them, and what
+// has been ground since. A jar weighed down by a reconciliation is not
+// counted back, because those grams were never there to budget.
+type Budget struct {
+	Start     time.Time // the day the cycle opened, at midnight
+	Until     time.Time // the last day budgeted for, at midnight
+	Fill      float64   // the cycle's own grams still in storage
+	Carried   float64   // older cycles' grams still in storage
+	Ground    float64   // ground from Start on
+	Allowance float64   // grams a day that last from Start through Until
+	Days      []BudgetDay
+}
+
+// BudgetDay is one day of a budget.
+type BudgetDay struct {
+	Day    time.Time
+	Ground float64
+	// Balance is the allowance to date less what was ground to date: ahead of
+	// the budget when positive, behind it when negative.
+	Balance float64
+}
+
+// Shelf returns the grams in storage now.
+func (b Budget) Shelf() float64 { return Round(b.Fill + b.Carried) }
+
+// Total returns the grams budgeted: what the shelf held when the cycle opened.
+func (b Budget) Total() float64 { return Round(b.Shelf() + b.Ground) }
+
+// Balance returns the balance of the last day so far, or 0 before the first.
+func (b Budget) Balance() float64 {
+	if len(b.Days) == 0 {
+		return 0
+	}
+	return b.Days[len(b.Days)-1].Balance
+}
+
+// Span returns the days budgeted for, from Start through Until.
+func (b Budget) Span() int { return max(daysApart(b.Start, b.Until)+1, 0) }
+
+// DaysLeft returns the days from now through Until, counting today.
+func (b Budget) DaysLeft(now time.Time) int {
+	return max(daysApart(Midnight(now), b.Until)+1, 0)
+}
+
+// FromHere returns the grams a day the shelf allows from now through Until,
+// or 0 once the date has passed.
+func (b Budget) FromHere(now time.Time) float64 {
+	days := b.DaysLeft(now)
+	if days <= 0 {
+		return 0
+	}
+	return Round(b.Shelf() / float64(days))
+}
+
+// Budget spreads what the shelf held when the cycle opened over the days from
+// its opening through until, and lays the days up to now against it.
+func (s *State) Budget(c *Cycle, until, now time.Time) Budget {
+	b := Budget{Start: Midnight(c.Start), Until: Midnight(until), Fill: s.FillOnShelf(c)}
+	b.Carried, _, _ = s.CarriedOnShelf(c)
+
+	perDay := map[string]float64{}
+	for _, e := range Standing(c.Events) {
+		if e.Type == journal.Grind && !e.OccurredAt.Before(b.Start) {
+			day := e.OccurredAt.Format(time.DateOnly)
+			perDay[day] = Round(perDay[day] + e.Grams)
+			b.Ground = Round(b.Ground + e.Grams)
+		}
+	}
+	days := b.Span()
+	if days <= 0 {
+		return b
+	}
+	b.Allowance = Round(b.Total() / float64(days))
+
+	last := Midnight(now)
+	if last.After(b.Until) {
+		last = b.Until
+	}
+	var ground float64
+	for day, n := b.Start, 1; !day.After(last); day, n = day.AddDate(0, 0, 1), n+1 {
+		grams := perDay[day.Format(time.DateOnly)]
+		ground += grams
+		b.Days = append(b.Days, BudgetDay{
+			Day:     day,
+			Ground:  grams,
+			Balance: Round(b.Total()*float64(n)/float64(days) - ground),
+		})
+	}
+	return b
+}
+
+// NextFill estimates when the next prescription will be filled: the latest
+// cycle's opening plus the median time between openings. It returns the zero
+// time until there are two cycles to measure between.
+func (s *State) NextFill() time.Time {
+	if len(s.Cycles) < 2 {
+		return time.Time{}
+	}
+	starts := make([]time.Time, 0, len(s.Cycles))
+	for _, c := range s.Cycles {
+		starts = append(starts, Midnight(c.Start))
+	}
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+	gaps := make([]float64, 0, len(starts)-1)
+	for i := 1; i < len(starts); i++ {
+		gaps = append(gaps, float64(daysApart(starts[i-1], starts[i])))
+	}
+	slices.Sort(gaps)
+	return starts[len(starts)-1].AddDate(0, 0, int(median(gaps)+0.5))
+}
+
+// Midnight returns the start of a time's day, in its own zone.
+func Midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// daysApart counts the calendar days from one midnight to another, rounding
+// away the hour a daylight-saving change adds or takes.
+func daysApart(from, to time.Time) int {
+	return int(to.Sub(from).Round(24*time.Hour).Hours() / 24)
+}
@@ -0,0 +1,161 @@
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// ErrStaleCheckpoint is returned when a checkpoint does not describe a prefix
+// of the journal it is being resumed against.
+var ErrStaleCheckpoint = errors.New("the checkpoint does not describe this journal")
+
+// checkpointVersion is the shape of an encoded checkpoint. A checkpoint of any
+// other version is stale, however well its tip matches: it is cheaper to fold
+// again than to migrate a cache.
+const checkpointVersion = 4
+
+// checkpoint is a fold paused after its first Seq events. It carries
+// everything the replay needs to carry on — balances, cycles, lots and the
+// folder's running accounts — but not the events themselves: those are in the
+// journal, and a cycle's share of them is a run of the patient's, recorded as
+// a count.
+type checkpoint struct {
+	Version  int                  `json:"version"`
+	Seq      int                  `json:"seq"`
+	Tip      string               `json:"tip"`
+	Patient  string               `json:"patient,omitempty"`
+	Balances map[string]*Balance  `json:"balances"`
+	Cycles   []Cycle              `json:"cycles"`
+	Tenures  []int                `json:"tenures"`
+	Lots     map[string][]lotMark `json:"lots"`
+	Share    []float64            `json:"share"`
+	Last     map[string]int       `json:"last"`
+	Current  int                  `json:"current"`
+}
+
+// lotMark is a lot as it is written down.
+type lotMark struct {
+	Cycle     int       `json:"cycle"`
+	Grams     float64   `json:"grams"`
+	Bought    time.Time `json:"bought"`
+	Purchased float64   `json:"purchased,omitempty"`
+	Entry     string    `json:"entry,omitempty"`
+}
+
+// Checkpoint encodes the state so that a later fold can resume from it rather
+// than replay the whole journal again. Only a fold in Recorded order can be
+// checkpointed. It is a cache and nothing more: Resume
+// refuses one that does not match the journal, and the answer to a refused
+// checkpoint is always to fold from the start.
+func (s *State) Checkpoint() ([]byte, error) {
+	if s.order != Recorded {
+		// An entry backdated tomorrow lands somewhere inside this fold
+		// rather than after it, and nothing resumes from the middle.
+		return nil, errors.New("only a fold in journal order can be checkpointed")
+	}
+	cp := checkpoint{
+		Version:  checkpointVersion,
+		Seq:      len(s.journal),
+		Tip:      s.Tip(),
+		Patient:  s.Patient,
+		Balances: s.Balances,
+		Cycles:   make([]Cycle, len(s.Cycles)),
+		Tenures:  make([]int, len(s.Cycles)),
+		Lots:     map[string][]lotMark{},
+		Share:    s.fold.share,
+		Last:     s.fold.last,
+		Current:  s.fold.cur,
+	}
+	for i, c := range s.Cycles {
+		cp.Tenures[i] = len(c.Events)
+		c.Events = nil
+		cp.Cycles[i] = c
+	}
+	for slug, q := range s.lots {
+		marks := make([]lotMark, len(q))
+		for i, l := range q {
+			marks[i] = lotMark{Cycle: l.cycle, Grams: l.grams, Bought: l.bought, Purchased: l.purchased, Entry: l.entry}
+		}
+		cp.Lots[slug] = marks
+	}
+	return json.Marshal(cp)
+}
+
+// Resume restores a checkpoint against the whole journal and folds whatever
+// was appended after it. The checkpoint must describe a prefix of events: its
+// tip is the hash of the last event it folded, and the next event must chain
+// onto it. Anything else is ErrStaleCheckpoint.
+func Resume(data []byte, events []journal.Event) (*State, error) {
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStaleCheckpoint, err)
+	}
+	if err := cp.matches(events); err != nil {
+		return nil, err
+	}
+
+	mine := OfPatient(events[:cp.Seq], cp.Patient)
+	s := &State{
+		Balances: cp.Balances, Events: mine[:len(mine):len(mine)], Cycles: cp.Cycles,
+		Patient: cp.Patient, journal: events, lots: map[string][]lot{},
+	}
+	if s.Balances == nil {
+		s.Balances = map[string]*Balance{}
+	}
+	// Each cycle's events are the run of the patient's entries recorded
+	// during its tenure, and the runs are contiguous: whatever precedes the
+	// first fill belongs to no cycle. The slices are capped so that folding
+	// on appends to a copy instead of writing over the next cycle's run.
+	at := len(mine)
+	for _, n := range cp.Tenures {
+		at -= n
+	}
+	if at < 0 {
+		return nil, fmt.Errorf("%w: its cycles hold more events than it folded", ErrStaleCheckpoint)
+	}
+	for i, n := range cp.Tenures {
+		s.Cycles[i].Events = mine[at : at+n : at+n]
+		at += n
+	}
+	for slug, marks := range cp.Lots {
+		q := make([]lot, len(marks))
+		for i, m := range marks {
+			q[i] = lot{cycle: m.Cycle, grams: m.Grams, bought: m.Bought, purchased: m.Purchased, entry: m.Entry}
+		}
+		s.lots[slug] = q
+	}
+	last := cp.Last
+	if last == nil {
+		last = map[string]int{}
+	}
+	s.fold = &folder{s: s, share: cp.Share, last: last, cur: cp.Current}
+
+	for _, e := range events[cp.Seq:] {
+		s.fold.step(e)
+	}
+	return s, nil
+}
+
+// matches checks that the checkpoint is a prefix of the events, and is whole
+// enough to resume from.
+func (cp checkpoint) matches(events []journal.Event) error {
+	switch {
+	case cp.Version != checkpointVersion:
+		return fmt.Errorf("%w: version %d, expected %d", ErrStaleCheckpoint, cp.Version, checkpointVersion)
+	case cp.Seq < 0 || cp.Seq > len(events):
+		return fmt.Errorf("%w: it folded %d events, the journal holds %d", ErrStaleCheckpoint, cp.Seq, len(events))
+	case cp.Seq > 0 && events[cp.Seq-1].Hash != cp.Tip:
+		return fmt.Errorf("%w: event %d is not its tip", ErrStaleCheckpoint, cp.Seq)
+	case cp.Seq < len(events) && events[cp.Seq].Prev != cp.Tip:
+		return fmt.Errorf("%w: event %d does not chain onto its tip", ErrStaleCheckpoint, cp.Seq+1)
+	case len(cp.Tenures) != len(cp.Cycles) || len(cp.Share) != len(cp.Cycles):
+		return fmt.Errorf("%w: its cycles do not add up", ErrStaleCheckpoint)
+	case cp.Current < -1 || cp.Current >= len(cp.Cycles):
+		return fmt.Errorf("%w: its current cycle does not exist", ErrStaleCheckpoint)
+	}
+	return nil
+}
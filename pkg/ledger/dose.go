@@ -0,0 +1,121 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Potency is what the catalogs say about strength: the THC and CBD percentage
+// of each product, and the percentage each device is assumed to extract. The
+// ledger reads only the journal, so whoever holds the catalogs fills this in.
+type Potency struct {
+	THC        map[string]float64 // percent, by product slug
+	CBD        map[string]float64 // percent, by product slug
+	Efficiency map[string]int     // percent, by device slug; absent when not assumed
+}
+
+// Dose is the milligrams of THC and CBD in the material put through a device,
+// and the share of them the devices are assumed to have extracted.
+//
+// Grams of products of different strength are not comparable; milligrams are
+// what a physician doses in. What was put through is arithmetic on the label.
+// What was extracted rests on the devices' efficiencies, and covers only the
+// grams seshed through a device that has one.
+type Dose struct {
+	Sessions     int
+	Grams        float64 // grams seshed
+	Unknown      float64 // grams of products with no THC or CBD on record
+	THC          float64 // milligrams in what was put through
+	CBD          float64
+	Assumed      float64 // grams seshed through a device with an efficiency
+	ExtractedTHC float64 // milligrams assumed extracted from Assumed
+	ExtractedCBD float64
+}
+
+// Extracted reports whether any of the dose was seshed through a device with
+// an efficiency, so that there is an extraction to speak of.
+func (d Dose) Extracted() bool { return d.Assumed > 0 }
+
+// add counts one session into the dose.
+func (d *Dose) add(e journal.Event, p Potency) {
+	d.Sessions++
+	d.Grams = Round(d.Grams + e.Grams)
+	thc, cbd := p.THC[e.Product], p.CBD[e.Product]
+	if thc == 0 && cbd == 0 {
+		d.Unknown = Round(d.Unknown + e.Grams)
+		return
+	}
+	// A gram at 22% holds 220mg.
+	mgTHC, mgCBD := e.Grams*thc*10, e.Grams*cbd*10
+	d.THC = Round(d.THC + mgTHC)
+	d.CBD = Round(d.CBD + mgCBD)
+	if eff := p.Efficiency[e.Device]; eff > 0 && e.Device != "" {
+		d.Assumed = Round(d.Assumed + e.Grams)
+		d.ExtractedTHC = Round(d.ExtractedTHC + mgTHC*float64(eff)/100)
+		d.ExtractedCBD = Round(d.ExtractedCBD + mgCBD*float64(eff)/100)
+	}
+}
+
+// Session returns the dose of one session or portion, or an empty dose for
+// any other entry.
+func (p Potency) Session(e journal.Event) Dose {
+	var d Dose
+	if e.Type == journal.Sesh || e.Type == journal.Portion {
+		d.add(e, p)
+	}
+	return d
+}
+
+// Eaten totals the standing portions of batches the way Dose totals sessions:
+// Sessions counts the portions and Grams their weight. Nothing is extracted,
+// since a batch's potency is already an estimate of what it holds, and a batch
+// with no estimate is Unknown.
+func (p Potency) Eaten(events []journal.Event) Dose {
+	var d Dose
+	for _, e := range Standing(events) {
+		if e.Type == journal.Portion {
+			d.add(e, p)
+		}
+	}
+	return d
+}
+
+// Dose totals the standing sessions among events. Corrected sessions are left
+// out along with their corrections.
+func (p Potency) Dose(events []journal.Event) Dose {
+	var d Dose
+	for _, e := range Standing(events) {
+		if e.Type == journal.Sesh {
+			d.add(e, p)
+		}
+	}
+	return d
+}
+
+// DoseDay is the dose of one calendar day.
+type DoseDay struct {
+	Day time.Time // midnight, in the entries' own zone
+	Dose
+}
+
+// PerDay totals the standing sessions of each calendar day, the earliest
+// first. Days without a session are left out.
+func (p Potency) PerDay(events []journal.Event) []DoseDay {
+	var out []DoseDay
+	index := map[string]int{}
+	for _, e := range Chronological(Standing(events)) {
+		if e.Type != journal.Sesh {
+			continue
+		}
+		key := e.OccurredAt.Format(time.DateOnly)
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, DoseDay{Day: Midnight(e.OccurredAt)})
+		}
+		out[i].add(e, p)
+	}
+	return out
+}
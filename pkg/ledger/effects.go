@@ -0,0 +1,170 @@
+package ledger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// minPairs is how many feels with a temperature a correlation needs before it
+// is worth a number. Two points always lie on a line.
+const minPairs = 3
+
+// Scored is how the feels of one group scored: per symptom, the mean score
+// and how many feels it is the mean of. Not every feel scores every symptom.
+type Scored struct {
+	Key    string // a product or device slug; empty for no device, and for a band
+	Band   int    // see Band, for a temperature band
+	Feels  int
+	Mean   map[string]float64
+	Counts map[string]int
+}
+
+// Effects are the standing feels read against the sessions they follow: the
+// evidence a prescriber asks for when a product or a setting is said to help.
+// They are averages of what was written down, not a trial; a group of two
+// feels says little, and the counts are kept so that a reader can tell.
+type Effects struct {
+	All         Scored // every standing feel, with a session or without
+	Alone       int    // feels that follow no session, in All but in no group
+	Product     []Scored
+	Device      []Scored
+	Temperature []Scored // by Band, coolest first
+	// Heat is the correlation, from -1 to 1, between a session's temperature
+	// and each symptom's score after it, where there are enough feels.
+	Heat map[string]float64
+}
+
+// EffectsOf reads the standing feels against the sessions they follow.
+// Corrected feels are left out, and so is the session of a feel whose session
+// was corrected: the feel still counts, but as one that follows nothing.
+func EffectsOf(events []journal.Event) Effects {
+	standing := Standing(events)
+	sessions := map[string]journal.Event{}
+	for _, e := range standing {
+		if e.Type == journal.Sesh {
+			sessions[e.Hash] = e
+		}
+	}
+
+	all := &scored{}
+	products, devices, bands := map[string]*scored{}, map[string]*scored{}, map[string]*scored{}
+	group := func(groups map[string]*scored, key string, as Scored) *scored {
+		g, ok := groups[key]
+		if !ok {
+			g = &scored{Scored: as}
+			groups[key] = g
+		}
+		return g
+	}
+	temps, scores := map[string][]float64{}, map[string][]float64{}
+	effects := Effects{}
+	for _, e := range standing {
+		if e.Type != journal.Feel {
+			continue
+		}
+		all.add(e.Scores)
+		s, ok := sessions[e.Session]
+		if !ok {
+			effects.Alone++
+			continue
+		}
+		group(products, s.Product, Scored{Key: s.Product}).add(e.Scores)
+		group(devices, s.Device, Scored{Key: s.Device}).add(e.Scores)
+		if band := Band(s.Temperature); band > 0 {
+			group(bands, fmt.Sprint(band), Scored{Band: band}).add(e.Scores)
+			for name, score := range e.Scores {
+				temps[name] = append(temps[name], float64(s.Temperature))
+				scores[name] = append(scores[name], float64(score))
+			}
+		}
+	}
+
+	effects.All = all.averaged()
+	effects.Product = sortedScored(products)
+	effects.Device = sortedScored(devices)
+	effects.Temperature = sortedScored(bands)
+	for name := range temps {
+		if len(temps[name]) < minPairs {
+			continue
+		}
+		if r, ok := pearson(temps[name], scores[name]); ok {
+			if effects.Heat == nil {
+				effects.Heat = map[string]float64{}
+			}
+			effects.Heat[name] = r
+		}
+	}
+	return effects
+}
+
+// scored sums a group's scores until they are averaged.
+type scored struct {
+	Scored
+	sums map[string]int
+}
+
+func (g *scored) add(scores journal.Scores) {
+	if g.sums == nil {
+		g.sums, g.Counts = map[string]int{}, map[string]int{}
+	}
+	g.Feels++
+	for name, score := range scores {
+		g.sums[name] += score
+		g.Counts[name]++
+	}
+}
+
+// averaged returns the group with its sums averaged.
+func (g *scored) averaged() Scored {
+	out := g.Scored
+	out.Mean = map[string]float64{}
+	for name, sum := range g.sums {
+		out.Mean[name] = Round(float64(sum) / float64(g.Counts[name]))
+	}
+	return out
+}
+
+// sortedScored averages each group, the groups with the most feels first;
+// temperature bands, which have no key, coolest first.
+func sortedScored(groups map[string]*scored) []Scored {
+	out := make([]Scored, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, g.averaged())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Band != out[j].Band {
+			return out[i].Band < out[j].Band
+		}
+		if out[i].Feels != out[j].Feels {
+			return out[i].Feels > out[j].Feels
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// pearson returns the correlation coefficient of two runs of values, and
+// false where either does not vary, which leaves it undefined.
+func pearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return Round(sxy / math.Sqrt(sxx*syy)), true
+}
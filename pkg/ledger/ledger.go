@@ -1,7 +1,9 @@
 package ledger
 
 import (
+	"cmp"
 	"math"
+	"slices"
 	"sort"
 	"time"
 
@@ -15,24 +17,84 @@ type Balance struct {
 	Stash    float64
 	Consumed float64
 	AVB      float64
+
+	// Placed is how much of the storage and of the stash sits in each named
+	// location. Whatever of them no location holds is at home.
+	Placed map[journal.Account]map[string]float64 `json:",omitempty"`
 }
 
 // Total returns the grams of this product still held, that is everything that
 // has not yet gone through a device.
 func (b Balance) Total() float64 { return Round(b.Storage + b.Stash) }
 
-// State is the result of replaying a journal.
+// In returns what one location of an account holds; the empty location is
+// home, and holds what the named ones do not.
+func (b Balance) In(account journal.Account, location string) float64 {
+	if location != "" {
+		return b.Placed[account][location]
+	}
+	home := held(&b, account)
+	for _, g := range b.Placed[account] {
+		home -= g
+	}
+	return Round(home)
+}
+
+// Locations returns the named locations holding some of an account, by name.
+func (b Balance) Locations(account journal.Account) []string {
+	var out []string
+	for location, g := range b.Placed[account] {
+		if g != 0 {
+			out = append(out, location)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// State is the result of replaying a journal for one patient.
 type State struct {
 	Balances map[string]*Balance
-	Events   []journal.Event
+	Events   []journal.Event // the patient's entries, in the order folded
 	Cycles   []Cycle
 
+	// Patient is whose state it is: empty for a journal that keeps no
+	// patients apart, or for the entries recorded without one.
+	Patient string
+
+	// journal is every entry the fold was given, the other patients' too.
+	// Nothing is counted from it; it is what the next entry chains onto.
+	journal []journal.Event
+
 	// lots is each product's jar split into the fills that stocked it,
 	// oldest first. It is how a gram in storage stays on the account of the
 	// cycle that dispensed it, and how a cycle knows when it is empty.
 	lots map[string][]lot
+
+	// fold is the replay's running bookkeeping, kept so that a checkpoint of
+	// the state can pick up exactly where the replay stopped.
+	fold *folder
+
+	// order is the order the events were replayed in.
+	order Order
 }
 
+// Order is the order a fold replays the journal in.
+type Order int
+
+const (
+	// Recorded replays entries in journal order, the order they were typed
+	// in. It is the order the hash chain runs in, and the only one a fold
+	// can be checkpointed and resumed in: entries are only ever added at
+	// its end.
+	Recorded Order = iota
+	// Occurred replays entries in the order they happened, ties broken by
+	// journal order. An evening logged the next morning with --date lands
+	// in the cycle it happened in and draws on the lot that was on the
+	// shelf that evening, not on whatever was bought since.
+	Occurred
+)
+
 // CycleGap is how long after a cycle opened a further purchase still counts as
 // part of the same fill.
 //
@@ -92,8 +154,11 @@ func (c Cycle) PurchasedOf(slug string) float64 {
 // so the ledger says the oldest do: grinds consume lots first-in-first-out,
 // and each cycle's claim shrinks in the order it was dispensed.
 type lot struct {
-	cycle int
-	grams float64
+	cycle     int
+	grams     float64
+	bought    time.Time
+	purchased float64 // zero for grams a reconciliation found in an empty jar
+	entry     string  // the purchase's hash, empty for found grams
 }
 
 // folder carries a fold's running accounts: what each cycle still holds in
@@ -135,18 +200,19 @@ func (f *folder) consume(product string, grams float64, at time.Time) {
 }
 
 // credit puts found grams back into the jar's newest lot: an upward
-// reconciliation corrects the present jar, not a bygone fill.
+// reconciliation corrects the present jar, not a bygone fill. A jar no fill
+// ever dispensed, a batch, has no lot to put them in.
 func (f *folder) credit(product string, grams float64, at time.Time) {
-	if len(f.s.Cycles) == 0 {
+	cycle, bought := f.last[product]
+	if len(f.s.Cycles) == 0 || !bought {
 		return
 	}
-	cycle := f.last[product]
 	q := f.s.lots[product]
 	if n := len(q); n > 0 {
 		cycle = q[n-1].cycle
 		q[n-1].grams = Round(q[n-1].grams + grams)
 	} else {
-		q = append(q, lot{cycle: cycle, grams: grams})
+		q = append(q, lot{cycle: cycle, grams: grams, bought: at})
 	}
 	f.s.lots[product] = q
 	f.share[cycle] = Round(f.share[cycle] + grams)
@@ -186,46 +252,145 @@ func (f *folder) purchase(e journal.Event) {
 	if !contains(s.Cycles[f.cur].Products, e.Product) {
 		s.Cycles[f.cur].Products = append(s.Cycles[f.cur].Products, e.Product)
 	}
-	s.lots[e.Product] = append(s.lots[e.Product], lot{cycle: f.cur, grams: e.Grams})
+	s.lots[e.Product] = append(s.lots[e.Product], lot{
+		cycle: f.cur, grams: e.Grams, bought: e.OccurredAt, purchased: e.Grams, entry: e.Hash,
+	})
 	f.share[f.cur] = Round(f.share[f.cur] + e.Grams)
 	f.last[e.Product] = f.cur
 }
 
-// Fold replays the events and returns the state they describe. Events are
-// folded in journal order, which is the order they were recorded in.
-func Fold(events []journal.Event) *State {
-	s := &State{Balances: map[string]*Balance{}, Events: events, lots: map[string][]lot{}}
-	f := &folder{s: s, last: map[string]int{}, cur: -1}
+// Fold replays one patient's entries among the events and returns the state
+// they describe. Events are folded in journal order, which is the order they
+// were recorded in.
+func Fold(patient string, events []journal.Event) *State { return FoldFor(Recorded, patient, events) }
+
+// FoldFor replays one patient's entries among the events in the given order.
+// The state's Events are the entries in that order, and so are each cycle's.
+//
+// Balances come out the same either way; sums do not care about order. What
+// the order decides is everything that is attributed: which cycle an entry
+// falls in, which fill's lot a grind draws down, when a cycle ran dry.
+//
+// A fold is always a single patient's, the empty one being the entries
+// recorded without a patient: the entries of any other are left out of its
+// balances, its lots, its cycles and its Events alike, so one patient's grind
+// cannot draw on another's storage however the journal interleaves them, and
+// one patient's fill never opens another's cycle.
+func FoldFor(order Order, patient string, events []journal.Event) *State {
+	all := events
+	if order == Occurred {
+		events = Chronological(events)
+	}
+	s := &State{Balances: map[string]*Balance{}, Patient: patient, journal: all, lots: map[string][]lot{}, order: order}
+	s.fold = &folder{s: s, last: map[string]int{}, cur: -1}
+	for _, e := range events {
+		s.fold.step(e)
+	}
+	return s
+}
+
+// Journal returns every entry the state was folded from, every patient's.
+func (s *State) Journal() []journal.Event { return s.journal }
 
+// Tip returns the hash of the last entry the state was folded from, whoever's
+// it was: the one the next entry has to chain onto.
+func (s *State) Tip() string {
+	if n := len(s.journal); n > 0 {
+		return s.journal[n-1].Hash
+	}
+	return ""
+}
+
+// Patients returns the patients the entries name, in alphabetical order. An
+// entry recorded without one names nobody.
+func Patients(events []journal.Event) []string {
+	seen := map[string]bool{}
+	var out []string
 	for _, e := range events {
-		b := s.balance(e.Product)
-		apply(b, e.From, -e.Grams)
-		apply(b, e.To, e.Grams)
-
-		switch e.Type {
-		case journal.Purchase:
-			f.purchase(e)
-		case journal.Grind:
-			if f.cur != -1 {
-				s.Cycles[f.cur].Ground = Round(s.Cycles[f.cur].Ground + e.Grams)
-			}
-			f.consume(e.Product, e.Grams, e.OccurredAt)
-		default:
-			// Anything else that moves grams through storage — adjustments
-			// down and up, corrections either way — settles the lots too, so
-			// a jar reconciled to zero closes its cycles' claims.
-			if e.From == journal.Storage {
-				f.consume(e.Product, e.Grams, e.OccurredAt)
-			}
-			if e.To == journal.Storage && e.Product != "" {
-				f.credit(e.Product, e.Grams, e.OccurredAt)
-			}
+		if e.Patient != "" && !seen[e.Patient] {
+			seen[e.Patient] = true
+			out = append(out, e.Patient)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// OfPatient returns the entries of one patient.
+func OfPatient(events []journal.Event, patient string) []journal.Event {
+	out := make([]journal.Event, 0, len(events))
+	for _, e := range events {
+		if e.Patient == patient {
+			out = append(out, e)
 		}
+	}
+	return out
+}
+
+// Chronological returns a copy of the events sorted by when they occurred,
+// those that occurred at the same moment kept in journal order.
+func Chronological(events []journal.Event) []journal.Event {
+	out := slices.Clone(events)
+	slices.SortStableFunc(out, func(a, b journal.Event) int {
+		if c := a.OccurredAt.Compare(b.OccurredAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+	return out
+}
+
+// step folds one event into the running state, unless it is another
+// patient's.
+func (f *folder) step(e journal.Event) {
+	s := f.s
+	if e.Patient != s.Patient {
+		return
+	}
+	s.Events = append(s.Events, e)
+	// A feel moves no grams and opens no balance, but it belongs to the cycle
+	// it was scored in like any other entry.
+	if e.Massless() {
 		if f.cur != -1 {
 			s.Cycles[f.cur].Events = append(s.Cycles[f.cur].Events, e)
 		}
+		return
+	}
+	b := s.balance(e.Product)
+	transfer(b, e)
+
+	switch e.Type {
+	case journal.Purchase:
+		f.purchase(e)
+	case journal.Grind:
+		if f.cur != -1 {
+			s.Cycles[f.cur].Ground = Round(s.Cycles[f.cur].Ground + e.Grams)
+		}
+		f.consume(e.Product, e.Grams, e.OccurredAt)
+	case journal.Move:
+		// Grams carried from the fridge to the travel tin are the same
+		// grams, on the same fill's account.
+	case journal.Batch, journal.Portion:
+		// A batch was made, not dispensed: its jar is on no fill's account,
+		// and a portion of it takes nothing from one.
+	default:
+		if e.From == e.To {
+			// The correction of a move, which carries them back.
+			break
+		}
+		// Anything else that moves grams through storage — adjustments
+		// down and up, corrections either way — settles the lots too, so
+		// a jar reconciled to zero closes its cycles' claims.
+		if e.From == journal.Storage {
+			f.consume(e.Product, e.Grams, e.OccurredAt)
+		}
+		if e.To == journal.Storage && e.Product != "" {
+			f.credit(e.Product, e.Grams, e.OccurredAt)
+		}
+	}
+	if f.cur != -1 {
+		s.Cycles[f.cur].Events = append(s.Cycles[f.cur].Events, e)
 	}
-	return s
 }
 
 // balance returns the balance record for a product, creating it on first use.
@@ -273,6 +438,19 @@ func (s *State) CurrentCycle() *Cycle {
 	return nil
 }
 
+// CycleOf returns the cycle an entry was recorded during, or nil for an entry
+// from before the first fill.
+func (s *State) CycleOf(hash string) *Cycle {
+	for i := range s.Cycles {
+		for _, e := range s.Cycles[i].Events {
+			if e.Hash == hash {
+				return &s.Cycles[i]
+			}
+		}
+	}
+	return nil
+}
+
 // ShareOf returns the grams of one product still standing on the cycle's own
 // account — the jar's balance minus whatever older or newer fills hold in it.
 func (s *State) ShareOf(c *Cycle, slug string) float64 {
@@ -316,14 +494,15 @@ func (s *State) CarriedOnShelf(c *Cycle) (grams float64, jars, cycles int) {
 	return Round(grams), jars, len(open)
 }
 
-// Stats summarises a run of events over time.
+// Stats summarises a run of events over time: the grams of whatever a Measure
+// counts, and how they spread over the days.
 //
 // The spreadsheet this replaces counted "therapy days" as the number of dated
 // rows, which included days pre-filled with a zero amount. Both readings are
 // reported here, and named, so it is clear which one a number came from.
 type Stats struct {
-	Ground        float64
-	ActiveDays    int // days with an amount actually ground
+	Grams         float64
+	ActiveDays    int // days with an amount actually counted
 	ElapsedDays   int // calendar days from the first to the last event
 	First, Last   time.Time
 	PerActiveDay  float64
@@ -340,19 +519,45 @@ func (st Stats) DaysLeft(grams float64) float64 {
 	return Round(grams / st.PerActiveDay)
 }
 
+// Measure picks the entries a summary counts: those of one type, and of those
+// only the ones moving grams out of From or into To where either is set.
+type Measure struct {
+	Type journal.Type
+	From journal.Account
+	To   journal.Account
+}
+
+// The measures the ledger reports on. Grinding is what the spreadsheet
+// recorded and what the supply projection runs on; the others are what the
+// journal has recorded since.
+var (
+	Grinding    = Measure{Type: journal.Grind}
+	Seshing     = Measure{Type: journal.Sesh, From: journal.Stash}
+	Collecting  = Measure{Type: journal.AVBCollect}
+	Adjustments = Measure{Type: journal.Adjust}
+)
+
+// Counts reports whether the measure counts an entry.
+func (m Measure) Counts(e journal.Event) bool {
+	return e.Type == m.Type && (m.From == "" || e.From == m.From) && (m.To == "" || e.To == m.To)
+}
+
 // Summarise returns the statistics for the grind events among the given events.
 // Grinding is what the spreadsheet recorded and what there is history for;
-// consumption out of the stash is tracked separately.
-func Summarise(events []journal.Event) Stats {
-	perDay := map[string]float64{}
+// consumption out of the stash is summarised with SummariseBy.
+func Summarise(events []journal.Event) Stats { return SummariseBy(Grinding, events) }
+
+// SummariseBy returns the statistics for the events the measure counts. The
+// events are taken as given: a corrected entry counts unless the caller
+// leaves it out with Standing.
+func SummariseBy(m Measure, events []journal.Event) Stats {
+	perDay := Daily(m, events)
 	var st Stats
 	for _, e := range events {
-		if e.Type != journal.Grind {
+		if !m.Counts(e) {
 			continue
 		}
-		day := e.OccurredAt.Format(time.DateOnly)
-		perDay[day] = Round(perDay[day] + e.Grams)
-		st.Ground = Round(st.Ground + e.Grams)
+		st.Grams = Round(st.Grams + e.Grams)
 		if st.First.IsZero() || e.OccurredAt.Before(st.First) {
 			st.First = e.OccurredAt
 		}
@@ -374,15 +579,171 @@ func Summarise(events []journal.Event) Stats {
 		st.ElapsedDays = int(st.Last.Sub(st.First).Hours()/24) + 1
 	}
 	if st.ActiveDays > 0 {
-		st.PerActiveDay = Round(st.Ground / float64(st.ActiveDays))
+		st.PerActiveDay = Round(st.Grams / float64(st.ActiveDays))
 		st.MedianPerDay = median(amounts)
 	}
 	if st.ElapsedDays > 0 {
-		st.PerElapsedDay = Round(st.Ground / float64(st.ElapsedDays))
+		st.PerElapsedDay = Round(st.Grams / float64(st.ElapsedDays))
 	}
 	return st
 }
 
+// Daily returns the grams the measure counts on each calendar day, keyed by
+// the date as time.DateOnly writes it. Days without any are absent.
+func Daily(m Measure, events []journal.Event) map[string]float64 {
+	perDay := map[string]float64{}
+	for _, e := range events {
+		if m.Counts(e) {
+			day := e.OccurredAt.Format(time.DateOnly)
+			perDay[day] = Round(perDay[day] + e.Grams)
+		}
+	}
+	return perDay
+}
+
+// RollingAverage returns the grams ground a day over the days days up to and
+// including the day of now. Corrected grinds are left out. A history shorter
+// than the window is averaged over the days it covers, so a week-old journal
+// does not read as half the rate it is.
+func RollingAverage(events []journal.Event, days int, now time.Time) float64 {
+	if days <= 0 {
+		return 0
+	}
+	end := Midnight(now).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -days)
+	var ground float64
+	var first time.Time
+	for _, e := range Standing(events) {
+		if e.Type != journal.Grind || !e.OccurredAt.Before(end) {
+			continue
+		}
+		if first.IsZero() || e.OccurredAt.Before(first) {
+			first = e.OccurredAt
+		}
+		if !e.OccurredAt.Before(start) {
+			ground += e.Grams
+		}
+	}
+	if first.IsZero() {
+		return 0
+	}
+	if first.After(start) {
+		days = min(days, max(daysApart(Midnight(first.In(now.Location())), end), 1))
+	}
+	return Round(ground / float64(days))
+}
+
+// Overdraft is an entry that drew an account below zero.
+type Overdraft struct {
+	Event    journal.Event
+	Account  journal.Account
+	Location string  // where in the account, empty for home
+	Balance  float64 // what the location held after the entry
+}
+
+// Overdrafts replays the events and returns every entry that took an account
+// from zero or more to below it. An account that stays negative across
+// several entries is reported once, where it went under: the entries after it
+// are not the mistake, only its consequences.
+//
+// The recorder refuses an overdraft when it is typed in, so a journal written
+// through it has none. One can still arrive by hand, by an import, or by two
+// writers working from stale balances, and it means the log has stopped
+// describing the jars.
+//
+// Each patient's balances are their own, as in FoldFor: one patient's grams
+// never cover another's withdrawal.
+func Overdrafts(events []journal.Event) []Overdraft {
+	type account struct{ patient, product string }
+	balances := map[account]*Balance{}
+	var out []Overdraft
+	for _, e := range events {
+		key := account{e.Patient, e.Product}
+		b, ok := balances[key]
+		if !ok {
+			b = &Balance{Product: e.Product}
+			balances[key] = b
+		}
+		before := b.In(e.From, e.FromLocation)
+		transfer(b, e)
+		if after := b.In(e.From, e.FromLocation); after < 0 && before >= 0 {
+			out = append(out, Overdraft{Event: e, Account: e.From, Location: e.FromLocation, Balance: after})
+		}
+	}
+	return out
+}
+
+// Shortfall is the first point at which drawing on an account would leave it
+// below zero.
+type Shortfall struct {
+	At   time.Time // when the account comes up short
+	Have float64   // what it holds there before the draw is taken off
+}
+
+// ShortfallAt asks whether drawing grams of a product from one location of an
+// account at a given time fits — not only then, but at every point after it. The product's
+// entries are replayed in the order they occurred, with the draw slotted in at
+// its own time after anything that occurred at the same moment, and the first
+// point the account would go below zero is returned; nil if there is none.
+//
+// A backdated grind is where this matters. Checked against today's balance,
+// a grind dated before the purchase that filled the jar looks fine, and a
+// grind dated before last week's sessions can take the grams they were drawn
+// from. A point the account was already below zero at is not counted: the
+// draw is not what put it there.
+//
+// The location is home for the empty one, and a draw from home cannot take
+// the grams in the travel tin: they are not in the jar it is drawn from.
+//
+// External is never short. Undoing an AVB use takes its grams back from
+// outside, which holds nothing the ledger counts and so nothing to run out of.
+func ShortfallAt(events []journal.Event, product string, account journal.Account, location string, grams float64, at time.Time) *Shortfall {
+	if account == journal.External {
+		return nil
+	}
+	var timeline []journal.Event
+	for _, e := range events {
+		if e.Product == product && (e.From == account || e.To == account) {
+			timeline = append(timeline, e)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].OccurredAt.Before(timeline[j].OccurredAt) })
+
+	b := &Balance{Product: product}
+	i := 0
+	for ; i < len(timeline) && !timeline[i].OccurredAt.After(at); i++ {
+		transfer(b, timeline[i])
+	}
+	short := func(have float64) bool { return Round(have) >= 0 && Round(have-grams) < 0 }
+	if have := b.In(account, location); short(have) {
+		return &Shortfall{At: at, Have: have}
+	}
+	for _, e := range timeline[i:] {
+		transfer(b, e)
+		if have := b.In(account, location); short(have) {
+			return &Shortfall{At: e.OccurredAt, Have: have}
+		}
+	}
+	return nil
+}
+
+// held returns what a balance holds in one account. External holds nothing
+// the ledger counts, so it can never be overdrawn.
+func held(b *Balance, account journal.Account) float64 {
+	switch account {
+	case journal.Storage:
+		return b.Storage
+	case journal.Stash:
+		return b.Stash
+	case journal.Consumed:
+		return b.Consumed
+	case journal.AVB:
+		return b.AVB
+	default:
+		return 0
+	}
+}
+
 // apply moves grams into an account on a balance. Movements to and from
 // External fall outside the tracked accounts and are ignored.
 func apply(b *Balance, account journal.Account, grams float64) {
@@ -398,6 +759,36 @@ func apply(b *Balance, account journal.Account, grams float64) {
 	}
 }
 
+// transfer applies an entry to a balance: its grams out of one account and
+// into the other, and out of and into the locations it names.
+func transfer(b *Balance, e journal.Event) {
+	apply(b, e.From, -e.Grams)
+	apply(b, e.To, e.Grams)
+	place(b, e.From, e.FromLocation, -e.Grams)
+	place(b, e.To, e.ToLocation, e.Grams)
+}
+
+// place moves grams into a named location of an account. Home is not kept
+// apart: it is whatever the named locations leave of the account, so an
+// entry at home needs nothing more than apply.
+func place(b *Balance, account journal.Account, location string, grams float64) {
+	if location == "" || !journal.Placed(account) {
+		return
+	}
+	if b.Placed == nil {
+		b.Placed = map[journal.Account]map[string]float64{}
+	}
+	if b.Placed[account] == nil {
+		b.Placed[account] = map[string]float64{}
+	}
+	g := Round(b.Placed[account][location] + grams)
+	if g == 0 {
+		delete(b.Placed[account], location)
+		return
+	}
+	b.Placed[account][location] = g
+}
+
 // median returns the median of a sorted slice.
 func median(sorted []float64) float64 {
 	n := len(sorted)
@@ -1,6 +1,8 @@
 package ledger
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -24,7 +26,7 @@ func event(typ journal.Type, product string, grams float64, at time.Time) journa
 
 func TestFold(t *testing.T) {
 	t.Run("MovesGramsBetweenAccounts", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Grind, "wedding-cake", 0.75, day(1)),
 			event(journal.Sesh, "wedding-cake", 0.5, day(1)),
@@ -40,7 +42,7 @@ func TestFold(t *testing.T) {
 	})
 
 	t.Run("ConservesMass", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Grind, "wedding-cake", 1.5, day(1)),
 			event(journal.Sesh, "wedding-cake", 1.0, day(1)),
@@ -52,7 +54,7 @@ func TestFold(t *testing.T) {
 	})
 
 	t.Run("KeepsStashesSeparate", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Purchase, "lemon-cookie", 20, day(0)),
 			event(journal.Grind, "wedding-cake", 0.75, day(1)),
@@ -66,7 +68,7 @@ func TestFold(t *testing.T) {
 	})
 
 	t.Run("ProductsAreSorted", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Purchase, "amnesia", 20, day(0)),
 			event(journal.Purchase, "lemon-cookie", 20, day(0)),
@@ -79,7 +81,7 @@ func TestFold(t *testing.T) {
 
 func TestCycles(t *testing.T) {
 	t.Run("APurchaseIntoAnEmptyStorageOpensACycle", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 2, day(0)),
 			event(journal.Grind, "wedding-cake", 1, day(1)),
 		})
@@ -92,7 +94,7 @@ func TestCycles(t *testing.T) {
 	})
 
 	t.Run("SeveralProductsInOneFillAreOneCycle", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Purchase, "lemon-cookie", 20, day(0)),
 			event(journal.Purchase, "mac1", 20, day(0)),
@@ -104,7 +106,7 @@ func TestCycles(t *testing.T) {
 	})
 
 	t.Run("ClosesWhenItsOwnJarsAreEmpty", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 2, day(0)),
 			event(journal.Grind, "wedding-cake", 1, day(1)),
 			event(journal.Grind, "wedding-cake", 1, day(2)),
@@ -117,7 +119,7 @@ func TestCycles(t *testing.T) {
 	})
 
 	t.Run("TheNextFillOpensTheNextCycle", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 1, day(0)),
 			event(journal.Grind, "wedding-cake", 1, day(1)),
 			event(journal.Purchase, "lemon-cookie", 1, day(30)),
@@ -129,7 +131,7 @@ func TestCycles(t *testing.T) {
 	})
 
 	t.Run("ATopUpJoinsTheRunningCycle", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 10, day(0)),
 			event(journal.Grind, "wedding-cake", 1, day(1)),
 			event(journal.Purchase, "lemon-cookie", 5, day(2)),
@@ -149,7 +151,7 @@ func TestCycles(t *testing.T) {
 			)
 		}
 
-		s := Fold(events)
+		s := Fold("", events)
 
 		require.Len(t, s.Cycles, 64, "Should record every cycle")
 		for i, c := range s.Cycles {
@@ -161,6 +163,43 @@ func TestCycles(t *testing.T) {
 	})
 }
 
+func TestBudget(t *testing.T) {
+	s := Fold("", []journal.Event{
+		event(journal.Purchase, "wedding-cake", 10, day(0)),
+		event(journal.Grind, "wedding-cake", 5, day(1)),
+		event(journal.Purchase, "lemon-cookie", 20, day(10)),
+		event(journal.Grind, "lemon-cookie", 2, day(10)),
+		event(journal.Grind, "lemon-cookie", 1, day(12)),
+	})
+	c := s.CurrentCycle()
+	require.NotNil(t, c)
+	b := s.Budget(c, day(19), day(12))
+
+	t.Run("CountsTheWholeShelf", func(t *testing.T) {
+		assert.Equal(t, 17.0, b.Fill, "Should count the fill's own share")
+		assert.Equal(t, 5.0, b.Carried, "Should count what the older cycle still has standing")
+		assert.Equal(t, 25.0, b.Total(), "Should budget what the shelf held when the cycle opened")
+		assert.Equal(t, 2.5, b.Allowance, "Should spread it over the ten days through the date")
+	})
+
+	t.Run("LaysEachDayAgainstIt", func(t *testing.T) {
+		require.Len(t, b.Days, 3, "Should cover the days from the opening to today")
+		assert.Equal(t, 0.5, b.Days[0].Balance, "Should be a little ahead after the first day")
+		assert.Equal(t, 3.0, b.Days[1].Balance, "Should gain a day's allowance on a day without grinding")
+		assert.Equal(t, 4.5, b.Balance(), "Should end on today's balance")
+	})
+
+	t.Run("SaysWhatTheRestAllows", func(t *testing.T) {
+		assert.Equal(t, 8, b.DaysLeft(day(12)), "Should count today among the days left")
+		assert.Equal(t, 2.75, b.FromHere(day(12)), "Should spread the shelf over the days left")
+	})
+
+	t.Run("NextFill", func(t *testing.T) {
+		assert.True(t, s.NextFill().Equal(Midnight(day(20))), "Should expect the next fill as far on as the last one")
+		assert.True(t, Fold("", nil).NextFill().IsZero(), "Should not guess without two fills")
+	})
+}
+
 func TestSummarise(t *testing.T) {
 	t.Run("AveragesOverDaysWithAnAmount", func(t *testing.T) {
 		st := Summarise([]journal.Event{
@@ -170,7 +209,7 @@ func TestSummarise(t *testing.T) {
 			event(journal.Grind, "wedding-cake", 1.5, day(2)),
 		})
 
-		assert.Equal(t, 3.0, st.Ground, "Should total the ground amount only")
+		assert.Equal(t, 3.0, st.Grams, "Should total the ground amount only")
 		assert.Equal(t, 2, st.ActiveDays, "Should count days with an amount, not events")
 		assert.Equal(t, 3, st.ElapsedDays, "Should count calendar days from first to last")
 		assert.Equal(t, 1.5, st.PerActiveDay, "Should average over active days")
@@ -183,7 +222,7 @@ func TestSummarise(t *testing.T) {
 			event(journal.Sesh, "wedding-cake", 5, day(0)),
 		})
 
-		assert.Zero(t, st.Ground, "Should not count a purchase or a consume as grinding")
+		assert.Zero(t, st.Grams, "Should not count a purchase or a consume as grinding")
 		assert.Zero(t, st.ActiveDays, "Should have no active days")
 	})
 
@@ -203,6 +242,132 @@ func TestSummarise(t *testing.T) {
 		assert.Zero(t, st.ActiveDays, "Should have no active days")
 		assert.Zero(t, st.DaysLeft(60), "Should not extrapolate without a rate")
 	})
+
+	t.Run("ByMeasure", func(t *testing.T) {
+		events := []journal.Event{
+			event(journal.Grind, "wedding-cake", 2, day(0)),
+			event(journal.Sesh, "wedding-cake", 0.5, day(0)),
+			event(journal.Sesh, "wedding-cake", 0.25, day(1)),
+			event(journal.AVBCollect, "wedding-cake", 0.3, day(1)),
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 1, From: journal.Storage, To: journal.External, OccurredAt: day(2)},
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.1, From: journal.Stash, To: journal.External, OccurredAt: day(2)},
+		}
+
+		assert.Equal(t, 0.75, SummariseBy(Seshing, events).Grams, "Should count the sessions out of the stash")
+		assert.Equal(t, 2, SummariseBy(Seshing, events).ActiveDays, "over the days they fell on")
+		assert.Equal(t, 0.3, SummariseBy(Collecting, events).Grams, "Should count the AVB collected")
+		assert.Equal(t, 1.1, SummariseBy(Adjustments, events).Grams, "Should count every adjustment")
+		assert.Equal(t, 0.1, SummariseBy(Measure{Type: journal.Adjust, From: journal.Stash}, events).Grams,
+			"and only those out of one account when it is given")
+		assert.Equal(t, map[string]float64{"2026-07-01": 0.5, "2026-07-02": 0.25}, Daily(Seshing, events),
+			"Should total each day")
+	})
+}
+
+func TestSessions(t *testing.T) {
+	sesh := func(grams float64, temp int, at time.Time) journal.Event {
+		e := event(journal.Sesh, "wedding-cake", grams, at)
+		e.Temperature = temp
+		return e
+	}
+
+	t.Run("MeasuresTheSessions", func(t *testing.T) {
+		st := Sessions([]journal.Event{
+			event(journal.Grind, "wedding-cake", 2, day(0)),
+			sesh(0.1, 180, day(0)),
+			sesh(0.2, 185, day(0)),
+			sesh(0.3, 0, day(0)),
+			sesh(0.4, 200, day(3)),
+		})
+
+		assert.Equal(t, 4, st.Sessions, "Should count the sessions, not the grind")
+		assert.Equal(t, 1.0, st.Grams, "Should total what they drew")
+		assert.Equal(t, 2.0, st.DailySessions, "Should count sessions per day with one")
+		assert.Equal(t, 1.0, st.ElapsedSessions, "and per calendar day")
+		assert.Equal(t, Spread{Min: 0.1, Lower: 0.15, Median: 0.25, Upper: 0.35, Max: 0.4}, st.PerSession,
+			"Should spread the grams per session")
+		assert.Equal(t, 0.25, st.MeanPerSession)
+		assert.Equal(t, 3, st.Temperatures, "Should count only the sessions with a temperature")
+		assert.Equal(t, 185, st.MedianTemp, "and take the median of those")
+	})
+
+	t.Run("LeavesOutACorrectedSession", func(t *testing.T) {
+		wrong := sesh(5, 0, day(1))
+		wrong.Hash = "wrong"
+		st := Sessions([]journal.Event{
+			sesh(0.2, 0, day(0)),
+			wrong,
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 5, From: journal.Consumed, To: journal.Stash,
+				Reverts: "wrong", OccurredAt: day(1)},
+		})
+
+		assert.Equal(t, 1, st.Sessions, "Should not count the session that was undone")
+		assert.Equal(t, 0.2, st.PerSession.Max, "nor let it skew the spread")
+	})
+
+	t.Run("NoSessions", func(t *testing.T) {
+		st := Sessions(nil)
+
+		assert.Zero(t, st.Sessions)
+		assert.Zero(t, st.MedianTemp, "Should not make up a temperature")
+	})
+}
+
+func TestEffects(t *testing.T) {
+	sesh := func(hash, product, device string, temp int) journal.Event {
+		e := event(journal.Sesh, product, 0.2, day(0))
+		e.Hash, e.Device, e.Temperature = hash, device, temp
+		return e
+	}
+	feel := func(hash, session string, scores journal.Scores) journal.Event {
+		return journal.Event{Type: journal.Feel, Hash: hash, Session: session, Scores: scores,
+			From: journal.External, To: journal.External, OccurredAt: day(0)}
+	}
+
+	t.Run("GroupsTheScoresBySession", func(t *testing.T) {
+		effects := EffectsOf([]journal.Event{
+			sesh("a", "wedding-cake", "volcano", 180),
+			sesh("b", "wedding-cake", "volcano", 190),
+			sesh("c", "lemon-cookie", "", 200),
+			feel("1", "a", journal.Scores{"pain": 6, "sleep": 4}),
+			feel("2", "b", journal.Scores{"pain": 4}),
+			feel("3", "c", journal.Scores{"pain": 2, "sleep": 8}),
+			feel("4", "", journal.Scores{"pain": 8}),
+		})
+
+		assert.Equal(t, 4, effects.All.Feels, "Should count every feel")
+		assert.Equal(t, 5.0, effects.All.Mean["pain"], "Should average all of them")
+		assert.Equal(t, 1, effects.Alone, "Should count the feel that follows no session")
+		require.Len(t, effects.Product, 2)
+		assert.Equal(t, Scored{Key: "wedding-cake", Feels: 2, Mean: map[string]float64{"pain": 5, "sleep": 4},
+			Counts: map[string]int{"pain": 2, "sleep": 1}}, effects.Product[0],
+			"Should average each symptom over the feels that scored it")
+		assert.Equal(t, "", effects.Device[1].Key, "Should group the sessions without a device together")
+		require.Len(t, effects.Temperature, 3)
+		assert.Equal(t, []int{180, 190, 200}, []int{effects.Temperature[0].Band, effects.Temperature[1].Band,
+			effects.Temperature[2].Band}, "Should band the temperatures, coolest first")
+		assert.Equal(t, -1.0, effects.Heat["pain"], "Pain should fall as the temperature rises")
+		assert.NotContains(t, effects.Heat, "sleep", "Two feels are too few to correlate")
+	})
+
+	t.Run("LeavesOutCorrections", func(t *testing.T) {
+		wrong := feel("2", "a", journal.Scores{"pain": 10})
+		undone := sesh("b", "wedding-cake", "volcano", 180)
+		effects := EffectsOf([]journal.Event{
+			sesh("a", "wedding-cake", "volcano", 180),
+			undone,
+			feel("1", "a", journal.Scores{"pain": 2}),
+			wrong,
+			{Type: journal.Adjust, Reverts: "2", OccurredAt: day(0)},
+			feel("3", "b", journal.Scores{"pain": 4}),
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.2, From: journal.Consumed, To: journal.Stash,
+				Reverts: "b", OccurredAt: day(0)},
+		})
+
+		assert.Equal(t, 2, effects.All.Feels, "Should leave out the corrected feel")
+		assert.Equal(t, 1, effects.Alone, "A feel after a corrected session should follow nothing")
+		assert.Equal(t, 2.0, effects.Product[0].Mean["pain"], "and not count towards the product")
+	})
 }
 
 func TestDaysLeft(t *testing.T) {
@@ -215,12 +380,47 @@ func TestDaysLeft(t *testing.T) {
 	assert.Equal(t, 10.0, st.DaysLeft(20), "Should estimate the supply at the observed rate")
 }
 
+func TestRollingAverage(t *testing.T) {
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 30, day(0)),
+		event(journal.Grind, "wedding-cake", 7, day(0)),
+		event(journal.Grind, "wedding-cake", 1, day(8)),
+		event(journal.Grind, "wedding-cake", 2, day(10)),
+		event(journal.Grind, "wedding-cake", 5, day(12)),
+	}
+
+	t.Run("AveragesOverTheWindow", func(t *testing.T) {
+		assert.Equal(t, 0.43, RollingAverage(events, 7, day(10)), "Should spread three grams over seven days")
+	})
+
+	t.Run("LeavesOutWhatCameAfter", func(t *testing.T) {
+		assert.Equal(t, 1.14, RollingAverage(events, 7, day(12)), "Should count the grind on the day itself")
+		assert.Equal(t, 0.43, RollingAverage(events, 7, day(11)), "Should not count a grind after the day")
+	})
+
+	t.Run("AShortHistoryIsAveragedOverItsOwnDays", func(t *testing.T) {
+		assert.Equal(t, 3.5, RollingAverage(events, 7, day(1)), "Should spread the first grind over two days, not seven")
+	})
+
+	t.Run("LeavesOutCorrectedGrinds", func(t *testing.T) {
+		events := append([]journal.Event{}, events...)
+		events[3].Hash = "abc"
+		events = append(events, journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 2,
+			From: journal.Stash, To: journal.Storage, OccurredAt: day(10), Reverts: "abc"})
+		assert.Equal(t, 0.14, RollingAverage(events, 7, day(10)), "Should not count the corrected grind")
+	})
+
+	t.Run("NothingGround", func(t *testing.T) {
+		assert.Zero(t, RollingAverage(nil, 7, day(10)), "Should report no rate without grinds")
+	})
+}
+
 func TestCycleGap(t *testing.T) {
 	t.Run("ALaterFillLeavesTheUnfinishedCycleOpen", func(t *testing.T) {
 		// 13 of 47 spreadsheet cycles ended with a remainder rather than at
 		// zero. The remainder stays on its own cycle's account, and that
 		// cycle stays open beside the new one.
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Grind, "wedding-cake", 15, day(10)),
 			event(journal.Purchase, "lemon-cookie", 20, day(30)),
@@ -245,7 +445,7 @@ func TestCycleGap(t *testing.T) {
 		// two cycles' grams, and no scale can say whose leave first — so the
 		// oldest do. The grind below takes the first cycle's 6 g remainder,
 		// closing it, then 6 g of the new fill.
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 10, day(0)),
 			event(journal.Grind, "wedding-cake", 4, day(5)),
 			event(journal.Purchase, "wedding-cake", 10, day(30)),
@@ -268,7 +468,7 @@ func TestCycleGap(t *testing.T) {
 		// listed. Every gram stands on the account of the cycle that
 		// dispensed it: the fill counts its own, the older cycles keep
 		// theirs, and a shared jar splits along its fills.
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "old-strain", 10, day(0)),
 			event(journal.Purchase, "wedding-cake", 10, day(0)),
 			event(journal.Grind, "old-strain", 2, day(5)),
@@ -300,7 +500,7 @@ func TestCycleGap(t *testing.T) {
 	})
 
 	t.Run("SameDayFillsStayTogether", func(t *testing.T) {
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "wedding-cake", 20, day(0)),
 			event(journal.Purchase, "lemon-cookie", 20, day(0)),
 			event(journal.Purchase, "mac1", 20, day(1)),
@@ -312,7 +512,7 @@ func TestCycleGap(t *testing.T) {
 
 	t.Run("TheTightestRealBoundaryStillSplits", func(t *testing.T) {
 		// 2024-12-1 and 2024-12-2 were eight days apart.
-		s := Fold([]journal.Event{
+		s := Fold("", []journal.Event{
 			event(journal.Purchase, "mac1", 10, day(0)),
 			event(journal.Purchase, "white-widow", 15, day(8)),
 		})
@@ -320,3 +520,914 @@ func TestCycleGap(t *testing.T) {
 		assert.Len(t, s.Cycles, 2, "Should split two fills eight days apart")
 	})
 }
+
+func TestOverdrafts(t *testing.T) {
+	t.Run("NoneInAHealthyJournal", func(t *testing.T) {
+		assert.Empty(t, Overdrafts([]journal.Event{
+			event(journal.Purchase, "wedding-cake", 2, day(0)),
+			event(journal.Grind, "wedding-cake", 2, day(1)),
+		}), "Should find nothing when every account stays at or above zero")
+	})
+
+	t.Run("ReportsWhereAnAccountWentUnder", func(t *testing.T) {
+		got := Overdrafts([]journal.Event{
+			event(journal.Purchase, "wedding-cake", 1, day(0)),
+			event(journal.Grind, "wedding-cake", 1.5, day(1)),
+			event(journal.Grind, "wedding-cake", 0.5, day(2)),
+			event(journal.Purchase, "wedding-cake", 5, day(3)),
+			event(journal.Sesh, "wedding-cake", 3, day(4)),
+		})
+
+		require.Len(t, got, 2, "Should report each time an account went under, not every entry after")
+		assert.Equal(t, journal.Storage, got[0].Account, "Should name the account")
+		assert.Equal(t, -0.5, got[0].Balance, "Should say how far under it went")
+		assert.Equal(t, journal.Stash, got[1].Account, "Should watch the stash as well as storage")
+	})
+
+	t.Run("KeepsEachPatientsBalances", func(t *testing.T) {
+		grind := event(journal.Grind, "wedding-cake", 5, day(1))
+		grind.Patient = "bob"
+
+		got := Overdrafts([]journal.Event{
+			event(journal.Purchase, "wedding-cake", 20, day(0)),
+			grind,
+		})
+
+		require.Len(t, got, 1, "Should not cover one patient's withdrawal with another's grams")
+		assert.Equal(t, -5.0, got[0].Balance)
+	})
+}
+
+func TestShortfallAt(t *testing.T) {
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 20, day(10)),
+		event(journal.Grind, "wedding-cake", 15, day(12)),
+	}
+
+	t.Run("FitsWhatWasThere", func(t *testing.T) {
+		assert.Nil(t, ShortfallAt(events, "wedding-cake", journal.Storage, "", 5, day(11)),
+			"Should allow what leaves every later point at zero or more")
+	})
+
+	t.Run("RefusesWhatWasNotThereYet", func(t *testing.T) {
+		got := ShortfallAt(events, "wedding-cake", journal.Storage, "", 1, day(9))
+
+		require.NotNil(t, got, "A grind dated before the purchase draws on an empty jar")
+		assert.Equal(t, day(9), got.At, "Should come up short on the day itself")
+		assert.Zero(t, got.Have)
+	})
+
+	t.Run("RefusesWhatALaterEntryTook", func(t *testing.T) {
+		got := ShortfallAt(events, "wedding-cake", journal.Storage, "", 10, day(11))
+
+		require.NotNil(t, got, "Twenty were there on the day, but fifteen of them go on the next")
+		assert.Equal(t, day(12), got.At, "Should name the day it comes up short")
+		assert.Equal(t, 5.0, got.Have, "and what was left there without it")
+	})
+
+	t.Run("IgnoresWhereItWasAlreadyUnder", func(t *testing.T) {
+		under := append([]journal.Event{event(journal.Grind, "wedding-cake", 1, day(5))}, events...)
+
+		assert.Nil(t, ShortfallAt(under, "wedding-cake", journal.Storage, "", 1, day(5)),
+			"Should not pin an old overdraft on the new entry")
+	})
+}
+
+func TestLocations(t *testing.T) {
+	// at places an entry: from where and to where.
+	at := func(e journal.Event, from, to string) journal.Event {
+		e.FromLocation, e.ToLocation = from, to
+		return e
+	}
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 20, day(0)),
+		at(event(journal.Move, "wedding-cake", 5, day(1)), "", "travel"),
+		at(event(journal.Grind, "wedding-cake", 1, day(2)), "travel", "travel"),
+		at(event(journal.Move, "wedding-cake", 3, day(3)), "", "fridge"),
+	}
+
+	t.Run("KeepsEachLocationApart", func(t *testing.T) {
+		b := Fold("", events).Balances["wedding-cake"]
+
+		assert.Equal(t, 19.0, b.Storage, "Should keep the account whole")
+		assert.Equal(t, 12.0, b.In(journal.Storage, ""), "Should leave at home what was not moved")
+		assert.Equal(t, 4.0, b.In(journal.Storage, "travel"), "Should take a grind from where it happened")
+		assert.Equal(t, 1.0, b.In(journal.Stash, "travel"), "and grind into the stash there")
+		assert.Zero(t, b.In(journal.Stash, ""))
+		assert.Equal(t, []string{"fridge", "travel"}, b.Locations(journal.Storage), "Should name the locations")
+	})
+
+	t.Run("LeavesTheLotsAlone", func(t *testing.T) {
+		s := Fold("", events)
+
+		assert.Equal(t, 19.0, s.FillOnShelf(s.CurrentCycle()), "A move should not spend the fill")
+		assert.Equal(t, 1.0, s.CurrentCycle().Ground, "nor count as grinding")
+	})
+
+	t.Run("AdjustsOneLocationAlone", func(t *testing.T) {
+		spilled := event(journal.Adjust, "wedding-cake", 0.5, day(4))
+		spilled.From, spilled.To, spilled.FromLocation = journal.Storage, journal.External, "fridge"
+
+		b := Fold("", append(slices.Clone(events), spilled)).Balances["wedding-cake"]
+
+		assert.Equal(t, 2.5, b.In(journal.Storage, "fridge"), "Should adjust the jar weighed")
+		assert.Equal(t, 12.0, b.In(journal.Storage, ""), "and leave home as it was")
+		assert.Equal(t, 4.0, b.In(journal.Storage, "travel"), "and the travel tin")
+	})
+
+	t.Run("ForgetsALocationEmptied", func(t *testing.T) {
+		back := at(event(journal.Move, "wedding-cake", 3, day(4)), "fridge", "")
+
+		b := Fold("", append(slices.Clone(events), back)).Balances["wedding-cake"]
+
+		assert.Equal(t, []string{"travel"}, b.Locations(journal.Storage))
+	})
+
+	t.Run("FallsShortAtHome", func(t *testing.T) {
+		assert.NotNil(t, ShortfallAt(events, "wedding-cake", journal.Storage, "", 13, day(5)),
+			"Should not let home draw on the travel tin")
+		assert.Nil(t, ShortfallAt(events, "wedding-cake", journal.Storage, "travel", 4, day(5)),
+			"Should let the travel tin give what it holds")
+		assert.NotNil(t, ShortfallAt(events, "wedding-cake", journal.Stash, "", 0.5, day(5)),
+			"Should not let a session at home draw on the stash ground elsewhere")
+	})
+
+	t.Run("FindsAnOverdraftInALocation", func(t *testing.T) {
+		over := append(slices.Clone(events), at(event(journal.Sesh, "wedding-cake", 2, day(5)), "travel", ""))
+
+		got := Overdrafts(over)
+
+		require.Len(t, got, 1)
+		assert.Equal(t, "travel", got[0].Location, "Should say where the account went under")
+		assert.Equal(t, -1.0, got[0].Balance)
+	})
+}
+
+func TestCycleOf(t *testing.T) {
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 2, day(0)),
+		event(journal.Grind, "wedding-cake", 2, day(1)),
+		event(journal.Purchase, "wedding-cake", 2, day(10)),
+	}
+	for i := range events {
+		events[i].Hash = string(rune('a' + i))
+	}
+	s := Fold("", events)
+
+	require.NotNil(t, s.CycleOf("b"))
+	assert.Equal(t, 0, s.CycleOf("b").Seq, "Should find the cycle an entry was recorded in")
+	assert.Equal(t, 1, s.CycleOf("c").Seq, "Should place a later fill in its own cycle")
+	assert.Nil(t, s.CycleOf("z"), "Should return nil for an entry it does not hold")
+}
+
+func TestLots(t *testing.T) {
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 10, day(0)),
+		event(journal.Grind, "wedding-cake", 7, day(6)),
+		event(journal.Purchase, "wedding-cake", 20, day(30)),
+		event(journal.Purchase, "lemon-cookie", 5, day(30)),
+	}
+	for i := range events {
+		events[i].Hash = fmt.Sprintf("h%d", i)
+	}
+
+	t.Run("SplitsASharedJarByFill", func(t *testing.T) {
+		lots := Fold("", events).Lots("wedding-cake")
+		require.Len(t, lots, 2, "Should hold a lot per fill")
+		assert.Equal(t, Lot{Product: "wedding-cake", Cycle: 0, Bought: day(0), Purchased: 10, Remaining: 3, Entry: "h0"},
+			lots[0], "Should put the older fill first, with what is left of it")
+		assert.Equal(t, Lot{Product: "wedding-cake", Cycle: 1, Bought: day(30), Purchased: 20, Remaining: 20, Entry: "h2"},
+			lots[1], "Should leave the newer fill whole")
+	})
+
+	t.Run("DropsALotGroundAway", func(t *testing.T) {
+		s := Fold("", append(events, event(journal.Grind, "wedding-cake", 5, day(31))))
+		lots := s.Lots("wedding-cake")
+		require.Len(t, lots, 1, "Should drop the older lot once it is ground away")
+		assert.Equal(t, 18.0, lots[0].Remaining, "Should take the rest from the newer fill")
+		assert.Len(t, s.AllLots(), 2, "Should list every product's lots")
+		assert.Equal(t, "lemon-cookie", s.AllLots()[0].Product, "Should list products alphabetically")
+	})
+
+	t.Run("NotesGramsFoundInAnEmptyJar", func(t *testing.T) {
+		s := Fold("", []journal.Event{
+			event(journal.Purchase, "wedding-cake", 10, day(0)),
+			event(journal.Grind, "wedding-cake", 10, day(6)),
+			{Type: journal.Adjust, Product: "wedding-cake", Grams: 1, From: journal.External, To: journal.Storage, OccurredAt: day(7)},
+		})
+		lots := s.Lots("wedding-cake")
+		require.Len(t, lots, 1)
+		assert.True(t, lots[0].Found(), "Should say the grams were found, not bought")
+		assert.Equal(t, day(7), lots[0].Bought, "Should date them when they were found")
+	})
+}
+
+func TestAnomalies(t *testing.T) {
+	// A gram a day for five weeks.
+	steady := func() []journal.Event {
+		var events []journal.Event
+		for i := 0; i < 35; i++ {
+			events = append(events, event(journal.Grind, "wedding-cake", 1, day(i)))
+		}
+		return events
+	}
+
+	t.Run("FlagsAHeavyDay", func(t *testing.T) {
+		events := append(steady(), event(journal.Grind, "wedding-cake", 3.5, day(35)))
+
+		found := Detector{Measure: Grinding}.Find(events, day(36))
+
+		require.Len(t, found, 1, "Should flag only the heavy day: %v", found)
+		assert.Equal(t, HeavyDay, found[0].Kind)
+		assert.Equal(t, Midnight(day(35)), found[0].Start, "Should date it to its day")
+		assert.Equal(t, 3.5, found[0].Grams, "Should total the day")
+		assert.Equal(t, 1.0, found[0].Usual, "against the median of the days before")
+		assert.Empty(t, Detector{Measure: Grinding, Factor: 4}.Find(events, day(36)),
+			"Should take a factor that lets it pass")
+		assert.Empty(t, Detector{Measure: Seshing}.Find(events, day(36)), "Should only count its measure")
+	})
+
+	t.Run("WaitsForABaseline", func(t *testing.T) {
+		events := []journal.Event{
+			event(journal.Grind, "wedding-cake", 1, day(0)),
+			event(journal.Grind, "wedding-cake", 9, day(1)),
+		}
+
+		assert.Empty(t, Detector{Measure: Grinding}.Find(events, day(2)),
+			"Should not call the second day of a journal unusual")
+	})
+
+	t.Run("FlagsSilentAndLightWeeks", func(t *testing.T) {
+		events := append(steady(), event(journal.Grind, "wedding-cake", 1, day(42)))
+
+		found := Detector{Measure: Grinding}.Find(events, day(50))
+
+		require.Len(t, found, 2, "Should flag two weeks: %v", found)
+		assert.Equal(t, SilentWeek, found[0].Kind, "Should flag the week with nothing logged")
+		assert.Equal(t, Midnight(day(35)), found[0].Start)
+		assert.Equal(t, Midnight(day(41)), found[0].End(), "Should span seven days")
+		assert.Equal(t, 7.0, found[0].Usual, "against the usual week")
+		assert.Equal(t, LightWeek, found[1].Kind, "Should flag the week of a single gram")
+		assert.Empty(t, Detector{Measure: Grinding}.Find(events, day(40)),
+			"Should not judge a week still in progress")
+	})
+
+	t.Run("LeavesOutACorrectedEntry", func(t *testing.T) {
+		wrong := event(journal.Grind, "wedding-cake", 25, day(35))
+		wrong.Hash = "wrong"
+		events := append(steady(), wrong, journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 25,
+			From: journal.Stash, To: journal.Storage, Reverts: "wrong", OccurredAt: day(35)})
+
+		assert.Empty(t, Detector{Measure: Grinding}.Find(events, day(36)),
+			"Should not flag a mistake that was already corrected")
+	})
+
+	t.Run("ExcusesAWeekOnBreak", func(t *testing.T) {
+		pause := journal.Event{Type: journal.Break, Days: 7, OccurredAt: Midnight(day(35))}
+		events := append(steady(), pause, event(journal.Grind, "wedding-cake", 1, day(42)))
+
+		found := Detector{Measure: Grinding}.Find(events, day(50))
+
+		require.Len(t, found, 1, "Should not call the break a silent week: %v", found)
+		assert.Equal(t, LightWeek, found[0].Kind, "Should still flag the light week after it")
+		assert.Equal(t, 7.0, found[0].Usual, "against the usual of the weeks before the break")
+	})
+
+	t.Run("ChecksAnEntryAsItIsRecorded", func(t *testing.T) {
+		heavy := event(journal.Grind, "wedding-cake", 3, day(35))
+
+		a, ok := Detector{Measure: Grinding}.Unusual(append(steady(), heavy), heavy)
+		require.True(t, ok, "Should flag the entry that makes its day heavy")
+		assert.Equal(t, 3.0, a.Ratio(), "at three times the usual day")
+
+		usual := event(journal.Grind, "wedding-cake", 1.2, day(35))
+		_, ok = Detector{Measure: Grinding}.Unusual(append(steady(), usual), usual)
+		assert.False(t, ok, "Should let a usual entry pass")
+	})
+}
+
+func TestBreaks(t *testing.T) {
+	// A gram a day for two weeks, a week's break with one session in it, and
+	// half a gram a day for the week after.
+	var events []journal.Event
+	for i := 0; i < 14; i++ {
+		events = append(events, event(journal.Grind, "wedding-cake", 1, day(i)))
+	}
+	events = append(events,
+		journal.Event{Type: journal.Break, Days: 7, OccurredAt: day(14), Hash: "pause"},
+		event(journal.Sesh, "wedding-cake", 0.2, day(16)))
+	for i := 21; i < 28; i++ {
+		events = append(events, event(journal.Grind, "wedding-cake", 0.5, day(i)))
+	}
+
+	t.Run("CountsTheLapses", func(t *testing.T) {
+		breaks := Breaks(events)
+
+		require.Len(t, breaks, 1)
+		assert.Equal(t, day(21), breaks[0].End, "Should last the days it was declared for")
+		assert.Len(t, breaks[0].Lapses, 1, "Should count the session during it")
+		assert.False(t, breaks[0].Kept(), "and not call it kept")
+	})
+
+	t.Run("KnowsWhereABreakStands", func(t *testing.T) {
+		b, ok := BreakAt(events, day(16))
+
+		require.True(t, ok, "Should find the break running")
+		assert.Equal(t, 3, b.Day(day(16)), "Should count its days from one")
+		assert.Equal(t, 4, b.Left(day(16)), "and the whole days still to come")
+		_, ok = BreakAt(events, day(21))
+		assert.False(t, ok, "Should be over once its days are")
+		assert.True(t, OnBreak(events, day(30))[day(20).Format(time.DateOnly)], "Should mark its days")
+		assert.False(t, OnBreak(events, day(15))[day(16).Format(time.DateOnly)], "but not the days still ahead")
+	})
+
+	t.Run("CountsTheStreak", func(t *testing.T) {
+		days, last := Streak(events, day(30))
+
+		assert.Equal(t, 3, days, "Should count the days since the last grind")
+		assert.Equal(t, day(27), last)
+		days, _ = Streak(events, day(27))
+		assert.Zero(t, days, "A grind today is no streak at all")
+	})
+
+	t.Run("ComparesBeforeAndAfter", func(t *testing.T) {
+		c := Breaks(events)[0].Compare(Grinding, events, day(40))
+
+		assert.Equal(t, 1.0, c.Before, "Should average the week before")
+		assert.Equal(t, 0.5, c.After, "and the week after")
+		changed, ok := c.Changed()
+		require.True(t, ok)
+		assert.Equal(t, -0.5, changed, "Should read it as half")
+
+		c = Breaks(events)[0].Compare(Grinding, events, day(24))
+		assert.Equal(t, 3, c.AfterDays, "Should only count the days after it so far")
+		assert.Equal(t, 0.5, c.After)
+	})
+
+	t.Run("DropsARevertedBreak", func(t *testing.T) {
+		reverted := append(append([]journal.Event(nil), events...),
+			journal.Event{Type: journal.Adjust, Reverts: "pause", OccurredAt: day(15)})
+
+		assert.Empty(t, Breaks(reverted), "A reverted break was no break")
+	})
+}
+
+func TestPatients(t *testing.T) {
+	mine := func(e journal.Event, patient string) journal.Event {
+		e.Patient = patient
+		return e
+	}
+	events := []journal.Event{
+		mine(event(journal.Purchase, "wedding-cake", 10, day(0)), "anna"),
+		mine(event(journal.Purchase, "wedding-cake", 5, day(0)), "ben"),
+		mine(event(journal.Grind, "wedding-cake", 4, day(1)), "ben"),
+		mine(event(journal.Grind, "wedding-cake", 2, day(2)), "anna"),
+		mine(event(journal.Purchase, "lemon", 5, day(9)), "ben"),
+	}
+	for i := range events {
+		events[i].Hash = string(rune('a' + i))
+	}
+
+	t.Run("KeepsTheJarsApart", func(t *testing.T) {
+		anna := FoldFor(Recorded, "anna", events)
+		ben := FoldFor(Recorded, "ben", events)
+
+		assert.Equal(t, 8.0, anna.Balances["wedding-cake"].Storage, "Should not let ben's grind draw on anna's jar")
+		assert.Equal(t, 1.0, ben.Balances["wedding-cake"].Storage, "Should draw ben's grind on his own")
+		assert.NotContains(t, anna.Balances, "lemon", "Should not stock anna with ben's fill")
+		assert.Len(t, anna.Events, 2, "Should hold only anna's entries")
+		assert.Equal(t, 8.0, anna.ShareOf(&anna.Cycles[0], "wedding-cake"), "Should draw anna's lot down by her grind alone")
+	})
+
+	t.Run("KeepsTheCyclesApart", func(t *testing.T) {
+		anna := FoldFor(Recorded, "anna", events)
+		ben := FoldFor(Recorded, "ben", events)
+
+		assert.Len(t, anna.Cycles, 1, "Should not open anna a cycle for ben's fill")
+		assert.Len(t, ben.Cycles, 2, "Should open ben a cycle for each of his fills")
+		assert.Equal(t, 10.0, anna.Cycles[0].Purchased, "Should count anna's fill alone")
+	})
+
+	t.Run("FoldsOnlyThePatientAsked", func(t *testing.T) {
+		s := Fold("", events)
+
+		assert.Empty(t, s.Events, "Should not take the patient from the first entry")
+		assert.Empty(t, s.Balances, "and count nobody else's grams")
+		assert.Equal(t, events[len(events)-1].Hash, s.Tip(), "Should still chain onto the whole journal")
+		assert.Len(t, s.Journal(), len(events), "and keep it")
+	})
+
+	t.Run("NamesThePatients", func(t *testing.T) {
+		assert.Equal(t, []string{"anna", "ben"}, Patients(events))
+		assert.Empty(t, Patients([]journal.Event{event(journal.Purchase, "lemon", 5, day(0))}),
+			"Should name nobody in a journal that keeps no patients")
+	})
+}
+
+func TestFoldFor(t *testing.T) {
+	// A grind on day 5, only typed in after the second fill had arrived.
+	backdated := event(journal.Grind, "wedding-cake", 5, day(5))
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 10, day(0)),
+		event(journal.Purchase, "wedding-cake", 10, day(10)),
+		backdated,
+	}
+	for i := range events {
+		events[i].Seq = i + 1
+		events[i].Hash = fmt.Sprintf("h%d", i+1)
+	}
+
+	t.Run("RecordedCountsItWhereItWasTypedIn", func(t *testing.T) {
+		s := FoldFor(Recorded, "", events)
+
+		require.Len(t, s.Cycles, 2)
+		assert.Equal(t, 5.0, s.Cycles[1].Ground, "Should book it to the cycle current when it was recorded")
+	})
+
+	t.Run("OccurredCountsItWhereItHappened", func(t *testing.T) {
+		s := FoldFor(Occurred, "", events)
+
+		require.Len(t, s.Cycles, 2)
+		assert.Equal(t, 5.0, s.Cycles[0].Ground, "Should book it to the cycle it happened in")
+		assert.Zero(t, s.Cycles[1].Ground)
+		assert.Equal(t, 0, s.CycleOf("h3").Seq)
+		assert.Equal(t, []string{"h1", "h3", "h2"}, hashes(s.Events), "Should hold the events in the order folded")
+		assert.Equal(t, Fold("", events).Balances, s.Balances, "The balances do not depend on the order")
+	})
+
+	t.Run("BreaksTiesInJournalOrder", func(t *testing.T) {
+		tied := []journal.Event{
+			{Seq: 2, Hash: "b", OccurredAt: day(1)},
+			{Seq: 1, Hash: "a", OccurredAt: day(1)},
+			{Seq: 3, Hash: "c", OccurredAt: day(0)},
+		}
+
+		assert.Equal(t, []string{"c", "a", "b"}, hashes(Chronological(tied)))
+		assert.Equal(t, "b", tied[0].Hash, "Should leave the events given alone")
+	})
+
+	t.Run("OnlyJournalOrderCheckpoints", func(t *testing.T) {
+		_, err := FoldFor(Occurred, "", events).Checkpoint()
+
+		assert.Error(t, err, "A later backdated entry would land inside the checkpoint")
+	})
+}
+
+// hashes lists the events by hash.
+func hashes(events []journal.Event) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.Hash
+	}
+	return out
+}
+
+func TestCheckpoint(t *testing.T) {
+	// Two fills of one jar, a grind across both lots, a reconciliation and a
+	// third fill: enough to exercise every piece of bookkeeping a resumed fold
+	// has to carry on with.
+	events := []journal.Event{
+		event(journal.Grind, "lemon", 1, day(0)),
+		event(journal.Purchase, "wedding-cake", 10, day(0)),
+		event(journal.Purchase, "lemon", 5, day(1)),
+		event(journal.Grind, "wedding-cake", 4, day(2)),
+		event(journal.Purchase, "wedding-cake", 10, day(10)),
+		event(journal.Grind, "wedding-cake", 8, day(11)),
+		{Type: journal.Adjust, Product: "lemon", Grams: 1, From: journal.Storage, To: journal.External, OccurredAt: day(12)},
+		event(journal.Purchase, "lemon", 5, day(20)),
+		event(journal.Sesh, "wedding-cake", 2, day(21)),
+	}
+	for i := range events {
+		events[i].Seq = i + 1
+		events[i].Hash = string(rune('a' + i))
+		if i > 0 {
+			events[i].Prev = events[i-1].Hash
+		}
+	}
+	want := Fold("", events)
+
+	t.Run("ResumesToTheSameState", func(t *testing.T) {
+		for n := 0; n <= len(events); n++ {
+			cp, err := Fold("", events[:n]).Checkpoint()
+			require.NoError(t, err)
+
+			got, err := Resume(cp, events)
+			require.NoError(t, err, "after %d events", n)
+
+			assert.Equal(t, want.Balances, got.Balances, "Should reach the same balances from %d events", n)
+			assert.Equal(t, want.Cycles, got.Cycles, "Should reach the same cycles from %d events", n)
+			assert.Equal(t, want.lots, got.lots, "Should reach the same lots from %d events", n)
+		}
+	})
+
+	t.Run("ResumingDoesNotDisturbTheJournal", func(t *testing.T) {
+		cp, err := Fold("", events[:4]).Checkpoint()
+		require.NoError(t, err)
+		before := append([]journal.Event(nil), events...)
+
+		_, err = Resume(cp, events)
+		require.NoError(t, err)
+
+		assert.Equal(t, before, events, "Should not write through a cycle's run into the events after it")
+	})
+
+	t.Run("RefusesAnotherJournal", func(t *testing.T) {
+		cp, err := Fold("", events[:4]).Checkpoint()
+		require.NoError(t, err)
+		other := append([]journal.Event(nil), events...)
+		other[3].Hash = "x"
+
+		_, err = Resume(cp, other)
+		assert.ErrorIs(t, err, ErrStaleCheckpoint, "Should refuse a checkpoint whose tip is not in the journal")
+
+		_, err = Resume(cp, events[:3])
+		assert.ErrorIs(t, err, ErrStaleCheckpoint, "Should refuse a checkpoint of a longer journal")
+
+		_, err = Resume([]byte("not json"), events)
+		assert.ErrorIs(t, err, ErrStaleCheckpoint, "Should refuse a checkpoint it cannot read")
+	})
+
+	t.Run("ResumesAPatient", func(t *testing.T) {
+		mixed := append([]journal.Event(nil), events...)
+		for i := range mixed {
+			if i%2 == 1 {
+				mixed[i].Patient = "anna"
+			}
+		}
+		want := FoldFor(Recorded, "anna", mixed)
+		for n := 0; n <= len(mixed); n++ {
+			cp, err := FoldFor(Recorded, "anna", mixed[:n]).Checkpoint()
+			require.NoError(t, err)
+
+			got, err := Resume(cp, mixed)
+			require.NoError(t, err, "after %d events", n)
+
+			assert.Equal(t, "anna", got.Patient, "Should resume the patient it was taken of")
+			assert.Equal(t, want.Events, got.Events, "Should hold only the patient's entries from %d events", n)
+			assert.Equal(t, want.Cycles, got.Cycles, "Should reach the same cycles from %d events", n)
+			assert.Equal(t, mixed[len(mixed)-1].Hash, got.Tip(), "Should chain onto the journal, not the patient")
+		}
+	})
+}
+
+func TestYields(t *testing.T) {
+	// session builds a session on a device at a temperature, and collect the
+	// weighing of what it left.
+	session := func(grams float64, device string, temp int, at time.Time) journal.Event {
+		e := event(journal.Sesh, "wedding-cake", grams, at)
+		e.Device, e.Temperature = device, temp
+		return e
+	}
+	collect := func(grams float64, device string, temp int, at time.Time) journal.Event {
+		e := event(journal.AVBCollect, "wedding-cake", grams, at)
+		e.Device, e.Temperature = device, temp
+		return e
+	}
+	events := []journal.Event{
+		session(0.3, "volcano", 185, day(1)),
+		session(0.3, "volcano", 182, day(2)),
+		collect(0.24, "volcano", 185, day(2)),
+		session(0.2, "mighty", 200, day(3)),
+		collect(0.05, "mighty", 200, day(3)),
+		session(0.2, "mighty", 200, day(40)),
+		collect(0.07, "mighty", 200, day(40)),
+	}
+
+	t.Run("BandsTheTemperature", func(t *testing.T) {
+		assert.Equal(t, 180, Band(185), "Should round down to the band")
+		assert.Equal(t, 180, Band(180), "Should keep a setting on the band's edge")
+		assert.Equal(t, 0, Band(0), "Should leave an unset temperature unbanded")
+	})
+
+	t.Run("GroupsByDeviceAndBand", func(t *testing.T) {
+		yields := Yields(events)
+
+		require.Len(t, yields, 2)
+		assert.Equal(t, Yield{Device: "volcano", Band: 180, Seshed: 0.6, Collected: 0.24}, yields[0],
+			"Should put the sessions at 182°C and 185°C in one band, most seshed first")
+		assert.Equal(t, 0.4, yields[0].Ratio(), "Should divide what was collected by what was seshed")
+		assert.Equal(t, 0.3, yields[1].Ratio(), "Should total the other device apart")
+	})
+
+	t.Run("TracksItOverTime", func(t *testing.T) {
+		months := YieldByMonth(events)
+
+		require.Len(t, months, 2)
+		assert.Equal(t, time.July, months[0].Month.Month(), "Should start with the oldest month")
+		assert.Equal(t, 0.36, months[0].Ratio(), "Should total July across devices")
+		assert.Equal(t, 0.35, months[1].Ratio(), "Should total August on its own")
+	})
+
+	t.Run("LeavesOutCorrectedEntries", func(t *testing.T) {
+		wrong := collect(2, "volcano", 185, day(2))
+		wrong.Hash = "wrong"
+		fix := event(journal.Adjust, "wedding-cake", 2, day(2))
+		fix.Reverts = "wrong"
+
+		total := TotalYield(append([]journal.Event{wrong, fix}, events...))
+
+		assert.Equal(t, 0.36, total.Collected, "Should count neither the mistake nor its correction")
+	})
+
+	t.Run("TotalsTheOutflowByPurpose", func(t *testing.T) {
+		use := func(grams float64, purpose string) journal.Event {
+			e := event(journal.AVBUse, "wedding-cake", grams, day(5))
+			e.Purpose = purpose
+			return e
+		}
+
+		out := Outflows([]journal.Event{use(1, "tincture"), use(2, "edibles"), use(0.5, "edibles"), use(0.2, "")})
+
+		assert.Equal(t, []Outflow{{"edibles", 2.5}, {"tincture", 1}, {"", 0.2}}, out,
+			"Should total each purpose, the largest first")
+	})
+}
+
+func TestSpent(t *testing.T) {
+	priced := func(product string, grams float64, at time.Time, price int64, currency string) journal.Event {
+		e := event(journal.Purchase, product, grams, at)
+		e.Price, e.Currency = price, currency
+		return e
+	}
+	events := []journal.Event{
+		priced("wedding-cake", 20, day(0), 18000, "EUR"),
+		event(journal.Grind, "wedding-cake", 1, day(1)),
+		priced("lemon-cookie", 10, day(10), 10500, "EUR"),
+		event(journal.Purchase, "lemon-cookie", 5, day(12)),
+		priced("wedding-cake", 10, day(200), 9500, "EUR"),
+		priced("wedding-cake", 5, day(201), 6000, "CHF"),
+	}
+
+	t.Run("TotalsPerCurrency", func(t *testing.T) {
+		s := Spent(events)
+		require.Len(t, s.Costs, 2, "Should keep currencies apart")
+		assert.Equal(t, "CHF", s.Costs[0].Currency, "Should order currencies alphabetically")
+		assert.Equal(t, int64(38000), s.In("EUR").Price, "Should add up the euro prices")
+		assert.Equal(t, 40.0, s.In("EUR").Grams, "Should count the grams the euros bought")
+		assert.Equal(t, 9.5, s.In("EUR").PerGram(), "Should work out the price of a gram")
+		assert.Equal(t, 5.0, s.Unpriced, "Should count the unpriced grams apart")
+		assert.Len(t, s.Purchases, 5, "Should list every purchase, priced or not")
+		assert.Zero(t, s.In("USD").Price, "Should cost nothing in a currency never paid in")
+	})
+
+	t.Run("PerProduct", func(t *testing.T) {
+		ps := SpentPerProduct(events)
+		require.Len(t, ps, 2)
+		assert.Equal(t, "lemon-cookie", ps[0].Product, "Should order products by slug")
+		assert.Equal(t, 10.5, ps[0].In("EUR").PerGram(), "Should price a gram of each product")
+		assert.Equal(t, 9.17, ps[1].In("EUR").PerGram(), "Should price a gram across several fills")
+	})
+
+	t.Run("PerYear", func(t *testing.T) {
+		ys := SpentPerYear(events)
+		require.Len(t, ys, 2)
+		assert.Equal(t, 2026, ys[0].Year, "Should put the earliest year first")
+		assert.Equal(t, int64(28500), ys[0].In("EUR").Price, "Should count 2026's purchases in 2026")
+		assert.Equal(t, int64(9500), SpentIn(events, 2027).In("EUR").Price, "Should count 2027's purchases in 2027")
+		assert.Empty(t, SpentIn(events, 2025).Purchases, "Should find nothing in a year without purchases")
+	})
+
+	t.Run("AnAmendedFillCountsOnce", func(t *testing.T) {
+		events := append([]journal.Event{}, events...)
+		events[0].Hash = "abc"
+		correction := journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 20,
+			From: journal.Storage, To: journal.External, OccurredAt: day(2), Reverts: "abc"}
+		amended := priced("wedding-cake", 19.5, day(0), 18000, "EUR")
+		events = append(events, correction, amended)
+		assert.Equal(t, int64(38000), Spent(events).In("EUR").Price, "Should count the amended fill's price once")
+		assert.Equal(t, 39.5, Spent(events).In("EUR").Grams, "Should count the amended weight")
+	})
+
+	t.Run("PerCycle", func(t *testing.T) {
+		s := Fold("", events)
+		require.NotEmpty(t, s.Cycles)
+		assert.Equal(t, int64(18000), Spent(s.Cycles[0].Events).In("EUR").Price, "Should total what the first fill cost")
+	})
+}
+
+func TestDose(t *testing.T) {
+	p := Potency{
+		THC:        map[string]float64{"wedding-cake": 22, "lemon-cookie": 28},
+		CBD:        map[string]float64{"wedding-cake": 1},
+		Efficiency: map[string]int{"volcano": 50},
+	}
+	sesh := func(product string, grams float64, at time.Time, device string) journal.Event {
+		e := event(journal.Sesh, product, grams, at)
+		e.Device = device
+		return e
+	}
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 20, day(0)),
+		event(journal.Grind, "wedding-cake", 2, day(0)),
+		sesh("wedding-cake", 0.3, day(0), "volcano"),
+		sesh("lemon-cookie", 0.2, day(0), "mighty"),
+		sesh("wedding-cake", 0.25, day(1), ""),
+		sesh("mystery", 0.1, day(1), "volcano"),
+	}
+
+	t.Run("OneSession", func(t *testing.T) {
+		d := p.Session(events[2])
+		assert.Equal(t, 66.0, d.THC, "Should find 66mg of THC in 0.3g at 22%")
+		assert.Equal(t, 3.0, d.CBD, "Should find 3mg of CBD in 0.3g at 1%")
+		assert.Equal(t, 33.0, d.ExtractedTHC, "Should assume half of it extracted at 50%")
+		assert.Zero(t, p.Session(events[1]).Sessions, "Should not dose a grind")
+	})
+
+	t.Run("TotalsTheSessions", func(t *testing.T) {
+		d := p.Dose(events)
+		assert.Equal(t, 4, d.Sessions)
+		assert.Equal(t, 66+56+55.0, d.THC, "Should add up the milligrams across products")
+		assert.Equal(t, 0.1, d.Unknown, "Should set apart grams of unknown strength")
+		assert.Equal(t, 0.3, d.Assumed, "Should only assume an extraction where the device has one")
+		assert.Equal(t, 33.0, d.ExtractedTHC)
+		assert.True(t, d.Extracted())
+	})
+
+	t.Run("PerDay", func(t *testing.T) {
+		days := p.PerDay(events)
+		require.Len(t, days, 2)
+		assert.Equal(t, day(0).Format(time.DateOnly), days[0].Day.Format(time.DateOnly), "Should start with the earliest day")
+		assert.Equal(t, 122.0, days[0].THC, "Should total the first day")
+		assert.Equal(t, 55.0, days[1].THC, "Should total the second day")
+	})
+
+	t.Run("LeavesOutCorrectedSessions", func(t *testing.T) {
+		events := append([]journal.Event{}, events...)
+		events[2].Hash = "abc"
+		events = append(events, journal.Event{Type: journal.Adjust, Product: "wedding-cake", Grams: 0.3,
+			From: journal.Consumed, To: journal.Stash, OccurredAt: day(1), Reverts: "abc"})
+		assert.Equal(t, 111.0, p.Dose(events).THC, "Should not count the corrected session")
+	})
+}
+
+func TestBatch(t *testing.T) {
+	p := Potency{
+		THC:        map[string]float64{"wedding-cake": 22, "lemon-cookie": 28, "butter": 0.44},
+		CBD:        map[string]float64{"wedding-cake": 1},
+		Efficiency: map[string]int{"volcano": 50},
+	}
+	sesh := func(product string, grams float64, device string) journal.Event {
+		e := event(journal.Sesh, product, grams, day(1))
+		e.Device = device
+		return e
+	}
+	use := func(product string, grams float64) journal.Event {
+		e := event(journal.AVBUse, product, grams, day(2))
+		e.Purpose, e.Into = "butter", "butter"
+		return e
+	}
+	events := []journal.Event{
+		event(journal.Purchase, "wedding-cake", 20, day(0)),
+		event(journal.Purchase, "lemon-cookie", 10, day(0)),
+		event(journal.Grind, "wedding-cake", 10, day(0)),
+		event(journal.Grind, "lemon-cookie", 2, day(0)),
+		sesh("wedding-cake", 10, "volcano"),
+		sesh("lemon-cookie", 2, "mighty"),
+		event(journal.AVBCollect, "wedding-cake", 8, day(1)),
+		event(journal.AVBCollect, "lemon-cookie", 1.6, day(1)),
+		use("wedding-cake", 4),
+		event(journal.Batch, "butter", 100, day(2)),
+		event(journal.Portion, "butter", 10, day(3)),
+	}
+
+	t.Run("ReadsWhatTheDevicesLeft", func(t *testing.T) {
+		left, ok := p.Residual(events, "wedding-cake")
+		assert.True(t, ok)
+		assert.Equal(t, 0.5, left, "Should leave half behind at 50%")
+
+		_, ok = p.Residual(events, "lemon-cookie")
+		assert.False(t, ok, "Should not guess for a device with no efficiency")
+	})
+
+	t.Run("EstimatesAGram", func(t *testing.T) {
+		est := p.Batch(events, events[8:9], 100, 80, 0)
+
+		// 4g of AVB at 0.8g a gram seshed was 5g of bud: 1100mg of THC,
+		// half of it left, 80% of that taken up.
+		assert.True(t, est.Known())
+		assert.Equal(t, 440.0, est.THC, "Should count the bud the AVB was, not the AVB")
+		assert.Equal(t, 20.0, est.CBD)
+		thc, cbd := est.PerGram()
+		assert.Equal(t, 4.4, thc, "Should spread it over the yield")
+		assert.Equal(t, 0.2, cbd)
+	})
+
+	t.Run("TakesTheResidualGiven", func(t *testing.T) {
+		est := p.Batch(events, []journal.Event{use("lemon-cookie", 1)}, 50, 80, 30)
+
+		assert.True(t, est.Known(), "Should not need an efficiency when told what is left")
+		assert.Equal(t, 84.0, est.THC, "1.25g of bud at 28%, 30% left, 80% taken up")
+	})
+
+	t.Run("KnowsWhatItCannotReckon", func(t *testing.T) {
+		est := p.Batch(events, []journal.Event{use("wedding-cake", 1), use("lemon-cookie", 1)}, 50, 0, 0)
+
+		assert.False(t, est.Known(), "Should not pass off part of the AVB as the whole")
+		assert.Equal(t, []string{"lemon-cookie"}, est.Unknown, "Should say whose AVB it could not reckon")
+		assert.Equal(t, 2.0, est.Grams)
+	})
+
+	t.Run("OpensNoCycle", func(t *testing.T) {
+		s := Fold("", events)
+
+		assert.Len(t, s.Cycles, 1, "A batch is not a fill")
+		assert.Equal(t, 18.0, s.FillOnShelf(s.CurrentCycle()), "and is not on the fill's account")
+		assert.Equal(t, 90.0, s.Balances["butter"].Storage, "Should keep what is left of the batch in storage")
+		assert.Empty(t, s.Lots("butter"), "Should give the batch no lot")
+
+		undone := append([]journal.Event{}, events...)
+		undone[10].Hash = "abc"
+		undone = append(undone, journal.Event{Type: journal.Adjust, Product: "butter", Grams: 10,
+			From: journal.External, To: journal.Storage, OccurredAt: day(4), Reverts: "abc"})
+		s = Fold("", undone)
+		assert.Empty(t, s.Lots("butter"), "Should not credit a fill with a portion put back")
+		assert.Equal(t, 18.0, s.FillOnShelf(s.CurrentCycle()))
+	})
+
+	t.Run("CountsThePortions", func(t *testing.T) {
+		d := p.Eaten(events)
+
+		assert.Equal(t, 1, d.Sessions, "Should count the portions")
+		assert.Equal(t, 44.0, d.THC, "Should dose them by the batch's estimate")
+		assert.Zero(t, p.Dose(events).THC-p.Dose(events[:10]).THC, "and leave the sessions' dose alone")
+		assert.Len(t, Ingredients(events, "butter"), 1, "Should find the AVB that went in")
+	})
+}
+
+func TestAt(t *testing.T) {
+	recorded := func(e journal.Event, at time.Time) journal.Event {
+		e.RecordedAt = at
+		return e
+	}
+	events := []journal.Event{
+		recorded(event(journal.Purchase, "wedding-cake", 20, day(0)), day(0)),
+		recorded(event(journal.Grind, "wedding-cake", 1, day(1)), day(1)),
+		// An evening logged two days late.
+		recorded(event(journal.Grind, "wedding-cake", 2, day(2)), day(4)),
+		recorded(event(journal.Grind, "wedding-cake", 0.5, day(5)), day(5)),
+	}
+	s := FoldFor(Occurred, "", events)
+
+	t.Run("AsItHappened", func(t *testing.T) {
+		then := s.At(day(3), AsOccurred)
+		assert.Equal(t, 17.0, then.Balances["wedding-cake"].Storage, "Should count the grind dated before, however late it was typed")
+		assert.Len(t, then.Events, 3)
+	})
+
+	t.Run("AsRecorded", func(t *testing.T) {
+		then := s.At(day(3), AsRecorded)
+		assert.Equal(t, 19.0, then.Balances["wedding-cake"].Storage, "Should leave out what had not been typed in yet")
+		assert.Len(t, then.Events, 2)
+	})
+
+	t.Run("CountsAnEntryAtTheMomentItself", func(t *testing.T) {
+		assert.Len(t, s.At(day(1), AsOccurred).Events, 2, "Should include the entry stamped at the moment")
+	})
+
+	t.Run("BeforeAnything", func(t *testing.T) {
+		then := s.At(day(-1), AsOccurred)
+		assert.Empty(t, then.Events)
+		assert.Nil(t, then.CurrentCycle(), "Should have no cycle before the first fill")
+	})
+
+	t.Run("KeepsTheOrder", func(t *testing.T) {
+		assert.Len(t, s.At(day(3), AsOccurred).Cycles, 1)
+		assert.Equal(t, 3.0, s.At(day(3), AsOccurred).Cycles[0].Ground, "Should attribute the grinds to the cycle")
+	})
+}
+
+func TestParseMoment(t *testing.T) {
+	late := event(journal.Grind, "wedding-cake", 2, day(2))
+	late.RecordedAt, late.Seq, late.Hash = day(4), 3, "abc123"
+	find := func(v string) (journal.Event, error) {
+		if v == "abc" {
+			return late, nil
+		}
+		return journal.Event{}, fmt.Errorf("no entry matches %s", v)
+	}
+
+	t.Run("ReadsADateAsItsEnd", func(t *testing.T) {
+		m, err := ParseMoment("2026-07-01", find)
+
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, time.July, 2, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond), m.Occurred)
+		assert.Equal(t, m.Occurred, m.Recorded, "Should read a date the same on both clocks")
+		assert.Empty(t, m.Entry)
+	})
+
+	t.Run("ReadsAnEntryByBothItsClocks", func(t *testing.T) {
+		m, err := ParseMoment("abc", find)
+
+		require.NoError(t, err)
+		assert.Equal(t, day(2), m.Occurred)
+		assert.Equal(t, day(4), m.Recorded)
+		assert.Equal(t, 3, m.Seq, "Should tell entries recorded in the same second apart")
+		assert.Equal(t, "abc123", m.Entry)
+	})
+
+	t.Run("RefusesNeither", func(t *testing.T) {
+		_, err := ParseMoment("yesterday", find)
+
+		assert.ErrorContains(t, err, "neither a date nor an entry")
+	})
+}
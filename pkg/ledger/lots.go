@@ -0,0 +1,54 @@
+package ledger
+
+import (
+	"time"
+)
+
+// Lot is one fill's grams still standing in a product's jar. A jar refilled
+// before it was empty holds a lot per fill, and grinds draw them down oldest
+// first, so the lots say whose grams are left.
+type Lot struct {
+	Product   string
+	Cycle     int       // the Seq of the cycle the lot is on the account of
+	Bought    time.Time // when the purchase happened, or when found grams were
+	Purchased float64   // grams the purchase brought; zero for found grams
+	Remaining float64
+	Entry     string // the purchase's hash, empty for found grams
+}
+
+// Found reports whether the lot is grams a reconciliation found in an empty
+// jar rather than a purchase.
+func (l Lot) Found() bool { return l.Entry == "" }
+
+// Lots returns the lots standing in one product's jar, oldest first: the
+// next grind draws on the first. A jar weighed down to zero has none.
+//
+// A lot can hold more than it was bought with when a reconciliation found
+// grams: those go to the newest lot, the present jar.
+func (s *State) Lots(slug string) []Lot {
+	q := s.lots[slug]
+	if len(q) == 0 {
+		return nil
+	}
+	out := make([]Lot, 0, len(q))
+	for _, l := range q {
+		if l.grams <= 0 {
+			continue
+		}
+		out = append(out, Lot{
+			Product: slug, Cycle: l.cycle, Bought: l.bought,
+			Purchased: l.purchased, Remaining: Round(l.grams), Entry: l.entry,
+		})
+	}
+	return out
+}
+
+// AllLots returns the lots of every product, by product in alphabetical
+// order and oldest first within each.
+func (s *State) AllLots() []Lot {
+	var out []Lot
+	for _, slug := range s.Products() {
+		out = append(out, s.Lots(slug)...)
+	}
+	return out
+}
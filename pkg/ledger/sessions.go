@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"sort"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Spread is how a run of amounts is distributed: the smallest, the quartiles
+// and the largest. The quartiles are the medians of the lower and upper half.
+type Spread struct {
+	Min, Lower, Median, Upper, Max float64
+}
+
+// spreadOf returns the spread of sorted amounts.
+func spreadOf(sorted []float64) Spread {
+	n := len(sorted)
+	if n == 0 {
+		return Spread{}
+	}
+	return Spread{
+		Min:    sorted[0],
+		Lower:  median(sorted[:n/2+n%2]),
+		Median: median(sorted),
+		Upper:  median(sorted[n/2:]),
+		Max:    sorted[n-1],
+	}
+}
+
+// SessionStats is Stats for the sessions, with what only sessions have: a
+// count, a size and a temperature.
+//
+// The grind statistics say how fast the storage goes; these say how fast the
+// stash does, and what a session is like. The imported years recorded only
+// grinding, so these cover what has been logged since.
+type SessionStats struct {
+	Stats                   // grams seshed out of the stash, per day
+	Sessions        int     // standing sessions
+	DailySessions   float64 // sessions a day with a session
+	ElapsedSessions float64 // sessions a calendar day
+	PerSession      Spread  // grams per session
+	MeanPerSession  float64 // grams
+	Temperatures    int     // sessions with a temperature set
+	MedianTemp      int     // °C; zero when none was set
+}
+
+// Sessions summarises the standing sessions among events. Corrected sessions
+// are left out along with their corrections, as Dose leaves them out.
+func Sessions(events []journal.Event) SessionStats {
+	standing := Standing(events)
+	st := SessionStats{Stats: SummariseBy(Seshing, standing)}
+	var grams, temps []float64
+	for _, e := range standing {
+		if !Seshing.Counts(e) {
+			continue
+		}
+		st.Sessions++
+		grams = append(grams, e.Grams)
+		if e.Temperature > 0 {
+			temps = append(temps, float64(e.Temperature))
+		}
+	}
+	if st.Sessions == 0 {
+		return st
+	}
+	sort.Float64s(grams)
+	st.PerSession = spreadOf(grams)
+	st.MeanPerSession = Round(st.Grams / float64(st.Sessions))
+	// Both day counts are at least one once there is a session.
+	st.DailySessions = Round(float64(st.Sessions) / float64(st.ActiveDays))
+	st.ElapsedSessions = Round(float64(st.Sessions) / float64(st.ElapsedDays))
+	if len(temps) > 0 {
+		sort.Float64s(temps)
+		st.Temperatures = len(temps)
+		st.MedianTemp = int(median(temps) + 0.5)
+	}
+	return st
+}
@@ -0,0 +1,145 @@
+package ledger
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// Cost is what purchases in one currency came to.
+type Cost struct {
+	Currency string
+	Price    int64   // in the currency's hundredths
+	Grams    float64 // the grams those purchases brought
+}
+
+// Amount returns the price in whole units of the currency.
+func (c Cost) Amount() float64 { return float64(c.Price) / 100 }
+
+// PerGram returns the price of a gram in whole units, or 0 when nothing was
+// bought.
+func (c Cost) PerGram() float64 {
+	if c.Grams <= 0 {
+		return 0
+	}
+	return Round(c.Amount() / c.Grams)
+}
+
+// Spending is what a run of purchases cost, totalled per currency. Grams
+// bought without a price are counted apart rather than as free: a claim that
+// quietly left them out would come to less than was paid.
+type Spending struct {
+	Costs     []Cost          // one per currency, in alphabetical order
+	Unpriced  float64         // grams bought with no price on record
+	Purchases []journal.Event // the purchases counted, priced or not
+}
+
+// Priced reports whether any purchase carried a price.
+func (s Spending) Priced() bool { return len(s.Costs) > 0 }
+
+// In returns the cost in a currency, which is zero when nothing was bought
+// in it.
+func (s Spending) In(currency string) Cost {
+	for _, c := range s.Costs {
+		if c.Currency == currency {
+			return c
+		}
+	}
+	return Cost{Currency: currency}
+}
+
+// Spent totals the standing purchases among events. Entries that were
+// corrected are left out along with their corrections, so an amended fill is
+// counted once, at its corrected weight and its original price.
+func Spent(events []journal.Event) Spending {
+	var s Spending
+	costs := map[string]*Cost{}
+	for _, e := range Standing(events) {
+		if e.Type != journal.Purchase {
+			continue
+		}
+		s.Purchases = append(s.Purchases, e)
+		if e.Price == 0 {
+			s.Unpriced = Round(s.Unpriced + e.Grams)
+			continue
+		}
+		c, ok := costs[e.Currency]
+		if !ok {
+			c = &Cost{Currency: e.Currency}
+			costs[e.Currency] = c
+		}
+		c.Price += e.Price
+		c.Grams = Round(c.Grams + e.Grams)
+	}
+	for _, c := range costs {
+		s.Costs = append(s.Costs, *c)
+	}
+	slices.SortFunc(s.Costs, func(a, b Cost) int { return cmp.Compare(a.Currency, b.Currency) })
+	return s
+}
+
+// ProductSpending is what one product's purchases cost.
+type ProductSpending struct {
+	Product string
+	Spending
+}
+
+// SpentPerProduct totals the standing purchases of each product, in the order
+// of their slugs. Products that were never bought are left out.
+func SpentPerProduct(events []journal.Event) []ProductSpending {
+	byProduct := map[string][]journal.Event{}
+	for _, e := range events {
+		byProduct[e.Product] = append(byProduct[e.Product], e)
+	}
+	var out []ProductSpending
+	for product, evs := range byProduct {
+		if s := Spent(evs); len(s.Purchases) > 0 {
+			out = append(out, ProductSpending{Product: product, Spending: s})
+		}
+	}
+	slices.SortFunc(out, func(a, b ProductSpending) int { return cmp.Compare(a.Product, b.Product) })
+	return out
+}
+
+// YearSpending is what the purchases of one calendar year cost.
+type YearSpending struct {
+	Year int
+	Spending
+}
+
+// SpentPerYear totals the standing purchases of each calendar year, the
+// earliest first. A purchase belongs to the year it occurred in, in the zone
+// it was recorded in, which is the year its receipt is dated.
+func SpentPerYear(events []journal.Event) []YearSpending {
+	byYear := map[int][]journal.Event{}
+	for _, e := range events {
+		byYear[e.OccurredAt.Year()] = append(byYear[e.OccurredAt.Year()], e)
+	}
+	var out []YearSpending
+	for year, evs := range byYear {
+		if s := Spent(evs); len(s.Purchases) > 0 {
+			out = append(out, YearSpending{Year: year, Spending: s})
+		}
+	}
+	slices.SortFunc(out, func(a, b YearSpending) int { return cmp.Compare(a.Year, b.Year) })
+	return out
+}
+
+// SpentIn totals the standing purchases of one calendar year.
+func SpentIn(events []journal.Event, year int) Spending {
+	var in []journal.Event
+	for _, e := range events {
+		if e.OccurredAt.Year() == year {
+			in = append(in, e)
+		}
+	}
+	return Spent(in)
+}
+
+// Money renders a price in hundredths with its currency, the way a receipt
+// writes it: "189.90 EUR".
+func Money(price int64, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", price/100, price%100, currency)
+}
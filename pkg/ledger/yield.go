@@ -0,0 +1,183 @@
+package ledger
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+)
+
+// BandWidth is how many degrees one temperature band spans. Ten is about as
+// fine as a dial is set and a scale reads: 183°C and 186°C leave the same bud
+// behind, 175°C and 195°C do not.
+const BandWidth = 10
+
+// Band returns the lowest temperature of the band a setting falls in, or 0 for
+// a session with no temperature set.
+func Band(celsius int) int {
+	if celsius <= 0 {
+		return 0
+	}
+	return celsius - celsius%BandWidth
+}
+
+// Yield is what the sessions on one device in one temperature band left
+// behind as AVB. Grams are matched by their device and temperature, not
+// session by session: a device is emptied after several sessions, and one
+// weighing stands for all of them.
+type Yield struct {
+	Device    string // empty for sessions logged without one
+	Band      int    // see Band; 0 when no temperature was set
+	Seshed    float64
+	Collected float64
+}
+
+// Ratio returns the grams of AVB collected per gram seshed, or 0 when nothing
+// was seshed to collect from.
+func (y Yield) Ratio() float64 {
+	if y.Seshed <= 0 {
+		return 0
+	}
+	return Round(y.Collected / y.Seshed)
+}
+
+// YieldMonth is the yield across every device over one calendar month.
+type YieldMonth struct {
+	Month time.Time // the first of the month, in the entries' own zone
+	Yield
+}
+
+// Yields totals the grams seshed and the AVB collected per device and
+// temperature band, the most seshed first. Entries that were corrected are
+// left out along with their corrections, as they are everywhere a view wants
+// only what currently stands.
+func Yields(events []journal.Event) []Yield {
+	type key struct {
+		device string
+		band   int
+	}
+	byKey := map[key]*Yield{}
+	for _, e := range yielding(events) {
+		k := key{e.Device, Band(e.Temperature)}
+		y, ok := byKey[k]
+		if !ok {
+			y = &Yield{Device: k.device, Band: k.band}
+			byKey[k] = y
+		}
+		count(y, e)
+	}
+	out := make([]Yield, 0, len(byKey))
+	for _, y := range byKey {
+		out = append(out, *y)
+	}
+	slices.SortFunc(out, func(a, b Yield) int {
+		if c := cmp.Compare(b.Seshed, a.Seshed); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Device, b.Device); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Band, b.Band)
+	})
+	return out
+}
+
+// YieldByMonth totals the same figures as Yields per calendar month, oldest
+// first, so that a change of device or habit shows up as a change over time.
+func YieldByMonth(events []journal.Event) []YieldMonth {
+	byMonth := map[time.Time]*YieldMonth{}
+	for _, e := range yielding(events) {
+		y, m, _ := e.OccurredAt.Date()
+		month := time.Date(y, m, 1, 0, 0, 0, 0, e.OccurredAt.Location())
+		ym, ok := byMonth[month]
+		if !ok {
+			ym = &YieldMonth{Month: month}
+			byMonth[month] = ym
+		}
+		count(&ym.Yield, e)
+	}
+	out := make([]YieldMonth, 0, len(byMonth))
+	for _, ym := range byMonth {
+		out = append(out, *ym)
+	}
+	slices.SortFunc(out, func(a, b YieldMonth) int { return a.Month.Compare(b.Month) })
+	return out
+}
+
+// TotalYield is Yields summed over every device and band.
+func TotalYield(events []journal.Event) Yield {
+	var y Yield
+	for _, e := range yielding(events) {
+		count(&y, e)
+	}
+	return y
+}
+
+// Outflow is the AVB used for one purpose.
+type Outflow struct {
+	Purpose string // empty for a use that gave none
+	Grams   float64
+}
+
+// Outflows totals the AVB used by purpose, the largest first, leaving out
+// corrected entries as Yields does.
+func Outflows(events []journal.Event) []Outflow {
+	byPurpose := map[string]float64{}
+	for _, e := range Standing(events) {
+		if e.Type == journal.AVBUse {
+			byPurpose[e.Purpose] = Round(byPurpose[e.Purpose] + e.Grams)
+		}
+	}
+	out := make([]Outflow, 0, len(byPurpose))
+	for p, g := range byPurpose {
+		out = append(out, Outflow{Purpose: p, Grams: g})
+	}
+	slices.SortFunc(out, func(a, b Outflow) int {
+		if c := cmp.Compare(b.Grams, a.Grams); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Purpose, b.Purpose)
+	})
+	return out
+}
+
+// count adds a session or a collection to a yield.
+func count(y *Yield, e journal.Event) {
+	switch e.Type {
+	case journal.Sesh:
+		y.Seshed = Round(y.Seshed + e.Grams)
+	case journal.AVBCollect:
+		y.Collected = Round(y.Collected + e.Grams)
+	}
+}
+
+// yielding returns the sessions and collections that still stand.
+func yielding(events []journal.Event) []journal.Event {
+	var out []journal.Event
+	for _, e := range Standing(events) {
+		if e.Type == journal.Sesh || e.Type == journal.AVBCollect {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Standing returns the entries that still stand, leaving out every corrected
+// entry and every correction.
+func Standing(events []journal.Event) []journal.Event {
+	corrected := map[string]bool{}
+	for _, e := range events {
+		if e.Reverts != "" {
+			corrected[e.Reverts] = true
+		}
+	}
+	out := make([]journal.Event, 0, len(events))
+	for _, e := range events {
+		if e.Reverts == "" && !corrected[e.Hash] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,6 +26,9 @@ type Recorder struct {
 	products *catalog.Catalog
 	devices  *catalog.Devices
 	state    *ledger.State
+
+	// warn, when set, is told of an overdraft instead of it being refused.
+	warn func(error)
 }
 
 // New returns a recorder over the given repository state.
@@ -39,91 +43,550 @@ func New(r *repo.Repo, products *catalog.Catalog, devices *catalog.Devices, stat
 // that is not already taken. It is the name every later entry refers to, so it
 // is settled once, when the product first appears, and never afterwards.
 func (r *Recorder) Buy(name, slug string, grams float64, at time.Time) (journal.Event, *catalog.Product, bool, error) {
-	product, err := r.products.Find(name)
-	added := false
+	return r.BuyPriced(name, slug, grams, at, 0, "")
+}
+
+// BuyPriced records a purchase the way Buy does, along with what it cost: a
+// price in the currency's hundredths, and the currency's code.
+func (r *Recorder) BuyPriced(name, slug string, grams float64, at time.Time, price int64, currency string) (journal.Event, *catalog.Product, bool, error) {
+	product, added, err := r.Register(name, slug)
 	if err != nil {
-		product = catalog.Parse(name)
-		if slug != "" {
-			if err := catalog.CheckSlug(slug); err != nil {
-				return journal.Event{}, nil, false, err
-			}
-			if r.products.Taken(slug) {
-				return journal.Event{}, nil, false, fmt.Errorf("the slug %q is already in use", slug)
-			}
-			product.Slug = slug
-		} else {
-			product.Slug = catalog.NewHandle(product, r.products.Handles())
+		return journal.Event{}, nil, false, err
+	}
+	e, err := r.append(func() (journal.Event, error) {
+		return journal.Event{
+			Type:       journal.Purchase,
+			Product:    product.Slug,
+			Grams:      grams,
+			OccurredAt: at,
+			Price:      price,
+			Currency:   currency,
+		}, nil
+	})
+	return e, product, added, err
+}
+
+// Register resolves a product the way Buy does, adding it to the catalog
+// under slug, or a handle made from its name, if it is not there yet. It
+// reports whether the product was added. A prescription names products before
+// any of them is bought.
+func (r *Recorder) Register(name, slug string) (*catalog.Product, bool, error) {
+	if product, err := r.products.Find(name); err == nil {
+		return product, false, nil
+	}
+	product := catalog.Parse(name)
+	if slug != "" {
+		if err := catalog.CheckSlug(slug); err != nil {
+			return nil, false, err
 		}
-		if err := r.products.Add(product); err != nil {
-			return journal.Event{}, nil, false, err
+		if r.products.Taken(slug) {
+			return nil, false, fmt.Errorf("the slug %q is already in use", slug)
 		}
-		if err := r.products.Save(r.repo.ProductsPath()); err != nil {
-			return journal.Event{}, nil, false, err
+		product.Slug = slug
+	} else {
+		product.Slug = catalog.NewHandle(product, r.products.Handles())
+	}
+	if err := r.products.Add(product); err != nil {
+		return nil, false, err
+	}
+	if err := r.repo.SaveProducts(r.products); err != nil {
+		return nil, false, err
+	}
+	return product, true, nil
+}
+
+// Grind moves grams from a product's storage into its stash, at home.
+func (r *Recorder) Grind(ref string, grams float64, at time.Time) (journal.Event, error) {
+	return r.GrindAt(ref, grams, at, "")
+}
+
+// GrindAt is Grind at a location: the grams are taken from the storage there
+// and ground into the stash there, the travel tin's into the travel tin.
+func (r *Recorder) GrindAt(ref string, grams float64, at time.Time, location string) (journal.Event, error) {
+	product, err := r.products.Find(ref)
+	if err != nil {
+		return journal.Event{}, err
+	}
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, journal.Storage, location, at); err != nil {
+			return journal.Event{}, err
 		}
-		added = true
+		return journal.Event{
+			Type:         journal.Grind,
+			Product:      product.Slug,
+			Grams:        grams,
+			OccurredAt:   at,
+			FromLocation: location,
+			ToLocation:   location,
+		}, nil
+	})
+}
+
+// Session records a session drawing on a product's stash at home.
+func (r *Recorder) Session(ref string, grams float64, at time.Time, device string, temp int, note string) (journal.Event, error) {
+	return r.SessionAt(ref, grams, at, "", device, temp, note)
+}
+
+// SessionAt is Session drawing on the stash at a location.
+func (r *Recorder) SessionAt(ref string, grams float64, at time.Time, location, device string, temp int, note string) (journal.Event, error) {
+	product, err := r.products.Find(ref)
+	if err != nil {
+		return journal.Event{}, err
+	}
+
+	slug, temp, err := r.setting(device, temp)
+	if err != nil {
+		return journal.Event{}, err
 	}
-	e, err := r.append(journal.Event{
-		Type:       journal.Purchase,
-		Product:    product.Slug,
-		Grams:      grams,
-		OccurredAt: at,
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, journal.Stash, location, at); err != nil {
+			return journal.Event{}, err
+		}
+		return journal.Event{
+			Type:         journal.Sesh,
+			Product:      product.Slug,
+			Grams:        grams,
+			OccurredAt:   at,
+			Device:       slug,
+			Temperature:  temp,
+			Note:         note,
+			FromLocation: location,
+		}, nil
 	})
-	return e, product, added, err
 }
 
-// Grind moves grams from a product's storage into its stash.
-func (r *Recorder) Grind(ref string, grams float64, at time.Time) (journal.Event, error) {
+// Move carries grams of a product from one location of its storage or its
+// stash to another. The account keeps every gram it had; only where they sit
+// changes, and a move cannot take more than the location it is from holds.
+func (r *Recorder) Move(ref string, account journal.Account, grams float64, from, to string, at time.Time, note string) (journal.Event, error) {
+	if !journal.Placed(account) {
+		return journal.Event{}, fmt.Errorf("%s is not kept in locations; storage and the stash are", account)
+	}
+	product, err := r.products.Find(ref)
+	if err != nil {
+		return journal.Event{}, err
+	}
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, account, from, at); err != nil {
+			return journal.Event{}, err
+		}
+		return journal.Event{
+			Type:         journal.Move,
+			Product:      product.Slug,
+			Grams:        grams,
+			From:         account,
+			To:           account,
+			FromLocation: from,
+			ToLocation:   to,
+			OccurredAt:   at,
+			Note:         note,
+		}, nil
+	})
+}
+
+// setting resolves a device reference to its slug, filling in its default
+// temperature when none was given and refusing one it cannot reach. No device
+// at all is allowed, and resolves to nothing.
+func (r *Recorder) setting(device string, temp int) (string, int, error) {
+	if device == "" {
+		return "", temp, nil
+	}
+	d, err := r.devices.Find(device)
+	if err != nil {
+		return "", 0, err
+	}
+	if temp == 0 {
+		temp = d.DefaultTemp
+	}
+	if d.MaxTemp > 0 && temp > d.MaxTemp {
+		return "", 0, fmt.Errorf("%s only goes up to %d°C", d.Name, d.MaxTemp)
+	}
+	return d.Slug, temp, nil
+}
+
+// Collect records the already vaped bud weighed out of a device, moving it
+// from what the product's sessions consumed into its AVB.
+//
+// The yield is read by device and temperature, so a collection carries both.
+// Left out, they are taken from the last session of the product before it:
+// a device is emptied after the sessions that filled it, and that is almost
+// always the one it came out of.
+func (r *Recorder) Collect(ref string, grams float64, at time.Time, device string, temp int, note string) (journal.Event, error) {
 	product, err := r.products.Find(ref)
 	if err != nil {
 		return journal.Event{}, err
 	}
-	if err := r.check(product.Slug, grams, journal.Storage); err != nil {
+	if device == "" {
+		if last := r.lastSession(product.Slug, at); last != nil {
+			device = last.Device
+			if temp == 0 {
+				temp = last.Temperature
+			}
+		}
+	}
+	slug, temp, err := r.setting(device, temp)
+	if err != nil {
 		return journal.Event{}, err
 	}
-	return r.append(journal.Event{
-		Type:       journal.Grind,
-		Product:    product.Slug,
-		Grams:      grams,
-		OccurredAt: at,
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, journal.Consumed, "", at); err != nil {
+			return journal.Event{}, err
+		}
+		return journal.Event{
+			Type:        journal.AVBCollect,
+			Product:     product.Slug,
+			Grams:       grams,
+			OccurredAt:  at,
+			Device:      slug,
+			Temperature: temp,
+			Note:        note,
+		}, nil
 	})
 }
 
-// Session records a session drawing on a product's stash.
-func (r *Recorder) Session(ref string, grams float64, at time.Time, device string, temp int, note string) (journal.Event, error) {
+// lastSession returns the latest session of a product at or before a time,
+// or nil if it has none.
+func (r *Recorder) lastSession(slug string, at time.Time) *journal.Event {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	var last *journal.Event
+	for i, e := range r.state.Events {
+		if e.Type != journal.Sesh || e.Product != slug || e.OccurredAt.After(at) {
+			continue
+		}
+		if last == nil || !e.OccurredAt.Before(last.OccurredAt) {
+			last = &r.state.Events[i]
+		}
+	}
+	return last
+}
+
+// UseAVB records AVB drawn down for something, edibles or a tincture. The
+// purpose is kept on the entry in lower case, so the outflow totals by it
+// however it was typed.
+func (r *Recorder) UseAVB(ref string, grams float64, at time.Time, purpose, note string) (journal.Event, error) {
 	product, err := r.products.Find(ref)
 	if err != nil {
 		return journal.Event{}, err
 	}
+	purpose = strings.ToLower(strings.TrimSpace(purpose))
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, journal.AVB, "", at); err != nil {
+			return journal.Event{}, err
+		}
+		return journal.Event{
+			Type:       journal.AVBUse,
+			Product:    product.Slug,
+			Grams:      grams,
+			OccurredAt: at,
+			Purpose:    purpose,
+			Note:       note,
+		}, nil
+	})
+}
 
-	var slug string
-	if device != "" {
-		d, err := r.devices.Find(device)
+// Ingredient is AVB drawn for a batch: grams of one product's AVB, or, with
+// no product, grams from every AVB jar in proportion to what each holds.
+type Ingredient struct {
+	Product string
+	Grams   float64
+}
+
+// Recipe is a batch as it is made: what it is, the AVB that went into it,
+// what came out, and how its strength is reckoned.
+type Recipe struct {
+	Kind     string // what it is made as: butter, tincture
+	Slug     string // the batch's slug; left empty, one is made from Kind
+	AVB      []Ingredient
+	Yield    float64 // grams of batch made
+	Decarb   int     // see ledger.Potency.Batch
+	Residual int     // see ledger.Potency.Batch
+	Note     string
+}
+
+// Made is a batch as recorded: its entry, the AVB uses it drew, the product it
+// became and what it is reckoned to hold.
+type Made struct {
+	Batch    journal.Event
+	Uses     []journal.Event
+	Product  *catalog.Product
+	Estimate ledger.Estimate
+}
+
+// MakeBatch records a batch made from AVB. The AVB is drawn down by a use per
+// product, for the recipe's kind and naming the batch, and the batch comes
+// into storage as a product of its own, with the milligrams of THC and CBD a
+// gram of it is estimated to hold standing where a label's percentage would.
+// The uses and the batch are written together, so no AVB is drawn for a batch
+// that was not recorded. The product is in the catalog before they are, so
+// the journal never names a batch the catalog does not know, and taken out
+// again if they are refused, so a refused batch leaves none behind.
+//
+// A batch whose estimate could not be made from every gram that went in is
+// recorded without a potency, rather than with one that reads too weak.
+func (r *Recorder) MakeBatch(recipe Recipe, potency ledger.Potency, at time.Time) (Made, error) {
+	kind := strings.ToLower(strings.TrimSpace(recipe.Kind))
+	if kind == "" {
+		return Made{}, fmt.Errorf("say what the batch is, such as butter or tincture")
+	}
+	if recipe.Yield <= 0 {
+		return Made{}, fmt.Errorf("a batch yields some grams, got %v", recipe.Yield)
+	}
+	if len(recipe.AVB) == 0 {
+		return Made{}, fmt.Errorf("a batch is made from some AVB")
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	product, err := r.batchProduct(kind, recipe.Slug, at)
+	if err != nil {
+		return Made{}, err
+	}
+	if err := r.products.Add(product); err != nil {
+		return Made{}, err
+	}
+	var made Made
+	stored, err := r.appendAll(func() ([]journal.Event, error) {
+		draws, err := r.draws(recipe.AVB)
 		if err != nil {
+			return nil, err
+		}
+		events := make([]journal.Event, 0, len(draws)+1)
+		for _, d := range draws {
+			if err := r.check(d.Product, d.Grams, journal.AVB, "", at); err != nil {
+				return nil, err
+			}
+			events = append(events, journal.Event{
+				Type:       journal.AVBUse,
+				Product:    d.Product,
+				Grams:      d.Grams,
+				OccurredAt: at,
+				Purpose:    kind,
+				Into:       product.Slug,
+			})
+		}
+		made.Estimate = potency.Batch(r.state.Events, events, recipe.Yield, recipe.Decarb, recipe.Residual)
+		// The catalog keeps percentages, and a percent is ten milligrams a gram.
+		product.THC, product.CBD = 0, 0
+		if made.Estimate.Known() {
+			thc, cbd := made.Estimate.PerGram()
+			product.THC, product.CBD = thc/10, cbd/10
+		}
+		if err := r.repo.SaveProducts(r.products); err != nil {
+			return nil, err
+		}
+		return append(events, journal.Event{
+			Type:       journal.Batch,
+			Product:    product.Slug,
+			Grams:      recipe.Yield,
+			OccurredAt: at,
+			Note:       recipe.Note,
+		}), nil
+	})
+	if err != nil {
+		return Made{}, r.unlist(product, err)
+	}
+	made.Uses, made.Batch, made.Product = stored[:len(stored)-1], stored[len(stored)-1], product
+	return made, nil
+}
+
+// unlist takes a product back out of the catalog when the entry it was added
+// for was refused, and returns why it was. A catalog that cannot be saved
+// without it says so too, since the product is then left behind with no entry.
+func (r *Recorder) unlist(product *catalog.Product, refused error) error {
+	r.products.Products = slices.DeleteFunc(r.products.Products, func(p *catalog.Product) bool { return p == product })
+	if err := r.repo.SaveProducts(r.products); err != nil {
+		return fmt.Errorf("%w; %s is left in the catalog: %v", refused, product.Slug, err)
+	}
+	return refused
+}
+
+// batchProduct makes the product a batch becomes, without adding it to the
+// catalog: under slug if one was given, otherwise under its kind, numbered
+// from the second batch of that kind on. It is named for its kind and the day
+// it was made.
+func (r *Recorder) batchProduct(kind, slug string, at time.Time) (*catalog.Product, error) {
+	if slug == "" {
+		base := catalog.Slugify(kind)
+		slug = base
+		for n := 2; r.products.Taken(slug); n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+	} else if r.products.Taken(slug) {
+		return nil, fmt.Errorf("the slug %q is already in use", slug)
+	}
+	if err := catalog.CheckSlug(slug); err != nil {
+		return nil, err
+	}
+	name := []rune(kind)
+	product := &catalog.Product{
+		Slug: slug,
+		Name: strings.ToUpper(string(name[0])) + string(name[1:]) + " " + at.Format(time.DateOnly),
+		Made: kind,
+	}
+	return product, nil
+}
+
+// draws resolves the AVB a batch is made from to grams of each product. Grams
+// given without a product are shared out over every AVB jar by what each
+// holds, to the hundredth, the last jar taking whatever rounding left over.
+func (r *Recorder) draws(ingredients []Ingredient) ([]Ingredient, error) {
+	var out []Ingredient
+	for _, in := range ingredients {
+		if in.Grams <= 0 {
+			return nil, fmt.Errorf("grams must be positive, got %v", in.Grams)
+		}
+		if in.Product != "" {
+			product, err := r.products.Find(in.Product)
+			if err != nil {
+				return nil, err
+			}
+			// Each draw is checked against the jar as it stands, so two of
+			// the same jar could take more than it holds between them.
+			for _, d := range out {
+				if d.Product == product.Slug {
+					return nil, fmt.Errorf("the AVB of %s is named twice", product.Slug)
+				}
+			}
+			out = append(out, Ingredient{Product: product.Slug, Grams: in.Grams})
+			continue
+		}
+		if len(ingredients) > 1 {
+			return nil, fmt.Errorf("draw from every AVB jar or from named ones, not both")
+		}
+		var jars []string
+		var total float64
+		for _, slug := range r.state.Products() {
+			if g := r.state.Balances[slug].AVB; g > 0 {
+				jars = append(jars, slug)
+				total += g
+			}
+		}
+		if total < in.Grams {
+			return nil, fmt.Errorf("only %.2fg of AVB in the jars, cannot take %.2fg", total, in.Grams)
+		}
+		left := in.Grams
+		for i, slug := range jars {
+			g := round(in.Grams * r.state.Balances[slug].AVB / total)
+			if i == len(jars)-1 {
+				g = round(left)
+			}
+			left -= g
+			if g > 0 {
+				out = append(out, Ingredient{Product: slug, Grams: g})
+			}
+		}
+	}
+	return out, nil
+}
+
+// Portion records a portion of a batch taken out of storage at a location,
+// home for the empty one. Only a batch is taken by the portion; a dispensed
+// product is ground and seshed.
+func (r *Recorder) Portion(ref string, grams float64, at time.Time, location, note string) (journal.Event, error) {
+	product, err := r.products.Find(ref)
+	if err != nil {
+		return journal.Event{}, err
+	}
+	if product.Made == "" {
+		return journal.Event{}, fmt.Errorf("%s is not a batch; grind it and sesh it", product.Slug)
+	}
+	return r.append(func() (journal.Event, error) {
+		if err := r.check(product.Slug, grams, journal.Storage, location, at); err != nil {
 			return journal.Event{}, err
 		}
-		slug = d.Slug
-		if temp == 0 {
-			temp = d.DefaultTemp
+		return journal.Event{
+			Type:         journal.Portion,
+			Product:      product.Slug,
+			Grams:        grams,
+			OccurredAt:   at,
+			Note:         note,
+			FromLocation: location,
+		}, nil
+	})
+}
+
+// Feel records how the symptoms were, moving no grams. A session may be named
+// by its hash, or as "last" for the latest session at or before the feel; the
+// feel then carries that session's product, so a score can be read against
+// what was in the device. Without one it stands on its own.
+func (r *Recorder) Feel(scores journal.Scores, at time.Time, session, note string) (journal.Event, error) {
+	return r.append(func() (journal.Event, error) {
+		e := journal.Event{Type: journal.Feel, OccurredAt: at, Scores: scores, Note: note}
+		if session == "" {
+			return e, nil
 		}
-		if d.MaxTemp > 0 && temp > d.MaxTemp {
-			return journal.Event{}, fmt.Errorf("%s only goes up to %d°C", d.Name, d.MaxTemp)
+		s, err := r.sessionOf(session, at)
+		if err != nil {
+			return journal.Event{}, err
 		}
+		e.Session, e.Product = s.Hash, s.Product
+		return e, nil
+	})
+}
+
+// sessionOf resolves the session a feel follows. A feel can only follow a
+// session that happened before it and still stands.
+func (r *Recorder) sessionOf(ref string, at time.Time) (journal.Event, error) {
+	if at.IsZero() {
+		at = time.Now()
 	}
-	if err := r.check(product.Slug, grams, journal.Stash); err != nil {
+	if ref == "last" {
+		var last journal.Event
+		for _, e := range ledger.Standing(r.state.Events) {
+			if e.Type == journal.Sesh && !e.OccurredAt.After(at) && !e.OccurredAt.Before(last.OccurredAt) {
+				last = e
+			}
+		}
+		if last.Hash == "" {
+			return journal.Event{}, fmt.Errorf("no session by %s to follow", day(at))
+		}
+		return last, nil
+	}
+	s, err := r.Find(ref)
+	if err != nil {
 		return journal.Event{}, err
 	}
-	return r.append(journal.Event{
-		Type:        journal.Sesh,
-		Product:     product.Slug,
-		Grams:       grams,
-		OccurredAt:  at,
-		Device:      slug,
-		Temperature: temp,
-		Note:        note,
+	switch {
+	case s.Type != journal.Sesh:
+		return journal.Event{}, fmt.Errorf("%s is a %s, not a session", short(s.Hash), s.Type)
+	case r.RevertOf(s.Hash) != nil:
+		return journal.Event{}, fmt.Errorf("%s has been corrected", short(s.Hash))
+	case s.OccurredAt.After(at):
+		return journal.Event{}, fmt.Errorf("%s was on %s, after the feel", short(s.Hash), day(s.OccurredAt))
+	}
+	return s, nil
+}
+
+// Break declares a tolerance break of days days from at. One break at a time:
+// a break declared while another runs would leave two ends to the same pause,
+// so the one running has to be reverted first.
+func (r *Recorder) Break(days int, at time.Time, note string) (journal.Event, error) {
+	return r.append(func() (journal.Event, error) {
+		if days <= 0 {
+			return journal.Event{}, fmt.Errorf("a break lasts at least a day, not %d", days)
+		}
+		if b, ok := ledger.BreakAt(r.state.Events, at); ok {
+			return journal.Event{}, fmt.Errorf("the break %s declared runs until %s; revert it to declare another",
+				short(b.Event.Hash), day(b.End))
+		}
+		return journal.Event{Type: journal.Break, OccurredAt: at, Days: days, Note: note}, nil
 	})
 }
 
-// Available returns how many grams of a product sit in an account.
+// AvailableAt returns how many grams of a product sit in one location of an
+// account, home for the empty one.
+func (r *Recorder) AvailableAt(slug string, account journal.Account, location string) float64 {
+	b := r.state.Balances[slug]
+	if b == nil {
+		return 0
+	}
+	return b.In(account, location)
+}
+
+// Available returns how many grams of a product sit in an account, wherever
+// they are.
 func (r *Recorder) Available(slug string, account journal.Account) float64 {
 	b := r.state.Balances[slug]
 	if b == nil {
@@ -134,6 +597,8 @@ func (r *Recorder) Available(slug string, account journal.Account) float64 {
 		return b.Storage
 	case journal.Stash:
 		return b.Stash
+	case journal.Consumed:
+		return b.Consumed
 	case journal.AVB:
 		return b.AVB
 	default:
@@ -141,33 +606,168 @@ func (r *Recorder) Available(slug string, account journal.Account) float64 {
 	}
 }
 
+// OverdraftError is an entry refused because it would draw an account below
+// zero, on the day it occurred or on any day after it.
+type OverdraftError struct {
+	Product  string
+	Account  journal.Account
+	Location string    // where in the account, named once there is more than home
+	Grams    float64   // what the entry takes
+	At       time.Time // when the entry occurred
+	Short    time.Time // when the account comes up short, At itself or later
+	Have     float64   // what the account holds then
+	dated    bool      // whether At needs saying: something occurred after it
+}
+
+func (e *OverdraftError) Error() string {
+	// The same names the reconcile forms use, so an account is called one
+	// thing everywhere. Undoing an avb-collect used to complain about
+	// "storage", which is not where the grams were.
+	where := "in " + string(e.Account)
+	if name, ok := reconcilable[e.Account]; ok {
+		where = "in " + name
+	} else if e.Account == journal.Consumed {
+		where = "seshed and not yet collected"
+	}
+	if e.Location != "" {
+		where += " at " + e.Location
+	}
+	switch {
+	case !e.dated:
+		return fmt.Sprintf("only %.2fg of %s %s, cannot take %.2fg", e.Have, e.Product, where, e.Grams)
+	case e.Short.Equal(e.At):
+		return fmt.Sprintf("only %.2fg of %s %s on %s, cannot take %.2fg",
+			e.Have, e.Product, where, day(e.At), e.Grams)
+	default:
+		return fmt.Sprintf("only %.2fg of %s %s by %s, counting the entries since, cannot take %.2fg on %s",
+			e.Have, e.Product, where, day(e.Short), e.Grams, day(e.At))
+	}
+}
+
+// day formats a date the way the --date flag takes one.
+func day(t time.Time) string { return t.Format(time.DateOnly) }
+
+// Force makes the recorder record an overdraft rather than refuse it, telling
+// warn about each one. It is for the entry the ledger cannot believe but the
+// jar on the table says is right. The entry is recorded as given; only the
+// warning marks it.
+func (r *Recorder) Force(warn func(error)) { r.warn = warn }
+
 // check refuses to draw an account below zero. The journal would record it
 // happily, but a negative balance means the log has stopped describing what is
 // actually there.
-func (r *Recorder) check(slug string, grams float64, account journal.Account) error {
-	have := r.Available(slug, account)
-	if have >= grams {
+//
+// The draw is checked at the time it occurred, against everything before it
+// and after it, so a backdated entry cannot take grams that were not yet there
+// or that a later entry has already taken. An entry made now is checked
+// against the balance as it stands, as it always was.
+//
+// A location is drawn on alone: grams in the travel tin are no help to a
+// grind at home, which the error says once there is somewhere else to look.
+func (r *Recorder) check(slug string, grams float64, account journal.Account, location string, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	short := ledger.ShortfallAt(r.state.Events, slug, account, location, grams, at)
+	if short == nil {
 		return nil
 	}
-	// The same names the reconcile forms use, so an account is called one
-	// thing everywhere. Undoing an avb-collect used to complain about
-	// "storage", which is not where the grams were.
-	where := "in " + string(account)
-	if name, ok := reconcilable[account]; ok {
-		where = "in " + name
+	err := &OverdraftError{
+		Product: slug, Account: account, Location: location, Grams: grams,
+		At: at, Short: short.At, Have: short.Have,
+	}
+	if b := r.state.Balances[slug]; location == "" && b != nil && len(b.Locations(account)) > 0 {
+		err.Location = journal.Home
 	}
-	return fmt.Errorf("only %.2fg of %s %s, cannot take %.2fg", have, slug, where, grams)
+	for _, e := range r.state.Events {
+		if e.Product == slug && e.OccurredAt.After(at) {
+			err.dated = true
+			break
+		}
+	}
+	if r.warn != nil {
+		r.warn(err)
+		return nil
+	}
+	return err
 }
 
-// append writes the event and folds it into the running state, so a recorder
-// used twice in a row sees its own first entry.
-func (r *Recorder) append(e journal.Event) (journal.Event, error) {
-	stored, err := r.repo.Journal().Append(e)
+// attempts is how many times an entry is built and offered to the journal
+// before a recorder gives up on a journal that keeps moving under it.
+const attempts = 5
+
+// append builds an entry and writes it, then folds it into the running state,
+// so a recorder used twice in a row sees its own first entry.
+//
+// The build runs the checks, and they are only as good as the fold they ran
+// on: a terminal left open beside the interface may have drawn on the same
+// jar since. So the entry is appended only if the journal's tip is still the
+// one the state was folded to. If it is not, the state is folded again from
+// the journal and the entry built again, checks and all — an overdraft that
+// appeared in the meantime is refused, not recorded.
+func (r *Recorder) append(build func() (journal.Event, error)) (journal.Event, error) {
+	stored, err := r.appendAll(func() ([]journal.Event, error) {
+		e, err := build()
+		return []journal.Event{e}, err
+	})
 	if err != nil {
 		return journal.Event{}, err
 	}
-	r.state = ledger.Fold(append(r.state.Events, stored))
-	return stored, nil
+	return stored[0], nil
+}
+
+// appendAll is append for entries that stand or fall together: they are
+// written in one go, and folded in together once they have landed.
+func (r *Recorder) appendAll(build func() ([]journal.Event, error)) ([]journal.Event, error) {
+	var conflict *journal.ConflictError
+	for range attempts {
+		events, err := build()
+		if err != nil || len(events) == 0 {
+			return nil, err
+		}
+		// Every entry is the patient's the state was folded for: the checks
+		// above ran on their accounts and nobody else's.
+		for i := range events {
+			events[i].Patient = r.state.Patient
+		}
+		stored, err := r.repo.Journal().AppendAllIf(events, r.state.Tip())
+		if errors.As(err, &conflict) {
+			if err := r.refresh(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.state = r.extend(stored)
+		return stored, nil
+	}
+	return nil, conflict
+}
+
+// extend folds entries that have just landed onto the state, resuming from it
+// the way the workspace resumes from its index rather than replaying the whole
+// journal for every entry. The state it started from is left as it was, for
+// whoever else still holds it.
+func (r *Recorder) extend(stored []journal.Event) *ledger.State {
+	events := slices.Concat(r.state.Journal(), stored)
+	if cp, err := r.state.Checkpoint(); err == nil {
+		if s, err := ledger.Resume(cp, events); err == nil {
+			return s
+		}
+	}
+	return ledger.FoldFor(ledger.Recorded, r.state.Patient, events)
+}
+
+// refresh folds the state again from the journal as it now stands.
+func (r *Recorder) refresh() error {
+	events, err := r.repo.Journal().Events()
+	if err != nil {
+		return err
+	}
+	r.state = ledger.FoldFor(ledger.Recorded, r.state.Patient, events)
+	return nil
 }
 
 // State returns the folded state, including anything this recorder appended.
@@ -181,6 +781,14 @@ func (r *Recorder) State() *ledger.State { return r.state }
 // the entry it reverses, so the pair can be recognised and hidden from a view
 // that wants to show only what currently stands.
 func (r *Recorder) Revert(hash string, reason string) (journal.Event, error) {
+	return r.append(func() (journal.Event, error) {
+		return r.reversal(hash, reason)
+	})
+}
+
+// reversal builds the correction of an entry, refusing what Revert refuses,
+// without recording it.
+func (r *Recorder) reversal(hash string, reason string) (journal.Event, error) {
 	original, err := r.Find(hash)
 	if err != nil {
 		return journal.Event{}, err
@@ -193,57 +801,70 @@ func (r *Recorder) Revert(hash string, reason string) (journal.Event, error) {
 	}
 	// Putting the grams back must not overdraw the account they went into: if
 	// they have since been ground on or used, the later entries have to go first.
-	if err := r.check(original.Product, original.Grams, original.To); err != nil {
+	if err := r.check(original.Product, original.Grams, original.To, original.ToLocation, time.Now()); err != nil {
 		return journal.Event{}, fmt.Errorf("cannot undo %s: %w", short(hash), err)
 	}
 	if reason == "" {
 		reason = "reverts " + short(hash)
 	}
-	return r.append(journal.Event{
-		Type:       journal.Adjust,
-		Product:    original.Product,
-		Grams:      original.Grams,
-		From:       original.To,
-		To:         original.From,
-		OccurredAt: time.Now(),
-		Reverts:    original.Hash,
-		Note:       reason,
-	})
+	return journal.Event{
+		Type:         journal.Adjust,
+		Product:      original.Product,
+		Grams:        original.Grams,
+		From:         original.To,
+		To:           original.From,
+		FromLocation: original.ToLocation,
+		ToLocation:   original.FromLocation,
+		OccurredAt:   time.Now(),
+		Reverts:      original.Hash,
+		Note:         reason,
+	}, nil
 }
 
 // Amend corrects the amount of an earlier entry, by reverting it and recording
 // it again as it should have been.
 //
-// The two appends are not a transaction, so everything that could refuse the
-// second one is checked before the first is written. Reverting and then
-// failing to re-record would leave the entry silently undone, which is worse
-// than either refusing outright or recording the correction.
+// The correction and the entry recorded in its place are appended together, so
+// the journal never holds one without the other: reverting and then failing to
+// re-record would leave the entry silently undone, which is worse than either
+// refusing outright or recording the correction.
 func (r *Recorder) Amend(hash string, grams float64, note string) (journal.Event, error) {
-	original, err := r.Find(hash)
-	if err != nil {
-		return journal.Event{}, err
-	}
 	if grams <= 0 {
 		return journal.Event{}, fmt.Errorf("grams must be positive, got %v", grams)
 	}
-	// The revert frees the original amount back into the source account, so
-	// only the difference beyond it has to be there already.
-	if extra := round(grams - original.Grams); extra > 0 {
-		if err := r.check(original.Product, extra, original.From); err != nil {
-			return journal.Event{}, fmt.Errorf("cannot amend %s to %.2fg: %w", short(hash), grams, err)
+	stored, err := r.appendAll(func() ([]journal.Event, error) {
+		original, err := r.Find(hash)
+		if err != nil {
+			return nil, err
 		}
-	}
-	if _, err := r.Revert(hash, "amended"); err != nil {
+		if original.Massless() {
+			return nil, fmt.Errorf("%s moved no grams, so it has no amount to amend", short(hash))
+		}
+		// The revert frees the original amount back into the source account,
+		// so only the difference beyond it has to be there already, and there
+		// when the entry happened: the amended entry keeps its date.
+		if extra := round(grams - original.Grams); extra > 0 {
+			if err := r.check(original.Product, extra, original.From, original.FromLocation, original.OccurredAt); err != nil {
+				return nil, fmt.Errorf("cannot amend %s to %.2fg: %w", short(hash), grams, err)
+			}
+		}
+		correction, err := r.reversal(hash, "amended")
+		if err != nil {
+			return nil, err
+		}
+		corrected := original
+		corrected.Grams = grams
+		corrected.Hash, corrected.Prev, corrected.Seq = "", "", 0
+		corrected.RecordedAt = time.Time{}
+		if note != "" {
+			corrected.Note = note
+		}
+		return []journal.Event{correction, corrected}, nil
+	})
+	if err != nil {
 		return journal.Event{}, err
 	}
-	corrected := original
-	corrected.Grams = grams
-	corrected.Hash, corrected.Prev, corrected.Seq = "", "", 0
-	corrected.RecordedAt = time.Time{}
-	if note != "" {
-		corrected.Note = note
-	}
-	return r.append(corrected)
+	return stored[1], nil
 }
 
 // Find returns the event with the given hash, which may be abbreviated.
@@ -261,6 +882,17 @@ func (r *Recorder) Find(hash string) (journal.Event, error) {
 		}
 	}
 	if found == nil {
+		// Another patient's entry is in the journal, but not in this fold:
+		// nothing around it could be read from the balances here.
+		for _, e := range r.state.Journal() {
+			if e.Patient == r.state.Patient || !strings.HasPrefix(e.Hash, hash) {
+				continue
+			}
+			if e.Patient == "" {
+				return journal.Event{}, fmt.Errorf("%s was recorded without a patient; leave out --patient to reach it", hash)
+			}
+			return journal.Event{}, fmt.Errorf("%s is %s's entry; reach it with --patient %s", hash, e.Patient, e.Patient)
+		}
 		return journal.Event{}, fmt.Errorf("no entry matches %s", hash)
 	}
 	return *found, nil
@@ -317,11 +949,76 @@ var reconcilable = map[journal.Account]string{
 // was wrong — but to record that the account is now known to hold a different
 // amount, and by how much. The difference becomes an adjustment, which the fold
 // applies like any other transfer.
+//
+// It weighs the account at home. Once some of it has been moved elsewhere,
+// ReconcileAt weighs the jars there.
 func (r *Recorder) Reconcile(ref string, account journal.Account, weighed float64, note string) (journal.Event, error) {
+	return r.ReconcileAt(ref, account, "", weighed, note)
+}
+
+// ReconcileAt is Reconcile for the jar in one location of an account. The
+// difference is taken against that location alone, and the adjustment moves
+// grams in or out of it alone: the travel tin on the scale says nothing about
+// the fridge.
+func (r *Recorder) ReconcileAt(ref string, account journal.Account, location string, weighed float64, note string) (journal.Event, error) {
+	return r.append(func() (journal.Event, error) {
+		return r.adjustment(ref, account, location, weighed, note)
+	})
+}
+
+// Reading is one jar on the scale, at home unless it names a location.
+type Reading struct {
+	Product  string
+	Location string
+	Weighed  float64
+}
+
+// ReconcileAll records a whole scale session: every jar that disagrees with
+// the ledger is adjusted, and the adjustments are appended together, so a
+// session refused or interrupted halfway records none of them. A jar that
+// already matches is left out rather than refused. The adjustments are
+// returned in the order the jars were read.
+func (r *Recorder) ReconcileAll(account journal.Account, readings []Reading, note string) ([]journal.Event, error) {
+	return r.appendAll(func() ([]journal.Event, error) {
+		seen := map[[2]string]bool{}
+		var adjustments []journal.Event
+		for _, reading := range readings {
+			e, err := r.adjustment(reading.Product, account, reading.Location, reading.Weighed, note)
+			if errors.Is(err, ErrNothingToReconcile) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			// Each difference is taken against the ledger as it stood before
+			// the session, so a jar weighed twice would be adjusted twice over.
+			jar := [2]string{e.Product, reading.Location}
+			if seen[jar] {
+				if reading.Location != "" {
+					return nil, fmt.Errorf("%s at %s was weighed twice", e.Product, reading.Location)
+				}
+				return nil, fmt.Errorf("%s was weighed twice", e.Product)
+			}
+			seen[jar] = true
+			adjustments = append(adjustments, e)
+		}
+		return adjustments, nil
+	})
+}
+
+// adjustment builds the entry reconciling one account to a weighed amount,
+// without recording it.
+func (r *Recorder) adjustment(ref string, account journal.Account, location string, weighed float64, note string) (journal.Event, error) {
 	where, ok := reconcilable[account]
 	if !ok {
 		return journal.Event{}, fmt.Errorf("%s cannot be weighed", account)
 	}
+	if location != "" {
+		if !journal.Placed(account) {
+			return journal.Event{}, fmt.Errorf("%s is not kept in locations", where)
+		}
+		where += " at " + location
+	}
 	product, err := r.products.Find(ref)
 	if err != nil {
 		return journal.Event{}, err
@@ -330,7 +1027,7 @@ func (r *Recorder) Reconcile(ref string, account journal.Account, weighed float6
 		return journal.Event{}, fmt.Errorf("a weight cannot be negative, got %.2f", weighed)
 	}
 
-	expected := r.Available(product.Slug, account)
+	expected := r.AvailableAt(product.Slug, account, location)
 	difference := round(weighed - expected)
 	if difference == 0 {
 		return journal.Event{}, fmt.Errorf("%w: %.2f g in %s", ErrNothingToReconcile, expected, where)
@@ -340,32 +1037,41 @@ func (r *Recorder) Reconcile(ref string, account journal.Account, weighed float6
 	// grams the ledger has but the jar does not have gone out of it. Either way
 	// the entry is a transfer, so the accounts still balance afterwards.
 	from, to := journal.External, account
+	fromLocation, toLocation := "", location
 	if difference < 0 {
 		from, to = account, journal.External
+		fromLocation, toLocation = location, ""
 	}
 	if note == "" {
 		note = fmt.Sprintf("reconciled %s: %.2f g weighed, %.2f g expected", where, weighed, expected)
 	}
-	return r.append(journal.Event{
-		Type:       journal.Adjust,
-		Product:    product.Slug,
-		Grams:      math.Abs(difference),
-		From:       from,
-		To:         to,
-		OccurredAt: time.Now(),
-		Note:       note,
-	})
+	return journal.Event{
+		Type:         journal.Adjust,
+		Product:      product.Slug,
+		Grams:        math.Abs(difference),
+		From:         from,
+		To:           to,
+		FromLocation: fromLocation,
+		ToLocation:   toLocation,
+		OccurredAt:   time.Now(),
+		Note:         note,
+	}, nil
 }
 
-// Difference reports what reconciling an account to a weighed amount would
-// change, without recording anything. A screen can show it before it is
+// Difference reports what reconciling an account at home to a weighed amount
+// would change, without recording anything. A screen can show it before it is
 // committed to.
 func (r *Recorder) Difference(ref string, account journal.Account, weighed float64) (expected, difference float64, err error) {
+	return r.DifferenceAt(ref, account, "", weighed)
+}
+
+// DifferenceAt is Difference for one location of an account.
+func (r *Recorder) DifferenceAt(ref string, account journal.Account, location string, weighed float64) (expected, difference float64, err error) {
 	product, err := r.products.Find(ref)
 	if err != nil {
 		return 0, 0, err
 	}
-	expected = r.Available(product.Slug, account)
+	expected = r.AvailableAt(product.Slug, account, location)
 	return expected, round(weighed - expected), nil
 }
 
@@ -387,7 +1093,7 @@ func (r *Recorder) Rename(ref, name string) (*catalog.Product, error) {
 		return nil, fmt.Errorf("a product needs a name")
 	}
 	product.Name = strings.TrimSpace(name)
-	if err := r.products.Save(r.repo.ProductsPath()); err != nil {
+	if err := r.repo.SaveProducts(r.products); err != nil {
 		return nil, err
 	}
 	return product, nil
@@ -407,7 +1113,7 @@ func (r *Recorder) Describe(ref string, p catalog.Product) (*catalog.Product, er
 	product.Manufacturer = strings.TrimSpace(p.Manufacturer)
 	product.Cultivar = strings.TrimSpace(p.Cultivar)
 	product.THC, product.CBD = p.THC, p.CBD
-	if err := r.products.Save(r.repo.ProductsPath()); err != nil {
+	if err := r.repo.SaveProducts(r.products); err != nil {
 		return nil, err
 	}
 	return product, nil
@@ -28,7 +28,7 @@ func recorder(t *testing.T) *Recorder {
 	t.Helper()
 	r, err := repo.Init(t.TempDir())
 	require.NoError(t, err)
-	return New(r, &catalog.Catalog{}, &catalog.Devices{}, ledger.Fold(nil))
+	return New(r, &catalog.Catalog{}, &catalog.Devices{}, ledger.Fold("", nil))
 }
 
 func TestBuy(t *testing.T) {
@@ -115,6 +115,141 @@ func TestSession(t *testing.T) {
 	})
 }
 
+func TestAVB(t *testing.T) {
+	rec := recorder(t)
+	devices := &catalog.Devices{}
+	require.NoError(t, devices.Add(&catalog.Device{Name: "Volcano", MaxTemp: 230, DefaultTemp: 185}))
+	rec.devices = devices
+	start := time.Now().Add(-time.Hour)
+	_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 20, start)
+	require.NoError(t, err)
+	_, err = rec.Grind("wedding", 1.0, start)
+	require.NoError(t, err)
+	_, err = rec.Session("wedding", 0.5, start, "volcano", 190, "")
+	require.NoError(t, err)
+
+	t.Run("CollectsWhatTheSessionsLeft", func(t *testing.T) {
+		e, err := rec.Collect("wedding", 0.2, time.Now(), "", 0, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, journal.AVBCollect, e.Type, "Should record a collection")
+		assert.Equal(t, "volcano", e.Device, "Should take the device of the last session")
+		assert.Equal(t, 190, e.Temperature, "Should take its temperature along with it")
+		assert.Equal(t, 0.2, rec.Available("wcake-221", journal.AVB), "Should land in the AVB jar")
+	})
+
+	t.Run("RefusesMoreThanWasSeshed", func(t *testing.T) {
+		_, err := rec.Collect("wedding", 1, time.Now(), "", 0, "")
+		assert.ErrorContains(t, err, "not yet collected", "Should say what is short")
+	})
+
+	t.Run("UsesItForAPurpose", func(t *testing.T) {
+		e, err := rec.UseAVB("wedding", 0.15, time.Now(), " Edibles ", "")
+		require.NoError(t, err)
+
+		assert.Equal(t, "edibles", e.Purpose, "Should keep the purpose, tidied")
+		assert.Equal(t, 0.05, rec.Available("wcake-221", journal.AVB), "Should draw the jar down")
+	})
+
+	t.Run("RefusesMoreThanTheJarHolds", func(t *testing.T) {
+		_, err := rec.UseAVB("wedding", 1, time.Now(), "tincture", "")
+		assert.ErrorContains(t, err, "in the AVB jar", "Should say which account is short")
+	})
+}
+
+func TestFeel(t *testing.T) {
+	rec := recorder(t)
+	_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 20, time.Now().Add(-3*time.Hour))
+	require.NoError(t, err)
+	_, err = rec.Grind("wedding", 1.0, time.Now().Add(-3*time.Hour))
+	require.NoError(t, err)
+	earlier, err := rec.Session("wedding", 0.3, time.Now().Add(-2*time.Hour), "", 0, "")
+	require.NoError(t, err)
+	latest, err := rec.Session("wedding", 0.3, time.Now().Add(-time.Hour), "", 0, "")
+	require.NoError(t, err)
+
+	t.Run("StandsOnItsOwn", func(t *testing.T) {
+		e, err := rec.Feel(journal.Scores{"pain": 6}, time.Now(), "", "woke up with it")
+
+		require.NoError(t, err)
+		assert.Empty(t, e.Product, "Should belong to no product without a session")
+		assert.Equal(t, 0.4, rec.Available("wcake-221", journal.Stash), "Should move nothing")
+	})
+
+	t.Run("FollowsASession", func(t *testing.T) {
+		e, err := rec.Feel(journal.Scores{"pain": 2}, time.Now(), earlier.Hash[:7], "")
+
+		require.NoError(t, err)
+		assert.Equal(t, earlier.Hash, e.Session, "Should name the session in full")
+		assert.Equal(t, "wcake-221", e.Product, "Should carry the session's product")
+	})
+
+	t.Run("FollowsTheLastSession", func(t *testing.T) {
+		e, err := rec.Feel(journal.Scores{"sleep": 8}, time.Now(), "last", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, latest.Hash, e.Session, "Should follow the latest session before it")
+
+		_, err = rec.Feel(journal.Scores{"sleep": 8}, time.Now().AddDate(0, 0, -1), "last", "")
+		assert.ErrorContains(t, err, "no session by", "Should not follow a session that came after it")
+	})
+
+	t.Run("OnlyFollowsASession", func(t *testing.T) {
+		grind := rec.State().Events[1]
+
+		_, err := rec.Feel(journal.Scores{"pain": 2}, time.Now(), grind.Hash, "")
+
+		assert.ErrorContains(t, err, "not a session", "Should refuse to follow a grind")
+	})
+
+	t.Run("RevertsButDoesNotAmend", func(t *testing.T) {
+		e, err := rec.Feel(journal.Scores{"nausea": 9}, time.Now(), "", "")
+		require.NoError(t, err)
+
+		_, err = rec.Amend(e.Hash, 1, "")
+		assert.ErrorContains(t, err, "no amount to amend", "Should refuse to give a feel grams")
+
+		fix, err := rec.Revert(e.Hash, "")
+		require.NoError(t, err)
+		assert.Zero(t, fix.Grams, "The correction of a feel should move nothing either")
+		assert.Equal(t, 0.4, rec.Available("wcake-221", journal.Stash), "Should leave the balances alone")
+	})
+}
+
+func TestBreak(t *testing.T) {
+	rec := recorder(t)
+
+	t.Run("DeclaresOne", func(t *testing.T) {
+		e, err := rec.Break(14, time.Now().Add(-time.Hour), "for the tolerance")
+
+		require.NoError(t, err)
+		assert.Equal(t, 14, e.Days, "Should keep how long it is planned for")
+		assert.Empty(t, e.Product, "Should be a break from everything")
+	})
+
+	t.Run("OneAtATime", func(t *testing.T) {
+		_, err := rec.Break(7, time.Now(), "")
+
+		assert.ErrorContains(t, err, "revert it to declare another", "Should refuse a second break while one runs")
+	})
+
+	t.Run("AnotherOnceItIsReverted", func(t *testing.T) {
+		running := rec.State().Events[0]
+		_, err := rec.Revert(running.Hash, "")
+		require.NoError(t, err)
+
+		_, err = rec.Break(7, time.Now(), "")
+
+		assert.NoError(t, err, "Should declare a break where the last was undone")
+	})
+
+	t.Run("AtLeastADay", func(t *testing.T) {
+		_, err := rec.Break(0, time.Now().AddDate(0, 1, 0), "")
+
+		assert.ErrorContains(t, err, "at least a day")
+	})
+}
+
 func TestStateFollowsAlong(t *testing.T) {
 	rec := recorder(t)
 	_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 20, time.Now())
@@ -124,6 +259,168 @@ func TestStateFollowsAlong(t *testing.T) {
 	assert.NotNil(t, rec.State().CurrentCycle(), "Should open a cycle on the first fill")
 }
 
+func TestStateIsNotWrittenOver(t *testing.T) {
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	bought, err := r.Journal().Append(journal.Event{
+		Type: journal.Purchase, Product: "wcake-221", Grams: 20,
+		From: journal.External, To: journal.Storage, OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+	products := &catalog.Catalog{Products: []*catalog.Product{{Slug: "wcake-221", Name: "Enua 22/1 Wedding Cake"}}}
+	// A journal with room to spare, the way one read from disk often has.
+	held := append(make([]journal.Event, 0, 8), bought)
+	state := ledger.Fold("", held)
+	rec := New(r, products, &catalog.Devices{}, state)
+
+	ground, err := rec.Grind("wcake-221", 1, time.Now())
+	require.NoError(t, err)
+
+	assert.Len(t, state.Journal(), 1, "Should leave the state it was given as it was")
+	assert.Empty(t, held[:2][1].Hash, "Should not write into the room behind someone else's journal")
+	assert.Equal(t, 20.0, state.Balances["wcake-221"].Storage, "Should not move the grams of the state it was given")
+
+	events, err := r.Journal().Events()
+	require.NoError(t, err)
+	refolded := ledger.Fold("", events)
+	assert.Equal(t, ground.Hash, rec.State().Tip(), "Should fold the entry onto its own state")
+	assert.Equal(t, refolded.Balances, rec.State().Balances, "and come out where a fold from the start does")
+	assert.Equal(t, len(refolded.Cycles), len(rec.State().Cycles), "with the same cycles")
+}
+
+func TestBackdated(t *testing.T) {
+	july := func(d int) time.Time { return time.Date(2026, time.July, d, 12, 0, 0, 0, time.Local) }
+	setup := func(t *testing.T) *Recorder {
+		t.Helper()
+		rec := recorder(t)
+		_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 20, july(10))
+		require.NoError(t, err)
+		_, err = rec.Grind("wcake-221", 15, july(12))
+		require.NoError(t, err)
+		return rec
+	}
+
+	t.Run("FitsOnItsDay", func(t *testing.T) {
+		rec := setup(t)
+
+		_, err := rec.Grind("wcake-221", 5, july(11))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("RefusesAJarNotYetBought", func(t *testing.T) {
+		rec := setup(t)
+
+		_, err := rec.Grind("wcake-221", 1, july(9))
+
+		var overdraft *OverdraftError
+		require.ErrorAs(t, err, &overdraft, "Today's balance has grams, but the jar was empty that day")
+		assert.EqualError(t, err, "only 0.00g of wcake-221 in storage on 2026-07-09, cannot take 1.00g")
+	})
+
+	t.Run("RefusesWhatALaterEntryTook", func(t *testing.T) {
+		rec := setup(t)
+
+		_, err := rec.Grind("wcake-221", 10, july(11))
+
+		assert.EqualError(t, err,
+			"only 5.00g of wcake-221 in storage by 2026-07-12, counting the entries since, cannot take 10.00g on 2026-07-11")
+	})
+
+	t.Run("WarnsWhenForced", func(t *testing.T) {
+		rec := setup(t)
+		var warned []error
+		rec.Force(func(err error) { warned = append(warned, err) })
+
+		_, err := rec.Grind("wcake-221", 1, july(9))
+
+		require.NoError(t, err, "Should record it")
+		require.Len(t, warned, 1, "and say what it overdraws")
+		assert.ErrorContains(t, warned[0], "on 2026-07-09")
+	})
+}
+
+func TestAnotherWriter(t *testing.T) {
+	// Two recorders over one repository, each with a Repo of its own, are two
+	// processes: neither sees the other's appends until it reads the journal.
+	setup := func(t *testing.T) (*Recorder, *Recorder) {
+		t.Helper()
+		mine := recorder(t)
+		_, _, _, err := mine.Buy("Enua 22/1 Wedding Cake", "", 20, time.Now())
+		require.NoError(t, err)
+		other, err := repo.Discover(mine.repo.WorkTree())
+		require.NoError(t, err)
+		events, err := other.Journal().Events()
+		require.NoError(t, err)
+		return mine, New(other, mine.products, mine.devices, ledger.Fold("", events))
+	}
+
+	t.Run("IsNotOverdrawnBehindItsBack", func(t *testing.T) {
+		mine, theirs := setup(t)
+		_, err := theirs.Grind("wcake-221", 15, time.Now())
+		require.NoError(t, err)
+
+		_, err = mine.Grind("wcake-221", 10, time.Now())
+
+		assert.ErrorContains(t, err, "only 5.00g", "Should check against the journal as it now stands")
+		events, err := mine.repo.Journal().Events()
+		require.NoError(t, err)
+		assert.Len(t, events, 2, "and write nothing")
+	})
+
+	t.Run("IsChainedOnto", func(t *testing.T) {
+		mine, theirs := setup(t)
+		theirs1, err := theirs.Grind("wcake-221", 15, time.Now())
+		require.NoError(t, err)
+
+		e, err := mine.Grind("wcake-221", 3, time.Now())
+
+		require.NoError(t, err, "Should record what still fits")
+		assert.Equal(t, theirs1.Hash, e.Prev, "after the other writer's entry")
+		assert.Equal(t, 2.0, mine.Available("wcake-221", journal.Storage), "and see both")
+	})
+}
+
+func TestPatients(t *testing.T) {
+	// Two patients of one household, recording into one repository and one
+	// catalog, each through a recorder folded for them.
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	products := &catalog.Catalog{}
+	anna := New(r, products, &catalog.Devices{}, ledger.FoldFor(ledger.Recorded, "anna", nil))
+	ben := New(r, products, &catalog.Devices{}, ledger.FoldFor(ledger.Recorded, "ben", nil))
+
+	bought, _, _, err := anna.Buy("Enua 22/1 Wedding Cake", "", 20, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "anna", bought.Patient, "Should record the entry as the patient's")
+
+	t.Run("NeverDrawsOnAnothersStorage", func(t *testing.T) {
+		_, err := ben.Grind("wcake-221", 1, time.Now())
+
+		assert.ErrorContains(t, err, "only 0.00g", "Should refuse a grind of grams that are someone else's")
+		assert.Equal(t, 20.0, anna.Available("wcake-221", journal.Storage), "and leave theirs alone")
+	})
+
+	t.Run("ChainsOntoTheOthersEntries", func(t *testing.T) {
+		_, _, _, err := ben.Buy("Enua 22/1 Wedding Cake", "", 5, time.Now())
+		require.NoError(t, err)
+
+		e, err := anna.Grind("wcake-221", 2, time.Now())
+
+		require.NoError(t, err, "Should append after the other patient's entry")
+		assert.Equal(t, 18.0, anna.Available("wcake-221", journal.Storage))
+		assert.Equal(t, 5.0, ben.Available("wcake-221", journal.Storage), "Should keep the other's jar as it was")
+		assert.Equal(t, "anna", e.Patient)
+	})
+
+	t.Run("CannotRevertAnothersEntry", func(t *testing.T) {
+		_, err := ben.Revert(bought.Hash, "")
+
+		assert.ErrorContains(t, err, "is anna's entry; reach it with --patient anna",
+			"Should not find the other patient's entries, and say whose it is")
+	})
+}
+
 func TestRevert(t *testing.T) {
 	t.Run("PutsTheGramsBackWithoutRemovingAnything", func(t *testing.T) {
 		rec := recorder(t)
@@ -190,6 +487,9 @@ func TestAmend(t *testing.T) {
 	assert.Equal(t, 0.75, rec.Available("wcake-221", journal.Stash), "The stash should hold the corrected amount")
 	assert.Equal(t, 19.25, rec.Available("wcake-221", journal.Storage), "Storage should reflect the correction")
 	assert.Len(t, rec.State().Events, 4, "Should keep the original, the undo and the replacement")
+	events, err := rec.repo.Journal().Events()
+	require.NoError(t, err)
+	assert.Equal(t, events[2].Hash, events[3].Prev, "The undo and the replacement should land together")
 }
 
 func TestAmendRefusesBeforeReverting(t *testing.T) {
@@ -199,9 +499,9 @@ func TestAmendRefusesBeforeReverting(t *testing.T) {
 	grind, err := rec.Grind("wedding", 7.5, time.Now())
 	require.NoError(t, err)
 
-	// Amending up to more than storage can cover must fail whole: the revert
-	// and the re-record are two appends, and refusing between them would leave
-	// the entry silently undone.
+	// Amending up to more than storage can cover must fail whole: refusing
+	// between the revert and the re-record would leave the entry silently
+	// undone.
 	_, err = rec.Amend(grind.Hash, 25, "")
 
 	assert.ErrorContains(t, err, "cannot amend", "Should refuse an amount storage cannot cover")
@@ -213,6 +513,24 @@ func TestAmendRefusesBeforeReverting(t *testing.T) {
 	assert.Len(t, rec.State().Events, 2, "Should write nothing for a zero amount either")
 }
 
+func TestAmendChecksWhenTheEntryHappened(t *testing.T) {
+	rec := recorder(t)
+	now := time.Now()
+	_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 10, now.AddDate(0, 0, -3))
+	require.NoError(t, err)
+	grind, err := rec.Grind("wedding", 8, now.AddDate(0, 0, -2))
+	require.NoError(t, err)
+	_, _, _, err = rec.Buy("wcake-221", "", 10, now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	// Storage holds 12g today, but only 2g were left after the grind: the
+	// second fill had not arrived to grind 4g more from.
+	_, err = rec.Amend(grind.Hash, 12, "")
+
+	assert.ErrorContains(t, err, "cannot amend", "Should check the extra grams on the day the entry happened")
+	assert.Len(t, rec.State().Events, 3, "and write nothing")
+}
+
 func TestReverted(t *testing.T) {
 	rec := recorder(t)
 	_, _, _, err := rec.Buy("Enua 22/1 Wedding Cake", "", 20, time.Now())
@@ -240,6 +558,192 @@ func stocked(t *testing.T) *Recorder {
 	return rec
 }
 
+func TestMakeBatch(t *testing.T) {
+	// vaped leaves 1.5g of wcake-221 AVB from 2g seshed and 0.5g of lcook-281
+	// from 1g in the jars.
+	vaped := func(t *testing.T) *Recorder {
+		t.Helper()
+		rec := stocked(t)
+		_, _, _, err := rec.Buy("Cannamedical 28/1 Lemon Cookie", "", 10, time.Now())
+		require.NoError(t, err)
+		_, err = rec.Grind("lemon", 1, time.Now())
+		require.NoError(t, err)
+		for _, s := range []struct {
+			product     string
+			seshed, avb float64
+		}{
+			{"wedding", 2, 1.5},
+			{"lemon", 1, 0.5},
+		} {
+			_, err = rec.Session(s.product, s.seshed, time.Now(), "", 0, "")
+			require.NoError(t, err)
+			_, err = rec.Collect(s.product, s.avb, time.Now(), "", 0, "")
+			require.NoError(t, err)
+		}
+		return rec
+	}
+	potency := ledger.Potency{THC: map[string]float64{"wcake-221": 22, "lcook-281": 28}}
+	butter := func(avb ...Ingredient) Recipe {
+		return Recipe{Kind: "Butter", AVB: avb, Yield: 100, Residual: 50}
+	}
+
+	t.Run("SharesTheAVBOut", func(t *testing.T) {
+		rec := vaped(t)
+
+		made, err := rec.MakeBatch(butter(Ingredient{Grams: 1}), potency, time.Now())
+		require.NoError(t, err)
+
+		require.Len(t, made.Uses, 2, "Should draw on every AVB jar")
+		assert.Equal(t, 0.75, rec.Available("wcake-221", journal.AVB), "Should take from each by what it holds")
+		assert.Equal(t, 0.25, rec.Available("lcook-281", journal.AVB))
+		assert.Equal(t, "butter", made.Uses[0].Purpose, "Should use the AVB for the batch's kind")
+		assert.Equal(t, "butter", made.Uses[0].Into, "and name the batch it went into")
+		assert.Equal(t, 100.0, rec.Available("butter", journal.Storage), "Should bring the batch into storage")
+		assert.Equal(t, "butter", made.Product.Made, "as a product made from AVB")
+	})
+
+	t.Run("KeepsTheEstimate", func(t *testing.T) {
+		rec := vaped(t)
+
+		made, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 1}), potency, time.Now())
+		require.NoError(t, err)
+
+		// 1.5g of AVB came of 2g seshed, so 1g of it was 1.33g of bud at 22%:
+		// 293mg, half of it left, 80% of that taken up.
+		assert.Equal(t, 117.33, made.Estimate.THC)
+		assert.InDelta(t, 0.117, made.Product.THC, 0.0001, "Should keep 1.17mg a gram as a percentage")
+	})
+
+	t.Run("NumbersTheNextOfAKind", func(t *testing.T) {
+		rec := vaped(t)
+		_, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 0.5}), potency, time.Now())
+		require.NoError(t, err)
+
+		made, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 0.5}), potency, time.Now())
+		require.NoError(t, err)
+
+		assert.Equal(t, "butter-2", made.Product.Slug, "Should not reuse the first batch's slug")
+	})
+
+	t.Run("LeavesAGuessUnmade", func(t *testing.T) {
+		rec := vaped(t)
+		recipe := butter(Ingredient{Product: "lemon", Grams: 0.5})
+		recipe.Residual = 0
+
+		made, err := rec.MakeBatch(recipe, potency, time.Now())
+		require.NoError(t, err)
+
+		assert.False(t, made.Estimate.Known(), "Should have nothing to reckon a device without an efficiency by")
+		assert.Zero(t, made.Product.THC, "and record the batch without a potency")
+	})
+
+	t.Run("RefusesMoreAVBThanThereIs", func(t *testing.T) {
+		rec := vaped(t)
+		before := len(rec.State().Events)
+
+		_, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 2}), potency, time.Now())
+
+		assert.ErrorContains(t, err, "in the AVB jar", "Should refuse to overdraw the jar")
+		assert.Len(t, rec.State().Events, before, "and record nothing of the batch")
+		assert.False(t, rec.products.Taken("butter"), "nor leave its product in the catalog")
+
+		made, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 1}), potency, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, "butter", made.Product.Slug, "Should give the next batch the slug the refused one would have had")
+	})
+
+	t.Run("TakesItsProductBackWhenTheJournalRefusesIt", func(t *testing.T) {
+		rec := vaped(t)
+		before := len(rec.State().Events)
+		// A journal that cannot be written to, whatever the batch.
+		require.NoError(t, os.Remove(rec.repo.JournalPath()))
+		require.NoError(t, os.Mkdir(rec.repo.JournalPath(), 0700))
+
+		_, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 1}), potency, time.Now())
+
+		require.Error(t, err, "Should say the batch was not recorded")
+		assert.Len(t, rec.State().Events, before, "and record nothing of it")
+		assert.False(t, rec.products.Taken("butter"), "nor keep its product in the catalog")
+		products, err := rec.repo.LoadProducts()
+		require.NoError(t, err)
+		assert.False(t, products.Taken("butter"), "nor in the catalog on disk")
+	})
+
+	t.Run("TakesPortions", func(t *testing.T) {
+		rec := vaped(t)
+		_, err := rec.MakeBatch(butter(Ingredient{Grams: 1}), potency, time.Now())
+		require.NoError(t, err)
+
+		e, err := rec.Portion("butter", 10, time.Now(), "", "")
+		require.NoError(t, err)
+		assert.Equal(t, journal.Portion, e.Type)
+		assert.Equal(t, 90.0, rec.Available("butter", journal.Storage), "Should take the portion out of storage")
+
+		_, err = rec.Portion("wedding", 1, time.Now(), "", "")
+		assert.ErrorContains(t, err, "not a batch", "Should leave dispensed bud to grind and sesh")
+	})
+
+	t.Run("PutsTheAVBBack", func(t *testing.T) {
+		rec := vaped(t)
+		made, err := rec.MakeBatch(butter(Ingredient{Product: "wedding", Grams: 1}), potency, time.Now())
+		require.NoError(t, err)
+
+		_, err = rec.Revert(made.Uses[0].Hash, "")
+		require.NoError(t, err, "Should undo a use of AVB like any other entry")
+
+		assert.Equal(t, 1.5, rec.Available("wcake-221", journal.AVB))
+	})
+}
+
+func TestMove(t *testing.T) {
+	t.Run("CarriesGramsBetweenLocations", func(t *testing.T) {
+		rec := stocked(t)
+
+		e, err := rec.Move("wedding", journal.Storage, 2, "", "travel", time.Now(), "")
+		require.NoError(t, err)
+
+		assert.Equal(t, journal.Move, e.Type, "Should record a move")
+		assert.InDelta(t, 2.0, rec.AvailableAt("wcake-221", journal.Storage, "travel"), 0.001,
+			"Should put the grams at the location")
+		assert.InDelta(t, 16.0, rec.AvailableAt("wcake-221", journal.Storage, ""), 0.001, "and take them from home")
+		assert.InDelta(t, 18.0, rec.Available("wcake-221", journal.Storage), 0.001, "Storage as a whole is unchanged")
+	})
+
+	t.Run("RefusesMoreThanTheLocationHolds", func(t *testing.T) {
+		rec := stocked(t)
+
+		_, err := rec.Move("wedding", journal.Storage, 1, "travel", "", time.Now(), "")
+
+		assert.ErrorContains(t, err, "at travel", "Should say which location is short")
+	})
+
+	t.Run("ReconcilesOneLocationAlone", func(t *testing.T) {
+		rec := stocked(t)
+		_, err := rec.Move("wedding", journal.Storage, 2, "", "travel", time.Now(), "")
+		require.NoError(t, err)
+
+		_, err = rec.ReconcileAt("wedding", journal.Storage, "travel", 1.5, "")
+		require.NoError(t, err)
+
+		assert.InDelta(t, 1.5, rec.AvailableAt("wcake-221", journal.Storage, "travel"), 0.001,
+			"Should agree with the scale at the location")
+		assert.InDelta(t, 16.0, rec.AvailableAt("wcake-221", journal.Storage, ""), 0.001,
+			"and should leave home alone")
+	})
+
+	t.Run("RevertsBackHome", func(t *testing.T) {
+		rec := stocked(t)
+		move, err := rec.Move("wedding", journal.Storage, 2, "", "travel", time.Now(), "")
+		require.NoError(t, err)
+
+		_, err = rec.Revert(move.Hash, "")
+		require.NoError(t, err)
+
+		assert.Zero(t, rec.AvailableAt("wcake-221", journal.Storage, "travel"), "Should empty the location again")
+		assert.InDelta(t, 18.0, rec.AvailableAt("wcake-221", journal.Storage, ""), 0.001, "and bring the grams home")
+	})
+}
+
 func TestReconcile(t *testing.T) {
 	t.Run("LessOnTheScaleThanInTheLedger", func(t *testing.T) {
 		rec := stocked(t)
@@ -347,6 +851,67 @@ func TestReconcile(t *testing.T) {
 	})
 }
 
+func TestReconcileAll(t *testing.T) {
+	// stockedTwice is stocked with a second product on the shelf.
+	stockedTwice := func(t *testing.T) *Recorder {
+		rec := stocked(t)
+		_, _, _, err := rec.Buy("Cannamedical 28/1 Lemon Cookie", "lcook", 10, time.Now())
+		require.NoError(t, err)
+		return rec
+	}
+
+	t.Run("AdjustsEveryJarThatDisagrees", func(t *testing.T) {
+		rec := stockedTwice(t)
+
+		recorded, err := rec.ReconcileAll(journal.Storage, []Reading{
+			{Product: "wedding", Weighed: 17.60},
+			{Product: "lcook", Weighed: 10},
+			{Product: "lcook-281", Weighed: 9.5},
+		}, "")
+
+		require.Error(t, err, "lcook-281 is not a product")
+		assert.Empty(t, recorded)
+		assert.Len(t, rec.State().Events, 3, "Should write none of a session it refuses")
+
+		recorded, err = rec.ReconcileAll(journal.Storage, []Reading{
+			{Product: "wedding", Weighed: 17.60},
+			{Product: "lcook", Weighed: 10},
+		}, "")
+		require.NoError(t, err)
+
+		require.Len(t, recorded, 1, "Should leave out the jar that already matches")
+		assert.Equal(t, "wcake-221", recorded[0].Product)
+		assert.InDelta(t, 17.60, rec.Available("wcake-221", journal.Storage), 0.001)
+	})
+
+	t.Run("RecordsTheSessionInOneGo", func(t *testing.T) {
+		rec := stockedTwice(t)
+
+		recorded, err := rec.ReconcileAll(journal.Storage, []Reading{
+			{Product: "wedding", Weighed: 17.60},
+			{Product: "lcook", Weighed: 9.5},
+		}, "weighed before the trip")
+		require.NoError(t, err)
+
+		require.Len(t, recorded, 2)
+		assert.Equal(t, recorded[0].Hash, recorded[1].Prev, "Should chain the adjustments together")
+		assert.Equal(t, "weighed before the trip", recorded[1].Note, "Should keep the reason on each")
+		assert.InDelta(t, 9.5, rec.Available("lcook", journal.Storage), 0.001)
+	})
+
+	t.Run("RefusesAJarWeighedTwice", func(t *testing.T) {
+		rec := stockedTwice(t)
+
+		_, err := rec.ReconcileAll(journal.Storage, []Reading{
+			{Product: "wedding", Weighed: 17.60},
+			{Product: "wcake-221", Weighed: 17.50},
+		}, "")
+
+		assert.ErrorContains(t, err, "weighed twice")
+		assert.Len(t, rec.State().Events, 3, "Should write nothing")
+	})
+}
+
 func TestDifference(t *testing.T) {
 	rec := stocked(t)
 
@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/TheDonDope/wits/pkg/catalog"
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/rx"
+	"github.com/TheDonDope/wits/pkg/seal"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,6 +22,12 @@ const (
 	devicesFile  = "devices.yml"
 	journalFile  = "journal.ndjson"
 	indexDir     = "index"
+
+	prescriptionsFile = "prescriptions.yml"
+
+	checkpointsDir = "checkpoints"
+	signingKeyFile = "signing.key"
+	publicKeyFile  = "signing.pub"
 )
 
 // Permissions are deliberately tight. This is a multi-year medical record, and
@@ -36,6 +45,13 @@ var (
 	ErrNotARepo = errors.New("not a wits repository (or any parent up to the root)")
 	// ErrAlreadyARepo is returned when initialising over an existing repository.
 	ErrAlreadyARepo = errors.New("a wits repository already exists here")
+	// ErrLocked is returned when reading or writing an encrypted repository
+	// that has not been unlocked with its passphrase.
+	ErrLocked = errors.New("the repository is encrypted and has not been unlocked")
+	// ErrNotEmpty is returned when encrypting a repository that already holds
+	// entries. Its history goes into an encrypted one by bundle and restore.
+	ErrNotEmpty = errors.New("the repository already holds entries; " +
+		"bundle it, `wits init --encrypt` a new one and restore the bundle there")
 )
 
 // Config holds the repository settings. It replaces the environment variables
@@ -44,9 +60,25 @@ var (
 // A log_level once sat beside log_file and was read by nothing — the same
 // dead setting the old .env had, moved to a new home. It is gone; a yaml
 // field nothing reads is a promise the program does not keep.
+//
+// Encryption is set in a repository made with `wits init --encrypt`. It holds
+// what it takes to derive the key from the passphrase, none of it secret.
+//
+// Anomalies tunes what `wits anomalies` flags; left out, the defaults apply.
 type Config struct {
-	Version int    `yaml:"version"`
-	LogFile string `yaml:"log_file"`
+	Version    int           `yaml:"version"`
+	LogFile    string        `yaml:"log_file"`
+	Encryption *seal.Params  `yaml:"encryption,omitempty"`
+	Anomalies  AnomalyConfig `yaml:"anomalies,omitempty"`
+}
+
+// AnomalyConfig is how far from the usual a day or a week must be to be
+// flagged, the days the usual is taken over, and whether recording an
+// unusually large grind or session warns about it.
+type AnomalyConfig struct {
+	Factor float64 `yaml:"factor,omitempty"`
+	Window int     `yaml:"window,omitempty"`
+	Warn   bool    `yaml:"warn,omitempty"`
 }
 
 // DefaultConfig returns the configuration a freshly initialised repository gets.
@@ -62,6 +94,7 @@ type Repo struct {
 	root    string
 	Config  Config
 	journal *journal.Journal
+	key     *seal.Key
 }
 
 // Init creates a repository under dir and returns it. It refuses to touch an
@@ -86,6 +119,14 @@ func Init(dir string) (*Repo, error) {
 	if err := r.writeConfig(); err != nil {
 		return nil, err
 	}
+	if err := r.seed(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// seed writes the empty catalogs a new repository starts with.
+func (r *Repo) seed() error {
 	for _, seed := range []struct {
 		path    string
 		content string
@@ -93,12 +134,11 @@ func Init(dir string) (*Repo, error) {
 		{r.ProductsPath(), "# Products dispensed to you. Managed by `wits buy`.\nproducts: []\n"},
 		{r.DevicesPath(), "# Vaporizers and their temperature ranges.\ndevices: []\n"},
 	} {
-		if err := os.WriteFile(seed.path, []byte(seed.content), filePerm); err != nil {
-			return nil, err
+		if err := r.WriteFile(seed.path, []byte(seed.content)); err != nil {
+			return err
 		}
 	}
-
-	return r, nil
+	return nil
 }
 
 // Discover walks up from start looking for a repository, the way git finds its
@@ -152,19 +192,293 @@ func (r *Repo) ProductsPath() string { return filepath.Join(r.root, productsFile
 // DevicesPath returns the path of the device catalog.
 func (r *Repo) DevicesPath() string { return filepath.Join(r.root, devicesFile) }
 
+// PrescriptionsPath returns the path of the prescriptions. It is written the
+// first time one is added, not seeded with the catalogs.
+func (r *Repo) PrescriptionsPath() string { return filepath.Join(r.root, prescriptionsFile) }
+
 // JournalPath returns the path of the event journal.
 func (r *Repo) JournalPath() string { return filepath.Join(r.root, journalFile) }
 
+// IndexPath returns the directory derived data is cached in. Nothing in it is
+// a source of truth: it can be deleted at any time and is rebuilt from the
+// journal.
+func (r *Repo) IndexPath() string { return filepath.Join(r.root, indexDir) }
+
+// CheckpointsPath returns the directory signed checkpoints of the journal are
+// kept in.
+func (r *Repo) CheckpointsPath() string { return filepath.Join(r.root, checkpointsDir) }
+
+// SigningKeyPath returns the path of the private key checkpoints are signed
+// with. It never leaves the machine; the public half beside it is what a
+// checkpoint is verified against.
+func (r *Repo) SigningKeyPath() string { return filepath.Join(r.root, signingKeyFile) }
+
+// PublicKeyPath returns the path of the public half of the signing key.
+func (r *Repo) PublicKeyPath() string { return filepath.Join(r.root, publicKeyFile) }
+
 // Journal returns the repository's event journal. The same instance is
 // returned every time: the journal's mutex and its cached tip only mean
 // something if every caller in the process appends through one value.
+//
+// The journal of an encrypted repository is sealed line by line. Until the
+// repository is unlocked, reading or appending to it fails with ErrLocked.
 func (r *Repo) Journal() *journal.Journal {
 	if r.journal == nil {
-		r.journal = journal.Open(r.JournalPath())
+		switch {
+		case r.key != nil:
+			r.journal = journal.OpenSealed(r.JournalPath(), r.key)
+		case r.Encrypted():
+			r.journal = journal.OpenSealed(r.JournalPath(), locked{})
+		default:
+			r.journal = journal.Open(r.JournalPath())
+		}
 	}
 	return r.journal
 }
 
+// Encrypted reports whether the repository's files are sealed.
+func (r *Repo) Encrypted() bool { return r.Config.Encryption != nil }
+
+// Locked reports whether the repository is encrypted and not yet unlocked.
+func (r *Repo) Locked() bool { return r.Encrypted() && r.key == nil }
+
+// Unlock derives the key of an encrypted repository from its passphrase. It
+// does nothing in a repository that is not encrypted.
+func (r *Repo) Unlock(passphrase []byte) error {
+	if !r.Encrypted() {
+		return nil
+	}
+	key, err := seal.Unlock(*r.Config.Encryption, passphrase)
+	if err != nil {
+		return err
+	}
+	r.key = key
+	r.journal = nil
+	return nil
+}
+
+// Encrypt seals the repository under a key derived from passphrase: the
+// catalogs, the prescriptions and the signing key are sealed in place and
+// every entry from now on is sealed as it is appended. Only a repository with
+// no entries can be encrypted; rewriting a journal that already holds years
+// of them is not something to do in place.
+//
+// Every file is sealed into a temporary one beside it before any is put in
+// its place, and the configuration that says the repository is encrypted goes
+// in last, so a failure on the way leaves a repository that is still plain
+// rather than one that claims a key its files were never sealed under.
+func (r *Repo) Encrypt(passphrase []byte) error {
+	if r.Encrypted() {
+		return errors.New("the repository is already encrypted")
+	}
+	if st, err := os.Stat(r.JournalPath()); err == nil && st.Size() > 0 {
+		return ErrNotEmpty
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Only the files there are: sealing one that was never written would
+	// make it from nothing, and an empty sealed signing key is not a key.
+	plain := map[string][]byte{}
+	for _, path := range []string{r.ProductsPath(), r.DevicesPath(), r.PrescriptionsPath(), r.SigningKeyPath()} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		plain[path] = data
+	}
+
+	key, params, err := seal.New(passphrase)
+	if err != nil {
+		return err
+	}
+	sealed := &Repo{root: r.root, Config: r.Config, key: key}
+	sealed.Config.Encryption = &params
+	config, err := yaml.Marshal(sealed.Config)
+	if err != nil {
+		return err
+	}
+
+	staged := map[string]string{}
+	defer func() {
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}()
+	for path, data := range plain {
+		data, err := sealed.Seal(data)
+		if err != nil {
+			return err
+		}
+		if staged[path], err = stage(path, data); err != nil {
+			return err
+		}
+	}
+	configPath := filepath.Join(r.root, configFile)
+	tmpConfig, err := stage(configPath, config)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpConfig)
+
+	for path, tmp := range staged {
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+		delete(staged, path)
+	}
+	if err := os.Rename(tmpConfig, configPath); err != nil {
+		return err
+	}
+	r.Config, r.key, r.journal = sealed.Config, key, nil
+	return nil
+}
+
+// stage writes data to a new temporary file beside path, to be renamed over
+// it once everything that has to change with it is staged too.
+func stage(path string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// Seal prepares data for one of the repository's files: sealed into a line
+// of its own when the repository is encrypted, untouched when it is not.
+func (r *Repo) Seal(data []byte) ([]byte, error) {
+	if !r.Encrypted() {
+		return data, nil
+	}
+	if r.key == nil {
+		return nil, ErrLocked
+	}
+	sealed, err := r.key.Seal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(sealed, '\n'), nil
+}
+
+// Unseal reverses Seal.
+func (r *Repo) Unseal(data []byte) ([]byte, error) {
+	if !r.Encrypted() {
+		return data, nil
+	}
+	if r.key == nil {
+		return nil, ErrLocked
+	}
+	return r.key.Open(data)
+}
+
+// ReadFile reads one of the repository's files, unsealing it if the
+// repository is encrypted. A missing file is reported as os.ReadFile does.
+func (r *Repo) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = r.Unseal(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	return data, nil
+}
+
+// WriteFile writes one of the repository's files, sealing it if the
+// repository is encrypted.
+func (r *Repo) WriteFile(path string, data []byte) error {
+	data, err := r.Seal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, filePerm)
+}
+
+// LoadProducts reads the product catalog. A missing file is an empty catalog.
+func (r *Repo) LoadProducts() (*catalog.Catalog, error) {
+	data, err := r.ReadFile(r.ProductsPath())
+	if os.IsNotExist(err) {
+		return &catalog.Catalog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Unmarshal(data)
+}
+
+// SaveProducts writes the product catalog.
+func (r *Repo) SaveProducts(c *catalog.Catalog) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	return r.WriteFile(r.ProductsPath(), data)
+}
+
+// LoadDevices reads the device catalog. A missing file is an empty catalog.
+func (r *Repo) LoadDevices() (*catalog.Devices, error) {
+	data, err := r.ReadFile(r.DevicesPath())
+	if os.IsNotExist(err) {
+		return &catalog.Devices{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return catalog.UnmarshalDevices(data)
+}
+
+// SaveDevices writes the device catalog.
+func (r *Repo) SaveDevices(d *catalog.Devices) error {
+	data, err := d.Marshal()
+	if err != nil {
+		return err
+	}
+	return r.WriteFile(r.DevicesPath(), data)
+}
+
+// LoadPrescriptions reads the prescriptions. A missing file is none.
+func (r *Repo) LoadPrescriptions() (*rx.Prescriptions, error) {
+	data, err := r.ReadFile(r.PrescriptionsPath())
+	if os.IsNotExist(err) {
+		return &rx.Prescriptions{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rx.Unmarshal(data)
+}
+
+// SavePrescriptions writes the prescriptions.
+func (r *Repo) SavePrescriptions(ps *rx.Prescriptions) error {
+	data, err := ps.Marshal()
+	if err != nil {
+		return err
+	}
+	return r.WriteFile(r.PrescriptionsPath(), data)
+}
+
+// locked stands in for the key of a repository not yet unlocked, so that its
+// journal refuses to be read rather than failing to parse what it reads.
+type locked struct{}
+
+func (locked) Seal([]byte) ([]byte, error) { return nil, ErrLocked }
+func (locked) Open([]byte) ([]byte, error) { return nil, ErrLocked }
+
+// SaveConfig persists a change to the configuration.
+func (r *Repo) SaveConfig() error { return r.writeConfig() }
+
 // writeConfig persists the configuration.
 func (r *Repo) writeConfig() error {
 	data, err := yaml.Marshal(r.Config)
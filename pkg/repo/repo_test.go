@@ -9,6 +9,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/seal"
 )
 
 // TestMain handles global test setup
@@ -128,3 +131,112 @@ func TestJournal(t *testing.T) {
 
 	assert.Equal(t, filepath.Join(r.Root(), journalFile), r.Journal().Path(), "Should hand out the repository journal")
 }
+
+func TestEncrypt(t *testing.T) {
+	encrypted := func(t *testing.T) *Repo {
+		t.Helper()
+		r, err := Init(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, r.Encrypt([]byte("correct horse")))
+		return r
+	}
+
+	t.Run("SealsTheCatalogs", func(t *testing.T) {
+		r := encrypted(t)
+
+		for _, path := range []string{r.ProductsPath(), r.DevicesPath()} {
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotContains(t, string(raw), "products", "Should not leave %s readable", filepath.Base(path))
+			assert.NotContains(t, string(raw), "devices", "Should not leave %s readable", filepath.Base(path))
+		}
+		products, err := r.LoadProducts()
+		require.NoError(t, err)
+		assert.Empty(t, products.Products, "Should still read as the empty catalog it was")
+		assert.NoFileExists(t, r.SigningKeyPath(), "Should not make a signing key where there was none")
+	})
+
+	t.Run("SealsASigningKeyMadeBefore", func(t *testing.T) {
+		r, err := Init(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(r.SigningKeyPath(), []byte("a2V5\n"), 0600))
+
+		require.NoError(t, r.Encrypt([]byte("correct horse")))
+
+		raw, err := os.ReadFile(r.SigningKeyPath())
+		require.NoError(t, err)
+		assert.NotEqual(t, "a2V5\n", string(raw), "Should not leave the signing key in the clear")
+		key, err := r.ReadFile(r.SigningKeyPath())
+		require.NoError(t, err)
+		assert.Equal(t, "a2V5\n", string(key), "and still read it once unlocked")
+	})
+
+	t.Run("MakesNoFileThatWasNotThere", func(t *testing.T) {
+		r := encrypted(t)
+
+		assert.NoFileExists(t, r.PrescriptionsPath(), "Should not seal prescriptions nobody has added")
+		entries, err := os.ReadDir(r.Root())
+		require.NoError(t, err)
+		for _, e := range entries {
+			assert.NotContains(t, e.Name(), ".yml.", "Should leave no staged file behind")
+		}
+	})
+
+	t.Run("StaysPlainWhenItCannotFinish", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("running as root, permissions are not enforced")
+		}
+		r, err := Init(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, os.Chmod(r.Root(), 0500))
+		defer os.Chmod(r.Root(), dirPerm)
+
+		assert.Error(t, r.Encrypt([]byte("correct horse")), "Should fail where it cannot write")
+
+		require.NoError(t, os.Chmod(r.Root(), dirPerm))
+		again, err := Discover(r.WorkTree())
+		require.NoError(t, err)
+		assert.False(t, again.Encrypted(), "Should not say it is encrypted")
+		assert.False(t, r.Encrypted(), "not even to the repository it was asked of")
+		_, err = again.LoadProducts()
+		assert.NoError(t, err, "Should leave the catalogs readable as they were")
+	})
+
+	t.Run("OpensLocked", func(t *testing.T) {
+		r := encrypted(t)
+
+		again, err := Discover(r.WorkTree())
+		require.NoError(t, err)
+
+		assert.True(t, again.Locked(), "Should not open without the passphrase")
+		_, err = again.LoadProducts()
+		assert.ErrorIs(t, err, ErrLocked)
+		_, err = again.Journal().Append(journal.Event{Type: journal.Purchase, Product: "wcake", Grams: 20})
+		assert.ErrorIs(t, err, ErrLocked, "Should refuse to append to a journal it cannot seal")
+	})
+
+	t.Run("UnlocksWithThePassphrase", func(t *testing.T) {
+		r := encrypted(t)
+		_, err := r.Journal().Append(journal.Event{Type: journal.Purchase, Product: "wcake", Grams: 20})
+		require.NoError(t, err)
+		again, err := Discover(r.WorkTree())
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, again.Unlock([]byte("battery staple")), seal.ErrWrongPassphrase)
+		require.NoError(t, again.Unlock([]byte("correct horse")))
+
+		events, err := again.Journal().Events()
+		require.NoError(t, err)
+		assert.Len(t, events, 1, "Should read the journal once unlocked")
+	})
+
+	t.Run("OnlyAnEmptyRepository", func(t *testing.T) {
+		r, err := Init(t.TempDir())
+		require.NoError(t, err)
+		_, err = r.Journal().Append(journal.Event{Type: journal.Purchase, Product: "wcake", Grams: 20})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, r.Encrypt([]byte("correct horse")), ErrNotEmpty,
+			"Should not rewrite a journal that already holds entries")
+	})
+}
@@ -0,0 +1,10 @@
+// Package rx holds prescriptions: who prescribed what, how much of it, how
+// much a day at most, and until when it can be filled.
+//
+// A prescription is reference data, like a product, and is kept beside the
+// catalogs in prescriptions.yml. What has been filled against it is not
+// stored there. It is matched against the purchases in the journal each time
+// it is asked for, the way a cycle is folded rather than kept, so a purchase
+// corrected or backdated fills the prescription it belongs to without anyone
+// relinking anything.
+package rx
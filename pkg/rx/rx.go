@@ -0,0 +1,229 @@
+package rx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoPrescription is returned when no prescription has a given number.
+var ErrNoPrescription = errors.New("no prescription has that number")
+
+// AverageDays is the window the daily average is taken over when it is held
+// against a prescribed maximum. A week evens out the day a jar was topped up
+// for the weekend without hiding a habit that has crept up.
+const AverageDays = 7
+
+// Item is one product on a prescription and the grams prescribed of it.
+type Item struct {
+	Product string  `yaml:"product"`
+	Grams   float64 `yaml:"grams"`
+}
+
+// Prescription is one prescription as written.
+type Prescription struct {
+	ID         int       `yaml:"id"`
+	Prescriber string    `yaml:"prescriber,omitempty"`
+	Issued     time.Time `yaml:"issued"`
+	ValidUntil time.Time `yaml:"valid_until,omitempty"` // zero when it does not lapse
+	MaxDaily   float64   `yaml:"max_daily,omitempty"`   // grams a day; zero when none is set
+	Items      []Item    `yaml:"items"`
+	Note       string    `yaml:"note,omitempty"`
+	Patient    string    `yaml:"patient,omitempty"` // whose it is, in a household that keeps several
+}
+
+// Prescribed returns the grams prescribed across every product.
+func (p *Prescription) Prescribed() float64 {
+	var grams float64
+	for _, it := range p.Items {
+		grams += it.Grams
+	}
+	return ledger.Round(grams)
+}
+
+// Valid reports whether the prescription can still be filled at a time: on
+// or after the day it was issued, and up to the end of the day it lapses.
+func (p *Prescription) Valid(at time.Time) bool {
+	if at.Before(ledger.Midnight(p.Issued)) {
+		return false
+	}
+	return p.ValidUntil.IsZero() || at.Before(ledger.Midnight(p.ValidUntil).AddDate(0, 0, 1))
+}
+
+// Prescriptions is every prescription on record.
+type Prescriptions struct {
+	Prescriptions []*Prescription `yaml:"prescriptions"`
+}
+
+// Unmarshal reads prescriptions from the contents of prescriptions.yml.
+func Unmarshal(data []byte) (*Prescriptions, error) {
+	ps := &Prescriptions{}
+	if err := yaml.Unmarshal(data, ps); err != nil {
+		return nil, fmt.Errorf("reading the prescriptions: %w", err)
+	}
+	return ps, nil
+}
+
+// Marshal returns the prescriptions as prescriptions.yml holds them, in the
+// order they were numbered.
+func (ps *Prescriptions) Marshal() ([]byte, error) {
+	slices.SortFunc(ps.Prescriptions, func(a, b *Prescription) int { return a.ID - b.ID })
+	return yaml.Marshal(ps)
+}
+
+// Add numbers a prescription after the last one and keeps it. A prescription
+// needs at least one product, and every amount must be positive.
+func (ps *Prescriptions) Add(p *Prescription) error {
+	if len(p.Items) == 0 {
+		return errors.New("a prescription needs at least one product")
+	}
+	for _, it := range p.Items {
+		if it.Grams <= 0 {
+			return fmt.Errorf("grams must be positive, got %v for %s", it.Grams, it.Product)
+		}
+	}
+	if p.MaxDaily < 0 {
+		return fmt.Errorf("the daily maximum cannot be negative, got %v", p.MaxDaily)
+	}
+	if !p.ValidUntil.IsZero() && p.ValidUntil.Before(ledger.Midnight(p.Issued)) {
+		return errors.New("the prescription lapses before it was issued")
+	}
+	for _, existing := range ps.Prescriptions {
+		p.ID = max(p.ID, existing.ID)
+	}
+	p.ID++
+	ps.Prescriptions = append(ps.Prescriptions, p)
+	return nil
+}
+
+// Of returns the prescriptions written for one patient, numbered as they are
+// among everyone's. The prescriptions are shared with ps, not copied.
+func (ps *Prescriptions) Of(patient string) *Prescriptions {
+	out := &Prescriptions{}
+	for _, p := range ps.Prescriptions {
+		if p.Patient == patient {
+			out.Prescriptions = append(out.Prescriptions, p)
+		}
+	}
+	return out
+}
+
+// Find returns a prescription by its number, written with or without a
+// leading "rx".
+func (ps *Prescriptions) Find(ref string) (*Prescription, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ref)), "rx"))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a prescription number", ref)
+	}
+	for _, p := range ps.Prescriptions {
+		if p.ID == n {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrNoPrescription, n)
+}
+
+// InForce returns the latest prescription issued by a time that sets a daily
+// maximum, or nil if none does. A new prescription replaces the old one's
+// limit even while the old one is still being filled.
+func (ps *Prescriptions) InForce(at time.Time) *Prescription {
+	var found *Prescription
+	for _, p := range ps.Prescriptions {
+		if p.MaxDaily <= 0 || p.Issued.After(at) {
+			continue
+		}
+		if found == nil || p.Issued.After(found.Issued) || (p.Issued.Equal(found.Issued) && p.ID > found.ID) {
+			found = p
+		}
+	}
+	return found
+}
+
+// Filled is a prescription with the purchases matched against it.
+type Filled struct {
+	*Prescription
+	Filled    map[string]float64 // grams matched per product
+	Purchases []journal.Event    // the purchases that filled it, oldest first
+}
+
+// Total returns the grams filled across every product.
+func (f Filled) Total() float64 {
+	var grams float64
+	for _, g := range f.Filled {
+		grams += g
+	}
+	return ledger.Round(grams)
+}
+
+// Unfilled returns the grams prescribed and not yet bought.
+func (f Filled) Unfilled() float64 {
+	var grams float64
+	for _, it := range f.Items {
+		grams += max(it.Grams-f.Filled[it.Product], 0)
+	}
+	return ledger.Round(grams)
+}
+
+// Fill matches the purchases among the events against the prescriptions and
+// returns each one with what filled it, in the order they were numbered.
+//
+// A purchase fills the oldest prescription that was valid on the day, names
+// its product and still has grams of it unfilled. A purchase bigger than what
+// is left fills the rest and spills over into the next prescription that
+// fits; whatever fits none is simply bought, the way the imported years were.
+// Corrected purchases are left out.
+func Fill(ps *Prescriptions, events []journal.Event) []Filled {
+	order := slices.Clone(ps.Prescriptions)
+	slices.SortStableFunc(order, func(a, b *Prescription) int {
+		if c := a.Issued.Compare(b.Issued); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+	byID := map[int]*Filled{}
+	for _, p := range order {
+		byID[p.ID] = &Filled{Prescription: p, Filled: map[string]float64{}}
+	}
+
+	for _, e := range ledger.Chronological(ledger.Standing(events)) {
+		if e.Type != journal.Purchase {
+			continue
+		}
+		left := e.Grams
+		for _, p := range order {
+			if left <= 0 {
+				break
+			}
+			if !p.Valid(e.OccurredAt) {
+				continue
+			}
+			f := byID[p.ID]
+			for _, it := range p.Items {
+				if it.Product != e.Product {
+					continue
+				}
+				take := min(left, ledger.Round(it.Grams-f.Filled[it.Product]))
+				if take <= 0 {
+					continue
+				}
+				f.Filled[it.Product] = ledger.Round(f.Filled[it.Product] + take)
+				f.Purchases = append(f.Purchases, e)
+				left = ledger.Round(left - take)
+			}
+		}
+	}
+
+	out := make([]Filled, 0, len(ps.Prescriptions))
+	for _, p := range ps.Prescriptions {
+		out = append(out, *byID[p.ID])
+	}
+	slices.SortFunc(out, func(a, b Filled) int { return a.ID - b.ID })
+	return out
+}
@@ -0,0 +1,144 @@
+package rx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// day returns a timestamp n days after the first of the month.
+func day(n int) time.Time {
+	return time.Date(2026, time.July, 1, 12, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+// purchase builds a purchase the way the journal would hold it.
+func purchase(hash, product string, grams float64, at time.Time) journal.Event {
+	return journal.Event{Type: journal.Purchase, Product: product, Grams: grams,
+		From: journal.External, To: journal.Storage, OccurredAt: at, Hash: hash}
+}
+
+func TestPrescriptions(t *testing.T) {
+	t.Run("NumbersThemInTurn", func(t *testing.T) {
+		ps := &Prescriptions{}
+		require.NoError(t, ps.Add(&Prescription{Issued: day(0), Items: []Item{{"wedding-cake", 20}}}))
+		require.NoError(t, ps.Add(&Prescription{Issued: day(30), Items: []Item{{"lemon", 10}}}))
+
+		p, err := ps.Find("rx2")
+		require.NoError(t, err)
+		assert.Equal(t, "lemon", p.Items[0].Product, "Should find it by its number")
+		_, err = ps.Find("3")
+		assert.ErrorIs(t, err, ErrNoPrescription, "Should say there is no such prescription")
+	})
+
+	t.Run("RefusesWhatCannotBeFilled", func(t *testing.T) {
+		ps := &Prescriptions{}
+		assert.Error(t, ps.Add(&Prescription{Issued: day(0)}), "Should need a product")
+		assert.Error(t, ps.Add(&Prescription{Issued: day(0), Items: []Item{{"lemon", 0}}}), "Should need grams")
+		assert.Error(t, ps.Add(&Prescription{Issued: day(5), ValidUntil: day(1), Items: []Item{{"lemon", 10}}}),
+			"Should refuse one that lapses before it was issued")
+		assert.Empty(t, ps.Prescriptions, "Should keep none of them")
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		ps := &Prescriptions{}
+		require.NoError(t, ps.Add(&Prescription{Prescriber: "Dr. Weber", Issued: day(0), ValidUntil: day(90),
+			MaxDaily: 1, Items: []Item{{"wedding-cake", 20}, {"lemon", 10}}}))
+		data, err := ps.Marshal()
+		require.NoError(t, err)
+
+		back, err := Unmarshal(data)
+		require.NoError(t, err)
+		assert.Equal(t, ps.Prescriptions[0].Items, back.Prescriptions[0].Items, "Should read back the items")
+		assert.True(t, back.Prescriptions[0].ValidUntil.Equal(day(90)), "Should read back when it lapses")
+	})
+
+	t.Run("ValidThroughItsLastDay", func(t *testing.T) {
+		p := &Prescription{Issued: day(0), ValidUntil: time.Date(2026, time.July, 31, 0, 0, 0, 0, time.UTC)}
+
+		assert.False(t, p.Valid(day(-1)), "Should not be valid before it was issued")
+		assert.True(t, p.Valid(day(30)), "Should be valid on the day it lapses")
+		assert.False(t, p.Valid(day(31)), "Should not be valid the day after")
+	})
+
+	t.Run("TheLatestLimitIsInForce", func(t *testing.T) {
+		ps := &Prescriptions{}
+		require.NoError(t, ps.Add(&Prescription{Issued: day(0), MaxDaily: 1, Items: []Item{{"lemon", 10}}}))
+		require.NoError(t, ps.Add(&Prescription{Issued: day(20), MaxDaily: 1.5, Items: []Item{{"lemon", 10}}}))
+		require.NoError(t, ps.Add(&Prescription{Issued: day(25), Items: []Item{{"lemon", 10}}}))
+
+		assert.Nil(t, ps.InForce(day(-1)), "Should have no limit before the first")
+		assert.Equal(t, 1.0, ps.InForce(day(10)).MaxDaily, "Should hold the first until the second")
+		assert.Equal(t, 1.5, ps.InForce(day(30)).MaxDaily, "Should pass over one that sets no limit")
+	})
+
+	t.Run("KeepsThePatientsApart", func(t *testing.T) {
+		ps := &Prescriptions{}
+		require.NoError(t, ps.Add(&Prescription{Issued: day(0), Items: []Item{{"lemon", 10}}, Patient: "anna"}))
+		require.NoError(t, ps.Add(&Prescription{Issued: day(0), Items: []Item{{"lemon", 20}}, Patient: "ben"}))
+
+		anna := ps.Of("anna")
+		require.Len(t, anna.Prescriptions, 1, "Should hold only the patient's")
+		assert.Equal(t, 1, anna.Prescriptions[0].ID, "Should keep the number it has among everyone's")
+		assert.Empty(t, ps.Of("").Prescriptions, "Should give nobody in particular none of them")
+	})
+}
+
+func TestFill(t *testing.T) {
+	ps := &Prescriptions{}
+	require.NoError(t, ps.Add(&Prescription{Issued: day(0), ValidUntil: day(60),
+		Items: []Item{{"wedding-cake", 20}, {"lemon", 10}}}))
+	require.NoError(t, ps.Add(&Prescription{Issued: day(10), Items: []Item{{"wedding-cake", 30}}}))
+
+	t.Run("MatchesAPurchaseToItsPrescription", func(t *testing.T) {
+		filled := Fill(ps, []journal.Event{purchase("a", "lemon", 7.5, day(1))})
+
+		assert.Equal(t, 7.5, filled[0].Filled["lemon"], "Should fill the prescription naming the product")
+		assert.Equal(t, 22.5, filled[0].Unfilled(), "Should leave the rest unfilled")
+		assert.Equal(t, 30.0, filled[1].Unfilled(), "Should leave the other untouched")
+	})
+
+	t.Run("SpillsOverIntoTheNext", func(t *testing.T) {
+		filled := Fill(ps, []journal.Event{
+			purchase("a", "wedding-cake", 15, day(11)),
+			purchase("b", "wedding-cake", 10, day(12)),
+		})
+
+		assert.Equal(t, 20.0, filled[0].Filled["wedding-cake"], "Should fill the oldest first")
+		assert.Equal(t, 5.0, filled[1].Filled["wedding-cake"], "Should carry the rest to the next")
+		assert.Len(t, filled[0].Purchases, 2, "Should link both purchases to the first")
+		assert.Equal(t, "b", filled[1].Purchases[0].Hash, "Should link the one that spilled over to the second")
+	})
+
+	t.Run("OnlyWhileValid", func(t *testing.T) {
+		filled := Fill(ps, []journal.Event{
+			purchase("a", "wedding-cake", 5, day(-1)),
+			purchase("b", "wedding-cake", 5, day(61)),
+		})
+
+		assert.Zero(t, filled[0].Total(), "Should not fill before it was issued or after it lapsed")
+		assert.Equal(t, 5.0, filled[1].Total(), "Should fill one still valid")
+	})
+
+	t.Run("LeavesOutCorrectedPurchases", func(t *testing.T) {
+		filled := Fill(ps, []journal.Event{
+			purchase("a", "lemon", 10, day(1)),
+			{Type: journal.Adjust, Product: "lemon", Grams: 10, From: journal.Storage, To: journal.External,
+				OccurredAt: day(2), Reverts: "a"},
+		})
+
+		assert.Zero(t, filled[0].Total(), "Should not count a purchase that was corrected")
+	})
+
+	t.Run("InTheOrderItHappened", func(t *testing.T) {
+		filled := Fill(ps, []journal.Event{
+			purchase("late", "wedding-cake", 20, day(12)),
+			purchase("backdated", "wedding-cake", 20, day(2)),
+		})
+
+		assert.Equal(t, "backdated", filled[0].Purchases[0].Hash, "Should fill with the purchase that came first")
+		assert.Equal(t, "late", filled[1].Purchases[0].Hash, "Should leave the later one to the next")
+	})
+}
@@ -0,0 +1,15 @@
+// Package seal encrypts a repository's files at rest with a key derived from a
+// passphrase.
+//
+// The key is derived with scrypt and used with XChaCha20-Poly1305, both from
+// golang.org/x/crypto. Every sealed piece gets a fresh random nonce, which the
+// extended nonce of XChaCha makes safe to draw at random for as many pieces as
+// a journal will ever hold. A sealed piece is written as one line of base64,
+// so a sealed journal is still a file of lines and a sealed catalog is still
+// text.
+//
+// Nothing here is secret but the passphrase. The salt and the cost of the
+// derivation are stored beside the repository's configuration, together with
+// a known value sealed under the key, so that a wrong passphrase is told apart
+// from a damaged file before anything is read with it.
+package seal
@@ -0,0 +1,161 @@
+package seal
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrWrongPassphrase is returned when a passphrase does not derive the
+	// key the repository was sealed with.
+	ErrWrongPassphrase = errors.New("the passphrase does not unlock this repository")
+	// ErrEmptyPassphrase is returned when sealing with no passphrase at all.
+	ErrEmptyPassphrase = errors.New("the passphrase is empty")
+	// ErrUnsealable is returned when sealed data does not open: it was cut
+	// short, edited, or sealed under another key.
+	ErrUnsealable = errors.New("the sealed data does not open")
+)
+
+// The schemes Params name. Naming them keeps a repository sealed today
+// readable by a build that has learned a second one.
+const (
+	kdfScrypt   = "scrypt"
+	aeadXChaCha = "xchacha20-poly1305"
+)
+
+// The scrypt cost. 2^15 is the figure the scrypt paper recommends for
+// interactive use: a tenth of a second to unlock, and as long again for every
+// guess an attacker holding the files makes.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// checkValue is what Params.Check seals.
+var checkValue = []byte("wits")
+
+// Params are everything but the passphrase that it takes to derive the key
+// again. They are stored in the repository's configuration in the clear.
+type Params struct {
+	KDF   string `yaml:"kdf"`
+	Salt  string `yaml:"salt"`
+	N     int    `yaml:"n"`
+	R     int    `yaml:"r"`
+	P     int    `yaml:"p"`
+	AEAD  string `yaml:"aead"`
+	Check string `yaml:"check"`
+}
+
+// Key seals and opens data. It is derived from a passphrase by New or Unlock
+// and never written anywhere.
+type Key struct {
+	aead cipher.AEAD
+}
+
+// New derives a key from a passphrase under a fresh salt, returning it with
+// the parameters that derive it again.
+func New(passphrase []byte) (*Key, Params, error) {
+	if len(passphrase) == 0 {
+		return nil, Params{}, ErrEmptyPassphrase
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, Params{}, err
+	}
+	p := Params{
+		KDF:  kdfScrypt,
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+		AEAD: aeadXChaCha,
+	}
+	k, err := derive(p, passphrase)
+	if err != nil {
+		return nil, Params{}, err
+	}
+	check, err := k.Seal(checkValue)
+	if err != nil {
+		return nil, Params{}, err
+	}
+	p.Check = string(check)
+	return k, p, nil
+}
+
+// Unlock derives the key the parameters describe from a passphrase, and
+// checks it against the value sealed when they were made.
+func Unlock(p Params, passphrase []byte) (*Key, error) {
+	k, err := derive(p, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	check, err := k.Open([]byte(p.Check))
+	if err != nil || !bytes.Equal(check, checkValue) {
+		return nil, ErrWrongPassphrase
+	}
+	return k, nil
+}
+
+// derive runs the key derivation the parameters name.
+func derive(p Params, passphrase []byte) (*Key, error) {
+	if p.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unknown key derivation %q", p.KDF)
+	}
+	if p.AEAD != aeadXChaCha {
+		return nil, fmt.Errorf("unknown cipher %q", p.AEAD)
+	}
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("reading the salt: %w", err)
+	}
+	secret, err := scrypt.Key(passphrase, salt, p.N, p.R, p.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead}, nil
+}
+
+// Seal encrypts data, returning the nonce and the ciphertext together as one
+// line of base64 with no newline.
+func (k *Key) Seal(data []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(data)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	raw := k.aead.Seal(nonce, nonce, data, nil)
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(raw)))
+	base64.StdEncoding.Encode(out, raw)
+	return out, nil
+}
+
+// Open decrypts what Seal returned. Surrounding whitespace is ignored, so a
+// sealed file may end in a newline.
+func (k *Key) Open(sealed []byte) ([]byte, error) {
+	sealed = bytes.TrimSpace(sealed)
+	raw := make([]byte, base64.StdEncoding.DecodedLen(len(sealed)))
+	n, err := base64.StdEncoding.Decode(raw, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsealable, err)
+	}
+	raw = raw[:n]
+	if len(raw) < k.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: it is shorter than a nonce", ErrUnsealable)
+	}
+	data, err := k.aead.Open(nil, raw[:k.aead.NonceSize()], raw[k.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsealable, err)
+	}
+	return data, nil
+}
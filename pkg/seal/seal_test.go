@@ -0,0 +1,86 @@
+package seal
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain handles global test setup
+func TestMain(m *testing.M) {
+	// Disable log output during tests
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSeal(t *testing.T) {
+	key, params, err := New([]byte("correct horse"))
+	require.NoError(t, err)
+
+	t.Run("RoundTrips", func(t *testing.T) {
+		sealed, err := key.Seal([]byte(`{"product":"wedding-cake"}`))
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(sealed), "wedding-cake", "Should not leave the plaintext readable")
+		assert.NotContains(t, string(sealed), "\n", "Should fit on one line")
+		opened, err := key.Open(append(sealed, '\n'))
+		require.NoError(t, err)
+		assert.Equal(t, `{"product":"wedding-cake"}`, string(opened), "Should open what it sealed, newline and all")
+	})
+
+	t.Run("NeverSealsTheSameWayTwice", func(t *testing.T) {
+		a, err := key.Seal([]byte("wits"))
+		require.NoError(t, err)
+		b, err := key.Seal([]byte("wits"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, a, b, "Should draw a fresh nonce every time")
+	})
+
+	t.Run("RefusesTamperedData", func(t *testing.T) {
+		sealed, err := key.Seal([]byte("20g of wedding-cake"))
+		require.NoError(t, err)
+		sealed[len(sealed)/2] ^= 'A' ^ 'B'
+
+		_, err = key.Open(sealed)
+
+		assert.ErrorIs(t, err, ErrUnsealable, "Should notice a changed byte")
+	})
+
+	t.Run("UnlocksWithThePassphrase", func(t *testing.T) {
+		again, err := Unlock(params, []byte("correct horse"))
+		require.NoError(t, err)
+		sealed, err := key.Seal([]byte("wits"))
+		require.NoError(t, err)
+
+		opened, err := again.Open(sealed)
+
+		require.NoError(t, err)
+		assert.Equal(t, "wits", string(opened), "Should derive the same key again")
+	})
+
+	t.Run("NotWithAnotherOne", func(t *testing.T) {
+		_, err := Unlock(params, []byte("battery staple"))
+
+		assert.ErrorIs(t, err, ErrWrongPassphrase)
+	})
+
+	t.Run("NorWithNone", func(t *testing.T) {
+		_, _, err := New(nil)
+
+		assert.ErrorIs(t, err, ErrEmptyPassphrase)
+	})
+
+	t.Run("NamesWhatItDoesNotKnow", func(t *testing.T) {
+		other := params
+		other.KDF = "argon2id"
+
+		_, err := Unlock(other, []byte("correct horse"))
+
+		assert.ErrorContains(t, err, "argon2id", "Should say which scheme it cannot run")
+	})
+}
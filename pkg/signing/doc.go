@@ -0,0 +1,11 @@
+// Package signing writes and verifies signed checkpoints of the journal.
+//
+// The hash chain proves the journal is consistent with itself, and nothing
+// more: anyone who can write the file can rewrite every entry and compute
+// every hash again. A checkpoint closes that gap. It is an Ed25519 signature
+// over the tip of the chain at one moment — the sequence number, the hash and
+// the time of signing — made with a key that lives only on the machine that
+// keeps the record. A journal that still holds that tip at that sequence
+// number has not been edited before it since the checkpoint was signed, and
+// anyone holding the public key can check it.
+package signing
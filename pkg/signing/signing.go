@@ -0,0 +1,269 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/repo"
+)
+
+var (
+	// ErrNoKey is returned when signing in a repository that has no key.
+	ErrNoKey = errors.New("this repository has no signing key; create one with `wits sign --new-key`")
+	// ErrKeyExists is returned when creating a key where there already is one.
+	// Replacing it would orphan every checkpoint it signed.
+	ErrKeyExists = errors.New("this repository already has a signing key")
+	// ErrBadCheckpoint is returned when a checkpoint does not hold: its
+	// signature does not verify, or the journal no longer has its tip.
+	ErrBadCheckpoint = errors.New("the checkpoint does not hold")
+)
+
+// Checkpoint is a signed statement that the journal's chain reached Tip at
+// entry Seq, made at SignedAt. Key is the public half of the key that signed
+// it, so a checkpoint handed on by itself still says who to check it against.
+type Checkpoint struct {
+	Seq       int       `json:"seq"`
+	Tip       string    `json:"tip"`
+	SignedAt  time.Time `json:"signed_at"`
+	Key       []byte    `json:"key"`
+	Signature []byte    `json:"signature"`
+}
+
+// message is what is signed. It is spelled out rather than taken from the
+// JSON, so that how a checkpoint is stored can change without invalidating
+// a signature made before.
+func (c Checkpoint) message() []byte {
+	return fmt.Appendf(nil, "wits checkpoint v1\nseq %d\ntip %s\nsigned_at %s\n",
+		c.Seq, c.Tip, c.SignedAt.UTC().Format(time.RFC3339))
+}
+
+// String names the checkpoint the way a problem report refers to it.
+func (c Checkpoint) String() string {
+	tip := c.Tip
+	if len(tip) > 7 {
+		tip = tip[:7]
+	}
+	return fmt.Sprintf("checkpoint of entry %d (%s), signed %s", c.Seq, tip, c.SignedAt.Format("2006-01-02 15:04"))
+}
+
+// NewKey creates the repository's signing key. The private half is written
+// where only its owner can read it, and in an encrypted repository it is
+// sealed under the repository's key like the catalogs, so it takes the
+// passphrase to sign. It has no passphrase of its own: whoever can unlock
+// the repository can rewrite the journal anyway.
+//
+// The public half is never sealed. A checkpoint is meant to be checked by
+// someone who holds no passphrase.
+func NewKey(r *repo.Repo) (ed25519.PublicKey, error) {
+	if _, err := os.Stat(r.SigningKeyPath()); err == nil {
+		return nil, ErrKeyExists
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := r.Seal(encodeKey(private.Seed()))
+	if err != nil {
+		return nil, err
+	}
+	// The public half first: a private key without one would sign checkpoints
+	// nothing in the repository could check.
+	if err := writeKey(r.PublicKeyPath(), encodeKey(public)); err != nil {
+		return nil, err
+	}
+	// Without its private half the public one is no key at all, and left
+	// behind it would make every later attempt fail as one that exists.
+	if err := writeKey(r.SigningKeyPath(), sealed); err != nil {
+		os.Remove(r.PublicKeyPath())
+		return nil, err
+	}
+	return public, nil
+}
+
+// PublicKey returns the repository's public key, nil if it has none.
+func PublicKey(r *repo.Repo) (ed25519.PublicKey, error) {
+	data, err := readKey(r.PublicKeyPath())
+	if err != nil || data == nil {
+		return nil, err
+	}
+	raw, err := decodeKey(r.PublicKeyPath(), data)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%s is not an Ed25519 public key", r.PublicKeyPath())
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// privateKey returns the repository's signing key, unsealing it in an
+// encrypted repository.
+func privateKey(r *repo.Repo) (ed25519.PrivateKey, error) {
+	data, err := readKey(r.SigningKeyPath())
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoKey
+	}
+	data, err = r.Unseal(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(r.SigningKeyPath()), err)
+	}
+	seed, err := decodeKey(r.SigningKeyPath(), data)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s is not an Ed25519 key", r.SigningKeyPath())
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// encodeKey spells a key as one line of base64.
+func encodeKey(key []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+}
+
+// decodeKey reads a key spelled by encodeKey.
+func decodeKey(path string, data []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// writeKey stores a key file, refusing to replace one.
+func writeKey(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	// A key cut off partway is no key, and must not stand in the way of the
+	// next attempt at one.
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// readKey reads a key file written by writeKey, nil if there is none.
+func readKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Sign writes a checkpoint of the journal's tip into the repository.
+func Sign(r *repo.Repo, events []journal.Event, at time.Time) (Checkpoint, error) {
+	if len(events) == 0 {
+		return Checkpoint{}, errors.New("the journal is empty; there is nothing to sign")
+	}
+	key, err := privateKey(r)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	tip := events[len(events)-1]
+	c := Checkpoint{
+		Seq:      tip.Seq,
+		Tip:      tip.Hash,
+		SignedAt: at.Truncate(time.Second),
+		Key:      key.Public().(ed25519.PublicKey),
+	}
+	c.Signature = ed25519.Sign(key, c.message())
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if err := os.MkdirAll(r.CheckpointsPath(), 0700); err != nil {
+		return Checkpoint{}, err
+	}
+	// Named so that a listing reads in order, and so that signing the same
+	// tip twice keeps both: each says the record stood unedited at its time.
+	name := fmt.Sprintf("%08d-%s.json", c.Seq, c.SignedAt.UTC().Format("20060102T150405Z"))
+	if err := os.WriteFile(filepath.Join(r.CheckpointsPath(), name), append(data, '\n'), 0600); err != nil {
+		return Checkpoint{}, err
+	}
+	return c, nil
+}
+
+// Load reads every checkpoint in the repository, oldest entry first.
+func Load(r *repo.Repo) ([]Checkpoint, error) {
+	entries, err := os.ReadDir(r.CheckpointsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Checkpoint
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.CheckpointsPath(), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var c Checkpoint
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("reading checkpoint %s: %w", entry.Name(), err)
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Seq != out[j].Seq {
+			return out[i].Seq < out[j].Seq
+		}
+		return out[i].SignedAt.Before(out[j].SignedAt)
+	})
+	return out, nil
+}
+
+// Verify checks a checkpoint against a journal's events: its signature must
+// verify, and the chain must still pass through its tip at its sequence
+// number. A trusted key, when given, must be the one that signed it; without
+// one the checkpoint is only checked against the key it carries, which proves
+// it is intact but not whose it is.
+//
+// The events are taken to be chained already. Whether the chain itself holds
+// is journal.VerifyChain's question, and a broken chain is reported there.
+func Verify(c Checkpoint, events []journal.Event, trusted ed25519.PublicKey) error {
+	if len(c.Key) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: %s carries no usable key", ErrBadCheckpoint, c)
+	}
+	if trusted != nil && !trusted.Equal(ed25519.PublicKey(c.Key)) {
+		return fmt.Errorf("%w: %s was signed with a key that is not this repository's", ErrBadCheckpoint, c)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(c.Key), c.message(), c.Signature) {
+		return fmt.Errorf("%w: the signature on %s does not verify", ErrBadCheckpoint, c)
+	}
+	if c.Seq < 1 || c.Seq > len(events) {
+		return fmt.Errorf("%w: %s, but the journal holds %d", ErrBadCheckpoint, c, len(events))
+	}
+	if got := events[c.Seq-1]; got.Seq != c.Seq || got.Hash != c.Tip {
+		return fmt.Errorf("%w: %s, but the journal no longer passes through it; "+
+			"what came before was rewritten", ErrBadCheckpoint, c)
+	}
+	return nil
+}
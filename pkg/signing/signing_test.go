@@ -0,0 +1,233 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/repo"
+)
+
+// TestMain handles global test setup
+func TestMain(m *testing.M) {
+	// Disable log output during tests
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// keyed returns a repository with a signing key and three entries.
+func keyed(t *testing.T) (*repo.Repo, []journal.Event) {
+	t.Helper()
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	_, err = NewKey(r)
+	require.NoError(t, err)
+	for _, grams := range []float64{20, 1, 0.5} {
+		typ := journal.Grind
+		if grams == 20 {
+			typ = journal.Purchase
+		}
+		_, err := r.Journal().Append(journal.Event{Type: typ, Product: "wcake", Grams: grams})
+		require.NoError(t, err)
+	}
+	events, err := r.Journal().Events()
+	require.NoError(t, err)
+	return r, events
+}
+
+func TestNewKey(t *testing.T) {
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+
+	public, err := NewKey(r)
+	require.NoError(t, err)
+
+	stored, err := PublicKey(r)
+	require.NoError(t, err)
+	assert.Equal(t, public, stored, "Should keep the public half beside the private one")
+	info, err := os.Stat(r.SigningKeyPath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Should keep the private half to its owner")
+
+	_, err = NewKey(r)
+	assert.ErrorIs(t, err, ErrKeyExists, "Should never replace a key that may have signed something")
+}
+
+func TestNewKeyThatFailsHalfway(t *testing.T) {
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	// A link to nowhere reads as no key, but cannot be written through.
+	require.NoError(t, os.Symlink(filepath.Join(t.TempDir(), "gone"), r.SigningKeyPath()))
+
+	_, err = NewKey(r)
+	require.Error(t, err, "Should fail where it cannot write the private half")
+	assert.NoFileExists(t, r.PublicKeyPath(), "Should not leave the public half behind without it")
+
+	require.NoError(t, os.Remove(r.SigningKeyPath()))
+	_, err = NewKey(r)
+	assert.NoError(t, err, "Should make a key on the next attempt")
+}
+
+func TestSealedKey(t *testing.T) {
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, r.Encrypt([]byte("correct horse")))
+	_, err = NewKey(r)
+	require.NoError(t, err)
+	_, err = r.Journal().Append(journal.Event{Type: journal.Purchase, Product: "wcake", Grams: 20})
+	require.NoError(t, err)
+	events, err := r.Journal().Events()
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(r.SigningKeyPath())
+	require.NoError(t, err)
+	assert.NotEqual(t, ed25519.SeedSize, len(mustDecode(t, raw)), "Should not leave the private half in the clear")
+	_, err = PublicKey(r)
+	assert.NoError(t, err, "Should leave the public half readable to anyone checking a checkpoint")
+
+	locked, err := repo.Discover(r.WorkTree())
+	require.NoError(t, err)
+	_, err = Sign(locked, events, time.Now())
+	assert.ErrorIs(t, err, repo.ErrLocked, "Should need the passphrase to sign")
+
+	require.NoError(t, locked.Unlock([]byte("correct horse")))
+	_, err = Sign(locked, events, time.Now())
+	assert.NoError(t, err, "Should sign once the repository is unlocked")
+}
+
+// mustDecode reads a file of base64, or nothing when it is not base64.
+func mustDecode(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+func TestSign(t *testing.T) {
+	t.Run("SignsTheTip", func(t *testing.T) {
+		r, events := keyed(t)
+
+		c, err := Sign(r, events, time.Now())
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, c.Seq)
+		assert.Equal(t, events[2].Hash, c.Tip)
+		loaded, err := Load(r)
+		require.NoError(t, err)
+		require.Len(t, loaded, 1, "Should write the checkpoint into the repository")
+		assert.NoError(t, Verify(loaded[0], events, nil))
+	})
+
+	t.Run("NeedsAKey", func(t *testing.T) {
+		r, err := repo.Init(t.TempDir())
+		require.NoError(t, err)
+
+		_, err = Sign(r, []journal.Event{{Seq: 1, Hash: "abc"}}, time.Now())
+
+		assert.ErrorIs(t, err, ErrNoKey)
+	})
+
+	t.Run("NeedsSomethingToSign", func(t *testing.T) {
+		r, err := repo.Init(t.TempDir())
+		require.NoError(t, err)
+		_, err = NewKey(r)
+		require.NoError(t, err)
+
+		_, err = Sign(r, nil, time.Now())
+
+		assert.ErrorContains(t, err, "nothing to sign")
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("HoldsAsTheJournalGrows", func(t *testing.T) {
+		r, events := keyed(t)
+		c, err := Sign(r, events[:2], time.Now())
+		require.NoError(t, err)
+		trusted, err := PublicKey(r)
+		require.NoError(t, err)
+
+		assert.NoError(t, Verify(c, events, trusted), "A checkpoint of a prefix holds over the whole")
+	})
+
+	t.Run("ARewrittenJournal", func(t *testing.T) {
+		r, events := keyed(t)
+		c, err := Sign(r, events[:2], time.Now())
+		require.NoError(t, err)
+
+		// The whole file written again from the same history with one entry
+		// changed: the chain verifies, the checkpoint does not.
+		edited := append([]journal.Event(nil), events...)
+		edited[1].Grams = 0.1
+		rechained, err := journal.Chain(edited)
+		require.NoError(t, err)
+		require.NoError(t, journal.VerifyChain(rechained))
+
+		assert.ErrorIs(t, Verify(c, rechained, nil), ErrBadCheckpoint)
+	})
+
+	t.Run("AShortenedJournal", func(t *testing.T) {
+		r, events := keyed(t)
+		c, err := Sign(r, events, time.Now())
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, Verify(c, events[:2], nil), "the journal holds 2")
+	})
+
+	t.Run("AForgedSignature", func(t *testing.T) {
+		r, events := keyed(t)
+		c, err := Sign(r, events, time.Now())
+		require.NoError(t, err)
+
+		c.SignedAt = c.SignedAt.AddDate(-1, 0, 0)
+
+		assert.ErrorContains(t, Verify(c, events, nil), "does not verify",
+			"Should refuse a checkpoint backdated after it was signed")
+	})
+
+	t.Run("SomebodyElsesKey", func(t *testing.T) {
+		_, events := keyed(t)
+		elsewhere, _ := keyed(t)
+		c, err := Sign(elsewhere, events, time.Now())
+		require.NoError(t, err)
+		r, _ := keyed(t)
+		trusted, err := PublicKey(r)
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, Verify(c, events, trusted), "not this repository's")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	r, events := keyed(t)
+	_, err := Sign(r, events, time.Now())
+	require.NoError(t, err)
+	_, err = Sign(r, events[:1], time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(r.CheckpointsPath(), "README"), []byte("not one"), 0600))
+
+	loaded, err := Load(r)
+	require.NoError(t, err)
+
+	require.Len(t, loaded, 2, "Should read the checkpoints and nothing else")
+	assert.Equal(t, []int{1, 3}, []int{loaded[0].Seq, loaded[1].Seq}, "oldest entry first")
+
+	raw, err := json.Marshal(loaded[0])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(r.CheckpointsPath(), "broken.json"), raw[:10], 0600))
+	_, err = Load(r)
+	assert.ErrorContains(t, err, "broken.json", "Should name a checkpoint it cannot read")
+}
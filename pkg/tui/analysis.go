@@ -276,6 +276,21 @@ func (v analysisView) View(a *App, height int) string {
 		"",
 	}
 	sections = append(sections, v.byProductSections(a, played, width)...)
+	if d := a.data.Potency().Dose(played); d.Sessions > 0 {
+		sections = append(sections, "", t.Rule("Milligrams", width), v.doses(a, d, played, width))
+	}
+	if fx := ledger.EffectsOf(played); fx.All.Feels > 0 {
+		sections = append(sections, "", t.Rule("Effects", width), v.effects(a, fx, width))
+	}
+	if breaks := ledger.Breaks(played); len(breaks) > 0 {
+		// Mid-replay, the days after a break are the ones the playhead has
+		// reached, not those the journal has since recorded.
+		now := a.data.Now
+		if len(played) < len(events) {
+			now = played[len(played)-1].OccurredAt
+		}
+		sections = append(sections, "", t.Rule("Breaks", width), v.breaks(a, breaks, played, now))
+	}
 	if v.scope != 0 {
 		sections = append(sections, "", t.Rule("Rhythm", width), v.rhythm(a, played, width))
 	}
@@ -304,7 +319,7 @@ func (v analysisView) View(a *App, height int) string {
 func (v analysisView) events(a *App) []journal.Event {
 	switch v.scope {
 	case 0:
-		if c := a.data.Cycle(); c != nil {
+		if c := a.data.Chronological().CurrentCycle(); c != nil {
 			return c.Events
 		}
 		return nil
@@ -315,14 +330,14 @@ func (v analysisView) events(a *App) []journal.Event {
 	case 3:
 		return since(a, a.data.Now.AddDate(-1, 0, 0))
 	default:
-		return a.data.State.Events
+		return a.data.Chronological().Events
 	}
 }
 
 // since returns the events that happened after the cutoff.
 func since(a *App, cutoff time.Time) []journal.Event {
 	var out []journal.Event
-	for _, e := range a.data.State.Events {
+	for _, e := range a.data.Chronological().Events {
 		if e.OccurredAt.After(cutoff) {
 			out = append(out, e)
 		}
@@ -337,7 +352,7 @@ func (v analysisView) summary(a *App, events []journal.Event, width int) string
 
 	label := scopes[v.scope]
 	if v.scope == 0 {
-		if c := a.data.Cycle(); c != nil {
+		if c := a.data.Chronological().CurrentCycle(); c != nil {
 			label = fmt.Sprintf("%s (started: %s)", label, c.Start.Format("2006-01-02"))
 		}
 	}
@@ -346,7 +361,7 @@ func (v analysisView) summary(a *App, events []journal.Event, width int) string
 
 	cols := lipgloss.JoinHorizontal(lipgloss.Top,
 		lipgloss.NewStyle().Width(width/4).Render(
-			t.Metric("ground", fmt.Sprintf("%.1f g", stats.Ground), "")),
+			t.Metric("ground", fmt.Sprintf("%.1f g", stats.Grams), "")),
 		lipgloss.NewStyle().Width(width/4).Render(
 			t.Metric("active days", fmt.Sprintf("%d", stats.ActiveDays),
 				fmt.Sprintf("of %d elapsed", stats.ElapsedDays))),
@@ -359,6 +374,145 @@ func (v analysisView) summary(a *App, events []journal.Event, width int) string
 	return lipgloss.JoinVertical(lipgloss.Left, scope, "", cols)
 }
 
+// doses is the scope's sessions in milligrams, which is what compares across
+// products of different strength where grams do not: what went through the
+// devices, a session day's worth of it, and what the devices are assumed to
+// have extracted where they have an efficiency.
+func (v analysisView) doses(a *App, d ledger.Dose, events []journal.Event, width int) string {
+	t := a.theme
+	days := len(a.data.Potency().PerDay(events))
+	extracted := t.Metric("extracted", "-", "no efficiency set")
+	if d.Extracted() {
+		extracted = t.Metric("extracted", fmt.Sprintf("%.0f mg", d.ExtractedTHC),
+			fmt.Sprintf("THC, from %.2f of %.2f g", d.Assumed, d.Grams))
+	}
+	note := fmt.Sprintf("%.2f g seshed", d.Grams)
+	if d.Unknown > 0 {
+		note = fmt.Sprintf("%.2f g of unknown strength", d.Unknown)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(width/4).Render(
+			t.Metric("thc put through", fmt.Sprintf("%.0f mg", d.THC), fmt.Sprintf("%.0f mg CBD", d.CBD))),
+		lipgloss.NewStyle().Width(width/4).Render(
+			t.Metric("per session day", fmt.Sprintf("%.0f mg", d.THC/float64(max(days, 1))),
+				"THC over "+plural(days, "day"))),
+		lipgloss.NewStyle().Width(width/4).Render(extracted),
+		lipgloss.NewStyle().Width(width/4).Render(
+			t.Metric("sessions", fmt.Sprintf("%d", d.Sessions), note)),
+	)
+}
+
+// breaks is each tolerance break in the scope: when, how long, whether it was
+// kept, and the grams ground a day before it against after it, each side over
+// as many days as the break lasted. Whether a pause brought the habit down is
+// the question a break is declared to answer.
+func (v analysisView) breaks(a *App, breaks []ledger.Break, events []journal.Event, now time.Time) string {
+	t := a.theme
+	row := func(when, days, kept, before, after, change string) string {
+		return fmt.Sprintf("%-17s%6s  %-10s%10s%10s%8s", when, days, kept, before, after, change)
+	}
+	rows := []string{t.Label.Render(row("", "days", "kept", "before", "after", "change"))}
+	for _, b := range breaks {
+		c := b.Compare(ledger.Grinding, events, now)
+		kept, style := "yes", t.Value
+		switch {
+		case !b.Kept():
+			kept, style = plural(len(b.Lapses), "lapse"), lipgloss.NewStyle().Foreground(t.Warn)
+		case b.On(now):
+			kept = "so far"
+		}
+		after, change := "·", "·"
+		if c.AfterDays > 0 {
+			after = fmt.Sprintf("%.2f g", c.After)
+		}
+		if changed, ok := c.Changed(); ok {
+			change = fmt.Sprintf("%+.0f%%", changed*100)
+		}
+		rows = append(rows, style.Render(row(
+			b.Start.Format("02 Jan")+" – "+b.End.Format("02 Jan"), fmt.Sprint(b.Days()), kept,
+			fmt.Sprintf("%.2f g", c.Before), after, change)))
+	}
+	rows = append(rows, t.Dim.Render("grams ground a day, over as many days before and after as each break lasted"))
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// effects is the scope's feels read against the sessions they follow: the mean
+// score of each symptom by product, by device and by temperature band, each
+// with how many feels it is the mean of, and whether hotter sessions went with
+// higher or lower scores. It is what to bring to a prescriber, and it says
+// how thin it is where it is thin.
+func (v analysisView) effects(a *App, fx ledger.Effects, width int) string {
+	t := a.theme
+	var names []string
+	for _, sym := range journal.Symptoms {
+		if fx.All.Counts[sym.Name] > 0 {
+			names = append(names, sym.Name)
+		}
+	}
+	labelW := max(min(width-10*len(names)-8, 28), 10)
+	row := func(label, feels string, cells []string) string {
+		out := pad(truncate(label, labelW), labelW) + fmt.Sprintf("%6s", feels)
+		for _, c := range cells {
+			out += fmt.Sprintf("%10s", c)
+		}
+		return out
+	}
+	means := func(g ledger.Scored) []string {
+		cells := make([]string, len(names))
+		for i, name := range names {
+			cells[i] = "·"
+			if g.Counts[name] > 0 {
+				cells[i] = fmt.Sprintf("%.1f", g.Mean[name])
+			}
+		}
+		return cells
+	}
+	rows := []string{
+		t.Label.Render(row("", "feels", names)),
+		t.Value.Render(row("every feel", fmt.Sprint(fx.All.Feels), means(fx.All))),
+	}
+	table := func(title string, groups []ledger.Scored, label func(ledger.Scored) string) {
+		if len(groups) == 0 {
+			return
+		}
+		rows = append(rows, t.Dim.Render(title))
+		for _, g := range groups {
+			rows = append(rows, row("  "+label(g), fmt.Sprint(g.Feels), means(g)))
+		}
+	}
+	table("by product", fx.Product, func(g ledger.Scored) string { return a.data.ProductName(g.Key) })
+	table("by device", fx.Device, func(g ledger.Scored) string {
+		if g.Key == "" {
+			return "no device"
+		}
+		if a.data.Devices != nil {
+			if d, err := a.data.Devices.Find(g.Key); err == nil {
+				return d.Name
+			}
+		}
+		return g.Key
+	})
+	table("by temperature", fx.Temperature, func(g ledger.Scored) string {
+		return fmt.Sprintf("%d–%d°C", g.Band, g.Band+ledger.BandWidth-1)
+	})
+
+	notes := []string{fmt.Sprintf("scores 0 to %d; means of what was scored", journal.MaxScore)}
+	if fx.Alone > 0 {
+		notes = append(notes, fmt.Sprintf("%s followed no session", plural(fx.Alone, "feel")))
+	}
+	for _, name := range names {
+		if r, ok := fx.Heat[name]; ok {
+			way := "higher"
+			if r < 0 {
+				way = "lower"
+			}
+			notes = append(notes, fmt.Sprintf("hotter sessions, %s %s (r %+.2f)", way, name, r))
+		}
+	}
+	rows = append(rows, t.Dim.Render(strings.Join(notes, " · ")))
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
 // perDay draws the daily amounts as columns across the whole scope.
 func (v analysisView) perDay(a *App, events []journal.Event, width int) string {
 	t := a.theme
@@ -380,6 +534,7 @@ func (v analysisView) perDay(a *App, events []journal.Event, width int) string {
 		return t.Dim.Render("nothing ground in this range")
 	}
 
+	last = throughBreaks(events, last, a.data.Now)
 	var values []float64
 	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
 		values = append(values, perDay[d.Format(time.DateOnly)])
@@ -394,9 +549,86 @@ func (v analysisView) perDay(a *App, events []journal.Event, width int) string {
 		lipgloss.NewStyle().Foreground(t.StashC).Render("⣿"), t.Dim.Render(" daily   "),
 		lipgloss.NewStyle().Foreground(t.Alt).Render("⠒⠒"), t.Dim.Render(" 7-day average"),
 	)
-	return lipgloss.JoinVertical(lipgloss.Left, chart,
-		strings.Repeat(" ", axisGutter)+dateAxis(t, first, last, max(width-axisGutter, 12)),
-		legend)
+	rows := []string{chart}
+	// The flagged days sit in a row of their own between the chart and its
+	// dates, so they mark a column without painting over the area.
+	marks := anomalyMarks(a)
+	if row := MarkRow(marks, first, last, max(width-axisGutter, 12), t); row != "" {
+		rows = append(rows, strings.Repeat(" ", axisGutter)+row)
+		legend += t.Dim.Render("   ") + anomalyLegend(t, marks)
+	}
+	rows = append(rows, strings.Repeat(" ", axisGutter)+dateAxis(t, first, last, max(width-axisGutter, 12)), legend)
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// anomalyMarks returns the days `wits anomalies` flags in the grinding, each
+// with its glyph: ▲ for a heavy day, △ for the days of a heavy week and ▽ for
+// those of a light or silent one, a heavy day keeping its own mark inside a
+// flagged week. The usual is taken over the whole journal, not the scope on
+// screen, so a cycle's first days are judged against the one before. The days
+// of a declared break that nothing flagged are marked BreakMark, so a pause
+// reads as one rather than as a hole.
+func anomalyMarks(a *App) map[string]rune {
+	marks := map[string]rune{}
+	for _, an := range a.data.Detector(ledger.Grinding).Find(a.data.Chronological().Events, a.data.Now) {
+		glyph := '▽'
+		switch an.Kind {
+		case ledger.HeavyDay:
+			glyph = '▲'
+		case ledger.HeavyWeek:
+			glyph = '△'
+		}
+		for d := 0; d < an.Days; d++ {
+			key := an.Start.AddDate(0, 0, d).Format(time.DateOnly)
+			if _, ok := marks[key]; !ok || an.Kind == ledger.HeavyDay {
+				marks[key] = glyph
+			}
+		}
+	}
+	for day := range ledger.OnBreak(a.data.State.Events, a.data.Now) {
+		if _, ok := marks[day]; !ok {
+			marks[day] = BreakMark
+		}
+	}
+	return marks
+}
+
+// anomalyLegend explains the marks drawn: the anomalies', the break's, or both.
+func anomalyLegend(t *Theme, marks map[string]rune) string {
+	flagged, paused := false, false
+	for _, mark := range marks {
+		flagged = flagged || mark != BreakMark
+		paused = paused || mark == BreakMark
+	}
+	var parts []string
+	if flagged {
+		warn := lipgloss.NewStyle().Foreground(t.Warn)
+		parts = append(parts, warn.Render("▲")+t.Dim.Render(" heavy day  ")+
+			warn.Render("△▽")+t.Dim.Render(" heavy or light week · wits anomalies"))
+	}
+	if paused {
+		parts = append(parts, markStyle(t, BreakMark).Render(string(BreakMark))+t.Dim.Render(" break"))
+	}
+	return strings.Join(parts, t.Dim.Render("  "))
+}
+
+// throughBreaks returns last moved on to the latest day of a break declared in
+// events, up to now, so that a chart ending at the last grind still shows the
+// pause after it.
+func throughBreaks(events []journal.Event, last, now time.Time) time.Time {
+	for _, e := range events {
+		if e.Type != journal.Break {
+			continue
+		}
+		end := e.OccurredAt.AddDate(0, 0, e.Days-1)
+		if end.After(now) {
+			end = now
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	return last
 }
 
 // rhythm draws the longer scopes as a calendar heatmap, one cell per day. The
@@ -421,7 +653,13 @@ func (v analysisView) rhythm(a *App, events []journal.Event, width int) string {
 	if first.IsZero() {
 		return t.Dim.Render("nothing ground in this range")
 	}
-	return Calendar(perDay, first, last, width, t)
+	last = throughBreaks(events, last, a.data.Now)
+	marks := anomalyMarks(a)
+	calendar := MarkedCalendar(perDay, marks, first, last, width, t)
+	if MarkRow(marks, first, last, width, t) != "" {
+		calendar += "\n" + anomalyLegend(t, marks)
+	}
+	return calendar
 }
 
 // byProduct ranks the products by how much of them was ground, each under its
@@ -480,7 +718,7 @@ func (v analysisView) byProductBars(a *App, events []journal.Event) (current []B
 
 	own := map[string]bool{}
 	if v.scope == 0 {
-		if c := a.data.Cycle(); c != nil {
+		if c := a.data.Chronological().CurrentCycle(); c != nil {
 			for _, slug := range c.Products {
 				own[slug] = true
 			}
@@ -516,7 +754,7 @@ func (v analysisView) byProductBars(a *App, events []journal.Event) (current []B
 // stocked — an imported grind with no purchase — returns -1 and stays a
 // nameless older cycle.
 func fillSeqOf(a *App, slug string) int {
-	cycles := a.data.State.Cycles
+	cycles := a.data.Chronological().Cycles
 	for i := len(cycles) - 2; i >= 0; i-- {
 		for _, p := range cycles[i].Products {
 			if p == slug {
@@ -532,9 +770,9 @@ func fillSeqOf(a *App, slug string) int {
 // applied so far, so they appear the way they appeared.
 func (v analysisView) cycles(a *App, events []journal.Event, width int) string {
 	t := a.theme
-	all := a.data.State.Cycles
+	all := a.data.Chronological().Cycles
 	if v.playhead >= 0 {
-		all = ledger.Fold(events).Cycles
+		all = ledger.FoldFor(ledger.Occurred, a.data.State.Patient, events).Cycles
 	}
 	if len(all) == 0 {
 		return t.Dim.Render("no cycles yet")
@@ -620,6 +858,9 @@ func (v sessionsView) View(a *App, height int) string {
 		t.Rule("Rhythm", width),
 		v.rhythm(a, events, width),
 	}
+	if yield := v.yield(a, width); yield != "" {
+		sections = append(sections, "", t.Rule("AVB yield", width), yield)
+	}
 
 	lines := strings.Split(lipgloss.JoinVertical(lipgloss.Left, sections...), "\n")
 	visible := max(height-2, 1)
@@ -637,35 +878,28 @@ func (v sessionsView) View(a *App, height int) string {
 }
 
 // summary is the headline figures: how many sessions, how much they drew, and
-// what a typical one looks like.
+// what a typical one looks like. The arithmetic is the ledger's, the same that
+// `wits status --sessions` prints.
 func (v sessionsView) summary(a *App, events []journal.Event, width int) string {
 	t := a.theme
-	total, days := 0.0, map[string]bool{}
-	temps, tempCount := 0, 0
-	for _, e := range events {
-		total += e.Grams
-		days[e.OccurredAt.Format(time.DateOnly)] = true
-		if e.Temperature > 0 {
-			temps += e.Temperature
-			tempCount++
-		}
-	}
-	perSession := total / float64(len(events))
-	avgTemp := "—"
-	if tempCount > 0 {
-		avgTemp = fmt.Sprintf("%d°C", temps/tempCount)
+	st := ledger.Sessions(events)
+	temp := "—"
+	if st.MedianTemp > 0 {
+		temp = fmt.Sprintf("%d°C", st.MedianTemp)
 	}
 
 	cols := lipgloss.JoinHorizontal(lipgloss.Top,
 		lipgloss.NewStyle().Width(width/4).Render(
-			t.Metric("sessions", fmt.Sprintf("%d", len(events)),
-				fmt.Sprintf("across %s", plural(len(days), "day")))),
+			t.Metric("sessions", fmt.Sprintf("%d", st.Sessions),
+				fmt.Sprintf("over %s, %.1f a day", plural(st.ActiveDays, "day"), st.DailySessions))),
 		lipgloss.NewStyle().Width(width/4).Render(
-			t.Metric("seshed", fmt.Sprintf("%.1f g", total), "out of the stash")),
+			t.Metric("seshed", fmt.Sprintf("%.1f g", st.Grams),
+				fmt.Sprintf("%.2f g a day", st.PerActiveDay))),
 		lipgloss.NewStyle().Width(width/4).Render(
-			t.Metric("per session", fmt.Sprintf("%.2f g", perSession), "")),
+			t.Metric("per session", fmt.Sprintf("%.2f g", st.PerSession.Median),
+				fmt.Sprintf("median of %.2f–%.2f g", st.PerSession.Min, st.PerSession.Max))),
 		lipgloss.NewStyle().Width(width/4).Render(
-			t.Metric("avg temp", avgTemp, "where one was set")),
+			t.Metric("median temp", temp, "where one was set")),
 	)
 	return cols
 }
@@ -674,24 +908,15 @@ func (v sessionsView) summary(a *App, events []journal.Event, width int) string
 // uses, with the same seven-day average riding over it.
 func (v sessionsView) perDay(a *App, events []journal.Event, width int) string {
 	t := a.theme
-	perDay := map[string]float64{}
-	var first, last time.Time
-	for _, e := range events {
-		perDay[e.OccurredAt.Format(time.DateOnly)] += e.Grams
-		if first.IsZero() || e.OccurredAt.Before(first) {
-			first = e.OccurredAt
-		}
-		if e.OccurredAt.After(last) {
-			last = e.OccurredAt
-		}
-	}
+	perDay := ledger.Daily(ledger.Seshing, events)
+	st := ledger.SummariseBy(ledger.Seshing, events)
 	var values []float64
-	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+	for d := st.First; !d.After(st.Last); d = d.AddDate(0, 0, 1) {
 		values = append(values, perDay[d.Format(time.DateOnly)])
 	}
 	chart := AreaChart(values, movingAverage(values, 7), width, 6, t, t.SeshC, t.Alt)
 	return lipgloss.JoinVertical(lipgloss.Left, chart,
-		axisLabels(t, first.Format("02 Jan 06"), last.Format("02 Jan 06"), width))
+		axisLabels(t, st.First.Format("02 Jan 06"), st.Last.Format("02 Jan 06"), width))
 }
 
 // deviceUsage is what the sessions drew through one device.
@@ -759,22 +984,57 @@ func (v sessionsView) byDevice(a *App, events []journal.Event, width int) string
 	return BarChart(bars, width, t)
 }
 
-// rhythm is the calendar of session days, in the same heat the analysis
-// screen reads consumption in.
-func (v sessionsView) rhythm(a *App, events []journal.Event, width int) string {
+// yield ranks each device and temperature band by the grams of AVB it left
+// per gram seshed, with a line of the monthly figure beneath, so a new device
+// or a hotter habit shows up as a change. It is empty until AVB has been
+// collected: a yield of nothing only says that nobody has weighed any.
+func (v sessionsView) yield(a *App, width int) string {
 	t := a.theme
-	perDay := map[string]float64{}
-	var first, last time.Time
-	for _, e := range events {
-		perDay[e.OccurredAt.Format(time.DateOnly)] += e.Grams
-		if first.IsZero() || e.OccurredAt.Before(first) {
-			first = e.OccurredAt
+	events := a.data.State.Events
+	total := ledger.TotalYield(events)
+	if total.Collected <= 0 {
+		return ""
+	}
+	var bars []Bar
+	for _, y := range ledger.Yields(events) {
+		if y.Seshed <= 0 {
+			continue
 		}
-		if e.OccurredAt.After(last) {
-			last = e.OccurredAt
+		label := "no device"
+		if y.Device != "" {
+			label = y.Device
+			if a.data.Devices != nil {
+				if d, err := a.data.Devices.Find(y.Device); err == nil {
+					label = d.Name
+				}
+			}
+		}
+		if y.Band > 0 {
+			label = fmt.Sprintf("%s %d–%d°C", label, y.Band, y.Band+ledger.BandWidth-1)
 		}
+		bars = append(bars, Bar{
+			Label: label,
+			Value: y.Ratio(),
+			Note:  fmt.Sprintf("%.2f g per g · %.2f g of %.2f g", y.Ratio(), y.Collected, y.Seshed),
+			Color: t.AVBC,
+		})
 	}
-	return Calendar(perDay, first, last, width, t)
+	months := ledger.YieldByMonth(events)
+	ratios := make([]float64, len(months))
+	for i, m := range months {
+		ratios[i] = m.Ratio()
+	}
+	trend := t.Dim.Render(fmt.Sprintf("%.2f g of AVB per gram seshed overall · by month since %s  ",
+		total.Ratio(), months[0].Month.Format("Jan 2006")))
+	trend += Sparkline(ratios, max(width-lipgloss.Width(trend), 1), lipgloss.NewStyle().Foreground(t.AVBC))
+	return lipgloss.JoinVertical(lipgloss.Left, BarChart(bars, width, t), trend)
+}
+
+// rhythm is the calendar of session days, in the same heat the analysis
+// screen reads consumption in.
+func (v sessionsView) rhythm(a *App, events []journal.Event, width int) string {
+	st := ledger.SummariseBy(ledger.Seshing, events)
+	return Calendar(ledger.Daily(ledger.Seshing, events), st.First, st.Last, width, a.theme)
 }
 
 // The Séance is the tab where the ledger is summoned back: the same replay
@@ -1057,7 +1317,7 @@ func jarRow(a *App, played []journal.Event, order []string, through map[string]f
 	if len(order) == 0 {
 		return t.Dim.Render(empty)
 	}
-	balances := ledger.Fold(played).Balances
+	balances := ledger.Fold(a.data.State.Patient, played).Balances
 	if room := max(width/15, 1); len(order) > room {
 		order = order[len(order)-room:]
 	}
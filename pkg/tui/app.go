@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/TheDonDope/wits/pkg/repo"
 	"github.com/TheDonDope/wits/pkg/workspace"
 )
@@ -68,6 +70,8 @@ type keyMap struct {
 	Help, Quit     key.Binding
 	New, Sesh, Buy key.Binding
 	Weigh          key.Binding
+	Collect, Use   key.Binding
+	Feel           key.Binding
 	Edit, Delete   key.Binding
 	Add            key.Binding
 }
@@ -85,10 +89,17 @@ func defaultKeys() keyMap {
 		// g grinds, mirroring the command's initial; n stays as a quiet alias
 		// for the muscle memory the first weeks built. Jumping to the top moved
 		// to home alone to make room.
-		New:    key.NewBinding(key.WithKeys("g", "n"), key.WithHelp("g", "grind")),
-		Sesh:   key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sesh")),
-		Buy:    key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "buy")),
-		Weigh:  key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "weigh")),
+		New:   key.NewBinding(key.WithKeys("g", "n"), key.WithHelp("g", "grind")),
+		Sesh:  key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sesh")),
+		Buy:   key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "buy")),
+		Weigh: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "weigh")),
+		// c is the storage screen's history clean-up there, and collects
+		// AVB everywhere else.
+		Collect: key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "collect AVB")),
+		Use:     key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "use AVB")),
+		// f filters the journal and frames the séance, so a feel takes the
+		// capital, which no screen has claimed.
+		Feel:   key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "feel")),
 		Edit:   key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
 		Delete: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
 		Add:    key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add")),
@@ -115,6 +126,7 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Up, k.Down, k.PageUp, k.PgDown},
 		{k.Top, k.Bottom},
 		{k.Buy, k.New, k.Sesh, k.Weigh},
+		{k.Collect, k.Use, k.Feel},
 		{k.Help, k.Quit},
 	}
 }
@@ -142,6 +154,10 @@ type App struct {
 	notice string
 	failed bool
 
+	// appends delivers what other processes record while the interface is
+	// open; nil until Watch subscribes.
+	appends <-chan []journal.Event
+
 	dashboard dashboard
 	journal   journalView
 	analysis  analysisView
@@ -176,7 +192,37 @@ func New(data Data) *App {
 func (a *App) Init() tea.Cmd {
 	// Ask the terminal what it is, so the theme can be resolved against the
 	// actual background rather than assumed.
-	return tea.Batch(tea.RequestBackgroundColor, tickWall())
+	return tea.Batch(tea.RequestBackgroundColor, tickWall(), a.awaitAppends())
+}
+
+// Watch subscribes the interface to the journal, so that an entry recorded
+// from another terminal reaches every screen without a restart. The
+// subscription lasts until ctx is done.
+func (a *App) Watch(ctx context.Context) error {
+	appends, err := a.data.Journal().Watch(ctx)
+	if err != nil {
+		return err
+	}
+	a.appends = appends
+	return nil
+}
+
+// appendedMsg carries entries another process recorded.
+type appendedMsg []journal.Event
+
+// awaitAppends waits for the next batch of entries recorded elsewhere.
+func (a *App) awaitAppends() tea.Cmd {
+	if a.appends == nil {
+		return nil
+	}
+	appends := a.appends
+	return func() tea.Msg {
+		events, ok := <-appends
+		if !ok {
+			return nil
+		}
+		return appendedMsg(events)
+	}
 }
 
 // wallTickMsg carries the clock, once a second.
@@ -227,6 +273,14 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			msg.from.Format("02 Jan 2006"), msg.to.AddDate(0, 0, -1).Format("02 Jan 2006")), false
 		return a, nil
 
+	case appendedMsg:
+		// Another patient's entries still move the journal on, so the reload
+		// goes out regardless; only this patient's are worth a word.
+		if n := len(ledger.OfPatient(msg, a.data.State.Patient)); n > 0 {
+			a.notice, a.failed = fmt.Sprintf("%s recorded elsewhere", plural(n, "entry")), false
+		}
+		return a, tea.Batch(a.reload(), a.awaitAppends())
+
 	case reloadedMsg:
 		if msg.err != nil {
 			a.notice, a.failed = msg.err.Error(), true
@@ -364,10 +418,18 @@ func (a *App) header() string {
 
 	// The cycle summary is the first thing to go when the terminal is narrow:
 	// the tabs are how you navigate, so they matter more.
+	// In a household of several patients it also says whose the figures are.
 	right := ""
+	var summary []string
+	if a.data.Patient != "" {
+		summary = append(summary, a.data.Patient)
+	}
 	if c := a.data.Cycle(); c != nil {
-		candidate := t.Dim.Render(fmt.Sprintf("cycle %d · day %d",
+		summary = append(summary, fmt.Sprintf("cycle %d · day %d",
 			len(a.data.State.Cycles), daysBetween(c.Start, a.data.Now)+1))
+	}
+	if len(summary) > 0 {
+		candidate := t.Dim.Render(strings.Join(summary, " · "))
 		if lipgloss.Width(left)+lipgloss.Width(candidate)+3 <= a.width {
 			right = candidate
 		}
@@ -441,8 +503,15 @@ func (a *App) entryDone(msg entryDoneMsg) (tea.Model, tea.Cmd) {
 		a.notice, a.failed = fmt.Sprintf("renamed %s to %s", msg.event.Product, msg.event.Note), false
 		return a, a.reload()
 	}
-	a.notice, a.failed = fmt.Sprintf("recorded %s %.2fg %s",
-		msg.event.Type, msg.event.Grams, msg.event.Product), false
+	a.notice, a.failed = fmt.Sprintf("recorded %s %s", msg.event.Type, amountOf(msg.event)), false
+	// A session during a break is recorded, and said to be a lapse in the
+	// colour of a warning.
+	if e := msg.event; e.Type == journal.Sesh || e.Type == journal.Grind {
+		if b, ok := ledger.BreakAt(a.data.State.Events, e.OccurredAt); ok {
+			a.notice += fmt.Sprintf(" — day %d of the %d-day break, a lapse", b.Day(e.OccurredAt), b.Days())
+			a.failed = true
+		}
+	}
 	return a, a.reload()
 }
 
@@ -493,7 +562,7 @@ func (a *App) open(f *entryForm) (bool, tea.Cmd) {
 }
 
 // entryKey opens the forms that record something new: a grind, a session, a
-// fill, a weighing or the history clean-up.
+// fill, a weighing, the history clean-up, AVB collected or used, or a feel.
 func (a *App) entryKey(msg tea.KeyPressMsg) (bool, tea.Cmd) {
 	switch {
 	case key.Matches(msg, a.keys.New):
@@ -510,6 +579,20 @@ func (a *App) entryKey(msg tea.KeyPressMsg) (bool, tea.Cmd) {
 		return true, nil
 	case key.Matches(msg, a.keys.Weigh) && a.screen != journalScreen:
 		return a.weighKey()
+	case key.Matches(msg, a.keys.Collect):
+		if len(productOptions(a, journal.Consumed)) == 0 {
+			a.notice, a.failed = "nothing seshed to collect AVB from", true
+			return true, nil
+		}
+		return a.open(newEntryForm(entryCollect, a))
+	case key.Matches(msg, a.keys.Use):
+		if len(productOptions(a, journal.AVB)) == 0 {
+			a.notice, a.failed = "the AVB jar is empty", true
+			return true, nil
+		}
+		return a.open(newEntryForm(entryUseAVB, a))
+	case key.Matches(msg, a.keys.Feel):
+		return a.open(newEntryForm(entryFeel, a))
 	}
 	return false, nil
 }
@@ -590,12 +673,16 @@ type reloadedMsg struct {
 }
 
 // reload re-reads the journal after something has been written to it, so every
-// screen sees the new entry at once.
+// screen sees the new entry at once. It reads for the patient on screen, so the
+// next entry is theirs too.
 func (a *App) reload() tea.Cmd {
-	repository := a.data.Repo
+	ws := a.data.Workspace
 	return func() tea.Msg {
-		data, err := Load(repository)
-		return reloadedMsg{data: data, err: err}
+		fresh, err := ws.Reload()
+		if err != nil {
+			return reloadedMsg{err: err}
+		}
+		return reloadedMsg{data: From(fresh)}
 	}
 }
 
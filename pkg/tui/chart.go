@@ -346,6 +346,25 @@ func resample(values []float64, n int) []float64 {
 // from weekdays — none of which a bar per day can say once the days outnumber
 // the columns of the terminal.
 func Calendar(perDay map[string]float64, from, to time.Time, width int, t *Theme) string {
+	return MarkedCalendar(perDay, nil, from, to, width, t)
+}
+
+// BreakMark is the glyph of a day on a tolerance break. It is drawn in the
+// colour of good news rather than of a warning: a pause that was planned.
+const BreakMark = '‖'
+
+// markStyle is the colour a marked day is drawn in.
+func markStyle(t *Theme, mark rune) lipgloss.Style {
+	if mark == BreakMark {
+		return lipgloss.NewStyle().Foreground(t.Good)
+	}
+	return lipgloss.NewStyle().Foreground(t.Warn)
+}
+
+// MarkedCalendar is Calendar with some days marked: each drawn as its glyph in
+// the warning colour instead of its heat, so a flagged day reads as flagged
+// however much or little it held. A day on a break takes BreakMark's colour.
+func MarkedCalendar(perDay map[string]float64, marks map[string]rune, from, to time.Time, width int, t *Theme) string {
 	const gutter = 4 // room for the weekday labels
 	if width <= gutter+1 || to.Before(from) {
 		return t.Dim.Render("nothing to show yet")
@@ -377,7 +396,12 @@ func Calendar(perDay map[string]float64, from, to time.Time, width int, t *Theme
 		b.WriteString(t.Dim.Render(padTo(labels[weekday], gutter)))
 		for week := 0; week < weeks; week++ {
 			day := start.AddDate(0, 0, week*7+weekday)
-			b.WriteString(calendarCell(t, perDay[day.Format(time.DateOnly)], peak, day, from, to))
+			key := day.Format(time.DateOnly)
+			if mark, ok := marks[key]; ok && !day.Before(from) && !day.After(to) {
+				b.WriteString(markStyle(t, mark).Render(string(mark)))
+				continue
+			}
+			b.WriteString(calendarCell(t, perDay[key], peak, day, from, to))
 		}
 		rows = append(rows, b.String())
 	}
@@ -385,6 +409,38 @@ func Calendar(perDay map[string]float64, from, to time.Time, width int, t *Theme
 	return strings.Join(rows, "\n")
 }
 
+// MarkRow writes the glyph of each marked day under a chart of the days from
+// first to last drawn width columns wide, at the column the day falls in, or
+// returns "" when none of them is in the range. Where two marks share a column
+// the first day's wins.
+func MarkRow(marks map[string]rune, first, last time.Time, width int, t *Theme) string {
+	days := daysBetween(first, last) + 1
+	if days <= 0 || width <= 0 {
+		return ""
+	}
+	row := []rune(strings.Repeat(" ", width))
+	marked := false
+	for i := 0; i < days; i++ {
+		mark, ok := marks[first.AddDate(0, 0, i).Format(time.DateOnly)]
+		if col := i * width / days; ok && row[col] == ' ' {
+			row[col] = mark
+			marked = true
+		}
+	}
+	if !marked {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range row {
+		if r == ' ' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(markStyle(t, r).Render(string(r)))
+	}
+	return b.String()
+}
+
 // monthLabels writes each month's name and its two-digit year where the month
 // begins — "Jan 26", so two Januaries a year apart cannot be confused. A label
 // is skipped when the previous one would still be under it: a cramped chart
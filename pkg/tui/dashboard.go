@@ -92,6 +92,7 @@ func (d dashboard) grid(a *App, c *ledger.Cycle, width int) []string {
 		pair(card(a, "Sessions", d.sessionsCard(a, inner), colW),
 			card(a, "Devices", d.devicesCard(a, inner), colW)),
 		card(a, "Supply projection", d.projectionCard(a, c, width-4), width),
+		card(a, "Budget", d.budgetCard(a, width-4), width),
 		pair(card(a, "Rhythm · grinding", rhythmCard(a, journal.Grind, inner), colW),
 			card(a, "Rhythm · sessions", rhythmCard(a, journal.Sesh, inner), colW)),
 	}
@@ -279,10 +280,30 @@ func (d dashboard) sessionsCard(a *App, w int) string {
 				nounOnly(len(events), "session"), total, total/float64(len(events)))),
 		lipgloss.NewStyle().Foreground(t.SeshC).Render(Sparkline(values, min(w, 28), lipgloss.NewStyle()))+
 			t.Dim.Render("  last 14 days"),
-		t.Dim.Render(lastLine),
+		streakLine(a, t.Dim.Render(lastLine)),
 	)
 }
 
+// streakLine is where the break running stands, or the days since the last
+// session or grind once there are two of them: a pause that reads as a count
+// rather than a hole. Otherwise it is the line it stands in for.
+func streakLine(a *App, otherwise string) string {
+	t := a.theme
+	events, now := a.data.State.Events, a.data.Now
+	if b, ok := ledger.BreakAt(events, now); ok {
+		line := fmt.Sprintf("break: day %d of %d, %s to go", b.Day(now), b.Days(), plural(b.Left(now), "day"))
+		if !b.Kept() {
+			return lipgloss.NewStyle().Foreground(t.Warn).Render(line + " · " + plural(len(b.Lapses), "lapse"))
+		}
+		return lipgloss.NewStyle().Foreground(t.Good).Render(line)
+	}
+	if days, _ := ledger.Streak(events, now); days >= 2 {
+		return lipgloss.NewStyle().Foreground(t.Good).Render(
+			fmt.Sprintf("streak: %d days without a session or grind", days))
+	}
+	return otherwise
+}
+
 // devicesCard is the devices at a glance: which one does the work, and how hot.
 func (d dashboard) devicesCard(a *App, w int) string {
 	t := a.theme
@@ -345,6 +366,9 @@ func (d dashboard) projectionCard(a *App, c *ledger.Cycle, w int) string {
 		caption = t.Dim.Render("empty around ") +
 			lipgloss.NewStyle().Foreground(t.Warn).Render(emptyAt.Format("02 Jan")) +
 			t.Dim.Render(" at the observed rate")
+		if b, ok := ledger.BreakAt(a.data.State.Events, a.data.Now); ok {
+			caption += t.Dim.Render(", from the end of the break on " + b.End.Format("02 Jan"))
+		}
 	}
 	return lipgloss.JoinVertical(lipgloss.Left,
 		chart,
@@ -353,6 +377,54 @@ func (d dashboard) projectionCard(a *App, c *ledger.Cycle, w int) string {
 	)
 }
 
+// budgetCard is `wits budget` to the expected next fill: the allowance a day,
+// a strip with one cell per day budgeted — ahead or behind for the days gone,
+// faint for the days to come — and where today stands. Like the command, it
+// reads the journal in the order things happened, so a backdated grind counts
+// against the day it was ground.
+func (d dashboard) budgetCard(a *App, w int) string {
+	t, data := a.theme, a.data
+	state := data.Chronological()
+	c, until := state.CurrentCycle(), state.NextFill()
+	if c == nil || until.IsZero() {
+		return t.Dim.Render("no next fill to budget to until there are two — `wits budget --until` sets a date")
+	}
+	b := state.Budget(c, until, data.Now)
+	left := b.DaysLeft(data.Now)
+	if left <= 0 {
+		return t.Dim.Render("the next fill was expected " + until.Format("02 Jan") +
+			" — `wits budget --until` budgets to another date")
+	}
+
+	cells := make([]string, 0, b.Span())
+	ahead := lipgloss.NewStyle().Foreground(t.Good)
+	behind := lipgloss.NewStyle().Foreground(t.Warn)
+	for _, day := range b.Days {
+		if day.Balance < 0 {
+			cells = append(cells, behind.Render("▮"))
+		} else {
+			cells = append(cells, ahead.Render("▮"))
+		}
+	}
+	for range left - 1 {
+		cells = append(cells, t.Dim.Render("·"))
+	}
+	// A strip wider than the card keeps today and what follows it.
+	cells = cells[max(len(cells)-w, 0):]
+
+	now := t.Positive.Render(fmt.Sprintf("%.2f g ahead", b.Balance()))
+	if b.Balance() < 0 {
+		now = t.Negative.Render(fmt.Sprintf("%.2f g behind", -b.Balance()))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		t.Big.Render(fmt.Sprintf("%.2f g a day", b.Allowance))+
+			t.Dim.Render(fmt.Sprintf("  until %s, the expected next fill · %s to go",
+				until.Format("02 Jan"), plural(left, "day"))),
+		strings.Join(cells, ""),
+		now+t.Dim.Render(fmt.Sprintf(" · %.2f g a day from here", b.FromHere(data.Now))),
+	)
+}
+
 // emptyDate labels the right end of the projection: the projected empty day,
 // or today when there is nothing to project.
 func emptyDate(emptyAt time.Time, now time.Time) string {
@@ -383,7 +455,13 @@ func storageProjection(a *App, c *ledger.Cycle) (hist, proj []float64, emptyAt t
 	if rate <= 0 || remaining <= 0 {
 		return hist, nil, time.Time{}
 	}
-	days := min(int(math.Ceil(remaining/rate)), 90)
+	// A break running holds the line flat until it is over, rather than
+	// spending grams the break is there not to spend.
+	paused := 0
+	if b, ok := ledger.BreakAt(a.data.State.Events, a.data.Now); ok {
+		paused = b.Left(a.data.Now)
+	}
+	days := min(paused+int(math.Ceil(remaining/rate)), 90)
 	proj = make([]float64, len(hist)+days)
 	// Zeroes under the history keep the line out of the past; the area chart
 	// draws no dot at zero.
@@ -391,7 +469,9 @@ func storageProjection(a *App, c *ledger.Cycle) (hist, proj []float64, emptyAt t
 	level := remaining
 	proj[len(hist)-1] = level
 	for i := 0; i < days; i++ {
-		level = math.Max(level-rate, 0.01)
+		if i >= paused {
+			level = math.Max(level-rate, 0.01)
+		}
 		proj[len(hist)+i] = level
 	}
 	hist = append(hist, make([]float64, days)...)
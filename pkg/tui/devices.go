@@ -155,11 +155,15 @@ func releasedSummary(a *App, celsius, width int) string {
 }
 
 // plural renders a count with its noun, so a device used once does not read
-// as "1 sessions" — and two stashes never read as "2 stashs".
+// as "1 sessions" — and two stashes never read as "2 stashs", nor two entries
+// "2 entrys".
 func plural(n int, noun string) string {
 	if n == 1 {
 		return fmt.Sprintf("1 %s", noun)
 	}
+	if stem, ok := strings.CutSuffix(noun, "y"); ok && stem != "" && !strings.ContainsAny(stem[len(stem)-1:], "aeiou") {
+		return fmt.Sprintf("%d %sies", n, stem)
+	}
 	if strings.HasSuffix(noun, "sh") || strings.HasSuffix(noun, "s") {
 		return fmt.Sprintf("%d %ses", n, noun)
 	}
@@ -295,7 +299,7 @@ func (f *deviceForm) commit(a *App) error {
 			return err
 		}
 	}
-	return devices.Save(a.data.Repo.DevicesPath())
+	return a.data.Repo.SaveDevices(devices)
 }
 
 // validTemps rejects a range that cannot be set, which would otherwise only
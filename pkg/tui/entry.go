@@ -24,6 +24,9 @@ const (
 	entryGrind entryKind = iota
 	entrySesh
 	entryBuy
+	entryCollect
+	entryUseAVB
+	entryFeel
 )
 
 func (k entryKind) String() string {
@@ -32,6 +35,12 @@ func (k entryKind) String() string {
 		return "Session"
 	case entryBuy:
 		return "Prescription fill"
+	case entryCollect:
+		return "Collect AVB"
+	case entryUseAVB:
+		return "Use AVB"
+	case entryFeel:
+		return "How it feels"
 	case entryAmend:
 		return "Amend entry"
 	case entryUndo:
@@ -69,10 +78,13 @@ type entryForm struct {
 	// The fields below belong to single forms. They used to be borrowed from
 	// the ones above — the account rode in device, the CBD in temp — which
 	// worked until anyone had to read commit and say what device meant there.
-	account      string // reconcile: which account is on the scale
-	manufacturer string // describe
-	cultivar     string // describe
-	thc, cbd     string // describe
+	account      string   // reconcile: which account is on the scale
+	manufacturer string   // describe
+	cultivar     string   // describe
+	thc, cbd     string   // describe
+	purpose      string   // use AVB
+	scores       []string // feel: one per symptom, in the order of journal.Symptoms
+	session      string   // feel: the hash of the session it follows, or nothing
 
 	// target is the entry being corrected, for the amend and undo forms.
 	target *journal.Event
@@ -129,6 +141,57 @@ func newEntryForm(kind entryKind, a *App) *entryForm {
 		}
 		fields = append(fields, huh.NewInput().Title("Note").Description("Optional").Value(&f.note))
 		f.form = huh.NewForm(huh.NewGroup(fields...))
+	case entryCollect:
+		fields := []huh.Field{
+			huh.NewSelect[string]().Title("Product").
+				Description("Seshed and not yet collected").
+				Options(productOptions(a, journal.Consumed)...).
+				Value(&f.product),
+			huh.NewInput().Title("Amount").Description("Grams of AVB out of the device").
+				Value(&f.amount).Validate(validGrams),
+		}
+		// The yield is read by device and temperature, and the last session
+		// is nearly always where the AVB came from, so that is the default.
+		if opts := deviceOptions(a); len(opts) > 0 {
+			opts = append([]huh.Option[string]{huh.NewOption("As the last session", "")}, opts...)
+			fields = append(fields,
+				huh.NewSelect[string]().Title("Device").Options(opts...).Value(&f.device),
+				huh.NewInput().Title("Temperature").Description("°C, blank for the last session's").
+					Value(&f.temp).Validate(optionalInt),
+			)
+		}
+		fields = append(fields, huh.NewInput().Title("Note").Description("Optional").Value(&f.note))
+		f.form = huh.NewForm(huh.NewGroup(fields...))
+	case entryUseAVB:
+		f.form = huh.NewForm(huh.NewGroup(
+			huh.NewSelect[string]().Title("Product").
+				Description("Taken out of the AVB jar").
+				Options(productOptions(a, journal.AVB)...).
+				Value(&f.product),
+			huh.NewInput().Title("Amount").Description("Grams used").
+				Value(&f.amount).Validate(validGrams),
+			huh.NewInput().Title("For").Description("Edibles, tincture, or whatever you make").
+				Value(&f.purpose),
+			huh.NewInput().Title("Note").Description("Optional").Value(&f.note),
+		))
+	case entryFeel:
+		// Every symptom is offered and none is required: a feel scores what
+		// was worth scoring, and a blank is not a zero.
+		f.scores = make([]string, len(journal.Symptoms))
+		var fields []huh.Field
+		for i, sym := range journal.Symptoms {
+			fields = append(fields, huh.NewInput().Title(strings.ToUpper(sym.Name[:1])+sym.Name[1:]).
+				Description(fmt.Sprintf("0 %s, %d %s — blank to leave out", sym.Low, journal.MaxScore, sym.High)).
+				Value(&f.scores[i]).Validate(optionalScore))
+		}
+		opts, last := sessionOptions(a)
+		f.session = last
+		fields = append(fields,
+			huh.NewSelect[string]().Title("After").Description("The session it follows, if any").
+				Options(opts...).Value(&f.session),
+			huh.NewInput().Title("Note").Description("Optional").Value(&f.note),
+		)
+		f.form = huh.NewForm(huh.NewGroup(fields...))
 	}
 
 	f.form = f.form.WithShowHelp(true).WithWidth(min(a.inner(), 72))
@@ -151,6 +214,10 @@ func productOptions(a *App, account journal.Account) []huh.Option[string] {
 			have = b.Storage
 		case journal.Stash:
 			have = b.Stash
+		case journal.Consumed:
+			have = b.Consumed
+		case journal.AVB:
+			have = b.AVB
 		}
 		if have <= 0 {
 			continue
@@ -173,6 +240,49 @@ func deviceOptions(a *App) []huh.Option[string] {
 	return opts
 }
 
+// feelSessions is how many of the latest sessions the feel form offers to
+// follow. A feel is written soon after, or not at all.
+const feelSessions = 8
+
+// sessionOptions lists the latest standing sessions for a feel to follow,
+// newest first, after the choice of following none. The newest is the
+// default when it was on the same day, since that is the one being felt.
+func sessionOptions(a *App) ([]huh.Option[string], string) {
+	opts := []huh.Option[string]{huh.NewOption("No session — on its own", "")}
+	standing := ledger.Standing(a.data.State.Events)
+	last := ""
+	for i := len(standing) - 1; i >= 0 && len(opts) <= feelSessions; i-- {
+		e := standing[i]
+		if e.Type != journal.Sesh {
+			continue
+		}
+		label := fmt.Sprintf("%s  %.2f g %s", e.OccurredAt.Format("Mon 02 Jan 15:04"), e.Grams,
+			truncate(a.data.ProductName(e.Product), 30))
+		if e.Device != "" {
+			label += "  " + e.Device
+		}
+		if e.Temperature > 0 {
+			label += fmt.Sprintf(" %d°C", e.Temperature)
+		}
+		if len(opts) == 1 && daysBetween(e.OccurredAt, a.data.Now) == 0 {
+			last = e.Hash
+		}
+		opts = append(opts, huh.NewOption(label, e.Hash))
+	}
+	return opts, last
+}
+
+// optionalScore accepts a score on the symptom scale, or nothing at all.
+func optionalScore(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || v < 0 || v > journal.MaxScore {
+		return fmt.Errorf("a score from 0 to %d", journal.MaxScore)
+	}
+	return nil
+}
+
 // required rejects an empty value.
 func required(what string) func(string) error {
 	return func(s string) error {
@@ -250,7 +360,7 @@ func (f *entryForm) commit(a *App) (journal.Event, error) {
 	at := time.Now()
 
 	var grams float64
-	if f.kind != entryUndo && f.kind != entryReconcile && f.kind != entryDescribe {
+	if f.kind != entryUndo && f.kind != entryReconcile && f.kind != entryDescribe && f.kind != entryFeel {
 		var err error
 		if grams, err = parseGrams(f.amount); err != nil {
 			return journal.Event{}, err
@@ -276,7 +386,10 @@ func (f *entryForm) commit(a *App) (journal.Event, error) {
 		if err != nil {
 			return journal.Event{}, err
 		}
-		return rec.Reconcile(f.product, journal.Account(f.account), weighed, strings.TrimSpace(f.note))
+		// The choice is an account, or an account and a location after a
+		// slash for a product kept in more than one place.
+		account, location, _ := strings.Cut(f.account, "/")
+		return rec.ReconcileAt(f.product, journal.Account(account), location, weighed, strings.TrimSpace(f.note))
 	case entryUndo:
 		if !f.confirm {
 			return journal.Event{}, errCancelled
@@ -291,6 +404,19 @@ func (f *entryForm) commit(a *App) (journal.Event, error) {
 		return e, err
 	case entryGrind:
 		return rec.Grind(f.product, grams, at)
+	case entryCollect:
+		temp, _ := strconv.Atoi(strings.TrimSpace(f.temp))
+		return rec.Collect(f.product, grams, at, f.device, temp, strings.TrimSpace(f.note))
+	case entryUseAVB:
+		return rec.UseAVB(f.product, grams, at, f.purpose, strings.TrimSpace(f.note))
+	case entryFeel:
+		scores := journal.Scores{}
+		for i, sym := range journal.Symptoms {
+			if v, err := strconv.Atoi(strings.TrimSpace(f.scores[i])); err == nil {
+				scores[sym.Name] = v
+			}
+		}
+		return rec.Feel(scores, at, f.session, strings.TrimSpace(f.note))
 	default:
 		temp, _ := strconv.Atoi(strings.TrimSpace(f.temp))
 		return rec.Session(f.product, grams, at, f.device, temp, strings.TrimSpace(f.note))
@@ -364,6 +490,9 @@ func newUndoForm(e *journal.Event, a *App) *entryForm {
 
 // describe summarises the entry being corrected.
 func describe(e *journal.Event, a *App) string {
+	if e.Type == journal.Feel || e.Type == journal.Break {
+		return fmt.Sprintf("%s  %s  ·  %s", e.Type, amountOf(*e), e.OccurredAt.Format("Mon 02 Jan 2006"))
+	}
 	return fmt.Sprintf("%s  %.2f g  %s  ·  %s",
 		e.Type, e.Grams, a.data.ProductName(e.Product), e.OccurredAt.Format("Mon 02 Jan 2006"))
 }
@@ -383,9 +512,27 @@ func newReconcileForm(slug string, a *App, account journal.Account) *entryForm {
 		b = &ledger.Balance{}
 	}
 
-	accounts := []huh.Option[string]{
-		huh.NewOption(fmt.Sprintf("Storage — ledger says %.2f g", b.Storage), string(journal.Storage)),
-		huh.NewOption(fmt.Sprintf("The stash — ledger says %.2f g", b.Stash), string(journal.Stash)),
+	// A product kept in several places is several jars, each weighed on its
+	// own; one kept only at home reads as it always did.
+	var accounts []huh.Option[string]
+	for _, acc := range []struct {
+		account journal.Account
+		label   string
+	}{{journal.Storage, "Storage"}, {journal.Stash, "The stash"}} {
+		locations := b.Locations(acc.account)
+		if len(locations) == 0 {
+			accounts = append(accounts, huh.NewOption(
+				fmt.Sprintf("%s — ledger says %.2f g", acc.label, b.In(acc.account, "")), string(acc.account)))
+			continue
+		}
+		for _, location := range append([]string{""}, locations...) {
+			value := string(acc.account)
+			if location != "" {
+				value += "/" + location
+			}
+			accounts = append(accounts, huh.NewOption(fmt.Sprintf("%s at %s — ledger says %.2f g",
+				acc.label, journal.LocationName(location), b.In(acc.account, location)), value))
+		}
 	}
 	if b.AVB > 0 {
 		accounts = append(accounts,
@@ -444,10 +591,17 @@ func newWeighManyForm(slugs []string, a *App, account journal.Account) *entryFor
 		if b == nil {
 			b = &ledger.Balance{}
 		}
+		// The jars elsewhere are weighed one at a time, from the product's
+		// own form; this sitting is the ones at home.
+		title := a.data.ProductName(slug)
+		if len(b.Locations(journal.Storage)) > 0 || len(b.Locations(journal.Stash)) > 0 {
+			title += " at " + journal.Home
+		}
 		groups = append(groups, huh.NewGroup(
 			huh.NewInput().
-				Title(a.data.ProductName(slug)).
-				Description(fmt.Sprintf("On the scale, in grams — ledger says storage %.2f g · stash %.2f g.\nBlank to skip.", b.Storage, b.Stash)).
+				Title(title).
+				Description(fmt.Sprintf("On the scale, in grams — ledger says storage %.2f g · stash %.2f g.\nBlank to skip.",
+					b.In(journal.Storage, ""), b.In(journal.Stash, ""))).
 				Value(&f.readings[i]).
 				Validate(optionalWeight),
 		))
@@ -458,12 +612,14 @@ func newWeighManyForm(slugs []string, a *App, account journal.Account) *entryFor
 
 // commitMany records the collected readings and sums up what changed. A jar
 // that already matches is counted rather than turned into an error, because a
-// scale agreeing with the ledger is a good day, not a failure.
+// scale agreeing with the ledger is a good day, not a failure. The readings
+// are recorded together or not at all.
 func (f *entryForm) commitMany(a *App) (string, error) {
 	rec := record.New(a.data.Repo, a.data.Products, a.data.Devices, a.data.State)
 	account := journal.Account(f.account)
 
-	adjusted, matched, skipped := 0, 0, 0
+	var readings []record.Reading
+	skipped := 0
 	for i, slug := range f.slugs {
 		reading := strings.TrimSpace(f.readings[i])
 		if reading == "" {
@@ -475,16 +631,13 @@ func (f *entryForm) commitMany(a *App) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("%q is not a weight in grams", reading)
 		}
-		_, err = rec.Reconcile(slug, account, weighed, "")
-		if errors.Is(err, record.ErrNothingToReconcile) {
-			matched++
-			continue
-		}
-		if err != nil {
-			return "", err
-		}
-		adjusted++
+		readings = append(readings, record.Reading{Product: slug, Weighed: weighed})
 	}
+	recorded, err := rec.ReconcileAll(account, readings, "")
+	if err != nil {
+		return "", err
+	}
+	adjusted, matched := len(recorded), len(readings)-len(recorded)
 
 	parts := []string{fmt.Sprintf("adjusted %s", plural(adjusted, "jar"))}
 	if matched > 0 {
@@ -554,20 +707,25 @@ func (f *entryForm) commitClean(a *App) (string, error) {
 		return "", errCancelled
 	}
 	rec := record.New(a.data.Repo, a.data.Products, a.data.Devices, a.data.State)
-	cleaned, grams := 0, 0.0
+	var readings []record.Reading
+	grams := 0.0
 	for _, slug := range f.slugs {
 		b := a.data.State.Balances[slug]
 		if b == nil || b.Stash <= 0 {
 			continue
 		}
-		was := b.Stash
-		if _, err := rec.Reconcile(slug, journal.Stash, 0, "clean history: consumed at some point"); err != nil {
-			return "", err
+		// Every location's stash, or the grams elsewhere would outlive it.
+		for _, location := range append([]string{""}, b.Locations(journal.Stash)...) {
+			if b.In(journal.Stash, location) > 0 {
+				readings = append(readings, record.Reading{Product: slug, Location: location})
+			}
 		}
-		cleaned++
-		grams += was
+		grams += b.Stash
+	}
+	if _, err := rec.ReconcileAll(journal.Stash, readings, "clean history: consumed at some point"); err != nil {
+		return "", err
 	}
-	return fmt.Sprintf("cleaned %s: %.2f g recorded as consumed", plural(cleaned, "stash"), grams), nil
+	return fmt.Sprintf("cleaned %s: %.2f g recorded as consumed", plural(len(readings), "stash"), grams), nil
 }
 
 // newDescribeForm edits a product's details.
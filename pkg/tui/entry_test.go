@@ -14,6 +14,7 @@ import (
 	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/TheDonDope/wits/pkg/record"
 	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/workspace"
 )
 
 // liveApp returns an app backed by a real repository on disk, so that entries
@@ -203,6 +204,98 @@ func TestProductOptionsOnlyOfferWhatIsThere(t *testing.T) {
 	assert.Empty(t, stash, "Should offer nothing from an empty stash, rather than a choice bound to be refused")
 }
 
+func TestAVBForms(t *testing.T) {
+	app := liveApp(t)
+	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
+	_, err := rec.Grind("wcake", 1, time.Now())
+	require.NoError(t, err)
+	_, err = rec.Session("wcake", 0.5, time.Now(), "", 0, "")
+	require.NoError(t, err)
+	data, err := Load(app.data.Repo)
+	require.NoError(t, err)
+	app.data = data
+	var m tea.Model = app
+
+	t.Run("UseRefusesAnEmptyJar", func(t *testing.T) {
+		m, _ = send(m, tea.KeyPressMsg{Code: 'u', Text: "u"})
+
+		assert.Nil(t, app.entry, "Should not open a form with nothing to choose")
+		assert.True(t, app.failed, "Should say why instead")
+	})
+
+	t.Run("CollectsFromTheSessions", func(t *testing.T) {
+		m, _ = send(m, tea.KeyPressMsg{Code: 'c', Text: "c"})
+		require.NotNil(t, app.entry, "c should open the collect form")
+		assert.Equal(t, entryCollect, app.entry.kind, "Should be a collection")
+
+		m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+		m = typeText(m, "0.2")
+		var msgs []tea.Msg
+		m, msgs = confirmThrough(m, 4)
+
+		done, ok := findDone(msgs)
+		require.True(t, ok, "Should report the entry, got %v", msgs)
+		require.NoError(t, done.err)
+		assert.Equal(t, journal.AVBCollect, done.event.Type, "Should have recorded a collection")
+		assert.Equal(t, 0.2, app.data.State.Balances["wcake"].AVB, "Should have reloaded the jar")
+	})
+
+	t.Run("UsesItForAPurpose", func(t *testing.T) {
+		m, _ = send(m, tea.KeyPressMsg{Code: 'u', Text: "u"})
+		require.NotNil(t, app.entry, "u should open the use form")
+
+		m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+		m = typeText(m, "0.1")
+		m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+		m = typeText(m, "tincture")
+		var msgs []tea.Msg
+		m, msgs = confirmThrough(m, 3)
+
+		done, ok := findDone(msgs)
+		require.True(t, ok, "Should report the entry, got %v", msgs)
+		require.NoError(t, done.err)
+		assert.Equal(t, "tincture", done.event.Purpose, "Should keep what it was used for")
+	})
+}
+
+func TestFeelForm(t *testing.T) {
+	app := liveApp(t)
+	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
+	_, err := rec.Grind("wcake", 1, time.Now())
+	require.NoError(t, err)
+	sesh, err := rec.Session("wcake", 0.5, time.Now(), "", 0, "")
+	require.NoError(t, err)
+	data, err := Load(app.data.Repo)
+	require.NoError(t, err)
+	app.data = data
+	var m tea.Model = app
+
+	m, _ = send(m, tea.KeyPressMsg{Code: 'F', Text: "F"})
+	require.NotNil(t, app.entry, "F should open the feel form")
+	assert.Equal(t, entryFeel, app.entry.kind, "Should be a feel")
+	assert.Equal(t, sesh.Hash, app.entry.session, "Should follow today's last session unless told otherwise")
+
+	m = typeText(m, "6")
+	m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = typeText(m, "11")
+	m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.Contains(t, stripANSI(app.entry.View(app, 96)), "a score from 0 to 10", "Should refuse a score off the scale")
+
+	m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyBackspace})
+	m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyBackspace})
+	m = typeText(m, "3")
+	var msgs []tea.Msg
+	_, msgs = confirmThrough(m, 8)
+
+	done, ok := findDone(msgs)
+	require.True(t, ok, "Should report the entry, got %v", msgs)
+	require.NoError(t, done.err)
+	assert.Equal(t, journal.Feel, done.event.Type, "Should have recorded a feel")
+	assert.Equal(t, journal.Scores{"pain": 6, "sleep": 3}, done.event.Scores, "Should keep what was scored and leave the blanks out")
+	assert.Equal(t, sesh.Hash, done.event.Session, "Should follow the session")
+	assert.Equal(t, 0.5, app.data.State.Balances["wcake"].Stash, "Should leave the balances alone")
+}
+
 func TestNoticeAfterAnEntry(t *testing.T) {
 	app := liveApp(t)
 	var m tea.Model = app
@@ -306,7 +399,9 @@ func TestJournalHidesCorrectedEntries(t *testing.T) {
 func TestJournalCursorSkipsHeadings(t *testing.T) {
 	app := liveApp(t)
 	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
-	_, err := rec.Grind("wedding", 1, time.Now().AddDate(0, 0, -1))
+	// A fill the day before, so the journal has two day headings; a grind
+	// dated then would be drawing on a jar not yet bought.
+	_, _, _, err := rec.Buy("wedding", "", 10, time.Now().AddDate(0, 0, -1))
 	require.NoError(t, err)
 	app.data, err = Load(app.data.Repo)
 	require.NoError(t, err)
@@ -541,3 +636,75 @@ func TestDevicesScreenKeys(t *testing.T) {
 	assert.Contains(t, help, "add", "Should mention adding")
 	assert.Contains(t, help, "remove", "Should mention removing")
 }
+
+func TestEntriesRecordedElsewhere(t *testing.T) {
+	app := liveApp(t)
+	require.NoError(t, app.Watch(t.Context()))
+
+	// Another terminal: its own journal over the same file, sharing nothing
+	// with the one the app records through.
+	elsewhere := journal.Open(app.data.Repo.JournalPath())
+	for range 2 {
+		_, err := elsewhere.Append(journal.Event{Type: journal.Grind, Product: "wcake", Grams: 1})
+		require.NoError(t, err)
+	}
+
+	var got appendedMsg
+	require.Eventually(t, func() bool {
+		if msg, ok := runCmd(app.awaitAppends()); ok {
+			batch, _ := msg.(appendedMsg)
+			got = append(got, batch...)
+		}
+		return len(got) == 2
+	}, 5*time.Second, 10*time.Millisecond, "Should hear about both entries")
+
+	// deliver, not send: the reload goes out batched with the next wait.
+	deliver(app, got)
+
+	assert.Equal(t, "2 entries recorded elsewhere", app.notice, "Should say what arrived")
+	assert.Equal(t, 18.0, app.data.State.Balances["wcake"].Storage, "and every screen should see it")
+}
+
+func TestEntriesRecordedElsewhereForAnotherPatient(t *testing.T) {
+	app := liveApp(t)
+	app.notice = ""
+	e, err := journal.Open(app.data.Repo.JournalPath()).Append(journal.Event{
+		Type: journal.Purchase, Product: "wcake", Grams: 5, Patient: "anna",
+		From: journal.External, To: journal.Storage, OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	deliver(app, appendedMsg{e})
+
+	assert.Empty(t, app.notice, "Should not count another patient's entries as this one's")
+	assert.Equal(t, 20.0, app.data.State.Balances["wcake"].Storage, "and should leave this patient's shelf alone")
+	assert.Len(t, app.data.State.Journal(), 2, "but should still read the journal on")
+}
+
+func TestReloadKeepsThePatient(t *testing.T) {
+	app := liveApp(t)
+	_, err := app.data.Repo.Journal().Append(journal.Event{
+		Type: journal.Purchase, Product: "wcake", Grams: 5, Patient: "anna",
+		From: journal.External, To: journal.Storage, OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+	ws, err := workspace.ReadFor(app.data.Repo, "anna")
+	require.NoError(t, err)
+	app.data = From(ws)
+	var m tea.Model = app
+
+	for range 2 {
+		m, _ = send(m, tea.KeyPressMsg{Code: 'n', Text: "n"})
+		m, _ = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+		m = typeText(m, "1")
+		var msgs []tea.Msg
+		m, msgs = send(m, tea.KeyPressMsg{Code: tea.KeyEnter})
+		done, ok := findDone(msgs)
+		require.True(t, ok, "Should report the entry, got %v", msgs)
+		require.NoError(t, done.err)
+		assert.Equal(t, "anna", done.event.Patient, "Should record the entry for the patient on screen, after a reload too")
+	}
+
+	assert.Equal(t, "anna", app.data.Patient, "Should reload for the same patient")
+	assert.Equal(t, 3.0, app.data.State.Balances["wcake"].Storage, "Should fold only anna's entries")
+}
@@ -8,6 +8,7 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
 )
 
 // glyphs give each event type a shape, so the kind of an entry is legible
@@ -19,6 +20,11 @@ var glyphs = map[journal.Type]string{
 	journal.AVBCollect: "◇",
 	journal.AVBUse:     "▽",
 	journal.Adjust:     "±",
+	journal.Feel:       "♥",
+	journal.Break:      "‖",
+	journal.Move:       "⇢",
+	journal.Batch:      "▣",
+	journal.Portion:    "▪",
 }
 
 // verbs are how each event type reads in a sentence.
@@ -29,20 +35,30 @@ var verbs = map[journal.Type]string{
 	journal.AVBCollect: "collected",
 	journal.AVBUse:     "used AVB",
 	journal.Adjust:     "adjusted",
+	journal.Feel:       "felt",
+	journal.Break:      "break",
+	journal.Move:       "moved",
+	journal.Batch:      "made",
+	journal.Portion:    "portion",
 }
 
 // eventColor gives an event the colour of the account it moves grams into, so
 // the palette means the same thing in the log as it does in the charts.
 func (t *Theme) eventColor(typ journal.Type) tint {
 	switch typ {
-	case journal.Purchase:
+	case journal.Purchase, journal.Batch:
 		return t.StorageC
 	case journal.Grind:
 		return t.StashC
-	case journal.Sesh:
+	case journal.Sesh, journal.Portion:
 		return t.SeshC
 	case journal.AVBCollect, journal.AVBUse:
 		return t.AVBC
+	case journal.Feel:
+		// A feel moves grams into nothing, so it takes the colour no account has.
+		return t.Alt
+	case journal.Break:
+		return t.Good
 	default:
 		return t.Muted
 	}
@@ -105,6 +121,17 @@ func (t *Theme) entryLine(e journal.Event, name string, width int, selected, cor
 		value = t.Dim.Strikethrough(true)
 	}
 
+	// A feel has no amount; its scores stand where the product would, and
+	// the product of the session it follows moves into the detail.
+	amount := value.Render(fmt.Sprintf("%6.2f", e.Grams)) + t.Unit.Render("g")
+	switch e.Type {
+	case journal.Feel:
+		amount, name = strings.Repeat(" ", 7), e.Scores.String()
+	case journal.Break:
+		// A break's amount is its days, in the column grams stand in.
+		amount = value.Render(fmt.Sprintf("%6d", e.Days)) + t.Unit.Render("d")
+		name = "until " + e.OccurredAt.AddDate(0, 0, e.Days).Format("Mon 02 Jan")
+	}
 	parts := []string{
 		marker,
 		t.Dim.Render(clock),
@@ -112,7 +139,7 @@ func (t *Theme) entryLine(e journal.Event, name string, width int, selected, cor
 		lipgloss.NewStyle().Foreground(colour).Render(glyph),
 		" ",
 		t.Label.Width(10).Render(verb),
-		value.Render(fmt.Sprintf("%6.2f", e.Grams)) + t.Unit.Render("g"),
+		amount,
 		"  ",
 		value.Render(name),
 	}
@@ -131,15 +158,31 @@ func (t *Theme) entryLine(e journal.Event, name string, width int, selected, cor
 }
 
 // eventDetail is the trailing, dimmed part of a log line: the device and
-// temperature of a session, a note, or nothing at all.
+// temperature of a session, what AVB was used for and the batch it went into,
+// what a fill cost, a note, or nothing at all.
 func (t *Theme) eventDetail(e journal.Event) string {
 	var bits []string
+	if e.Type == journal.Feel && e.Product != "" {
+		bits = append(bits, "after "+e.Product)
+	}
 	if e.Device != "" {
 		bits = append(bits, e.Device)
 	}
 	if e.Temperature > 0 {
 		bits = append(bits, fmt.Sprintf("%d°C", e.Temperature))
 	}
+	if where := placement(e); where != "" {
+		bits = append(bits, where)
+	}
+	if e.Purpose != "" {
+		bits = append(bits, "for "+e.Purpose)
+	}
+	if e.Into != "" {
+		bits = append(bits, "into "+e.Into)
+	}
+	if e.Price != 0 {
+		bits = append(bits, ledger.Money(e.Price, e.Currency))
+	}
 	if e.Note != "" {
 		bits = append(bits, e.Note)
 	}
@@ -149,6 +192,21 @@ func (t *Theme) eventDetail(e journal.Event) string {
 	return t.Dim.Render("· " + strings.Join(bits, " · "))
 }
 
+// placement says where an entry's grams were and went, for an entry that
+// names a location: home → travel for a move, at travel for a grind there.
+func placement(e journal.Event) string {
+	switch {
+	case e.FromLocation == "" && e.ToLocation == "":
+		return ""
+	case e.From == e.To || e.FromLocation != "" && e.ToLocation != "" && e.FromLocation != e.ToLocation:
+		return journal.LocationName(e.FromLocation) + " → " + journal.LocationName(e.ToLocation)
+	case e.FromLocation != "":
+		return "at " + e.FromLocation
+	default:
+		return "at " + e.ToLocation
+	}
+}
+
 // selectionBg is the background for the highlighted row.
 func (t *Theme) selectionBg() tint {
 	if t.Dark {
@@ -227,6 +285,11 @@ var figurines = map[journal.Type][]string{
 	journal.AVBCollect: {"◇ ◇ ◇", " ◇ ◇ ", "  ◇  ", " ═╩═ "},
 	journal.AVBUse:     {"  ▽  ", " ▽ ▽ ", "▽ ▽ ▽", " ═╩═ "},
 	journal.Adjust:     {"◢─┴─◣", "▽   ▽", "  │  ", " ═╩═ "},
+	journal.Feel:       {"♥♥ ♥♥", "♥♥♥♥♥", " ♥♥♥ ", " ═╩═ "},
+	journal.Break:      {" ┃ ┃ ", " ┃ ┃ ", " ┃ ┃ ", " ═╩═ "},
+	journal.Move:       {"  ⇢  ", "▲ ⇢ ▲", "  ⇢  ", " ═╩═ "},
+	journal.Batch:      {"▽ ▽ ▽", " ▣▣▣ ", " ▣▣▣ ", " ═╩═ "},
+	journal.Portion:    {"  ▪  ", " ▪ ▪ ", "▪ ▪ ▪", " ═╩═ "},
 }
 
 // Card geometry. Every card in the séance is cut to the same size, front and
@@ -305,8 +368,27 @@ func (t *Theme) CardFront(e journal.Event, name string) string {
 	for _, line := range figurines[e.Type] {
 		rows = append(rows, center(ink.Render(line), w))
 	}
-	rows = append(rows, "", center(t.Big.Render(fmt.Sprintf("%.2f", e.Grams))+t.Unit.Render(" g"), w))
-	rows = append(rows, wrapName(t, name, w)...)
+	if e.Type == journal.Feel {
+		// A feel's face is its scores, two to a row so that all five fit
+		// above the product of the session it followed.
+		names := e.Scores.Names()
+		for i := 0; i < len(names); i += 2 {
+			row := make([]string, 0, 2)
+			for _, sym := range names[i:min(i+2, len(names))] {
+				row = append(row, t.Label.Render(sym)+" "+t.Big.Render(fmt.Sprint(e.Scores[sym])))
+			}
+			rows = append(rows, center(strings.Join(row, "   "), w))
+		}
+		if name != "" {
+			rows = append(rows, center(t.Dim.Render("after "+name), w))
+		}
+	} else if e.Type == journal.Break {
+		rows = append(rows, "", center(t.Big.Render(fmt.Sprint(e.Days))+t.Unit.Render(" days"), w),
+			center(t.Dim.Render("until "+e.OccurredAt.AddDate(0, 0, e.Days).Format("Mon 02 Jan")), w))
+	} else {
+		rows = append(rows, "", center(t.Big.Render(fmt.Sprintf("%.2f", e.Grams))+t.Unit.Render(" g"), w))
+		rows = append(rows, wrapName(t, name, w)...)
+	}
 	// The date pins to the bottom edge, wherever the name folding left off.
 	rows = append(padRows(rows, cardWellH-1),
 		spread(t.Dim.Render(e.OccurredAt.Format("Mon 02 Jan")),
@@ -334,6 +416,9 @@ func (t *Theme) CardBack(e journal.Event, name string) string {
 		field("recorded", stamp(e.RecordedAt)),
 		field("moved", fmt.Sprintf("%s→%s", e.From, e.To)),
 	}
+	if where := placement(e); where != "" {
+		rows = append(rows, field("where", where))
+	}
 	// The product keeps its whole name, folded over the card's full width
 	// rather than squeezed into the field column.
 	rows = append(rows, wrapName(t, name, w)...)
@@ -343,6 +428,12 @@ func (t *Theme) CardBack(e journal.Event, name string) string {
 	if e.Temperature > 0 {
 		rows = append(rows, field("temp", fmt.Sprintf("%d°C", e.Temperature)))
 	}
+	if e.Session != "" {
+		rows = append(rows, field("after", e.Session[:min(12, len(e.Session))]+"…"))
+	}
+	if e.Days > 0 {
+		rows = append(rows, field("until", stamp(e.OccurredAt.AddDate(0, 0, e.Days))))
+	}
 	if e.Note != "" {
 		rows = append(rows, field("note", e.Note))
 	}
@@ -360,7 +451,7 @@ func (t *Theme) CardGhost(e journal.Event) string {
 		center(ink.Render(glyphs[e.Type]), w),
 		center(t.Dim.Render(verbs[e.Type]), w),
 		"",
-		center(t.Dim.Render(fmt.Sprintf("%.1fg", e.Grams)), w),
+		center(t.Dim.Render(ghostAmount(e)), w),
 		center(t.Dim.Render(e.OccurredAt.Format("02 Jan")), w),
 	}
 	return lipgloss.NewStyle().
@@ -370,6 +461,30 @@ func (t *Theme) CardGhost(e journal.Event) string {
 		Render(strings.Join(rows, "\n"))
 }
 
+// ghostAmount is what a ghost card says of an entry's amount: its grams, how
+// many symptoms a feel scored, or the days of a break.
+func ghostAmount(e journal.Event) string {
+	switch e.Type {
+	case journal.Feel:
+		return fmt.Sprintf("%d×♥", len(e.Scores))
+	case journal.Break:
+		return fmt.Sprintf("%dd", e.Days)
+	}
+	return fmt.Sprintf("%.1fg", e.Grams)
+}
+
+// amountOf is an entry's amount in a sentence: its grams and product, a
+// feel's scores, or the days of a break.
+func amountOf(e journal.Event) string {
+	switch e.Type {
+	case journal.Feel:
+		return e.Scores.String()
+	case journal.Break:
+		return plural(e.Days, "day")
+	}
+	return fmt.Sprintf("%.2fg %s", e.Grams, e.Product)
+}
+
 // CardSleeping is the face-down card shown before the séance has summoned
 // anything: a patterned back and an invitation.
 func (t *Theme) CardSleeping() string {
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -94,6 +95,35 @@ type productRow struct {
 	Bought   float64
 	Fills    int
 	LastSeen time.Time
+	Lots     []ledger.Lot // the fills still standing in the jar, oldest first
+	Places   []place      // where the jar is, once it is more than home
+}
+
+// place is what one location holds of a product.
+type place struct {
+	Location string
+	Storage  float64
+	Stash    float64
+}
+
+// placesOf splits a balance into its locations, home first, or returns
+// nothing for a product that has never left home.
+func placesOf(b *ledger.Balance) []place {
+	var named []string
+	for _, l := range append(b.Locations(journal.Storage), b.Locations(journal.Stash)...) {
+		if !slices.Contains(named, l) {
+			named = append(named, l)
+		}
+	}
+	if len(named) == 0 {
+		return nil
+	}
+	slices.Sort(named)
+	out := make([]place, 0, len(named)+1)
+	for _, l := range append([]string{""}, named...) {
+		out = append(out, place{Location: l, Storage: b.In(journal.Storage, l), Stash: b.In(journal.Stash, l)})
+	}
+	return out
 }
 
 // Held is what is still on the shelf and in the stash.
@@ -180,10 +210,13 @@ func (v storageView) rows(a *App) []productRow {
 // history writes itself the way it happened.
 func (v storageView) tables(a *App) (shelf, history []productRow) {
 	events := v.played(a.data.State.Events)
-	balances := a.data.State.Balances
+	// Lots are attributed in the order entries happened, as status
+	// attributes them; the balances come out the same either way.
+	state := a.data.Chronological()
 	if v.playhead >= 0 {
-		balances = ledger.Fold(events).Balances
+		state = ledger.FoldFor(ledger.Occurred, a.data.State.Patient, events)
 	}
+	balances := state.Balances
 
 	byProduct := map[string]*productRow{}
 	get := func(slug string) *productRow {
@@ -216,6 +249,8 @@ func (v storageView) tables(a *App) (shelf, history []productRow) {
 	for slug, b := range balances {
 		r := get(slug)
 		r.Storage, r.Stash, r.AVB = b.Storage, b.Stash, b.AVB
+		r.Lots = state.Lots(slug)
+		r.Places = placesOf(b)
 	}
 
 	all := make([]productRow, 0, len(byProduct))
@@ -440,12 +475,17 @@ func (v storageView) historyLine(a *App, r productRow, nameW int, selected bool)
 	)
 }
 
-// potency renders the THC/CBD ratio, or a dash where none is known.
+// potency renders the THC/CBD ratio, or a dash where none is known. A batch
+// made from AVB is a fraction of a percent, which reads better as the
+// milligrams of THC in a gram.
 func potency(r productRow) string {
-	if r.Product != nil && r.Product.THC > 0 {
-		return fmt.Sprintf("%g/%g", r.Product.THC, r.Product.CBD)
+	switch {
+	case r.Product == nil || r.Product.THC <= 0:
+		return "—"
+	case r.Product.Made != "":
+		return fmt.Sprintf("%.1fmg", r.Product.THC*10)
 	}
-	return "—"
+	return fmt.Sprintf("%g/%g", r.Product.THC, r.Product.CBD)
 }
 
 // grams renders an amount in an account's colour, dimmed when it is empty so
@@ -470,6 +510,9 @@ func (v storageView) detail(a *App, r productRow, width int) string {
 		if r.Product.Cultivar != "" {
 			facts = append(facts, r.Product.Cultivar)
 		}
+		if r.Product.Made != "" {
+			facts = append(facts, "a batch of "+r.Product.Made+" made from AVB")
+		}
 	}
 	if !r.LastSeen.IsZero() {
 		facts = append(facts, "last used "+humanDay(r.LastSeen, a.data.Now))
@@ -491,6 +534,18 @@ func (v storageView) detail(a *App, r productRow, width int) string {
 				r.Held(), r.Bought, plural(r.Fills, "fill"))),
 		))
 	}
+	// A jar refilled before it was empty says whose grams are left; a jar
+	// of one fill is its own lot and needs no breakdown.
+	if len(r.Lots) > 1 {
+		for i, l := range r.Lots {
+			rows = append(rows, "  "+t.Dim.Render(lotLine(l, i == 0)))
+		}
+	}
+	// Where the grams are, once some have been carried off with `wits move`.
+	for _, p := range r.Places {
+		rows = append(rows, "  "+t.Dim.Render(fmt.Sprintf("%s %s · %.2f g in storage · %.2f g ground",
+			glyphs[journal.Move], journal.LocationName(p.Location), p.Storage, p.Stash)))
+	}
 	rows = append(rows,
 		"  "+t.Dim.Render("press ")+t.Key.Render("space")+t.Dim.Render(" to mark, ")+
 			t.Key.Render("r")+t.Dim.Render(" to weigh, ")+
@@ -499,6 +554,19 @@ func (v storageView) detail(a *App, r productRow, width int) string {
 	return lipgloss.JoinVertical(lipgloss.Left, rows...)
 }
 
+// lotLine describes one lot of a shared jar.
+func lotLine(l ledger.Lot, next bool) string {
+	line := fmt.Sprintf("cycle %d · bought %s · %.2f g of %.2f g left",
+		l.Cycle+1, l.Bought.Format("02 Jan 2006"), l.Remaining, l.Purchased)
+	if l.Found() {
+		line = fmt.Sprintf("cycle %d · found %s · %.2f g", l.Cycle+1, l.Bought.Format("02 Jan 2006"), l.Remaining)
+	}
+	if next {
+		line += " · ground next"
+	}
+	return line
+}
+
 // humanDay says today or yesterday where it can, and a date otherwise.
 func humanDay(d, now time.Time) string {
 	switch daysBetween(d, now) {
@@ -541,6 +609,11 @@ func stashHistoryOf(events []journal.Event) map[string]*stashStats {
 		return s
 	}
 	for _, e := range events {
+		// A move between the stash's locations, or the correction of one,
+		// leaves the stash as full as it was.
+		if e.From == e.To {
+			continue
+		}
 		touched := false
 		if e.To == journal.Stash {
 			s := get(e.Product)
@@ -615,7 +688,7 @@ func (v stashView) balances(a *App) map[string]*ledger.Balance {
 	if v.playhead < 0 {
 		return a.data.State.Balances
 	}
-	return ledger.Fold(v.played(a.data.State.Events)).Balances
+	return ledger.Fold(a.data.State.Patient, v.played(a.data.State.Events)).Balances
 }
 
 // active returns the stashes holding something, fullest first: the tin most
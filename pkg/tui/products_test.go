@@ -55,6 +55,41 @@ func TestStorageScreenShowsBothTables(t *testing.T) {
 	assert.Contains(t, out, "on the shelf · 1   history · 1", "Should count both tables")
 }
 
+func TestStorageSplitsASharedJarIntoLots(t *testing.T) {
+	app := liveApp(t)
+	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
+	// An older fill of the same jar, recorded late, with 4 g of it ground.
+	_, _, _, err := rec.Buy("wcake", "", 10, time.Now().AddDate(0, 0, -40))
+	require.NoError(t, err)
+	_, err = rec.Grind("wcake", 4, time.Now().AddDate(0, 0, -35))
+	require.NoError(t, err)
+	app.data, err = Load(app.data.Repo)
+	require.NoError(t, err)
+	app.screen = storageScreen
+	var m tea.Model = app
+
+	out := stripANSI(m.View().Content)
+	assert.Contains(t, out, "cycle 1 · bought "+time.Now().AddDate(0, 0, -40).Format("02 Jan 2006")+
+		" · 6.00 g of 10.00 g left · ground next", "Should put the older fill first, drawn down")
+	assert.Contains(t, out, "cycle 2 · bought "+time.Now().Format("02 Jan 2006")+" · 20.00 g of 20.00 g left",
+		"Should leave the newer fill whole")
+}
+
+func TestStorageSaysWhereTheGramsAre(t *testing.T) {
+	app := liveApp(t)
+	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
+	_, err := rec.Move("wcake", journal.Storage, 2, "", "travel", time.Now(), "")
+	require.NoError(t, err)
+	app.data, err = Load(app.data.Repo)
+	require.NoError(t, err)
+	app.screen = storageScreen
+	var m tea.Model = app
+
+	out := stripANSI(m.View().Content)
+	assert.Contains(t, out, "⇢ home · 18.00 g in storage", "Should put home first, less what was moved")
+	assert.Contains(t, out, "⇢ travel · 2.00 g in storage · 0.00 g ground", "and then each location")
+}
+
 func TestStorageDoesNotAbbreviateNames(t *testing.T) {
 	app := liveApp(t)
 	rec := record.New(app.data.Repo, app.data.Products, app.data.Devices, app.data.State)
@@ -546,6 +581,8 @@ func TestSessionsScreenShowsTheStory(t *testing.T) {
 	assert.Contains(t, out, "SESSIONS", "Should count the sessions")
 	assert.Contains(t, out, "3", "all three of them")
 	assert.Contains(t, out, "SESHED", "Should total the grams drawn")
+	assert.Contains(t, out, "median of 0.50–2.00 g", "Should spread the grams per session")
+	assert.Contains(t, out, "188°C", "Should take the median temperature of the sessions that had one")
 	assert.Contains(t, out, "By device", "Should break usage down by device")
 	assert.Contains(t, out, "Volcano Hybrid", "naming the device properly")
 	assert.Contains(t, out, "avg 187°C",
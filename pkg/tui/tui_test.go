@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -55,7 +56,7 @@ func sample(t *testing.T) Data {
 		Workspace: &workspace.Workspace{
 			Products: products,
 			Devices:  &catalog.Devices{},
-			State:    ledger.Fold(events),
+			State:    ledger.Fold("", events),
 		},
 		Now: at.AddDate(0, 0, 4),
 	}
@@ -102,6 +103,32 @@ func TestAnalysisScreen(t *testing.T) {
 	assert.Contains(t, out, "By product", "Should break the total down")
 }
 
+func TestAnalysisDosesInMilligrams(t *testing.T) {
+	t.Run("PutThroughTheDevices", func(t *testing.T) {
+		out := render(t, sample(t), analysisScreen, 96, 60)
+
+		assert.Contains(t, out, "Milligrams", "Should give the doses a section")
+		assert.Contains(t, out, "66 mg", "Should count 0.30g at 22% as 66mg of THC")
+		assert.Contains(t, out, "no efficiency set", "Should not claim an extraction it has no figure for")
+	})
+
+	t.Run("ExtractedAtTheDevicesEfficiency", func(t *testing.T) {
+		data := sample(t)
+		require.NoError(t, data.Devices.Add(&catalog.Device{Name: "Volcano", Efficiency: 50}))
+		events := append([]journal.Event{}, data.State.Events...)
+		for i := range events {
+			if events[i].Type == journal.Sesh {
+				events[i].Device = "volcano"
+			}
+		}
+		data.State = ledger.Fold("", events)
+
+		out := render(t, data, analysisScreen, 96, 60)
+
+		assert.Contains(t, out, "33 mg", "Should assume half extracted at 50%")
+	})
+}
+
 func TestNavigation(t *testing.T) {
 	app := New(sample(t))
 	var m tea.Model = app
@@ -139,7 +166,7 @@ func TestRendersAtAwkwardSizes(t *testing.T) {
 
 func TestEmptyRepository(t *testing.T) {
 	empty := Data{Workspace: &workspace.Workspace{
-		Products: &catalog.Catalog{}, Devices: &catalog.Devices{}, State: ledger.Fold(nil),
+		Products: &catalog.Catalog{}, Devices: &catalog.Devices{}, State: ledger.Fold("", nil),
 	}, Now: time.Now()}
 
 	out := render(t, empty, dashboardScreen, 96, 30)
@@ -181,7 +208,7 @@ func product(name, slug string) *catalog.Product {
 func emptyData(t *testing.T) Data {
 	t.Helper()
 	return Data{Workspace: &workspace.Workspace{
-		Products: &catalog.Catalog{}, Devices: &catalog.Devices{}, State: ledger.Fold(nil),
+		Products: &catalog.Catalog{}, Devices: &catalog.Devices{}, State: ledger.Fold("", nil),
 	}, Now: time.Now()}
 }
 
@@ -238,7 +265,7 @@ func TestDashboardBillsTheFillNotTheShelf(t *testing.T) {
 		Workspace: &workspace.Workspace{
 			Products: products,
 			Devices:  &catalog.Devices{},
-			State:    ledger.Fold(events),
+			State:    ledger.Fold("", events),
 		},
 		Now: at.AddDate(0, 0, 2),
 	}
@@ -252,6 +279,66 @@ func TestDashboardBillsTheFillNotTheShelf(t *testing.T) {
 		"with the previous cycle's remainder on its own line")
 }
 
+func TestDashboardBudgetsToTheNextFill(t *testing.T) {
+	at := time.Date(2026, time.July, 9, 10, 0, 0, 0, time.UTC)
+	mk := func(typ journal.Type, product string, grams float64, day int) journal.Event {
+		from, to, _ := journal.Flow(typ)
+		return journal.Event{
+			Type: typ, Product: product, Grams: grams, From: from, To: to,
+			OccurredAt: at.AddDate(0, 0, day),
+		}
+	}
+	events := []journal.Event{
+		mk(journal.Purchase, "old", 10, -40),
+		mk(journal.Grind, "old", 2, -35),
+		mk(journal.Purchase, "wcake", 20, 0),
+		mk(journal.Grind, "wcake", 1, 1),
+	}
+	products := &catalog.Catalog{}
+	require.NoError(t, products.Add(product("Khiron 20/1 Old Strain", "old")))
+	require.NoError(t, products.Add(product("Enua 22/1 Wedding Cake", "wcake")))
+	data := Data{
+		Workspace: &workspace.Workspace{
+			Products: products,
+			Devices:  &catalog.Devices{},
+			State:    ledger.Fold("", events),
+		},
+		Now: at.AddDate(0, 0, 2),
+	}
+
+	out := render(t, data, dashboardScreen, 110, 80)
+
+	assert.Contains(t, out, "0.68 g a day", "Should spread the shelf, carry-over and all, to the next fill")
+	assert.Contains(t, out, "until 18 Aug, the expected next fill", "Should expect it as far on as the last one")
+	assert.Contains(t, out, "1.05 g ahead", "Should say where today stands")
+
+	out = render(t, sample(t), dashboardScreen, 96, 80)
+	assert.Contains(t, out, "no next fill to budget to", "Should not guess from a single fill")
+}
+
+func TestDashboardCountsTheBreak(t *testing.T) {
+	at := time.Date(2026, time.July, 1, 10, 0, 0, 0, time.UTC)
+	events := []journal.Event{
+		{Type: journal.Purchase, Product: "wcake", Grams: 20, To: journal.Storage, OccurredAt: at},
+		{Type: journal.Grind, Product: "wcake", Grams: 1, From: journal.Storage, To: journal.Stash, OccurredAt: at},
+		{Type: journal.Sesh, Product: "wcake", Grams: 0.2, From: journal.Stash, To: journal.Consumed, OccurredAt: at},
+	}
+	data := sample(t)
+	data.State = ledger.Fold("", events)
+	data.Now = at.AddDate(0, 0, 3)
+
+	out := render(t, data, dashboardScreen, 96, 80)
+	assert.Contains(t, out, "streak: 3 days without a session or grind", "Should count the days since the last")
+
+	events = append(events, journal.Event{Type: journal.Break, Days: 14, From: journal.External,
+		To: journal.External, OccurredAt: at.AddDate(0, 0, 1), Hash: "brk"})
+	data.State = ledger.Fold("", events)
+
+	out = render(t, data, dashboardScreen, 96, 80)
+	assert.Contains(t, out, "break: day 3 of 14, 11 days to go", "Should say where the break stands")
+	assert.Contains(t, out, "from the end of the break on 16 Jul", "Should project from the end of the break")
+}
+
 func TestDashboardWallClockAndCycleStart(t *testing.T) {
 	app := New(sample(t))
 	app.screen = dashboardScreen
@@ -267,6 +354,17 @@ func TestDashboardWallClockAndCycleStart(t *testing.T) {
 		"The storage card should date the cycle it counts")
 }
 
+func TestHeaderNamesThePatient(t *testing.T) {
+	data := sample(t)
+	data.Patient = "anna"
+
+	out := render(t, data, dashboardScreen, 140, 40)
+	assert.Contains(t, out, "anna · cycle 1 · day", "Should say whose the figures are")
+
+	out = render(t, sample(t), dashboardScreen, 140, 40)
+	assert.NotContains(t, out, "anna · ", "Should name nobody in a journal that keeps nobody apart")
+}
+
 // TestAnalysisCycleScopeNamesItsWindow pins the cycle scope's heading to the
 // fill's start date, and the by-product panel to saying which grinds drew on
 // older cycles' jars during the window.
@@ -293,7 +391,7 @@ func TestAnalysisCycleScopeNamesItsWindow(t *testing.T) {
 		Workspace: &workspace.Workspace{
 			Products: products,
 			Devices:  &catalog.Devices{},
-			State:    ledger.Fold(events),
+			State:    ledger.Fold("", events),
 		},
 		Now: at.AddDate(0, 0, 3),
 	}
@@ -335,6 +433,106 @@ func TestAnalysisAxes(t *testing.T) {
 	assert.Contains(t, out, "0.0 ┴", "and where zero is")
 }
 
+func TestAnalysisMarksAnomalies(t *testing.T) {
+	at := time.Date(2026, time.July, 1, 10, 0, 0, 0, time.UTC)
+	events := []journal.Event{{Type: journal.Purchase, Product: "wcake", Grams: 20,
+		To: journal.Storage, OccurredAt: at}}
+	for day := 1; day <= 8; day++ {
+		grams := 0.5
+		if day == 8 {
+			grams = 4
+		}
+		events = append(events, journal.Event{Type: journal.Grind, Product: "wcake", Grams: grams,
+			From: journal.Storage, To: journal.Stash, OccurredAt: at.AddDate(0, 0, day)})
+	}
+	data := sample(t)
+	data.State = ledger.Fold("", events)
+	data.Now = at.AddDate(0, 0, 9)
+
+	// The last 30 days, which carry the heatmap under the chart.
+	app := New(data)
+	app.screen = analysisScreen
+	app.analysis.scope = 1
+	var m tea.Model = app
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 96, Height: 80})
+	out := stripANSI(m.View().Content)
+
+	assert.Contains(t, out, "▲ heavy day", "Should explain the marks")
+	assert.Equal(t, 2, strings.Count(out, "▲ heavy day"), "on the chart and on the heatmap alike")
+	assert.Equal(t, 4, strings.Count(out, "▲"), "Should mark the 4g day once under the chart and once in the calendar")
+
+	plain := render(t, sample(t), analysisScreen, 96, 80)
+	assert.NotContains(t, plain, "heavy day", "Too short a journal has no usual to stand out from")
+}
+
+func TestAnalysisReadsEffects(t *testing.T) {
+	at := time.Date(2026, time.July, 1, 10, 0, 0, 0, time.UTC)
+	events := []journal.Event{
+		{Type: journal.Purchase, Product: "wcake", Grams: 20, To: journal.Storage, OccurredAt: at},
+		{Type: journal.Grind, Product: "wcake", Grams: 2, From: journal.Storage, To: journal.Stash, OccurredAt: at},
+	}
+	for i, temp := range []int{180, 190, 200} {
+		day := at.Add(time.Duration(i+1) * time.Hour)
+		hash := fmt.Sprintf("sesh%d", i)
+		events = append(events,
+			journal.Event{Type: journal.Sesh, Product: "wcake", Grams: 0.2, From: journal.Stash,
+				To: journal.Consumed, Device: "mighty", Temperature: temp, OccurredAt: day, Hash: hash},
+			journal.Event{Type: journal.Feel, Product: "wcake", Session: hash, OccurredAt: day.Add(time.Minute),
+				Scores: journal.Scores{"pain": 7 - 2*i, "sleep": 5}})
+	}
+	data := sample(t)
+	data.State = ledger.Fold("", events)
+	data.Now = at.AddDate(0, 0, 1)
+
+	out := render(t, data, analysisScreen, 96, 80)
+
+	assert.Contains(t, out, "Effects", "Should read the feels against the sessions")
+	assert.Contains(t, out, "Enua 22/1 Wedding Cake", "Should name the product the feels followed")
+	assert.Contains(t, out, "mighty", "Should group by device")
+	assert.Contains(t, out, "180–189°C", "Should group by temperature band")
+	assert.Contains(t, out, "5.0", "Should average the pain")
+	assert.Contains(t, out, "hotter sessions, lower pain (r -1.00)", "Should read the heat against the pain")
+	assert.NotContains(t, out, "hotter sessions, higher sleep", "A score that never moved correlates with nothing")
+
+	plain := render(t, sample(t), analysisScreen, 96, 80)
+	assert.NotContains(t, plain, "Effects", "Nothing felt, nothing to read")
+}
+
+func TestAnalysisComparesBreaks(t *testing.T) {
+	at := time.Date(2026, time.July, 1, 10, 0, 0, 0, time.UTC)
+	grind := func(day int, grams float64) journal.Event {
+		return journal.Event{Type: journal.Grind, Product: "wcake", Grams: grams, From: journal.Storage,
+			To: journal.Stash, OccurredAt: at.AddDate(0, 0, day)}
+	}
+	events := []journal.Event{{Type: journal.Purchase, Product: "wcake", Grams: 30, To: journal.Storage, OccurredAt: at}}
+	for day := 0; day < 7; day++ {
+		events = append(events, grind(day, 1))
+	}
+	events = append(events,
+		journal.Event{Type: journal.Break, Days: 7, From: journal.External, To: journal.External,
+			OccurredAt: at.AddDate(0, 0, 7), Hash: "brk"},
+		journal.Event{Type: journal.Sesh, Product: "wcake", Grams: 0.1, From: journal.Stash,
+			To: journal.Consumed, OccurredAt: at.AddDate(0, 0, 9)})
+	for day := 14; day < 21; day++ {
+		events = append(events, grind(day, 0.5))
+	}
+	data := sample(t)
+	data.State = ledger.Fold("", events)
+	data.Now = at.AddDate(0, 0, 21)
+
+	out := render(t, data, analysisScreen, 96, 120)
+
+	assert.Contains(t, out, "Breaks", "Should report the breaks")
+	assert.Contains(t, out, "08 Jul – 15 Jul", "Should say when the break ran")
+	assert.Contains(t, out, "1 lapse", "Should count the session during it")
+	assert.Contains(t, out, "1.00 g", "Should read the grams a day before it")
+	assert.Contains(t, out, "0.50 g", "and after it")
+	assert.Contains(t, out, "-50%", "and the change")
+
+	plain := render(t, sample(t), analysisScreen, 96, 80)
+	assert.NotContains(t, plain, "Breaks", "No break, nothing to compare")
+}
+
 func TestAnalysisPlayback(t *testing.T) {
 	app := New(sample(t))
 	app.screen = analysisScreen
@@ -392,6 +590,22 @@ func TestAnalysisByProductKeepsFullNames(t *testing.T) {
 	assert.Contains(t, out, "%", "and give its share")
 }
 
+func TestSessionsScreenShowsTheYield(t *testing.T) {
+	data := sample(t)
+	out := render(t, data, sessionsScreen, 96, 80)
+	assert.NotContains(t, out, "AVB yield", "Should say nothing of a yield before anything is collected")
+
+	collected := data.State.Events[4]
+	collected.Type, collected.Grams = journal.AVBCollect, 0.12
+	collected.From, collected.To, _ = journal.Flow(journal.AVBCollect)
+	data.State = ledger.Fold("", append(data.State.Events, collected))
+
+	out = render(t, data, sessionsScreen, 96, 80)
+
+	assert.Contains(t, out, "AVB yield", "Should add the yield once AVB is collected")
+	assert.Contains(t, out, "0.40 g per g", "Should read the grams collected per gram seshed")
+}
+
 func TestSnapshot(t *testing.T) {
 	shot, err := Snapshot(sample(t), "storage", 96, 30, nil)
 	require.NoError(t, err)
@@ -427,7 +641,7 @@ func TestReplaySkipsAdjustments(t *testing.T) {
 	products := &catalog.Catalog{}
 	require.NoError(t, products.Add(product("Enua 22/1 Wedding Cake", "wcake")))
 	data := Data{Workspace: &workspace.Workspace{
-		Products: products, Devices: &catalog.Devices{}, State: ledger.Fold(events),
+		Products: products, Devices: &catalog.Devices{}, State: ledger.Fold("", events),
 	}, Now: at.AddDate(0, 0, 4)}
 
 	app := New(data)
@@ -0,0 +1,160 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/TheDonDope/wits/pkg/journal"
+	"github.com/TheDonDope/wits/pkg/ledger"
+	"github.com/TheDonDope/wits/pkg/repo"
+)
+
+// foldFile is the checkpoint of the fold, inside the repository's index. Each
+// patient's fold is cached beside it under their name.
+const foldFile = "fold.json"
+
+// foldFileOf returns the name of a patient's checkpoint.
+func foldFileOf(patient string) string {
+	if patient == "" {
+		return foldFile
+	}
+	return "fold." + patient + ".json"
+}
+
+// cached is the index as it is stored: a checkpoint of the fold, keyed by how
+// many events it folded and the size the journal had when it was taken. The
+// checkpoint carries its own tip hash, so together they pin it to one journal.
+type cached struct {
+	Events      int             `json:"events"`
+	JournalSize int64           `json:"journal_size"`
+	Fold        json.RawMessage `json:"fold"`
+}
+
+// fold replays the journal, starting from the cached checkpoint when there is
+// one that describes it, and refreshes the cache when it has moved on.
+//
+// The cache is never trusted over the journal. One that cannot be read, was
+// taken of a different journal or of a longer one, or is simply stale is
+// dropped without a word and the whole journal folded instead: the worst a
+// bad cache can cost is the time it was meant to save.
+func fold(r *repo.Repo, events []journal.Event, size int64, patient string) *ledger.State {
+	state, current := resume(r, events, size, patient)
+	if state == nil {
+		state = ledger.FoldFor(ledger.Recorded, patient, events)
+	}
+	if !current {
+		// A cache that cannot be written is only a cache that was not
+		// written; the next open folds again.
+		_ = save(r, state, size)
+	}
+	return state
+}
+
+// resume restores the cached checkpoint against the journal, reporting
+// whether the cache already described it exactly.
+func resume(r *repo.Repo, events []journal.Event, size int64, patient string) (*ledger.State, bool) {
+	data, err := r.ReadFile(filepath.Join(r.IndexPath(), foldFileOf(patient)))
+	if err != nil {
+		return nil, false
+	}
+	var c cached
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, false
+	}
+	// The size says nothing was rewritten around the tip: the same events
+	// must be the same bytes, and more events must be more of them. The tip
+	// itself is the checkpoint's to check.
+	switch {
+	case c.Events == len(events) && c.JournalSize != size,
+		c.Events < len(events) && c.JournalSize >= size,
+		c.Events > len(events):
+		return nil, false
+	}
+	state, err := ledger.Resume(c.Fold, events)
+	if err != nil || state.Patient != patient {
+		return nil, false
+	}
+	return state, c.Events == len(events)
+}
+
+// save writes a checkpoint of the state into the index, replacing the old one
+// in a single rename so a reader never sees half a cache.
+func save(r *repo.Repo, state *ledger.State, size int64) error {
+	checkpoint, err := state.Checkpoint()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached{Events: len(state.Journal()), JournalSize: size, Fold: checkpoint})
+	if err != nil {
+		return err
+	}
+	// The balances are the record in all but name; an encrypted repository
+	// seals them as it seals the journal.
+	if data, err = r.Seal(data); err != nil {
+		return err
+	}
+	dir := r.IndexPath()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, foldFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, foldFileOf(state.Patient)))
+}
+
+// Reindex throws the cached folds away and builds them again from the
+// journal, every patient's, returning the patient's asked for. Nothing should
+// ever need it — a cache that does not match is discarded on its own — but a
+// cache that is never rebuilt on request is one nobody can rule out.
+func Reindex(r *repo.Repo, patient string) (*ledger.State, error) {
+	size, err := journalSize(r)
+	if err != nil {
+		return nil, err
+	}
+	events, err := r.Journal().Events()
+	if err != nil {
+		return nil, err
+	}
+	var asked *ledger.State
+	for _, p := range append([]string{""}, ledger.Patients(events)...) {
+		if err := os.Remove(filepath.Join(r.IndexPath(), foldFileOf(p))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		state := ledger.FoldFor(ledger.Recorded, p, events)
+		if err := save(r, state, size); err != nil {
+			return nil, err
+		}
+		if p == patient {
+			asked = state
+		}
+	}
+	if asked == nil {
+		asked = ledger.FoldFor(ledger.Recorded, patient, events)
+	}
+	return asked, nil
+}
+
+// journalSize returns how many bytes the journal holds, zero while it does not
+// exist yet.
+func journalSize(r *repo.Repo) (int64, error) {
+	st, err := os.Stat(r.JournalPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return st.Size(), nil
+}
@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TheDonDope/wits/pkg/agent"
+	"github.com/TheDonDope/wits/pkg/repo"
+)
+
+// PassphraseEnv names the environment variable the passphrase of an encrypted
+// repository is read from. It is what a script or a test hands it over with;
+// a session has the agent for that.
+const PassphraseEnv = "WITS_PASSPHRASE"
+
+// Passphrase supplies the passphrase of an encrypted repository that is opened
+// locked. It reads PassphraseEnv; a program with a terminal to ask on replaces
+// it with one that asks when the variable is not set.
+var Passphrase = FromEnvironment
+
+// FromEnvironment reads the passphrase from PassphraseEnv, or asks the agent
+// named in agent.SocketEnv for it.
+func FromEnvironment(r *repo.Repo) ([]byte, error) {
+	if p, ok := os.LookupEnv(PassphraseEnv); ok {
+		return []byte(p), nil
+	}
+	if p, err := agent.Ask(r.Root()); err == nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %s is not set", repo.ErrLocked, PassphraseEnv)
+}
+
+// Unlock unlocks an encrypted repository with the passphrase Passphrase
+// supplies. A repository that is not encrypted, or is already unlocked, is
+// left as it is.
+//
+// A passphrase that unlocks it is handed to the agent, when there is one, so
+// the next command need not ask; one that does not is never kept.
+func Unlock(r *repo.Repo) error {
+	if !r.Locked() {
+		return nil
+	}
+	passphrase, err := Passphrase(r)
+	if err != nil {
+		return err
+	}
+	if err := r.Unlock(passphrase); err != nil {
+		return err
+	}
+	// An agent that has gone away only means asking again next time.
+	_ = agent.Hand(r.Root(), passphrase)
+	return nil
+}
@@ -8,7 +8,10 @@
 //
 // A workspace is a snapshot. It reads the journal once and folds it once, so
 // every screen and every handler working from one sees the same figures.
-// Reload takes a fresh one after something has been written.
+// Reload takes a fresh one after something has been written. The fold resumes
+// from a checkpoint in .wits/index/ where one matches the journal, so opening
+// a long history only replays what was appended since; the checkpoint is a
+// cache, and one that does not match is thrown away.
 package workspace
 
 import (
@@ -20,6 +23,7 @@ import (
 	"github.com/TheDonDope/wits/pkg/ledger"
 	"github.com/TheDonDope/wits/pkg/record"
 	"github.com/TheDonDope/wits/pkg/repo"
+	"github.com/TheDonDope/wits/pkg/rx"
 )
 
 // Workspace is a repository and everything derived from it.
@@ -30,37 +34,80 @@ type Workspace struct {
 	State    *ledger.State
 	Recorder *record.Recorder
 
+	// Prescriptions are the prescriptions as written. What filled them is
+	// matched against the journal when it is asked for; see rx.Fill.
+	Prescriptions *rx.Prescriptions
+
+	// Patient is whose snapshot it is. A household keeps its patients in one
+	// repository, sharing the catalogs and the journal, and every balance,
+	// cycle and entry here is this patient's alone; empty is the patient of a
+	// journal that keeps nobody apart.
+	Patient string
+
 	// OpenedAt is when the snapshot was taken. Anything reporting "how long has
 	// this cycle been running" should measure against it rather than call
 	// time.Now itself, so a single view cannot disagree with itself.
 	OpenedAt time.Time
+
+	// chronological is the journal folded in the order it happened, made
+	// the first time it is asked for.
+	chronological *ledger.State
 }
 
 // Open finds the repository containing dir and reads it.
-func Open(dir string) (*Workspace, error) {
+func Open(dir string) (*Workspace, error) { return OpenFor(dir, "") }
+
+// OpenFor is Open for one patient of the household.
+func OpenFor(dir, patient string) (*Workspace, error) {
 	r, err := repo.Discover(dir)
 	if err != nil {
 		return nil, err
 	}
-	return Read(r)
+	return ReadFor(r, patient)
 }
 
 // Here opens the repository containing the working directory.
-func Here() (*Workspace, error) {
+func Here() (*Workspace, error) { return HereFor("") }
+
+// HereFor is Here for one patient of the household.
+func HereFor(patient string) (*Workspace, error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
-	return Open(wd)
+	return OpenFor(wd, patient)
 }
 
-// Read loads an already-discovered repository.
-func Read(r *repo.Repo) (*Workspace, error) {
-	products, err := catalog.Load(r.ProductsPath())
+// Read loads an already-discovered repository, unlocking it first if it is
+// encrypted.
+func Read(r *repo.Repo) (*Workspace, error) { return ReadFor(r, "") }
+
+// ReadFor is Read for one patient of the household: the catalogs are
+// everyone's, the state is theirs.
+func ReadFor(r *repo.Repo, patient string) (*Workspace, error) {
+	if patient != "" {
+		if err := journal.CheckPatient(patient); err != nil {
+			return nil, err
+		}
+	}
+	if err := Unlock(r); err != nil {
+		return nil, err
+	}
+	products, err := r.LoadProducts()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := r.LoadDevices()
 	if err != nil {
 		return nil, err
 	}
-	devices, err := catalog.LoadDevices(r.DevicesPath())
+	prescriptions, err := r.LoadPrescriptions()
+	if err != nil {
+		return nil, err
+	}
+	// The size is taken before the events are read, so an append landing in
+	// between can only make the cache look stale, never fresher than it is.
+	size, err := journalSize(r)
 	if err != nil {
 		return nil, err
 	}
@@ -68,19 +115,34 @@ func Read(r *repo.Repo) (*Workspace, error) {
 	if err != nil {
 		return nil, err
 	}
-	state := ledger.Fold(events)
+	state := fold(r, events, size, patient)
 	return &Workspace{
-		Repo:     r,
-		Products: products,
-		Devices:  devices,
-		State:    state,
-		Recorder: record.New(r, products, devices, state),
-		OpenedAt: time.Now(),
+		Repo:          r,
+		Products:      products,
+		Devices:       devices,
+		State:         state,
+		Recorder:      record.New(r, products, devices, state),
+		Prescriptions: prescriptions,
+		Patient:       patient,
+		OpenedAt:      time.Now(),
 	}, nil
 }
 
-// Reload returns a fresh snapshot of the same repository.
-func (w *Workspace) Reload() (*Workspace, error) { return Read(w.Repo) }
+// Reload returns a fresh snapshot of the same repository, for the same
+// patient.
+func (w *Workspace) Reload() (*Workspace, error) { return ReadFor(w.Repo, w.Patient) }
+
+// Patients returns the patients the journal names, this one among them.
+func (w *Workspace) Patients() []string { return ledger.Patients(w.State.Journal()) }
+
+// Prescribed returns the patient's prescriptions: those written for them, or
+// those written for nobody in particular in a journal that keeps nobody apart.
+func (w *Workspace) Prescribed() *rx.Prescriptions {
+	if w.Prescriptions == nil {
+		return nil
+	}
+	return w.Prescriptions.Of(w.Patient)
+}
 
 // Journal returns the repository's journal.
 func (w *Workspace) Journal() *journal.Journal { return w.Repo.Journal() }
@@ -91,6 +153,28 @@ func (w *Workspace) Events() []journal.Event { return w.State.Events }
 // Cycle returns the prescription cycle in progress, or nil.
 func (w *Workspace) Cycle() *ledger.Cycle { return w.State.CurrentCycle() }
 
+// Chronological returns the journal folded in the order its entries
+// occurred rather than the order they were recorded in, for whatever reports
+// on cycles and lots: a backdated entry belongs to the cycle it happened in.
+// State stays in journal order, which is what the recorder checks against and
+// what the index caches.
+func (w *Workspace) Chronological() *ledger.State {
+	if w.chronological == nil {
+		w.chronological = ledger.FoldFor(ledger.Occurred, w.State.Patient, w.State.Events)
+	}
+	return w.chronological
+}
+
+// Detector returns the anomaly detector for a measure, tuned by the
+// repository's configuration where it has any.
+func (w *Workspace) Detector(m ledger.Measure) ledger.Detector {
+	d := ledger.Detector{Measure: m}
+	if w.Repo != nil {
+		d.Factor, d.Window = w.Repo.Config.Anomalies.Factor, w.Repo.Config.Anomalies.Window
+	}
+	return d
+}
+
 // ProductName resolves a slug to its display name, falling back to the slug so
 // that an entry for a product missing from the catalog still reads sensibly.
 func (w *Workspace) ProductName(slug string) string {
@@ -101,3 +185,22 @@ func (w *Workspace) ProductName(slug string) string {
 	}
 	return slug
 }
+
+// Potency collects the strength of every product and the efficiency of every
+// device from the catalogs, for the ledger to work doses out in milligrams.
+func (w *Workspace) Potency() ledger.Potency {
+	p := ledger.Potency{THC: map[string]float64{}, CBD: map[string]float64{}, Efficiency: map[string]int{}}
+	if w.Products != nil {
+		for _, product := range w.Products.Products {
+			p.THC[product.Slug], p.CBD[product.Slug] = product.THC, product.CBD
+		}
+	}
+	if w.Devices != nil {
+		for _, d := range w.Devices.Devices {
+			if d.Efficiency > 0 {
+				p.Efficiency[d.Slug] = d.Efficiency
+			}
+		}
+	}
+	return p
+}
@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"context"
 	"io"
 	"log"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/TheDonDope/wits/pkg/agent"
 	"github.com/TheDonDope/wits/pkg/journal"
 	"github.com/TheDonDope/wits/pkg/repo"
 )
@@ -169,3 +171,179 @@ func TestReadRefusesABrokenJournal(t *testing.T) {
 
 	assert.Error(t, err, "Should refuse to open rather than fold a journal it could not read")
 }
+
+func TestFoldCache(t *testing.T) {
+	cache := func(r *repo.Repo) string { return filepath.Join(r.IndexPath(), foldFile) }
+
+	t.Run("IsWrittenOnOpen", func(t *testing.T) {
+		r := filled(t)
+
+		_, err := Read(r)
+		require.NoError(t, err)
+
+		assert.FileExists(t, cache(r), "Should checkpoint the fold into the index")
+	})
+
+	t.Run("ResumesAcrossAppends", func(t *testing.T) {
+		r := filled(t)
+		ws, err := Read(r)
+		require.NoError(t, err)
+		_, err = ws.Recorder.Grind("wedding", 1.25, time.Now())
+		require.NoError(t, err)
+
+		fresh, err := Read(r)
+		require.NoError(t, err)
+
+		assert.Equal(t, 18.0, fresh.State.Balances["wcake-221"].Storage,
+			"Should fold what was appended since the checkpoint")
+		assert.Len(t, fresh.Cycle().Events, 3, "and carry the cycle on where it stopped")
+	})
+
+	t.Run("IgnoresAnUnreadableCache", func(t *testing.T) {
+		r := filled(t)
+		require.NoError(t, os.WriteFile(cache(r), []byte("{oh dear"), 0600))
+
+		ws, err := Read(r)
+		require.NoError(t, err)
+
+		assert.Equal(t, 19.25, ws.State.Balances["wcake-221"].Storage, "Should fold from the journal instead")
+	})
+
+	t.Run("DiscardsACacheOfAnotherJournal", func(t *testing.T) {
+		elsewhere := filled(t)
+		_, err := Read(elsewhere)
+		require.NoError(t, err)
+		taken, err := os.ReadFile(cache(elsewhere))
+		require.NoError(t, err)
+
+		r, err := repo.Init(t.TempDir())
+		require.NoError(t, err)
+		ws, err := Read(r)
+		require.NoError(t, err)
+		_, _, _, err = ws.Recorder.Buy("Enua 22/1 Wedding Cake", "", 5, time.Now())
+		require.NoError(t, err)
+		_, err = ws.Recorder.Grind("wedding", 1, time.Now())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(cache(r), taken, 0600))
+
+		ws, err = Read(r)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4.0, ws.State.Balances["wcake-221"].Storage,
+			"Should never take a cache over the journal it does not describe")
+	})
+
+	t.Run("DiscardsACacheWhenTheJournalWasRewritten", func(t *testing.T) {
+		r := filled(t)
+		_, err := Read(r)
+		require.NoError(t, err)
+		// The same events, padded: the tip still matches, the bytes do not.
+		raw, err := os.ReadFile(r.JournalPath())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(r.JournalPath(), append([]byte("\n"), raw...), 0600))
+		before, err := os.ReadFile(cache(r))
+		require.NoError(t, err)
+
+		_, err = Read(r)
+		require.NoError(t, err)
+
+		after, err := os.ReadFile(cache(r))
+		require.NoError(t, err)
+		assert.NotEqual(t, string(before), string(after), "Should have folded again and replaced the cache")
+	})
+
+	t.Run("Reindex", func(t *testing.T) {
+		r := filled(t)
+		require.NoError(t, os.WriteFile(cache(r), []byte("{oh dear"), 0600))
+
+		state, err := Reindex(r, "")
+		require.NoError(t, err)
+
+		assert.Len(t, state.Events, 2, "Should fold the whole journal")
+		raw, err := os.ReadFile(cache(r))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), `"events":2`, "and write a cache that describes it")
+	})
+
+	t.Run("KeepsOnePerPatient", func(t *testing.T) {
+		r := filled(t)
+		anna, err := ReadFor(r, "anna")
+		require.NoError(t, err)
+		_, _, _, err = anna.Recorder.Buy("Enua 22/1 Wedding Cake", "", 5, time.Now())
+		require.NoError(t, err)
+
+		anna, err = ReadFor(r, "anna")
+		require.NoError(t, err)
+		ws, err := Read(r)
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(r.IndexPath(), "fold.anna.json"), "Should cache the patient's fold apart")
+		assert.Equal(t, 5.0, anna.State.Balances["wcake-221"].Storage, "Should resume the patient's own")
+		assert.Equal(t, 19.25, ws.State.Balances["wcake-221"].Storage, "and leave the other's as it was")
+	})
+}
+
+func TestPatients(t *testing.T) {
+	r := filled(t)
+	ben, err := ReadFor(r, "ben")
+	require.NoError(t, err)
+	_, _, _, err = ben.Recorder.Buy("Lemon Skunk", "", 10, time.Now())
+	require.NoError(t, err)
+
+	ben, err = ReadFor(r, "ben")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ben", ben.Patient)
+	assert.Len(t, ben.Events(), 1, "Should hold the patient's entries alone")
+	assert.Len(t, ben.Products.Products, 2, "Should share the catalog")
+	assert.Equal(t, []string{"ben"}, ben.Patients(), "Should name the patients the journal keeps")
+	assert.Zero(t, ben.Cycle().Carried, "Should not carry the other's storage into the fill")
+
+	reloaded, err := ben.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, "ben", reloaded.Patient, "Should reload for the same patient")
+
+	_, err = ReadFor(r, "Ben")
+	assert.Error(t, err, "Should refuse a name that is not a patient's")
+}
+
+func TestUnlockThroughTheAgent(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	require.NoError(t, os.Unsetenv(PassphraseEnv))
+	defer func() { Passphrase = FromEnvironment }()
+	r, err := repo.Init(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, r.Encrypt([]byte("correct horse")))
+	l, path, err := agent.Listen(filepath.Join(t.TempDir(), "agent.sock"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = agent.New(0).Serve(ctx, l) }()
+	t.Setenv(agent.SocketEnv, path)
+	locked := func() *repo.Repo {
+		again, err := repo.Discover(r.WorkTree())
+		require.NoError(t, err)
+		return again
+	}
+
+	t.Run("KeepsNoWrongPassphrase", func(t *testing.T) {
+		Passphrase = func(*repo.Repo) ([]byte, error) { return []byte("battery staple"), nil }
+
+		_, err := Read(locked())
+		require.Error(t, err)
+
+		_, err = agent.Ask(r.Root())
+		assert.ErrorIs(t, err, agent.ErrNotHeld, "Should not hand the agent a passphrase that did not unlock")
+	})
+
+	t.Run("HandsTheAgentOneThatUnlocks", func(t *testing.T) {
+		Passphrase = func(*repo.Repo) ([]byte, error) { return []byte("correct horse"), nil }
+		_, err := Read(locked())
+		require.NoError(t, err)
+
+		Passphrase = FromEnvironment
+		_, err = Read(locked())
+
+		assert.NoError(t, err, "Should unlock with the passphrase the agent holds")
+	})
+}